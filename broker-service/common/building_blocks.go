@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Handler[K any, V any] interface {
+	Handle(context.Context, K) (V, error)
+}
+
+// DomainEvent is internal to the bounded context that raises it. Its shape can
+// change freely; what leaves the service is the IntegrationEvent it translates to.
+type DomainEvent interface {
+	Id() string
+	Name() string
+	Timestamp() time.Time
+}
+
+func NewBaseDomainEvent(name string) *BaseDomainEvent {
+	return &BaseDomainEvent{
+		id:        uuid.NewString(),
+		name:      name,
+		timestamp: time.Now().UTC(),
+	}
+}
+
+type BaseDomainEvent struct {
+	id        string
+	name      string
+	timestamp time.Time
+}
+
+func (d BaseDomainEvent) Id() string {
+	return d.id
+}
+
+func (d BaseDomainEvent) Name() string {
+	return d.name
+}
+
+func (d BaseDomainEvent) Timestamp() time.Time {
+	return d.timestamp
+}
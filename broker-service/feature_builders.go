@@ -0,0 +1,48 @@
+package main
+
+import (
+	"stock-trader/broker-service/order"
+	order_features "stock-trader/broker-service/order/features"
+	"stock-trader/broker-service/quotes"
+	quote_features "stock-trader/broker-service/quotes/features"
+	"stock-trader/broker-service/streaming"
+	stream_features "stock-trader/broker-service/streaming/features"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func BuildPlaceOrderFeature(exchange *order.Exchange) echo.HandlerFunc {
+	return order_features.NewPlaceOrderEndpoint(
+		order_features.NewPlaceOrderHandler(exchange),
+	).Place
+}
+
+func BuildCancelOrderFeature(exchange *order.Exchange) echo.HandlerFunc {
+	return order_features.NewCancelOrderEndpoint(
+		order_features.NewCancelOrderHandler(exchange),
+	).Cancel
+}
+
+func BuildGetQuoteFeature(board *quotes.Board) echo.HandlerFunc {
+	return quote_features.NewGetQuoteEndpoint(
+		quote_features.NewGetQuoteHandler(board),
+	).Get
+}
+
+func BuildListQuotesFeature(board *quotes.Board) echo.HandlerFunc {
+	return quote_features.NewListQuotesEndpoint(
+		quote_features.NewListQuotesHandler(board),
+	).List
+}
+
+func BuildStreamFeature(hub *streaming.Hub, tokens *streaming.StreamTokens, allowedOrigins []string) *stream_features.StreamEndpoint {
+	return stream_features.NewStreamEndpoint(
+		stream_features.NewSubscribeHandler(hub, tokens),
+		stream_features.StreamOptions{
+			HeartbeatInterval: 15 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			AllowedOrigins:    allowedOrigins,
+		},
+	)
+}
@@ -1,17 +1,142 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"stock-trader/broker-service/infrastructure"
+	"stock-trader/broker-service/order"
+	"stock-trader/broker-service/quotes"
+	"stock-trader/broker-service/streaming"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/labstack/echo/v4"
 )
 
 func main() {
 	e := echo.New()
+	e.Validator = infrastructure.NewRequestValidator()
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	db, err := infrastructure.ConnectDB()
+	if err != nil {
+		panic("Could not connect to the database")
+	}
+	orders := order.NewOrderRepository(db)
+
+	notifier := order.NewHTTPPortfolioNotifier(orders, getEnv("PORTFOLIO_URL", "http://portfolio-service:8080"), os.Getenv("BROKER_CALLBACK_TOKEN"), &http.Client{Timeout: 10 * time.Second}, func(err error) {
+		e.Logger.Error(err)
+	})
+	go notifier.Run(ctx)
+
+	expiry, err := expiryPolicy()
+	if err != nil {
+		panic(fmt.Sprintf("Invalid order expiry configuration: %v", err))
+	}
+
+	exchange := order.NewExchange(orders, notifier, expiry)
+	if err := exchange.Restore(ctx); err != nil {
+		panic(fmt.Sprintf("Could not restore the order books: %v", err))
+	}
+
+	maxSymbols, err := strconv.Atoi(getEnv("STREAM_MAX_SYMBOLS", "20"))
+	if err != nil {
+		panic(fmt.Sprintf("Invalid STREAM_MAX_SYMBOLS: %v", err))
+	}
+	hub := streaming.NewHub(streaming.HubOptions{
+		MaxSymbols:             maxSymbols,
+		MaxPendingOrderUpdates: 256,
+	})
+	exchange.Watch(func(o order.Order) {
+		hub.PublishOrderUpdate(streaming.NewOrderUpdate(o))
+	})
+
+	go order.NewExpiryScheduler(exchange, order.ExpirySchedulerOptions{
+		PollInterval: 10 * time.Second,
+		BatchSize:    100,
+		OnError: func(err error) {
+			e.Logger.Error(err)
+		},
+	}).Run(ctx)
+
+	quoteInterval, err := time.ParseDuration(getEnv("QUOTE_INTERVAL", "1s"))
+	if err != nil {
+		panic(fmt.Sprintf("Invalid QUOTE_INTERVAL: %v", err))
+	}
+	provider, err := quoteProvider(quoteInterval)
+	if err != nil {
+		panic(fmt.Sprintf("Could not start the quote provider: %v", err))
+	}
+	board := quotes.NewBoard()
+	go quotes.NewTicker(provider, board, quotes.TickerOptions{
+		Interval: quoteInterval,
+		OnQuote: func(ctx context.Context, quote quotes.Quote) {
+			hub.PublishQuote(quote)
+			if err := exchange.PriceChanged(ctx, quote.Symbol, quote.Last); err != nil {
+				e.Logger.Error(err)
+			}
+		},
+		OnError: func(err error) {
+			e.Logger.Error(err)
+		},
+	}).Run(ctx)
 
 	e.GET("/", func(ctx echo.Context) error {
 		return ctx.String(http.StatusOK, "Hello from broker-service! ")
 	})
+	// Orders are placed and cancelled by the portfolio service alone.
+	portfolioAuth := infrastructure.ServiceAuth(os.Getenv("BROKER_ORDER_TOKEN"))
+	e.POST("/orders", BuildPlaceOrderFeature(exchange), portfolioAuth)
+	e.DELETE("/orders/:id", BuildCancelOrderFeature(exchange), portfolioAuth)
+	e.GET("/quotes", BuildListQuotesFeature(board))
+	e.GET("/quotes/:symbol", BuildGetQuoteFeature(board))
+
+	// Dashboards are served from other origins, listed in STREAM_ALLOWED_ORIGINS.
+	var allowedOrigins []string
+	if origins := os.Getenv("STREAM_ALLOWED_ORIGINS"); origins != "" {
+		allowedOrigins = strings.Split(origins, ",")
+	}
+	stream := BuildStreamFeature(hub, streaming.NewStreamTokens(os.Getenv("STREAM_TOKEN_SECRET")), allowedOrigins)
+	e.GET("/stream/ws", stream.WebSocket)
+	e.GET("/stream/sse", stream.SSE)
 
 	e.Logger.Fatal(e.Start(":8081"))
 }
+
+// expiryPolicy reads when orders expire from MARKET_CLOSE (a time of day such
+// as 16:00), MARKET_TIMEZONE and GTC_HORIZON (a duration such as 2160h).
+func expiryPolicy() (order.ExpiryPolicy, error) {
+	location, err := time.LoadLocation(getEnv("MARKET_TIMEZONE", "America/New_York"))
+	if err != nil {
+		return order.ExpiryPolicy{}, err
+	}
+
+	close, err := time.Parse("15:04", getEnv("MARKET_CLOSE", "16:00"))
+	if err != nil {
+		return order.ExpiryPolicy{}, err
+	}
+
+	horizon, err := time.ParseDuration(getEnv("GTC_HORIZON", "2160h"))
+	if err != nil {
+		return order.ExpiryPolicy{}, err
+	}
+
+	return order.ExpiryPolicy{
+		MarketClose:              time.Duration(close.Hour())*time.Hour + time.Duration(close.Minute())*time.Minute,
+		Location:                 location,
+		GoodTillCancelledHorizon: horizon,
+	}, nil
+}
+
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
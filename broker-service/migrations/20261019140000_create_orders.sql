@@ -0,0 +1,4 @@
+-- Add new schema named "broker"
+CREATE DATABASE IF NOT EXISTS `broker`;
+-- Create "orders" table
+CREATE TABLE `broker`.`orders` (`id` varchar(36) NOT NULL, `portfolio_id` varchar(36) NOT NULL, `symbol` varchar(8) NOT NULL, `side` varchar(4) NOT NULL, `quantity` bigint NOT NULL, `limit_price` decimal(19,4) NOT NULL, `filled_quantity` bigint NOT NULL DEFAULT 0, `status` varchar(16) NOT NULL, `sequence` bigint NOT NULL, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_status_x_sequence` (`status`, `sequence`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
@@ -0,0 +1,2 @@
+-- Modify "orders" table
+ALTER TABLE `broker`.`orders` MODIFY COLUMN `limit_price` decimal(19,4) NULL, ADD COLUMN `type` varchar(10) NOT NULL DEFAULT 'limit' AFTER `side`, ADD COLUMN `stop_price` decimal(19,4) NULL AFTER `limit_price`, ADD COLUMN `triggered` bool NOT NULL DEFAULT 0 AFTER `stop_price`;
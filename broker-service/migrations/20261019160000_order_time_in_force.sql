@@ -0,0 +1,2 @@
+-- Modify "orders" table
+ALTER TABLE `broker`.`orders` ADD COLUMN `time_in_force` varchar(3) NOT NULL DEFAULT 'gtc' AFTER `triggered`, ADD COLUMN `expires_at` datetime(6) NULL AFTER `time_in_force`, ADD INDEX `idx_status_x_expires_at` (`status`, `expires_at`);
@@ -0,0 +1,2 @@
+-- Modify "orders" table
+ALTER TABLE `broker`.`orders` MODIFY COLUMN `quantity` decimal(19,6) NOT NULL, MODIFY COLUMN `filled_quantity` decimal(19,6) NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Create "portfolio_notifications" table
+CREATE TABLE `broker`.`portfolio_notifications` (`id` bigint NOT NULL AUTO_INCREMENT, `order_id` varchar(36) NOT NULL, `type` varchar(16) NOT NULL, `trade_id` varchar(36) NOT NULL DEFAULT '', `quantity` decimal(19,6) NULL, `price` decimal(19,4) NULL, `reason` varchar(256) NOT NULL DEFAULT '', `status` varchar(16) NOT NULL, `attempts` int NOT NULL DEFAULT 0, `last_error` varchar(512) NOT NULL DEFAULT '', `created_at` datetime(6) NOT NULL, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_status_x_id` (`status`, `id`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
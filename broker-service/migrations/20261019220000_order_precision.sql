@@ -0,0 +1,2 @@
+-- Modify "orders" table
+ALTER TABLE `broker`.`orders` ADD COLUMN `precision` int NOT NULL DEFAULT 6 AFTER `quantity`;
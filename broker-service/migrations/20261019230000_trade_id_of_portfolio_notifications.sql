@@ -0,0 +1,2 @@
+-- Modify "portfolio_notifications" table
+ALTER TABLE `broker`.`portfolio_notifications` MODIFY COLUMN `trade_id` varchar(64) NOT NULL DEFAULT '';
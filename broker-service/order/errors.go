@@ -0,0 +1,13 @@
+package order
+
+import "errors"
+
+var ErrOrderNotFound = errors.New("order not found")
+
+var ErrOrderAlreadyFilled = errors.New("order already filled")
+
+var ErrOrderAlreadyCancelled = errors.New("order already cancelled")
+
+var ErrOrderExpired = errors.New("order expired")
+
+var ErrInvalidOrder = errors.New("invalid order")
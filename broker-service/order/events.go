@@ -0,0 +1,126 @@
+package order
+
+import (
+	"stock-trader/broker-service/common"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type baseDomainEvent = common.BaseDomainEvent
+
+// orderFill is what every fill event carries. UnfilledQuantity is what is
+// left of the order after the fill.
+type orderFill struct {
+	orderId          string
+	portfolioId      string
+	tradeId          string
+	symbol           string
+	side             OrderSide
+	quantity         decimal.Decimal
+	price            decimal.Decimal
+	unfilledQuantity decimal.Decimal
+}
+
+func (f orderFill) OrderId() string {
+	return f.orderId
+}
+
+func (f orderFill) PortfolioId() string {
+	return f.portfolioId
+}
+
+func (f orderFill) TradeId() string {
+	return f.tradeId
+}
+
+func (f orderFill) Symbol() string {
+	return f.symbol
+}
+
+func (f orderFill) Side() OrderSide {
+	return f.side
+}
+
+func (f orderFill) Quantity() decimal.Decimal {
+	return f.quantity
+}
+
+func (f orderFill) Price() decimal.Decimal {
+	return f.price
+}
+
+func (f orderFill) UnfilledQuantity() decimal.Decimal {
+	return f.unfilledQuantity
+}
+
+func (f orderFill) Trade() Trade {
+	return Trade{
+		Id:       f.tradeId,
+		OrderId:  f.orderId,
+		Quantity: f.quantity,
+		Price:    f.price,
+	}
+}
+
+type OrderPartiallyFilled struct {
+	*baseDomainEvent
+	orderFill
+}
+
+type OrderFilled struct {
+	*baseDomainEvent
+	orderFill
+}
+
+// OrderCancelled is raised when the broker cancels what is left of an order
+// that the portfolio did not ask to cancel.
+type OrderCancelled struct {
+	*baseDomainEvent
+	orderId          string
+	portfolioId      string
+	unfilledQuantity decimal.Decimal
+	reason           string
+}
+
+func (e OrderCancelled) OrderId() string {
+	return e.orderId
+}
+
+func (e OrderCancelled) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e OrderCancelled) UnfilledQuantity() decimal.Decimal {
+	return e.unfilledQuantity
+}
+
+func (e OrderCancelled) Reason() string {
+	return e.reason
+}
+
+// OrderExpired is raised when an order's time in force runs out before it is
+// filled.
+type OrderExpired struct {
+	*baseDomainEvent
+	orderId          string
+	portfolioId      string
+	unfilledQuantity decimal.Decimal
+	expiresAt        time.Time
+}
+
+func (e OrderExpired) OrderId() string {
+	return e.orderId
+}
+
+func (e OrderExpired) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e OrderExpired) UnfilledQuantity() decimal.Decimal {
+	return e.unfilledQuantity
+}
+
+func (e OrderExpired) ExpiresAt() time.Time {
+	return e.expiresAt
+}
@@ -0,0 +1,338 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"stock-trader/broker-service/orderbook"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Exchange matches orders in the order book of their symbol. It runs one
+// command at a time and stores every order a command changes, together with
+// the notifications for the portfolios, so the books always agree with the
+// stored orders and no notification is lost.
+type Exchange struct {
+	mu       sync.Mutex
+	books    *orderbook.Books
+	sequence int64
+	orders   OrderRepository
+	notifier PortfolioNotifier
+	expiry   ExpiryPolicy
+	watchers []func(Order)
+}
+
+func NewExchange(orders OrderRepository, notifier PortfolioNotifier, expiry ExpiryPolicy) *Exchange {
+	return &Exchange{
+		books:    orderbook.NewBooks(),
+		orders:   orders,
+		notifier: notifier,
+		expiry:   expiry,
+	}
+}
+
+// Watch tells watcher of every change to an order once it is stored. Watchers
+// are called while the exchange runs the command, so they must not block.
+// Watch is meant to be called before the exchange takes any order.
+func (x *Exchange) Watch(watcher func(Order)) {
+	x.watchers = append(x.watchers, watcher)
+}
+
+// Restore puts the open orders back in their books, keeping their time
+// priority. It is meant to run once, before the exchange takes any order.
+func (x *Exchange) Restore(ctx context.Context) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	open, err := x.orders.FindOpen(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, resting := range open {
+		x.restore(resting)
+		if resting.sequence > x.sequence {
+			x.sequence = resting.sequence
+		}
+	}
+	return nil
+}
+
+// Place matches an order against the orders resting in its book. Whatever is
+// not filled rests in the book until it is matched, cancelled or expires,
+// except for market, immediate-or-cancel and fill-or-kill orders, which are
+// cancelled. Stop orders wait in the book until the price reaches their stop
+// price. Placing an order that was already placed does nothing.
+func (x *Exchange) Place(ctx context.Context, placed *Order) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if _, err := x.orders.FindById(ctx, placed.id); err == nil {
+		return nil
+	} else if !errors.Is(err, ErrOrderNotFound) {
+		return err
+	}
+
+	m := x.matching(ctx, placed.symbol)
+	m.track(placed)
+	x.sequence++
+	placed.sequence = x.sequence
+	placed.expiresAt = x.expiry.ExpiresAt(placed.timeInForce, time.Now())
+
+	if placed.AwaitingTrigger() {
+		m.book.AddStop(stopEntry(placed))
+		return m.commit()
+	}
+
+	if err := m.submit(placed); err != nil {
+		return x.discard(ctx, placed.symbol, err)
+	}
+	return m.commit()
+}
+
+// PriceChanged triggers the stop orders of a symbol that the price reached.
+// The exchange already does so with the price of its own trades.
+func (x *Exchange) PriceChanged(ctx context.Context, symbol string, price decimal.Decimal) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	m := x.matching(ctx, symbol)
+	if err := m.trigger(price); err != nil {
+		return x.discard(ctx, symbol, err)
+	}
+	return m.commit()
+}
+
+// Expire expires up to limit orders that are due to expire by now and takes
+// them out of their books. It returns how many it expired.
+func (x *Exchange) Expire(ctx context.Context, now time.Time, limit int) (int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	expiring, err := x.orders.FindExpiring(ctx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(expiring) == 0 {
+		return 0, nil
+	}
+
+	for _, expired := range expiring {
+		if err := expired.Expire(now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := x.orders.Save(ctx, expiring...); err != nil {
+		return 0, err
+	}
+
+	for _, expired := range expiring {
+		x.books.Book(expired.symbol).Cancel(expired.id)
+	}
+	x.publish(expiring)
+	return len(expiring), nil
+}
+
+// Cancel takes an order out of its book so it is not filled any further, and
+// returns it, with the quantity filled before. The order is also returned when
+// it can not be cancelled because it is no longer open.
+func (x *Exchange) Cancel(ctx context.Context, orderId string) (*Order, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	cancelled, err := x.orders.FindById(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cancelled.Cancel(); err != nil {
+		return cancelled, err
+	}
+
+	if err := x.orders.Save(ctx, cancelled); err != nil {
+		return nil, err
+	}
+
+	x.books.Book(cancelled.symbol).Cancel(orderId)
+	x.publish([]*Order{cancelled})
+	return cancelled, nil
+}
+
+func (x *Exchange) restore(order *Order) {
+	book := x.books.Book(order.symbol)
+	if order.AwaitingTrigger() {
+		book.AddStop(stopEntry(order))
+	} else {
+		book.Rest(bookEntry(order))
+	}
+}
+
+// discard rebuilds a book from the stored orders after a command on it could
+// not be stored.
+func (x *Exchange) discard(ctx context.Context, symbol string, cause error) error {
+	x.books.Reset(symbol)
+
+	open, err := x.orders.FindOpen(ctx)
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	for _, resting := range open {
+		if resting.symbol == symbol {
+			x.restore(resting)
+		}
+	}
+	return cause
+}
+
+// publish tells the watchers of the changed orders once they are stored, and
+// the notifier that the outbox has the notifications stored with them.
+func (x *Exchange) publish(changed []*Order) {
+	for _, order := range changed {
+		order.ClearDomainEvents()
+
+		for _, watcher := range x.watchers {
+			watcher(*order)
+		}
+	}
+	x.notifier.Wake()
+}
+
+func (x *Exchange) matching(ctx context.Context, symbol string) *matching {
+	return &matching{
+		ctx:      ctx,
+		exchange: x,
+		book:     x.books.Book(symbol),
+		symbol:   symbol,
+		tracked:  map[string]*Order{},
+	}
+}
+
+// matching is a single command on the book of a symbol. It keeps the orders it
+// changes so an order touched more than once, as when a stop order triggered
+// by a trade matches again, is loaded only once.
+type matching struct {
+	ctx      context.Context
+	exchange *Exchange
+	book     *orderbook.OrderBook
+	symbol   string
+	tracked  map[string]*Order
+	changed  []*Order
+}
+
+func (m *matching) track(order *Order) {
+	if _, ok := m.tracked[order.id]; !ok {
+		m.tracked[order.id] = order
+		m.changed = append(m.changed, order)
+	}
+}
+
+func (m *matching) find(orderId string) (*Order, error) {
+	if order, ok := m.tracked[orderId]; ok {
+		return order, nil
+	}
+	order, err := m.exchange.orders.FindById(m.ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	m.track(order)
+	return order, nil
+}
+
+func (m *matching) submit(incoming *Order) error {
+	entry := bookEntry(incoming)
+	if incoming.timeInForce == FillOrKill && m.book.Available(entry).LessThan(entry.Quantity) {
+		return incoming.CancelRemainder("fill-or-kill order could not be filled entirely")
+	}
+
+	matches := m.book.Submit(entry)
+
+	for _, match := range matches {
+		resting, err := m.find(match.RestingOrderId)
+		if err != nil {
+			return err
+		}
+		tradeId := uuid.NewString()
+		if err := errors.Join(
+			incoming.Fill(tradeId, match.Quantity, match.Price),
+			resting.Fill(tradeId, match.Quantity, match.Price),
+		); err != nil {
+			return err
+		}
+	}
+
+	if !incoming.Rests() && incoming.status == OrderStatusOpen {
+		if err := incoming.CancelRemainder(unfilledReason(incoming)); err != nil {
+			return err
+		}
+		m.book.Cancel(incoming.id)
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+	return m.trigger(matches[len(matches)-1].Price)
+}
+
+// trigger submits the stop orders the price reached. Their trades may move the
+// price and trigger further stop orders.
+func (m *matching) trigger(price decimal.Decimal) error {
+	for _, orderId := range m.book.Trigger(price) {
+		triggered, err := m.find(orderId)
+		if err != nil {
+			return err
+		}
+		if err := triggered.Trigger(); err != nil {
+			return err
+		}
+		// A triggered order reaches the book now, which gives it its time priority.
+		m.exchange.sequence++
+		triggered.sequence = m.exchange.sequence
+
+		if err := m.submit(triggered); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commit stores the changed orders and tells their portfolios.
+func (m *matching) commit() error {
+	if len(m.changed) == 0 {
+		return nil
+	}
+	if err := m.exchange.orders.Save(m.ctx, m.changed...); err != nil {
+		return m.exchange.discard(m.ctx, m.symbol, err)
+	}
+	m.exchange.publish(m.changed)
+	return nil
+}
+
+func unfilledReason(order *Order) string {
+	if order.Marketable() {
+		return "no orders left to match the market order"
+	}
+	return "immediate-or-cancel order could not be filled entirely"
+}
+
+func bookEntry(order *Order) orderbook.Entry {
+	return orderbook.Entry{
+		OrderId:    order.id,
+		Side:       orderbook.Side(order.side),
+		Market:     order.Marketable(),
+		LimitPrice: order.limitPrice,
+		Quantity:   order.UnfilledQuantity(),
+		Precision:  order.precision,
+	}
+}
+
+func stopEntry(order *Order) orderbook.StopEntry {
+	return orderbook.StopEntry{
+		OrderId:   order.id,
+		Side:      orderbook.Side(order.side),
+		StopPrice: order.stopPrice,
+	}
+}
@@ -0,0 +1,312 @@
+package order_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/broker-service/order"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+var expiry = order.ExpiryPolicy{
+	MarketClose:              16 * time.Hour,
+	Location:                 time.UTC,
+	GoodTillCancelledHorizon: 90 * 24 * time.Hour,
+}
+
+func place(t *testing.T, exchange *order.Exchange, side order.OrderSide, quantity int64, limitPrice int64) *order.Order {
+	return placeTyped(t, exchange, side, order.Limit, quantity, limitPrice, 0)
+}
+
+func placeTyped(t *testing.T, exchange *order.Exchange, side order.OrderSide, orderType order.OrderType, quantity int64, limitPrice int64, stopPrice int64) *order.Order {
+	placed, err := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", side, orderType, order.GoodTillCancelled, decimal.NewFromInt(quantity), 0, decimal.NewFromInt(limitPrice), decimal.NewFromInt(stopPrice))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.NoError(t, exchange.Place(context.Background(), placed)) {
+		t.FailNow()
+	}
+	return placed
+}
+
+func TestExchangePlace(t *testing.T) {
+	t.Run("Fills of both orders share the trade", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		notifier := &StubPortfolioNotifier{}
+		exchange := order.NewExchange(orders, notifier, expiry)
+		resting := place(t, exchange, order.Sell, 10, 20)
+
+		incoming := place(t, exchange, order.Buy, 4, 21)
+
+		assert.Equal(t, 2, notifier.woken)
+
+		if assert.Len(t, notified(orders).trades, 2) {
+			assert.Equal(t, order.Trade{Id: notified(orders).trades[0].Id, OrderId: incoming.Id(), Quantity: decimal.NewFromInt(4), Price: decimal.NewFromInt(20)}, notified(orders).trades[0])
+			assert.Equal(t, order.Trade{Id: notified(orders).trades[0].Id, OrderId: resting.Id(), Quantity: decimal.NewFromInt(4), Price: decimal.NewFromInt(20)}, notified(orders).trades[1])
+		}
+		saved, _ := orders.FindById(context.Background(), resting.Id())
+		assert.Equal(t, "6", saved.UnfilledQuantity().String())
+		assert.Equal(t, order.OrderStatusOpen, saved.Status())
+	})
+
+	t.Run("Orders of other symbols are not matched", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		place(t, exchange, order.Sell, 10, 20)
+
+		other, _ := order.NewOrder(uuid.NewString(), uuid.NewString(), "OTHER", order.Buy, order.Limit, order.GoodTillCancelled, decimal.NewFromInt(10), 0, decimal.NewFromInt(20), decimal.Zero)
+		exchange.Place(context.Background(), other)
+
+		assert.Empty(t, notified(orders).trades)
+	})
+
+	t.Run("Failing to store the orders leaves the book untouched", func(t *testing.T) {
+		orders := &FailingOrderRepository{OrderRepository: order.NewInMemoryOrderRepository()}
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		resting := place(t, exchange, order.Sell, 10, 20)
+
+		orders.fail = true
+		failed, _ := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", order.Buy, order.Limit, order.GoodTillCancelled, decimal.NewFromInt(10), 0, decimal.NewFromInt(20), decimal.Zero)
+		assert.Error(t, exchange.Place(context.Background(), failed))
+
+		orders.fail = false
+		place(t, exchange, order.Buy, 10, 20)
+		if assert.Len(t, notified(orders).trades, 2) {
+			assert.Equal(t, "10", notified(orders).trades[1].Quantity.String())
+			assert.Equal(t, resting.Id(), notified(orders).trades[1].OrderId)
+		}
+	})
+}
+
+func TestExchangeWatch(t *testing.T) {
+	t.Run("Watchers are told of every stored change", func(t *testing.T) {
+		exchange := order.NewExchange(order.NewInMemoryOrderRepository(), &StubPortfolioNotifier{}, expiry)
+		var statuses []order.OrderStatus
+		exchange.Watch(func(o order.Order) {
+			statuses = append(statuses, o.Status())
+		})
+
+		resting := place(t, exchange, order.Sell, 10, 20)
+		place(t, exchange, order.Buy, 4, 20)
+		exchange.Cancel(context.Background(), resting.Id())
+
+		assert.Equal(t, []order.OrderStatus{order.OrderStatusOpen, order.OrderStatusFilled, order.OrderStatusOpen, order.OrderStatusCancelled}, statuses)
+	})
+}
+
+func TestExchangeOrderTypes(t *testing.T) {
+	t.Run("Market order cancels what it can not fill", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		place(t, exchange, order.Sell, 4, 25)
+
+		market := placeTyped(t, exchange, order.Buy, order.Market, 10, 0, 0)
+
+		assert.Len(t, notified(orders).trades, 2)
+		assert.Equal(t, []string{market.Id()}, notified(orders).cancelled)
+		saved, _ := orders.FindById(context.Background(), market.Id())
+		assert.Equal(t, order.OrderStatusCancelled, saved.Status())
+		assert.Equal(t, "4", saved.FilledQuantity().String())
+	})
+
+	t.Run("Trade price triggers stop orders", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		stop := placeTyped(t, exchange, order.Sell, order.Stop, 5, 0, 19)
+		place(t, exchange, order.Buy, 5, 18)
+		place(t, exchange, order.Sell, 2, 19)
+		assert.Empty(t, notified(orders).trades)
+
+		place(t, exchange, order.Buy, 2, 19)
+
+		if assert.Len(t, notified(orders).trades, 4) {
+			assert.Equal(t, stop.Id(), notified(orders).trades[2].OrderId)
+			assert.Equal(t, "18", notified(orders).trades[2].Price.String())
+		}
+		saved, _ := orders.FindById(context.Background(), stop.Id())
+		assert.Equal(t, order.OrderStatusFilled, saved.Status())
+	})
+
+	t.Run("Incoming price triggers stop-limit orders that rest at their limit", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		stopLimit := placeTyped(t, exchange, order.Buy, order.StopLimit, 5, 21, 20)
+
+		assert.NoError(t, exchange.PriceChanged(context.Background(), "ACME", decimal.NewFromInt(19)))
+		place(t, exchange, order.Sell, 5, 21)
+		assert.Empty(t, notified(orders).trades)
+
+		assert.NoError(t, exchange.PriceChanged(context.Background(), "ACME", decimal.NewFromInt(20)))
+
+		if assert.Len(t, notified(orders).trades, 2) {
+			assert.Equal(t, stopLimit.Id(), notified(orders).trades[0].OrderId)
+			assert.Equal(t, "21", notified(orders).trades[0].Price.String())
+		}
+	})
+}
+
+func TestExchangeTimeInForce(t *testing.T) {
+	placeWithTimeInForce := func(t *testing.T, exchange *order.Exchange, side order.OrderSide, timeInForce order.TimeInForce, quantity int64, limitPrice int64) *order.Order {
+		placed, _ := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", side, order.Limit, timeInForce, decimal.NewFromInt(quantity), 0, decimal.NewFromInt(limitPrice), decimal.Zero)
+		if !assert.NoError(t, exchange.Place(context.Background(), placed)) {
+			t.FailNow()
+		}
+		return placed
+	}
+
+	t.Run("Immediate-or-cancel order cancels what it can not fill at its limit", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		place(t, exchange, order.Sell, 4, 20)
+		place(t, exchange, order.Sell, 4, 22)
+
+		ioc := placeWithTimeInForce(t, exchange, order.Buy, order.ImmediateOrCancel, 10, 21)
+
+		assert.Len(t, notified(orders).trades, 2)
+		assert.Equal(t, []string{ioc.Id()}, notified(orders).cancelled)
+		saved, _ := orders.FindById(context.Background(), ioc.Id())
+		assert.Equal(t, "4", saved.FilledQuantity().String())
+
+		place(t, exchange, order.Sell, 1, 19)
+		assert.Len(t, notified(orders).trades, 2)
+	})
+
+	t.Run("Fill-or-kill order is cancelled unless it can be filled entirely", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		place(t, exchange, order.Sell, 4, 20)
+		place(t, exchange, order.Sell, 4, 22)
+
+		killed := placeWithTimeInForce(t, exchange, order.Buy, order.FillOrKill, 8, 21)
+
+		assert.Empty(t, notified(orders).trades)
+		assert.Equal(t, []string{killed.Id()}, notified(orders).cancelled)
+
+		filled := placeWithTimeInForce(t, exchange, order.Buy, order.FillOrKill, 8, 22)
+
+		assert.Len(t, notified(orders).trades, 4)
+		saved, _ := orders.FindById(context.Background(), filled.Id())
+		assert.Equal(t, order.OrderStatusFilled, saved.Status())
+	})
+
+	t.Run("Expire orders that are due", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		day := placeWithTimeInForce(t, exchange, order.Sell, order.Day, 5, 20)
+		gtc := place(t, exchange, order.Sell, 5, 20)
+
+		expired, err := exchange.Expire(context.Background(), day.ExpiresAt(), 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, expired)
+		assert.Equal(t, []string{day.Id()}, notified(orders).cancelled)
+		saved, _ := orders.FindById(context.Background(), day.Id())
+		assert.Equal(t, order.OrderStatusExpired, saved.Status())
+		_, err = exchange.Cancel(context.Background(), day.Id())
+		assert.ErrorIs(t, err, order.ErrOrderExpired)
+
+		place(t, exchange, order.Buy, 5, 20)
+		if assert.Len(t, notified(orders).trades, 2) {
+			assert.Equal(t, gtc.Id(), notified(orders).trades[1].OrderId)
+		}
+	})
+}
+
+func TestExpiryScheduler(t *testing.T) {
+	t.Run("Tick expires every due order a batch at a time", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, order.ExpiryPolicy{GoodTillCancelledHorizon: -time.Hour})
+		for i := 0; i < 3; i++ {
+			place(t, exchange, order.Buy, 5, 20)
+		}
+		scheduler := order.NewExpiryScheduler(exchange, order.ExpirySchedulerOptions{
+			BatchSize: 2,
+			OnError: func(err error) {
+				assert.NoError(t, err)
+			},
+		})
+
+		scheduler.Tick(context.Background())
+
+		assert.Len(t, notified(orders).cancelled, 3)
+	})
+}
+
+func TestExchangeRestore(t *testing.T) {
+	t.Run("Stop orders keep waiting for their price after a restart", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		stop := placeTyped(t, order.NewExchange(orders, &StubPortfolioNotifier{}, expiry), order.Buy, order.Stop, 5, 0, 20)
+
+		after := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		assert.NoError(t, after.Restore(context.Background()))
+		place(t, after, order.Sell, 5, 25)
+		assert.Empty(t, notified(orders).trades)
+
+		assert.NoError(t, after.PriceChanged(context.Background(), "ACME", decimal.NewFromInt(20)))
+
+		if assert.Len(t, notified(orders).trades, 2) {
+			assert.Equal(t, stop.Id(), notified(orders).trades[0].OrderId)
+		}
+	})
+
+	t.Run("Resting orders keep their priority after a restart", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		before := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		first := place(t, before, order.Sell, 5, 20)
+		place(t, before, order.Sell, 5, 20)
+		cancelled := place(t, before, order.Sell, 5, 19)
+		before.Cancel(context.Background(), cancelled.Id())
+
+		after := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		assert.NoError(t, after.Restore(context.Background()))
+		incoming := place(t, after, order.Buy, 5, 20)
+
+		if assert.Len(t, notified(orders).trades, 2) {
+			assert.Equal(t, incoming.Id(), notified(orders).trades[0].OrderId)
+			assert.Equal(t, first.Id(), notified(orders).trades[1].OrderId)
+		}
+	})
+}
+
+type FailingOrderRepository struct {
+	order.OrderRepository
+	fail bool
+}
+
+func (r *FailingOrderRepository) Save(ctx context.Context, orders ...*order.Order) error {
+	if r.fail {
+		return errors.New("connection lost")
+	}
+	return r.OrderRepository.Save(ctx, orders...)
+}
+
+type StubPortfolioNotifier struct {
+	woken int
+}
+
+func (n *StubPortfolioNotifier) Wake() {
+	n.woken++
+}
+
+type outbox struct {
+	trades    []order.Trade
+	cancelled []string
+}
+
+// notified reads the notifications the exchange stored for the portfolios.
+func notified(orders order.OrderRepository) outbox {
+	notifications, _ := orders.PendingNotifications(context.Background(), 1000)
+	notified := outbox{}
+	for _, notification := range notifications {
+		if notification.Type == order.NotificationTrade {
+			notified.trades = append(notified.trades, notification.Trade)
+		} else {
+			notified.cancelled = append(notified.cancelled, notification.OrderId)
+		}
+	}
+	return notified
+}
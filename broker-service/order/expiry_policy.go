@@ -0,0 +1,34 @@
+package order
+
+import "time"
+
+// ExpiryPolicy tells when the orders that rest in the book expire.
+type ExpiryPolicy struct {
+	// MarketClose is the time of day the market closes, in Location.
+	MarketClose time.Duration
+	Location    *time.Location
+	// GoodTillCancelledHorizon is how long a good-till-cancelled order rests
+	// before it expires.
+	GoodTillCancelledHorizon time.Duration
+}
+
+// ExpiresAt returns when an order placed at placedAt expires, or the zero time
+// for orders that never rest. Day orders expire at the next market close on a
+// weekday, which is the same day when they are placed before the close.
+func (p ExpiryPolicy) ExpiresAt(timeInForce TimeInForce, placedAt time.Time) time.Time {
+	switch timeInForce {
+	case Day:
+		local := placedAt.In(p.Location)
+		for days := 0; ; days++ {
+			// The close is a wall clock time, so it stays put when daylight saving time changes.
+			close := time.Date(local.Year(), local.Month(), local.Day()+days, 0, 0, 0, int(p.MarketClose), p.Location)
+			if placedAt.Before(close) && close.Weekday() != time.Saturday && close.Weekday() != time.Sunday {
+				return close.UTC()
+			}
+		}
+	case GoodTillCancelled:
+		return placedAt.Add(p.GoodTillCancelledHorizon).UTC()
+	default:
+		return time.Time{}
+	}
+}
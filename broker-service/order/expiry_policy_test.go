@@ -0,0 +1,50 @@
+package order_test
+
+import (
+	"stock-trader/broker-service/order"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiresAt(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+	policy := order.ExpiryPolicy{
+		MarketClose:              16 * time.Hour,
+		Location:                 newYork,
+		GoodTillCancelledHorizon: 30 * 24 * time.Hour,
+	}
+	at := func(value string) time.Time {
+		parsed, _ := time.ParseInLocation("2006-01-02 15:04", value, newYork)
+		return parsed
+	}
+
+	t.Run("Day order placed before the close expires at the close", func(t *testing.T) {
+		assert.Equal(t, at("2026-10-19 16:00").UTC(), policy.ExpiresAt(order.Day, at("2026-10-19 09:30")))
+	})
+
+	t.Run("Day order placed after the close expires at the next close", func(t *testing.T) {
+		assert.Equal(t, at("2026-10-20 16:00").UTC(), policy.ExpiresAt(order.Day, at("2026-10-19 16:00")))
+	})
+
+	t.Run("Day order placed on a weekend expires on monday", func(t *testing.T) {
+		assert.Equal(t, at("2026-10-26 16:00").UTC(), policy.ExpiresAt(order.Day, at("2026-10-23 17:00")))
+		assert.Equal(t, at("2026-10-26 16:00").UTC(), policy.ExpiresAt(order.Day, at("2026-10-25 12:00")))
+	})
+
+	t.Run("Day order expires at the close when daylight saving time ends", func(t *testing.T) {
+		expiresAt := policy.ExpiresAt(order.Day, at("2026-11-02 00:30"))
+
+		assert.Equal(t, "2026-11-02 16:00", expiresAt.In(newYork).Format("2006-01-02 15:04"))
+	})
+
+	t.Run("Good-till-cancelled order expires after the horizon", func(t *testing.T) {
+		assert.Equal(t, at("2026-10-19 09:30").Add(30*24*time.Hour).UTC(), policy.ExpiresAt(order.GoodTillCancelled, at("2026-10-19 09:30")))
+	})
+
+	t.Run("Immediate orders never expire", func(t *testing.T) {
+		assert.True(t, policy.ExpiresAt(order.ImmediateOrCancel, at("2026-10-19 09:30")).IsZero())
+		assert.True(t, policy.ExpiresAt(order.FillOrKill, at("2026-10-19 09:30")).IsZero())
+	})
+}
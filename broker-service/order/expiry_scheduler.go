@@ -0,0 +1,56 @@
+package order
+
+import (
+	"context"
+	"time"
+)
+
+type ExpirySchedulerOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	OnError      func(error)
+}
+
+// ExpiryScheduler expires the orders whose time in force ran out. The expiry
+// of every order is stored with it, so a restarted broker expires the orders
+// that came due while it was down on its first tick.
+type ExpiryScheduler struct {
+	exchange *Exchange
+	options  ExpirySchedulerOptions
+	now      func() time.Time
+}
+
+func NewExpiryScheduler(exchange *Exchange, options ExpirySchedulerOptions) *ExpiryScheduler {
+	return &ExpiryScheduler{
+		exchange: exchange,
+		options:  options,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *ExpiryScheduler) Run(ctx context.Context) {
+	for {
+		s.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.options.PollInterval):
+		}
+	}
+}
+
+// Tick expires every order that is due, a batch at a time.
+func (s *ExpiryScheduler) Tick(ctx context.Context) {
+	now := s.now()
+	for {
+		expired, err := s.exchange.Expire(ctx, now, s.options.BatchSize)
+		if err != nil {
+			s.options.OnError(err)
+			return
+		}
+		if expired < s.options.BatchSize {
+			return
+		}
+	}
+}
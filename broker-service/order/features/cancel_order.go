@@ -0,0 +1,81 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/broker-service/common"
+	"stock-trader/broker-service/order"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+type CancelOrderEndpoint struct {
+	handler common.Handler[CancelOrderCommand, CancelOrderResult]
+}
+
+func NewCancelOrderEndpoint(handler common.Handler[CancelOrderCommand, CancelOrderResult]) *CancelOrderEndpoint {
+	return &CancelOrderEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *CancelOrderEndpoint) Cancel(c echo.Context) error {
+	command := new(CancelOrderCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	result, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, order.ErrOrderNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, order.ErrOrderAlreadyFilled) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+type CancelOrderCommand struct {
+	OrderId string `param:"id" validate:"required"`
+}
+
+// CancelOrderResult tells how much of the order was filled before it was
+// cancelled. Fills the portfolio has not been notified of yet are on their way.
+type CancelOrderResult struct {
+	OrderId        string          `json:"order_id"`
+	FilledQuantity decimal.Decimal `json:"filled_quantity"`
+}
+
+type CancelOrderHandler struct {
+	exchange *order.Exchange
+}
+
+func NewCancelOrderHandler(exchange *order.Exchange) *CancelOrderHandler {
+	return &CancelOrderHandler{
+		exchange: exchange,
+	}
+}
+
+// Handle cancels the unfilled part of an order. Cancelling it again, or after
+// it expired, succeeds, so the caller can retry.
+func (h *CancelOrderHandler) Handle(ctx context.Context, command CancelOrderCommand) (CancelOrderResult, error) {
+	cancelled, err := h.exchange.Cancel(ctx, command.OrderId)
+	if err != nil && !errors.Is(err, order.ErrOrderAlreadyCancelled) && !errors.Is(err, order.ErrOrderExpired) {
+		return CancelOrderResult{}, err
+	}
+	return CancelOrderResult{
+		OrderId:        cancelled.Id(),
+		FilledQuantity: cancelled.FilledQuantity(),
+	}, nil
+}
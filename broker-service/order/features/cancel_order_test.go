@@ -0,0 +1,90 @@
+package order_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/broker-service/infrastructure"
+	"stock-trader/broker-service/order"
+	features "stock-trader/broker-service/order/features"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CancelOrderEndpoint(t *testing.T) {
+	newContext := func(orderId string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodDelete, "/orders/"+orderId, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(orderId)
+		return c, rec
+	}
+
+	placeOrder := func(exchange *order.Exchange, side order.OrderSide) string {
+		placed, _ := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", side, order.Limit, order.GoodTillCancelled, decimal.NewFromInt(10), 0, decimal.NewFromInt(20), decimal.Zero)
+		exchange.Place(context.Background(), placed)
+		return placed.Id()
+	}
+
+	t.Run("Cancel Open Order Twice", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		exchange := order.NewExchange(orders, &StubPortfolioNotifier{}, expiry)
+		orderId := placeOrder(exchange, order.Buy)
+		endpoint := features.NewCancelOrderEndpoint(features.NewCancelOrderHandler(exchange))
+
+		for i := 0; i < 2; i++ {
+			c, rec := newContext(orderId)
+			if assert.NoError(t, endpoint.Cancel(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.JSONEq(t, `{"order_id":"`+orderId+`","filled_quantity":"0"}`, rec.Body.String())
+			}
+		}
+		cancelled, _ := orders.FindById(context.Background(), orderId)
+		assert.Equal(t, order.OrderStatusCancelled, cancelled.Status())
+	})
+
+	t.Run("Cancel Partially Filled Order", func(t *testing.T) {
+		exchange := order.NewExchange(order.NewInMemoryOrderRepository(), &StubPortfolioNotifier{}, expiry)
+		orderId := placeOrder(exchange, order.Buy)
+		counterparty, _ := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", order.Sell, order.Limit, order.GoodTillCancelled, decimal.NewFromInt(4), 0, decimal.NewFromInt(20), decimal.Zero)
+		exchange.Place(context.Background(), counterparty)
+		endpoint := features.NewCancelOrderEndpoint(features.NewCancelOrderHandler(exchange))
+		c, rec := newContext(orderId)
+
+		if assert.NoError(t, endpoint.Cancel(c)) {
+			assert.JSONEq(t, `{"order_id":"`+orderId+`","filled_quantity":"4"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Cancel Filled Order", func(t *testing.T) {
+		exchange := order.NewExchange(order.NewInMemoryOrderRepository(), &StubPortfolioNotifier{}, expiry)
+		orderId := placeOrder(exchange, order.Buy)
+		placeOrder(exchange, order.Sell)
+		endpoint := features.NewCancelOrderEndpoint(features.NewCancelOrderHandler(exchange))
+		c, _ := newContext(orderId)
+
+		err := endpoint.Cancel(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusConflict, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("Cancel Unknown Order", func(t *testing.T) {
+		endpoint := features.NewCancelOrderEndpoint(features.NewCancelOrderHandler(order.NewExchange(order.NewInMemoryOrderRepository(), &StubPortfolioNotifier{}, expiry)))
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Cancel(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+}
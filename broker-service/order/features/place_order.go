@@ -0,0 +1,105 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/broker-service/common"
+	"stock-trader/broker-service/order"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+type PlaceOrderEndpoint struct {
+	handler common.Handler[PlaceOrderCommand, string]
+}
+
+func NewPlaceOrderEndpoint(handler common.Handler[PlaceOrderCommand, string]) *PlaceOrderEndpoint {
+	return &PlaceOrderEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *PlaceOrderEndpoint) Place(c echo.Context) error {
+	command := new(PlaceOrderCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	orderId, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, order.ErrInvalidOrder) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, struct {
+		OrderId string `json:"order_id"`
+	}{
+		OrderId: orderId,
+	})
+}
+
+// PlaceOrderCommand places an order of the given type and time in force. Without
+// them it places a day limit order, which is what portfolios placed before there
+// were other types. Without a precision the order may be filled in the finest
+// fraction of a share.
+type PlaceOrderCommand struct {
+	OrderId     string          `json:"order_id" validate:"required,uuid"`
+	PortfolioId string          `json:"portfolio_id" validate:"required,uuid"`
+	Symbol      string          `json:"symbol" validate:"required,max=8"`
+	Side        string          `json:"side" validate:"required,oneof=buy sell"`
+	Type        string          `json:"type" validate:"omitempty,oneof=market limit stop stop-limit"`
+	TimeInForce string          `json:"time_in_force" validate:"omitempty,oneof=day gtc ioc fok"`
+	Quantity    decimal.Decimal `json:"quantity" validate:"gt=0"`
+	Precision   *int32          `json:"precision" validate:"omitempty,gte=0,lte=6"`
+	LimitPrice  decimal.Decimal `json:"limit_price" validate:"omitempty,gt=0"`
+	StopPrice   decimal.Decimal `json:"stop_price" validate:"omitempty,gt=0"`
+}
+
+type PlaceOrderHandler struct {
+	exchange *order.Exchange
+}
+
+func NewPlaceOrderHandler(exchange *order.Exchange) *PlaceOrderHandler {
+	return &PlaceOrderHandler{
+		exchange: exchange,
+	}
+}
+
+// Handle accepts an order once: submitting an order id that is already known
+// does nothing.
+func (h *PlaceOrderHandler) Handle(ctx context.Context, command PlaceOrderCommand) (string, error) {
+	orderType := order.OrderType(command.Type)
+	if orderType == "" {
+		orderType = order.Limit
+	}
+
+	timeInForce := order.TimeInForce(command.TimeInForce)
+	if timeInForce == "" {
+		timeInForce = order.Day
+	}
+
+	precision := int32(order.MaxPrecision)
+	if command.Precision != nil {
+		precision = *command.Precision
+	}
+
+	placed, err := order.NewOrder(command.OrderId, command.PortfolioId, command.Symbol, order.OrderSide(command.Side), orderType, timeInForce, command.Quantity, precision, command.LimitPrice, command.StopPrice)
+	if err != nil {
+		return "", err
+	}
+
+	if err := h.exchange.Place(ctx, placed); err != nil {
+		return "", err
+	}
+
+	return placed.Id(), nil
+}
@@ -0,0 +1,162 @@
+package order_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/broker-service/infrastructure"
+	"stock-trader/broker-service/order"
+	features "stock-trader/broker-service/order/features"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PlaceOrderHandler(t *testing.T) {
+	command := func(side string, quantity int64, limitPrice int64) features.PlaceOrderCommand {
+		return features.PlaceOrderCommand{
+			OrderId:     uuid.NewString(),
+			PortfolioId: uuid.NewString(),
+			Symbol:      "ACME",
+			Side:        side,
+			Quantity:    decimal.NewFromInt(quantity),
+			LimitPrice:  decimal.NewFromInt(limitPrice),
+		}
+	}
+
+	t.Run("Place order without a counterparty rests it", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		handler := features.NewPlaceOrderHandler(order.NewExchange(orders, &StubPortfolioNotifier{}, expiry))
+		placed := command("buy", 10, 20)
+
+		orderId, err := handler.Handle(context.Background(), placed)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, placed.OrderId, orderId)
+			saved, _ := orders.FindById(context.Background(), orderId)
+			assert.Equal(t, order.OrderStatusOpen, saved.Status())
+			assert.Empty(t, notifiedTrades(orders))
+		}
+	})
+
+	t.Run("Place order matching a resting one notifies both portfolios", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		handler := features.NewPlaceOrderHandler(order.NewExchange(orders, &StubPortfolioNotifier{}, expiry))
+		resting := command("sell", 10, 19)
+		handler.Handle(context.Background(), resting)
+
+		orderId, err := handler.Handle(context.Background(), command("buy", 4, 20))
+
+		if assert.NoError(t, err) {
+			saved, _ := orders.FindById(context.Background(), orderId)
+			assert.Equal(t, order.OrderStatusFilled, saved.Status())
+			if assert.Len(t, notifiedTrades(orders), 2) {
+				assert.Equal(t, orderId, notifiedTrades(orders)[0].OrderId)
+				assert.Equal(t, resting.OrderId, notifiedTrades(orders)[1].OrderId)
+				assert.Equal(t, notifiedTrades(orders)[0].Id, notifiedTrades(orders)[1].Id)
+				assert.Equal(t, "4", notifiedTrades(orders)[1].Quantity.String())
+				assert.Equal(t, "19", notifiedTrades(orders)[1].Price.String())
+			}
+		}
+	})
+
+	t.Run("Place the same order twice", func(t *testing.T) {
+		orders := order.NewInMemoryOrderRepository()
+		handler := features.NewPlaceOrderHandler(order.NewExchange(orders, &StubPortfolioNotifier{}, expiry))
+		handler.Handle(context.Background(), command("sell", 10, 20))
+		placed := command("buy", 10, 20)
+
+		handler.Handle(context.Background(), placed)
+		_, err := handler.Handle(context.Background(), placed)
+
+		assert.NoError(t, err)
+		assert.Len(t, notifiedTrades(orders), 2)
+	})
+}
+
+func Test_PlaceOrderEndpoint(t *testing.T) {
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return e.NewContext(req, rec), rec
+	}
+
+	t.Run("Place Order Successfully", func(t *testing.T) {
+		orderId := uuid.NewString()
+		endpoint := features.NewPlaceOrderEndpoint(&StubHandler[features.PlaceOrderCommand, string]{
+			call: func(ctx context.Context, command features.PlaceOrderCommand) (string, error) {
+				return command.OrderId, nil
+			},
+		})
+		c, rec := newContext(`{"order_id":"` + orderId + `","portfolio_id":"` + uuid.NewString() + `","symbol":"ACME","side":"sell","quantity":5,"limit_price":"20"}`)
+
+		if assert.NoError(t, endpoint.Place(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.JSONEq(t, `{"order_id":"`+orderId+`"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Place Invalid Order", func(t *testing.T) {
+		endpoint := features.NewPlaceOrderEndpoint(features.NewPlaceOrderHandler(order.NewExchange(order.NewInMemoryOrderRepository(), &StubPortfolioNotifier{}, expiry)))
+		c, _ := newContext(`{"order_id":"` + uuid.NewString() + `","portfolio_id":"` + uuid.NewString() + `","symbol":"   ","side":"buy","quantity":5,"limit_price":"20"}`)
+
+		err := endpoint.Place(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusUnprocessableEntity, err.Code)
+			assert.Equal(t, "invalid order: symbol must be between 1 and 8 characters long", err.Message)
+		}
+	})
+
+	t.Run("Place Stop Order Without Stop Price", func(t *testing.T) {
+		endpoint := features.NewPlaceOrderEndpoint(features.NewPlaceOrderHandler(order.NewExchange(order.NewInMemoryOrderRepository(), &StubPortfolioNotifier{}, expiry)))
+		c, _ := newContext(`{"order_id":"` + uuid.NewString() + `","portfolio_id":"` + uuid.NewString() + `","symbol":"ACME","side":"sell","type":"stop","quantity":5}`)
+
+		err := endpoint.Place(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusUnprocessableEntity, err.Code)
+			assert.Equal(t, "invalid order: stop orders require a stop price greater than zero", err.Message)
+		}
+	})
+}
+
+var expiry = order.ExpiryPolicy{
+	MarketClose:              16 * time.Hour,
+	Location:                 time.UTC,
+	GoodTillCancelledHorizon: 90 * 24 * time.Hour,
+}
+
+type StubHandler[K any, V any] struct {
+	call func(context.Context, K) (V, error)
+}
+
+func (s *StubHandler[K, V]) Handle(ctx context.Context, command K) (V, error) {
+	return s.call(ctx, command)
+}
+
+type StubPortfolioNotifier struct{}
+
+func (n *StubPortfolioNotifier) Wake() {}
+
+// notifiedTrades reads the trades the exchange stored for the portfolios.
+func notifiedTrades(orders order.OrderRepository) []order.Trade {
+	notifications, _ := orders.PendingNotifications(context.Background(), 1000)
+	trades := []order.Trade{}
+	for _, notification := range notifications {
+		if notification.Type == order.NotificationTrade {
+			trades = append(trades, notification.Trade)
+		}
+	}
+	return trades
+}
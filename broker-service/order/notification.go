@@ -0,0 +1,93 @@
+package order
+
+import (
+	"net/url"
+)
+
+type NotificationType string
+
+const (
+	NotificationTrade        NotificationType = "trade"
+	NotificationCancellation NotificationType = "cancellation"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending   NotificationStatus = "pending"
+	NotificationDelivered NotificationStatus = "delivered"
+	// NotificationDead is a notification the portfolio service refused. It is
+	// kept in the outbox for an operator to look into, but not retried.
+	NotificationDead NotificationStatus = "dead"
+)
+
+// Notification tells the portfolio service what happened to one of its
+// orders. Notifications are stored in the outbox together with the orders
+// whose changes they report, and delivered in the order they were stored.
+type Notification struct {
+	Id        int64
+	Type      NotificationType
+	OrderId   string
+	Trade     Trade
+	Reason    string
+	Status    NotificationStatus
+	Attempts  int
+	LastError string
+}
+
+func (n Notification) path() string {
+	if n.Type == NotificationTrade {
+		return "/orders/" + url.PathEscape(n.OrderId) + "/trades"
+	}
+	return "/orders/" + url.PathEscape(n.OrderId) + "/cancellations"
+}
+
+func (n Notification) body() map[string]any {
+	if n.Type == NotificationTrade {
+		return map[string]any{
+			"trade_id": n.Trade.Id,
+			"quantity": n.Trade.Quantity,
+			"price":    n.Trade.Price,
+		}
+	}
+	return map[string]any{
+		"reason": n.Reason,
+	}
+}
+
+// notificationsOf returns the notifications the pending domain events of an
+// order call for.
+func notificationsOf(order *Order) []Notification {
+	notifications := []Notification{}
+	for _, event := range order.DomainEvents() {
+		switch e := event.(type) {
+		case OrderPartiallyFilled:
+			notifications = append(notifications, tradeNotification(e.Trade()))
+		case OrderFilled:
+			notifications = append(notifications, tradeNotification(e.Trade()))
+		case OrderCancelled:
+			notifications = append(notifications, cancellationNotification(e.OrderId(), e.Reason()))
+		case OrderExpired:
+			notifications = append(notifications, cancellationNotification(e.OrderId(), "order expired"))
+		}
+	}
+	return notifications
+}
+
+func tradeNotification(trade Trade) Notification {
+	return Notification{
+		Type:    NotificationTrade,
+		OrderId: trade.OrderId,
+		Trade:   trade,
+		Status:  NotificationPending,
+	}
+}
+
+func cancellationNotification(orderId string, reason string) Notification {
+	return Notification{
+		Type:    NotificationCancellation,
+		OrderId: orderId,
+		Reason:  reason,
+		Status:  NotificationPending,
+	}
+}
@@ -0,0 +1,345 @@
+package order
+
+import (
+	"fmt"
+	"stock-trader/broker-service/common"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type OrderSide string
+
+const (
+	Buy  OrderSide = "buy"
+	Sell OrderSide = "sell"
+)
+
+type OrderType string
+
+const (
+	Market    OrderType = "market"
+	Limit     OrderType = "limit"
+	Stop      OrderType = "stop"
+	StopLimit OrderType = "stop-limit"
+)
+
+// TimeInForce tells how long an order stays in the book. Day orders expire at
+// market close and good-till-cancelled ones after a horizon. Immediate-or-cancel
+// orders cancel what they can not fill at once, and fill-or-kill ones are
+// cancelled unless they can be filled entirely at once.
+type TimeInForce string
+
+const (
+	Day               TimeInForce = "day"
+	GoodTillCancelled TimeInForce = "gtc"
+	ImmediateOrCancel TimeInForce = "ioc"
+	FillOrKill        TimeInForce = "fok"
+)
+
+// MaxPrecision is the finest fraction of a share an order can be placed in, in
+// decimal places.
+const MaxPrecision = 6
+
+type OrderStatus string
+
+const (
+	OrderStatusOpen      OrderStatus = "open"
+	OrderStatusFilled    OrderStatus = "filled"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusExpired   OrderStatus = "expired"
+)
+
+// Order is an order a portfolio submitted to the broker. Its id is chosen by the
+// portfolio, which lets the portfolio submit the same order more than once.
+type Order struct {
+	id             string
+	portfolioId    string
+	symbol         string
+	side           OrderSide
+	orderType      OrderType
+	quantity       decimal.Decimal
+	precision      int32
+	limitPrice     decimal.Decimal
+	stopPrice      decimal.Decimal
+	triggered      bool
+	timeInForce    TimeInForce
+	expiresAt      time.Time
+	filledQuantity decimal.Decimal
+	status         OrderStatus
+	sequence       int64
+	domainEvents   []common.DomainEvent
+}
+
+// Trade is a fill of an order, as told to the portfolio that placed it.
+type Trade struct {
+	Id       string
+	OrderId  string
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+}
+
+// NewOrder validates an order of the given type. Market orders take no price,
+// limit orders a limit price, stop orders a stop price and stop-limit orders
+// both. A price that does not apply to the type must be zero. The order is
+// only ever filled in the decimal places of its precision.
+func NewOrder(id string, portfolioId string, symbol string, side OrderSide, orderType OrderType, timeInForce TimeInForce, quantity decimal.Decimal, precision int32, limitPrice decimal.Decimal, stopPrice decimal.Decimal) (*Order, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if len(symbol) == 0 || len(symbol) > 8 {
+		return nil, fmt.Errorf("%w: symbol must be between 1 and 8 characters long", ErrInvalidOrder)
+	}
+	if side != Buy && side != Sell {
+		return nil, fmt.Errorf("%w: order side must be either buy or sell", ErrInvalidOrder)
+	}
+	if timeInForce != Day && timeInForce != GoodTillCancelled && timeInForce != ImmediateOrCancel && timeInForce != FillOrKill {
+		return nil, fmt.Errorf("%w: time in force must be one of day, gtc, ioc or fok", ErrInvalidOrder)
+	}
+	if !quantity.IsPositive() {
+		return nil, fmt.Errorf("%w: order quantity must be greater than zero", ErrInvalidOrder)
+	}
+	if precision < 0 || precision > MaxPrecision {
+		return nil, fmt.Errorf("%w: order precision must be between 0 and %d places", ErrInvalidOrder, MaxPrecision)
+	}
+	if !quantity.Equal(quantity.RoundFloor(precision)) {
+		return nil, fmt.Errorf("%w: order quantity must be a multiple of %s", ErrInvalidOrder, decimal.New(1, -precision))
+	}
+	if err := validatePrices(orderType, limitPrice, stopPrice); err != nil {
+		return nil, err
+	}
+
+	return &Order{
+		id:             id,
+		portfolioId:    portfolioId,
+		symbol:         symbol,
+		side:           side,
+		orderType:      orderType,
+		quantity:       quantity,
+		precision:      precision,
+		limitPrice:     limitPrice,
+		stopPrice:      stopPrice,
+		timeInForce:    timeInForce,
+		filledQuantity: decimal.Zero,
+		status:         OrderStatusOpen,
+	}, nil
+}
+
+func validatePrices(orderType OrderType, limitPrice decimal.Decimal, stopPrice decimal.Decimal) error {
+	var takesLimitPrice, takesStopPrice bool
+	switch orderType {
+	case Market:
+	case Limit:
+		takesLimitPrice = true
+	case Stop:
+		takesStopPrice = true
+	case StopLimit:
+		takesLimitPrice, takesStopPrice = true, true
+	default:
+		return fmt.Errorf("%w: order type must be one of market, limit, stop or stop-limit", ErrInvalidOrder)
+	}
+
+	if takesLimitPrice && !limitPrice.IsPositive() {
+		return fmt.Errorf("%w: %s orders require a limit price greater than zero", ErrInvalidOrder, orderType)
+	}
+	if !takesLimitPrice && !limitPrice.IsZero() {
+		return fmt.Errorf("%w: %s orders do not take a limit price", ErrInvalidOrder, orderType)
+	}
+	if takesStopPrice && !stopPrice.IsPositive() {
+		return fmt.Errorf("%w: %s orders require a stop price greater than zero", ErrInvalidOrder, orderType)
+	}
+	if !takesStopPrice && !stopPrice.IsZero() {
+		return fmt.Errorf("%w: %s orders do not take a stop price", ErrInvalidOrder, orderType)
+	}
+	return nil
+}
+
+func (o Order) Id() string {
+	return o.id
+}
+
+func (o Order) PortfolioId() string {
+	return o.portfolioId
+}
+
+func (o Order) Symbol() string {
+	return o.symbol
+}
+
+func (o Order) Side() OrderSide {
+	return o.side
+}
+
+func (o Order) Type() OrderType {
+	return o.orderType
+}
+
+func (o Order) Quantity() decimal.Decimal {
+	return o.quantity
+}
+
+func (o Order) LimitPrice() decimal.Decimal {
+	return o.limitPrice
+}
+
+func (o Order) StopPrice() decimal.Decimal {
+	return o.stopPrice
+}
+
+func (o Order) TimeInForce() TimeInForce {
+	return o.timeInForce
+}
+
+// ExpiresAt is when the order expires if it is not filled by then. It is zero
+// for orders that never rest in the book and until the exchange takes the order.
+func (o Order) ExpiresAt() time.Time {
+	return o.expiresAt
+}
+
+// AwaitingTrigger tells whether the order is a stop order that is waiting for
+// the price to reach its stop price before it can be filled.
+func (o Order) AwaitingTrigger() bool {
+	return (o.orderType == Stop || o.orderType == StopLimit) && !o.triggered && o.status == OrderStatusOpen
+}
+
+// Marketable tells whether the order takes any price once it can be filled.
+func (o Order) Marketable() bool {
+	return o.orderType == Market || o.orderType == Stop
+}
+
+// Rests tells whether what the order can not fill at once waits in the book.
+func (o Order) Rests() bool {
+	return !o.Marketable() && (o.timeInForce == Day || o.timeInForce == GoodTillCancelled)
+}
+
+// Precision is the decimal places the order is filled in.
+func (o Order) Precision() int32 {
+	return o.precision
+}
+
+func (o Order) FilledQuantity() decimal.Decimal {
+	return o.filledQuantity
+}
+
+func (o Order) UnfilledQuantity() decimal.Decimal {
+	return o.quantity.Sub(o.filledQuantity)
+}
+
+func (o Order) Status() OrderStatus {
+	return o.status
+}
+
+// Sequence is the position in which the order reached the exchange. It gives
+// the order its time priority among the orders at the same price.
+func (o Order) Sequence() int64 {
+	return o.sequence
+}
+
+// Trigger releases a stop order once the price reached its stop price: a stop
+// order becomes a market order and a stop-limit order a limit order.
+func (o *Order) Trigger() error {
+	if !o.AwaitingTrigger() {
+		return fmt.Errorf("order %s is not awaiting a trigger", o.id)
+	}
+	o.triggered = true
+	return nil
+}
+
+// Fill records a trade of the order, identified by tradeId.
+func (o *Order) Fill(tradeId string, quantity decimal.Decimal, price decimal.Decimal) error {
+	if o.status != OrderStatusOpen {
+		return fmt.Errorf("order %s is %s", o.id, o.status)
+	}
+	if o.AwaitingTrigger() {
+		return fmt.Errorf("order %s is awaiting its stop price", o.id)
+	}
+	if !quantity.IsPositive() || quantity.GreaterThan(o.UnfilledQuantity()) {
+		return fmt.Errorf("fill quantity must be greater than zero and at most %s", o.UnfilledQuantity())
+	}
+
+	o.filledQuantity = o.filledQuantity.Add(quantity)
+
+	fill := orderFill{
+		orderId:          o.id,
+		portfolioId:      o.portfolioId,
+		tradeId:          tradeId,
+		symbol:           o.symbol,
+		side:             o.side,
+		quantity:         quantity,
+		price:            price,
+		unfilledQuantity: o.UnfilledQuantity(),
+	}
+	if o.UnfilledQuantity().IsZero() {
+		o.status = OrderStatusFilled
+		o.domainEvents = append(o.domainEvents, OrderFilled{
+			baseDomainEvent: common.NewBaseDomainEvent("order-filled"),
+			orderFill:       fill,
+		})
+	} else {
+		o.domainEvents = append(o.domainEvents, OrderPartiallyFilled{
+			baseDomainEvent: common.NewBaseDomainEvent("order-partially-filled"),
+			orderFill:       fill,
+		})
+	}
+
+	return nil
+}
+
+// Cancel stops the order from being filled any further.
+func (o *Order) Cancel() error {
+	switch o.status {
+	case OrderStatusFilled:
+		return fmt.Errorf("%w: %s", ErrOrderAlreadyFilled, o.id)
+	case OrderStatusCancelled:
+		return fmt.Errorf("%w: %s", ErrOrderAlreadyCancelled, o.id)
+	case OrderStatusExpired:
+		return fmt.Errorf("%w: %s", ErrOrderExpired, o.id)
+	}
+	o.status = OrderStatusCancelled
+	return nil
+}
+
+// Expire closes an open order whose time in force ran out by now.
+func (o *Order) Expire(now time.Time) error {
+	if o.status != OrderStatusOpen {
+		return fmt.Errorf("order %s is %s", o.id, o.status)
+	}
+	if o.expiresAt.IsZero() || now.Before(o.expiresAt) {
+		return fmt.Errorf("order %s is not due to expire", o.id)
+	}
+
+	o.status = OrderStatusExpired
+	o.domainEvents = append(o.domainEvents, OrderExpired{
+		baseDomainEvent:  common.NewBaseDomainEvent("order-expired"),
+		orderId:          o.id,
+		portfolioId:      o.portfolioId,
+		unfilledQuantity: o.UnfilledQuantity(),
+		expiresAt:        o.expiresAt,
+	})
+	return nil
+}
+
+// CancelRemainder cancels what is left of the order on the broker's own
+// account, as when a market order runs out of orders to match, and tells why.
+func (o *Order) CancelRemainder(reason string) error {
+	if err := o.Cancel(); err != nil {
+		return err
+	}
+	o.domainEvents = append(o.domainEvents, OrderCancelled{
+		baseDomainEvent:  common.NewBaseDomainEvent("order-cancelled"),
+		orderId:          o.id,
+		portfolioId:      o.portfolioId,
+		unfilledQuantity: o.UnfilledQuantity(),
+		reason:           reason,
+	})
+	return nil
+}
+
+func (o Order) DomainEvents() []common.DomainEvent {
+	output := []common.DomainEvent{}
+	output = append(output, o.domainEvents...)
+	return output
+}
+
+func (o *Order) ClearDomainEvents() {
+	o.domainEvents = []common.DomainEvent{}
+}
@@ -0,0 +1,352 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+type OrderRepository interface {
+	FindById(context.Context, string) (*Order, error)
+	// FindOpen returns the orders that can still be filled, in the order they
+	// reached the exchange.
+	FindOpen(context.Context) ([]*Order, error)
+	// FindExpiring returns up to limit open orders that are due to expire by
+	// now, the earliest first.
+	FindExpiring(ctx context.Context, now time.Time, limit int) ([]*Order, error)
+	// Save stores all the orders or none of them, together with the
+	// notifications their domain events call for.
+	Save(context.Context, ...*Order) error
+	// PendingNotifications returns up to limit notifications still to be
+	// delivered, in the order they were stored.
+	PendingNotifications(ctx context.Context, limit int) ([]Notification, error)
+	// SaveNotification stores how the delivery of a notification went.
+	SaveNotification(context.Context, Notification) error
+}
+
+type orderEntity struct {
+	Id             string              `gorm:"column:id;primaryKey"`
+	PortfolioId    string              `gorm:"column:portfolio_id"`
+	Symbol         string              `gorm:"column:symbol"`
+	Side           string              `gorm:"column:side"`
+	Type           string              `gorm:"column:type"`
+	Quantity       decimal.Decimal     `gorm:"column:quantity"`
+	Precision      int32               `gorm:"column:precision"`
+	LimitPrice     decimal.NullDecimal `gorm:"column:limit_price"`
+	StopPrice      decimal.NullDecimal `gorm:"column:stop_price"`
+	Triggered      bool                `gorm:"column:triggered"`
+	TimeInForce    string              `gorm:"column:time_in_force"`
+	ExpiresAt      *time.Time          `gorm:"column:expires_at"`
+	FilledQuantity decimal.Decimal     `gorm:"column:filled_quantity"`
+	Status         string              `gorm:"column:status"`
+	Sequence       int64               `gorm:"column:sequence"`
+	UpdatedAt      time.Time           `gorm:"column:updated_at"`
+}
+
+func (orderEntity) TableName() string {
+	return "orders"
+}
+
+func mapOrderEntity(order *Order) *orderEntity {
+	return &orderEntity{
+		Id:             order.id,
+		PortfolioId:    order.portfolioId,
+		Symbol:         order.symbol,
+		Side:           string(order.side),
+		Type:           string(order.orderType),
+		Quantity:       order.quantity,
+		Precision:      order.precision,
+		LimitPrice:     nullPrice(order.limitPrice),
+		StopPrice:      nullPrice(order.stopPrice),
+		Triggered:      order.triggered,
+		TimeInForce:    string(order.timeInForce),
+		ExpiresAt:      nullTime(order.expiresAt),
+		FilledQuantity: order.filledQuantity,
+		Status:         string(order.status),
+		Sequence:       order.sequence,
+		UpdatedAt:      time.Now().UTC(),
+	}
+}
+
+func mapOrder(entity *orderEntity) *Order {
+	order := &Order{
+		id:             entity.Id,
+		portfolioId:    entity.PortfolioId,
+		symbol:         entity.Symbol,
+		side:           OrderSide(entity.Side),
+		orderType:      OrderType(entity.Type),
+		quantity:       entity.Quantity,
+		precision:      entity.Precision,
+		limitPrice:     entity.LimitPrice.Decimal,
+		stopPrice:      entity.StopPrice.Decimal,
+		triggered:      entity.Triggered,
+		timeInForce:    TimeInForce(entity.TimeInForce),
+		filledQuantity: entity.FilledQuantity,
+		status:         OrderStatus(entity.Status),
+		sequence:       entity.Sequence,
+	}
+	if entity.ExpiresAt != nil {
+		order.expiresAt = entity.ExpiresAt.UTC()
+	}
+	return order
+}
+
+type notificationEntity struct {
+	Id        int64               `gorm:"column:id;primaryKey;autoIncrement"`
+	OrderId   string              `gorm:"column:order_id"`
+	Type      string              `gorm:"column:type"`
+	TradeId   string              `gorm:"column:trade_id"`
+	Quantity  decimal.NullDecimal `gorm:"column:quantity"`
+	Price     decimal.NullDecimal `gorm:"column:price"`
+	Reason    string              `gorm:"column:reason"`
+	Status    string              `gorm:"column:status"`
+	Attempts  int                 `gorm:"column:attempts"`
+	LastError string              `gorm:"column:last_error"`
+	CreatedAt time.Time           `gorm:"column:created_at"`
+	UpdatedAt time.Time           `gorm:"column:updated_at"`
+}
+
+func (notificationEntity) TableName() string {
+	return "portfolio_notifications"
+}
+
+func mapNotificationEntity(notification Notification) *notificationEntity {
+	entity := &notificationEntity{
+		Id:        notification.Id,
+		OrderId:   notification.OrderId,
+		Type:      string(notification.Type),
+		Reason:    notification.Reason,
+		Status:    string(notification.Status),
+		Attempts:  notification.Attempts,
+		LastError: notification.LastError,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if notification.Type == NotificationTrade {
+		entity.TradeId = notification.Trade.Id
+		entity.Quantity = decimal.NewNullDecimal(notification.Trade.Quantity)
+		entity.Price = decimal.NewNullDecimal(notification.Trade.Price)
+	}
+	return entity
+}
+
+func mapNotification(entity *notificationEntity) Notification {
+	notification := Notification{
+		Id:        entity.Id,
+		Type:      NotificationType(entity.Type),
+		OrderId:   entity.OrderId,
+		Reason:    entity.Reason,
+		Status:    NotificationStatus(entity.Status),
+		Attempts:  entity.Attempts,
+		LastError: entity.LastError,
+	}
+	if notification.Type == NotificationTrade {
+		notification.Trade = Trade{
+			Id:       entity.TradeId,
+			OrderId:  entity.OrderId,
+			Quantity: entity.Quantity.Decimal,
+			Price:    entity.Price.Decimal,
+		}
+	}
+	return notification
+}
+
+// nullPrice stores the prices that do not apply to the type of an order as NULL.
+func nullPrice(price decimal.Decimal) decimal.NullDecimal {
+	return decimal.NullDecimal{Decimal: price, Valid: !price.IsZero()}
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+type mySQLOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &mySQLOrderRepository{
+		db: db,
+	}
+}
+
+func (r *mySQLOrderRepository) FindById(ctx context.Context, orderId string) (*Order, error) {
+	entity := &orderEntity{}
+	if err := r.db.WithContext(ctx).Where("id = ?", orderId).First(entity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderId)
+		}
+		return nil, err
+	}
+	return mapOrder(entity), nil
+}
+
+func (r *mySQLOrderRepository) FindOpen(ctx context.Context) ([]*Order, error) {
+	var entities []orderEntity
+	if err := r.db.WithContext(ctx).Where("status = ?", OrderStatusOpen).Order("sequence").Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	orders := []*Order{}
+	for i := range entities {
+		orders = append(orders, mapOrder(&entities[i]))
+	}
+	return orders, nil
+}
+
+func (r *mySQLOrderRepository) FindExpiring(ctx context.Context, now time.Time, limit int) ([]*Order, error) {
+	var entities []orderEntity
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", OrderStatusOpen, now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	orders := []*Order{}
+	for i := range entities {
+		orders = append(orders, mapOrder(&entities[i]))
+	}
+	return orders, nil
+}
+
+func (r *mySQLOrderRepository) Save(ctx context.Context, orders ...*Order) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, order := range orders {
+			if err := tx.Save(mapOrderEntity(order)).Error; err != nil {
+				return err
+			}
+			for _, notification := range notificationsOf(order) {
+				entity := mapNotificationEntity(notification)
+				entity.CreatedAt = entity.UpdatedAt
+				if err := tx.Create(entity).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *mySQLOrderRepository) PendingNotifications(ctx context.Context, limit int) ([]Notification, error) {
+	var entities []notificationEntity
+	if err := r.db.WithContext(ctx).Where("status = ?", NotificationPending).Order("id").Limit(limit).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	notifications := []Notification{}
+	for i := range entities {
+		notifications = append(notifications, mapNotification(&entities[i]))
+	}
+	return notifications, nil
+}
+
+func (r *mySQLOrderRepository) SaveNotification(ctx context.Context, notification Notification) error {
+	entity := mapNotificationEntity(notification)
+	return r.db.WithContext(ctx).Model(entity).Select("status", "attempts", "last_error", "updated_at").Updates(entity).Error
+}
+
+type inMemoryOrderRepository struct {
+	mu            sync.Mutex
+	orders        map[string]Order
+	notifications []Notification
+}
+
+func NewInMemoryOrderRepository() OrderRepository {
+	return &inMemoryOrderRepository{
+		orders: map[string]Order{},
+	}
+}
+
+func (r *inMemoryOrderRepository) FindById(ctx context.Context, orderId string) (*Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order, ok := r.orders[orderId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderId)
+	}
+	return &order, nil
+}
+
+func (r *inMemoryOrderRepository) FindOpen(ctx context.Context) ([]*Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := []*Order{}
+	for _, order := range r.orders {
+		if order.status == OrderStatusOpen {
+			order := order
+			orders = append(orders, &order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].sequence < orders[j].sequence })
+	return orders, nil
+}
+
+func (r *inMemoryOrderRepository) FindExpiring(ctx context.Context, now time.Time, limit int) ([]*Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := []*Order{}
+	for _, order := range r.orders {
+		if order.status == OrderStatusOpen && !order.expiresAt.IsZero() && !now.Before(order.expiresAt) {
+			order := order
+			orders = append(orders, &order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].expiresAt.Before(orders[j].expiresAt) })
+	if len(orders) > limit {
+		orders = orders[:limit]
+	}
+	return orders, nil
+}
+
+func (r *inMemoryOrderRepository) Save(ctx context.Context, orders ...*Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, order := range orders {
+		for _, notification := range notificationsOf(order) {
+			notification.Id = int64(len(r.notifications) + 1)
+			r.notifications = append(r.notifications, notification)
+		}
+		saved := *order
+		saved.domainEvents = nil
+		r.orders[order.id] = saved
+	}
+	return nil
+}
+
+func (r *inMemoryOrderRepository) PendingNotifications(ctx context.Context, limit int) ([]Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	notifications := []Notification{}
+	for _, notification := range r.notifications {
+		if notification.Status == NotificationPending && len(notifications) < limit {
+			notifications = append(notifications, notification)
+		}
+	}
+	return notifications, nil
+}
+
+func (r *inMemoryOrderRepository) SaveNotification(ctx context.Context, notification Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if notification.Id < 1 || notification.Id > int64(len(r.notifications)) {
+		return fmt.Errorf("notification %d not found", notification.Id)
+	}
+	r.notifications[notification.Id-1] = notification
+	return nil
+}
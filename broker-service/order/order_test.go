@@ -0,0 +1,203 @@
+package order_test
+
+import (
+	"stock-trader/broker-service/order"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOrder(t *testing.T, quantity int64) *order.Order {
+	placed, err := order.NewOrder(uuid.NewString(), uuid.NewString(), " acme ", order.Buy, order.Limit, order.GoodTillCancelled, decimal.NewFromInt(quantity), 0, decimal.NewFromInt(20), decimal.Zero)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return placed
+}
+
+func TestNewOrder(t *testing.T) {
+	t.Run("New order successfully", func(t *testing.T) {
+		placed := newOrder(t, 10)
+
+		assert.Equal(t, "ACME", placed.Symbol())
+		assert.Equal(t, order.OrderStatusOpen, placed.Status())
+		assert.Equal(t, "10", placed.UnfilledQuantity().String())
+	})
+
+	t.Run("New order with invalid side", func(t *testing.T) {
+		placed, err := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", "hold", order.Limit, order.GoodTillCancelled, decimal.NewFromInt(10), 0, decimal.NewFromInt(20), decimal.Zero)
+
+		assert.ErrorIs(t, err, order.ErrInvalidOrder)
+		assert.EqualError(t, err, "invalid order: order side must be either buy or sell")
+		assert.Nil(t, placed)
+	})
+
+	t.Run("New order with invalid time in force", func(t *testing.T) {
+		_, err := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", order.Buy, order.Limit, "week", decimal.NewFromInt(10), 0, decimal.NewFromInt(20), decimal.Zero)
+
+		assert.EqualError(t, err, "invalid order: time in force must be one of day, gtc, ioc or fok")
+	})
+
+	t.Run("New order finer than its precision", func(t *testing.T) {
+		_, err := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", order.Buy, order.Limit, order.GoodTillCancelled, decimal.RequireFromString("1.505"), 2, decimal.NewFromInt(20), decimal.Zero)
+
+		assert.EqualError(t, err, "invalid order: order quantity must be a multiple of 0.01")
+	})
+
+	t.Run("New order validates the prices of its type", func(t *testing.T) {
+		none, price := decimal.Zero, decimal.NewFromInt(20)
+		cases := []struct {
+			orderType  order.OrderType
+			limitPrice decimal.Decimal
+			stopPrice  decimal.Decimal
+			err        string
+		}{
+			{order.Market, none, none, ""},
+			{order.Market, price, none, "invalid order: market orders do not take a limit price"},
+			{order.Limit, price, none, ""},
+			{order.Limit, none, none, "invalid order: limit orders require a limit price greater than zero"},
+			{order.Limit, price, price, "invalid order: limit orders do not take a stop price"},
+			{order.Stop, none, price, ""},
+			{order.Stop, none, none, "invalid order: stop orders require a stop price greater than zero"},
+			{order.Stop, price, price, "invalid order: stop orders do not take a limit price"},
+			{order.StopLimit, price, price, ""},
+			{order.StopLimit, price, none, "invalid order: stop-limit orders require a stop price greater than zero"},
+			{order.StopLimit, none, price, "invalid order: stop-limit orders require a limit price greater than zero"},
+			{"trailing", price, none, "invalid order: order type must be one of market, limit, stop or stop-limit"},
+		}
+
+		for _, c := range cases {
+			placed, err := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", order.Buy, c.orderType, order.GoodTillCancelled, decimal.NewFromInt(10), 0, c.limitPrice, c.stopPrice)
+
+			if c.err == "" {
+				assert.NoError(t, err, c.orderType)
+				assert.Equal(t, c.orderType, placed.Type())
+			} else {
+				assert.EqualError(t, err, c.err)
+			}
+		}
+	})
+}
+
+func TestTriggerOrder(t *testing.T) {
+	newStop := func(t *testing.T) *order.Order {
+		placed, err := order.NewOrder(uuid.NewString(), uuid.NewString(), "ACME", order.Sell, order.Stop, order.GoodTillCancelled, decimal.NewFromInt(10), 0, decimal.Zero, decimal.NewFromInt(18))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return placed
+	}
+
+	t.Run("Stop order can not be filled before it is triggered", func(t *testing.T) {
+		placed := newStop(t)
+
+		assert.True(t, placed.AwaitingTrigger())
+		assert.EqualError(t, placed.Fill("trade-1", decimal.NewFromInt(1), decimal.NewFromInt(18)), "order "+placed.Id()+" is awaiting its stop price")
+	})
+
+	t.Run("Triggered stop order can be filled", func(t *testing.T) {
+		placed := newStop(t)
+
+		assert.NoError(t, placed.Trigger())
+		assert.False(t, placed.AwaitingTrigger())
+		assert.NoError(t, placed.Fill("trade-1", decimal.NewFromInt(10), decimal.NewFromInt(17)))
+	})
+
+	t.Run("Trigger an order twice", func(t *testing.T) {
+		placed := newStop(t)
+		placed.Trigger()
+
+		assert.Error(t, placed.Trigger())
+		assert.Error(t, newOrder(t, 10).Trigger())
+	})
+}
+
+func TestFillOrder(t *testing.T) {
+	t.Run("Fill part of an order", func(t *testing.T) {
+		placed := newOrder(t, 10)
+
+		err := placed.Fill("trade-1", decimal.NewFromInt(4), decimal.NewFromInt(19))
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderStatusOpen, placed.Status())
+		if assert.Len(t, placed.DomainEvents(), 1) {
+			filled, ok := placed.DomainEvents()[0].(order.OrderPartiallyFilled)
+			if assert.True(t, ok) {
+				assert.Equal(t, "order-partially-filled", filled.Name())
+				assert.Equal(t, order.Trade{Id: "trade-1", OrderId: placed.Id(), Quantity: decimal.NewFromInt(4), Price: decimal.NewFromInt(19)}, filled.Trade())
+				assert.Equal(t, "6", filled.UnfilledQuantity().String())
+			}
+		}
+	})
+
+	t.Run("Fill the rest of an order", func(t *testing.T) {
+		placed := newOrder(t, 10)
+		placed.Fill("trade-1", decimal.NewFromInt(4), decimal.NewFromInt(19))
+
+		err := placed.Fill("trade-2", decimal.NewFromInt(6), decimal.NewFromInt(20))
+
+		assert.NoError(t, err)
+		assert.Equal(t, order.OrderStatusFilled, placed.Status())
+		if assert.Len(t, placed.DomainEvents(), 2) {
+			filled, ok := placed.DomainEvents()[1].(order.OrderFilled)
+			if assert.True(t, ok) {
+				assert.Equal(t, "order-filled", filled.Name())
+				assert.Equal(t, "0", filled.UnfilledQuantity().String())
+			}
+		}
+	})
+
+	t.Run("Fill a fraction of a share", func(t *testing.T) {
+		placed := newOrder(t, 1)
+
+		err := placed.Fill("trade-1", decimal.RequireFromString("0.25"), decimal.NewFromInt(20))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0.25", placed.FilledQuantity().String())
+		assert.Equal(t, "0.75", placed.UnfilledQuantity().String())
+	})
+
+	t.Run("Fill more than the unfilled quantity", func(t *testing.T) {
+		placed := newOrder(t, 10)
+
+		err := placed.Fill("trade-1", decimal.NewFromInt(11), decimal.NewFromInt(20))
+
+		assert.EqualError(t, err, "fill quantity must be greater than zero and at most 10")
+		assert.Empty(t, placed.DomainEvents())
+	})
+}
+
+func TestCancelOrder(t *testing.T) {
+	t.Run("Cancel an open order", func(t *testing.T) {
+		placed := newOrder(t, 10)
+
+		assert.NoError(t, placed.Cancel())
+		assert.Equal(t, order.OrderStatusCancelled, placed.Status())
+		err := placed.Fill("trade-1", decimal.NewFromInt(1), decimal.NewFromInt(20))
+		assert.Error(t, err)
+	})
+
+	t.Run("Cancel a filled order", func(t *testing.T) {
+		placed := newOrder(t, 10)
+		placed.Fill("trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20))
+
+		assert.ErrorIs(t, placed.Cancel(), order.ErrOrderAlreadyFilled)
+	})
+
+	t.Run("Cancel the remainder of an order", func(t *testing.T) {
+		placed := newOrder(t, 10)
+		placed.Fill("trade-1", decimal.NewFromInt(4), decimal.NewFromInt(20))
+		placed.ClearDomainEvents()
+
+		assert.NoError(t, placed.CancelRemainder("no orders left"))
+		assert.Equal(t, order.OrderStatusCancelled, placed.Status())
+		if assert.Len(t, placed.DomainEvents(), 1) {
+			cancelled := placed.DomainEvents()[0].(order.OrderCancelled)
+			assert.Equal(t, "order-cancelled", cancelled.Name())
+			assert.Equal(t, "6", cancelled.UnfilledQuantity().String())
+			assert.Equal(t, "no orders left", cancelled.Reason())
+		}
+	})
+}
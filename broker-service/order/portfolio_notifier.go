@@ -0,0 +1,155 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PortfolioNotifier tells the portfolio service what happened to its orders.
+// The exchange stores the notifications in the outbox with the orders, so the
+// notifier only has to be told there is something new to deliver.
+type PortfolioNotifier interface {
+	// Wake tells the notifier the outbox has new notifications. It never blocks.
+	Wake()
+}
+
+type httpPortfolioNotifier struct {
+	outbox       OrderRepository
+	baseURL      string
+	token        string
+	client       *http.Client
+	wake         chan struct{}
+	batchSize    int
+	pollInterval time.Duration
+	retryBackoff time.Duration
+	onError      func(error)
+}
+
+// NewHTTPPortfolioNotifier delivers the notifications in the outbox to the
+// portfolio service at baseURL, authenticated with the callback token the two
+// services share.
+func NewHTTPPortfolioNotifier(outbox OrderRepository, baseURL string, token string, client *http.Client, onError func(error)) *httpPortfolioNotifier {
+	return &httpPortfolioNotifier{
+		outbox:       outbox,
+		baseURL:      baseURL,
+		token:        token,
+		client:       client,
+		wake:         make(chan struct{}, 1),
+		batchSize:    100,
+		pollInterval: 5 * time.Second,
+		retryBackoff: time.Second,
+		onError:      onError,
+	}
+}
+
+func (n *httpPortfolioNotifier) Wake() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers the notifications in the outbox, in the order they were stored,
+// until ctx is done. A notification the portfolio service could not take is
+// retried, holding back the ones after it, so that it does not see a
+// cancellation before the fills that came first. The outbox is also polled, so
+// notifications stored before a restart are delivered too.
+func (n *httpPortfolioNotifier) Run(ctx context.Context) {
+	backoff := n.retryBackoff
+	for {
+		delivered, err := n.DeliverPending(ctx)
+		wait := n.pollInterval
+		if err != nil {
+			n.onError(err)
+			wait, backoff = backoff, nextBackoff(backoff)
+		} else {
+			backoff = n.retryBackoff
+			if delivered == n.batchSize {
+				wait = 0
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-n.wake:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// DeliverPending delivers a batch of pending notifications and returns how many
+// it settled, stopping at the first one that has to be retried.
+func (n *httpPortfolioNotifier) DeliverPending(ctx context.Context) (int, error) {
+	pending, err := n.outbox.PendingNotifications(ctx, n.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, next := range pending {
+		next.Attempts++
+		refused, err := n.deliver(ctx, next)
+		switch {
+		case err != nil:
+			next.LastError = err.Error()
+			if saveErr := n.outbox.SaveNotification(ctx, next); saveErr != nil {
+				return i, saveErr
+			}
+			return i, err
+		case refused != nil:
+			next.Status, next.LastError = NotificationDead, refused.Error()
+			n.onError(refused)
+		default:
+			next.Status, next.LastError = NotificationDelivered, ""
+		}
+
+		if err := n.outbox.SaveNotification(ctx, next); err != nil {
+			return i, err
+		}
+	}
+	return len(pending), nil
+}
+
+// deliver tells apart the portfolio service refusing a notification, which no
+// retry will change, from failing to take it. It treats 404 and 409 as
+// delivered: the portfolio does not know the order or already finished with it.
+// A 401 is retried, as it means the services do not share the same token yet.
+func (n *httpPortfolioNotifier) deliver(ctx context.Context, next Notification) (refused error, err error) {
+	body, err := json.Marshal(next.body())
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+next.path(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+n.token)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300, resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusConflict:
+		return nil, nil
+	case resp.StatusCode < 500 && resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusTooManyRequests:
+		return fmt.Errorf("notifying %s: portfolio service refused it with %d", next.path(), resp.StatusCode), nil
+	default:
+		return nil, fmt.Errorf("notifying %s: portfolio service answered %d", next.path(), resp.StatusCode)
+	}
+}
+
+func nextBackoff(backoff time.Duration) time.Duration {
+	if backoff*2 > time.Minute {
+		return time.Minute
+	}
+	return backoff * 2
+}
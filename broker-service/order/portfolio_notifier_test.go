@@ -0,0 +1,91 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPPortfolioNotifier(t *testing.T) {
+	type request struct {
+		path          string
+		authorization string
+		body          map[string]any
+	}
+
+	newNotifier := func(t *testing.T, statuses ...int) (*httpPortfolioNotifier, *inMemoryOrderRepository, *[]request) {
+		received := &[]request{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := map[string]any{}
+			json.NewDecoder(r.Body).Decode(&body)
+			*received = append(*received, request{path: r.URL.Path, authorization: r.Header.Get("Authorization"), body: body})
+			status := http.StatusNoContent
+			if len(statuses) > 0 {
+				status, statuses = statuses[0], statuses[1:]
+			}
+			w.WriteHeader(status)
+		}))
+		t.Cleanup(server.Close)
+
+		outbox := NewInMemoryOrderRepository().(*inMemoryOrderRepository)
+		outbox.notifications = []Notification{
+			{Id: 1, Type: NotificationTrade, OrderId: "order-1", Trade: Trade{Id: "trade-1", OrderId: "order-1", Quantity: decimal.NewFromInt(10), Price: decimal.RequireFromString("20.5")}, Status: NotificationPending},
+			{Id: 2, Type: NotificationCancellation, OrderId: "order-1", Reason: "expired", Status: NotificationPending},
+		}
+		return NewHTTPPortfolioNotifier(outbox, server.URL, "callback-token", server.Client(), func(error) {}), outbox, received
+	}
+
+	t.Run("Notifications are retried in order until the portfolio service takes them", func(t *testing.T) {
+		notifier, outbox, received := newNotifier(t, http.StatusServiceUnavailable)
+
+		delivered, err := notifier.DeliverPending(context.Background())
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, delivered)
+		assert.Len(t, *received, 1)
+		assert.Equal(t, 1, outbox.notifications[0].Attempts)
+
+		delivered, err = notifier.DeliverPending(context.Background())
+
+		if assert.NoError(t, err) && assert.Len(t, *received, 3) {
+			assert.Equal(t, 2, delivered)
+			assert.Equal(t, request{
+				path:          "/orders/order-1/trades",
+				authorization: "Bearer callback-token",
+				body:          map[string]any{"trade_id": "trade-1", "quantity": "10", "price": "20.5"},
+			}, (*received)[1])
+			assert.Equal(t, "/orders/order-1/cancellations", (*received)[2].path)
+			assert.Equal(t, NotificationDelivered, outbox.notifications[0].Status)
+			assert.Equal(t, 2, outbox.notifications[0].Attempts)
+		}
+	})
+
+	t.Run("Orders the portfolio service already finished count as delivered", func(t *testing.T) {
+		notifier, outbox, _ := newNotifier(t, http.StatusConflict, http.StatusNotFound)
+
+		_, err := notifier.DeliverPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, NotificationDelivered, outbox.notifications[0].Status)
+		assert.Equal(t, NotificationDelivered, outbox.notifications[1].Status)
+	})
+
+	t.Run("Notifications the portfolio service refuses become dead letters", func(t *testing.T) {
+		notifier, outbox, received := newNotifier(t, http.StatusUnprocessableEntity)
+
+		_, err := notifier.DeliverPending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, *received, 2)
+		assert.Equal(t, NotificationDead, outbox.notifications[0].Status)
+		assert.Contains(t, outbox.notifications[0].LastError, "422")
+		assert.Equal(t, NotificationDelivered, outbox.notifications[1].Status)
+		pending, _ := outbox.PendingNotifications(context.Background(), 10)
+		assert.Empty(t, pending)
+	})
+}
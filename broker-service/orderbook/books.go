@@ -0,0 +1,28 @@
+package orderbook
+
+// Books holds the order book of every symbol traded. It is not safe for
+// concurrent use.
+type Books struct {
+	books map[string]*OrderBook
+}
+
+func NewBooks() *Books {
+	return &Books{
+		books: map[string]*OrderBook{},
+	}
+}
+
+// Book returns the book of a symbol, opening it on first use.
+func (b *Books) Book(symbol string) *OrderBook {
+	book, ok := b.books[symbol]
+	if !ok {
+		book = NewOrderBook()
+		b.books[symbol] = book
+	}
+	return book
+}
+
+// Reset empties the book of a symbol.
+func (b *Books) Reset(symbol string) {
+	delete(b.books, symbol)
+}
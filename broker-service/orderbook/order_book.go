@@ -0,0 +1,301 @@
+package orderbook
+
+import (
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+type Side string
+
+const (
+	Buy  Side = "buy"
+	Sell Side = "sell"
+)
+
+// Entry is an order entering or resting in a book. Quantity is what is left
+// to fill, in the decimal places of Precision, which no match splits it finer
+// than. A market entry takes any price and never rests in the book.
+type Entry struct {
+	OrderId    string
+	Side       Side
+	Market     bool
+	LimitPrice decimal.Decimal
+	Quantity   decimal.Decimal
+	Precision  int32
+}
+
+// matchable returns how much of the entry can trade with the resting one in
+// the precision of both.
+func (e Entry) matchable(resting *Entry) decimal.Decimal {
+	places := e.Precision
+	if resting.Precision < places {
+		places = resting.Precision
+	}
+	return decimal.Min(e.Quantity, resting.Quantity).RoundFloor(places)
+}
+
+// StopEntry is an order waiting in a book for the price to reach StopPrice.
+type StopEntry struct {
+	OrderId   string
+	Side      Side
+	StopPrice decimal.Decimal
+}
+
+// Match is a trade between an incoming order and one resting in the book. It
+// always executes at the price of the resting order.
+type Match struct {
+	IncomingOrderId string
+	RestingOrderId  string
+	Quantity        decimal.Decimal
+	Price           decimal.Decimal
+}
+
+// OrderBook is the limit order book of a single symbol. Orders are matched by
+// price-time priority: the best price first and, within the same price, the
+// order that arrived first. It is not safe for concurrent use.
+type OrderBook struct {
+	bids    bookSide
+	asks    bookSide
+	resting map[string]*Entry
+	stops   []StopEntry
+}
+
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		bids:    bookSide{better: func(a, b decimal.Decimal) bool { return a.GreaterThan(b) }, levels: map[string]*priceLevel{}},
+		asks:    bookSide{better: func(a, b decimal.Decimal) bool { return a.LessThan(b) }, levels: map[string]*priceLevel{}},
+		resting: map[string]*Entry{},
+	}
+}
+
+// Submit matches the entry against the opposite side of the book and leaves
+// whatever is not filled resting in it, unless it is a market entry. A resting
+// order too small to trade in the precision of the entry is passed over for
+// the next one.
+func (b *OrderBook) Submit(entry Entry) []Match {
+	own, opposite := &b.bids, &b.asks
+	if entry.Side == Sell {
+		own, opposite = &b.asks, &b.bids
+	}
+
+	var matches []Match
+	for entry.Quantity.IsPositive() {
+		resting, quantity := b.next(entry, own, opposite)
+		if resting == nil {
+			break
+		}
+
+		matches = append(matches, Match{
+			IncomingOrderId: entry.OrderId,
+			RestingOrderId:  resting.OrderId,
+			Quantity:        quantity,
+			Price:           resting.LimitPrice,
+		})
+
+		entry.Quantity = entry.Quantity.Sub(quantity)
+		resting.Quantity = resting.Quantity.Sub(quantity)
+		if resting.Quantity.IsZero() {
+			// The entry is dropped when it reaches the front of its level.
+			delete(b.resting, resting.OrderId)
+		}
+	}
+
+	if entry.Quantity.IsPositive() && !entry.Market {
+		b.Rest(entry)
+	}
+	return matches
+}
+
+// next returns the resting order the entry matches first by price-time
+// priority, and how much of it they trade.
+func (b *OrderBook) next(entry Entry, own *bookSide, opposite *bookSide) (*Entry, decimal.Decimal) {
+	if opposite.best() == nil {
+		return nil, decimal.Zero
+	}
+	for i := len(opposite.sorted) - 1; i >= 0; i-- {
+		level := opposite.sorted[i]
+		if !entry.Market && own.better(level.price, entry.LimitPrice) {
+			break
+		}
+		for _, resting := range level.orders {
+			if quantity := entry.matchable(resting); quantity.IsPositive() {
+				return resting, quantity
+			}
+		}
+	}
+	return nil, decimal.Zero
+}
+
+// Available returns how much of the entry the book could fill right now,
+// without matching it.
+func (b *OrderBook) Available(entry Entry) decimal.Decimal {
+	own, opposite := &b.bids, &b.asks
+	if entry.Side == Sell {
+		own, opposite = &b.asks, &b.bids
+	}
+
+	available := decimal.Zero
+	for i := len(opposite.sorted) - 1; i >= 0 && available.LessThan(entry.Quantity); i-- {
+		level := opposite.sorted[i]
+		if !entry.Market && own.better(level.price, entry.LimitPrice) {
+			break
+		}
+		for _, resting := range level.orders {
+			available = available.Add(entry.matchable(resting))
+		}
+	}
+
+	return decimal.Min(available, entry.Quantity)
+}
+
+// Rest puts an entry in the book without matching it, as when restoring the
+// orders that were resting before a restart. Entries must be given in the
+// order they arrived, which is their time priority.
+func (b *OrderBook) Rest(entry Entry) {
+	side := &b.bids
+	if entry.Side == Sell {
+		side = &b.asks
+	}
+
+	resting := entry
+	side.add(&resting)
+	b.resting[entry.OrderId] = &resting
+}
+
+// AddStop keeps a stop order in the book until Trigger is given a price that
+// reaches its stop price.
+func (b *OrderBook) AddStop(stop StopEntry) {
+	b.stops = append(b.stops, stop)
+}
+
+// Trigger takes out of the book the stop orders the price reaches, buy stops
+// at or above their stop price and sell stops at or below it, and returns
+// their ids in the order they arrived.
+func (b *OrderBook) Trigger(price decimal.Decimal) []string {
+	var triggered []string
+	waiting := b.stops[:0]
+	for _, stop := range b.stops {
+		if stop.Side == Buy && price.GreaterThanOrEqual(stop.StopPrice) ||
+			stop.Side == Sell && price.LessThanOrEqual(stop.StopPrice) {
+			triggered = append(triggered, stop.OrderId)
+		} else {
+			waiting = append(waiting, stop)
+		}
+	}
+	b.stops = waiting
+	return triggered
+}
+
+// Cancel takes an order out of the book and reports whether it was resting or
+// waiting for its stop price in it.
+func (b *OrderBook) Cancel(orderId string) bool {
+	for i, stop := range b.stops {
+		if stop.OrderId == orderId {
+			b.stops = append(b.stops[:i], b.stops[i+1:]...)
+			return true
+		}
+	}
+
+	resting, ok := b.resting[orderId]
+	if !ok {
+		return false
+	}
+	// The entry is skipped and dropped when it reaches the front of its level.
+	resting.Quantity = decimal.Zero
+	delete(b.resting, orderId)
+	return true
+}
+
+// Resting returns the orders resting in the book, best bids first and then
+// best asks. Stop orders waiting for their price are not included.
+func (b *OrderBook) Resting() []Entry {
+	return append(b.bids.entries(), b.asks.entries()...)
+}
+
+// BestBid returns the highest price a resting order is willing to buy at.
+func (b *OrderBook) BestBid() (decimal.Decimal, bool) {
+	return b.bids.bestPrice()
+}
+
+// BestAsk returns the lowest price a resting order is willing to sell at.
+func (b *OrderBook) BestAsk() (decimal.Decimal, bool) {
+	return b.asks.bestPrice()
+}
+
+// bookSide keeps its price levels sorted from worst to best, so the best level
+// is taken from the end.
+type bookSide struct {
+	better func(a, b decimal.Decimal) bool
+	sorted []*priceLevel
+	levels map[string]*priceLevel
+}
+
+func (s *bookSide) add(entry *Entry) {
+	key := entry.LimitPrice.String()
+	level, ok := s.levels[key]
+	if !ok {
+		level = &priceLevel{price: entry.LimitPrice}
+		s.levels[key] = level
+		i := sort.Search(len(s.sorted), func(i int) bool { return s.better(s.sorted[i].price, level.price) })
+		s.sorted = append(s.sorted, nil)
+		copy(s.sorted[i+1:], s.sorted[i:])
+		s.sorted[i] = level
+	}
+	level.orders = append(level.orders, entry)
+}
+
+// best returns the best level with orders left, dropping the levels emptied by
+// cancellations on the way.
+func (s *bookSide) best() *priceLevel {
+	for len(s.sorted) > 0 {
+		level := s.sorted[len(s.sorted)-1]
+		if level.front() != nil {
+			return level
+		}
+		s.sorted = s.sorted[:len(s.sorted)-1]
+		delete(s.levels, level.price.String())
+	}
+	return nil
+}
+
+func (s *bookSide) bestPrice() (decimal.Decimal, bool) {
+	level := s.best()
+	if level == nil {
+		return decimal.Zero, false
+	}
+	return level.price, true
+}
+
+func (s *bookSide) entries() []Entry {
+	entries := []Entry{}
+	for i := len(s.sorted) - 1; i >= 0; i-- {
+		for _, entry := range s.sorted[i].orders {
+			if entry.Quantity.IsPositive() {
+				entries = append(entries, *entry)
+			}
+		}
+	}
+	return entries
+}
+
+// priceLevel holds the orders resting at the same price in arrival order.
+type priceLevel struct {
+	price  decimal.Decimal
+	orders []*Entry
+}
+
+func (l *priceLevel) front() *Entry {
+	for len(l.orders) > 0 {
+		if l.orders[0].Quantity.IsPositive() {
+			return l.orders[0]
+		}
+		l.popFront()
+	}
+	return nil
+}
+
+func (l *priceLevel) popFront() {
+	l.orders[0] = nil
+	l.orders = l.orders[1:]
+}
@@ -0,0 +1,253 @@
+package orderbook_test
+
+import (
+	"fmt"
+	"stock-trader/broker-service/orderbook"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func entry(orderId string, side orderbook.Side, limitPrice int64, quantity int64) orderbook.Entry {
+	return orderbook.Entry{
+		OrderId:    orderId,
+		Side:       side,
+		LimitPrice: decimal.NewFromInt(limitPrice),
+		Quantity:   decimal.NewFromInt(quantity),
+	}
+}
+
+func match(incoming string, resting string, quantity int64, price int64) orderbook.Match {
+	return orderbook.Match{
+		IncomingOrderId: incoming,
+		RestingOrderId:  resting,
+		Quantity:        decimal.NewFromInt(quantity),
+		Price:           decimal.NewFromInt(price),
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	t.Run("Orders that do not cross rest in the book", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+
+		assert.Empty(t, book.Submit(entry("bid", orderbook.Buy, 19, 10)))
+		assert.Empty(t, book.Submit(entry("ask", orderbook.Sell, 21, 10)))
+
+		bid, _ := book.BestBid()
+		ask, _ := book.BestAsk()
+		assert.Equal(t, "19", bid.String())
+		assert.Equal(t, "21", ask.String())
+	})
+
+	t.Run("Best price is matched first, at the resting price", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("ask-21", orderbook.Sell, 21, 5))
+		book.Submit(entry("ask-20", orderbook.Sell, 20, 5))
+
+		matches := book.Submit(entry("bid", orderbook.Buy, 22, 8))
+
+		assert.Equal(t, []orderbook.Match{
+			match("bid", "ask-20", 5, 20),
+			match("bid", "ask-21", 3, 21),
+		}, matches)
+		assert.Equal(t, []orderbook.Entry{entry("ask-21", orderbook.Sell, 21, 2)}, book.Resting())
+	})
+
+	t.Run("Orders at the same price are matched in arrival order", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("first", orderbook.Buy, 20, 5))
+		book.Submit(entry("second", orderbook.Buy, 20, 5))
+
+		matches := book.Submit(entry("ask", orderbook.Sell, 20, 7))
+
+		assert.Equal(t, []orderbook.Match{
+			match("ask", "first", 5, 20),
+			match("ask", "second", 2, 20),
+		}, matches)
+		assert.Equal(t, []orderbook.Entry{entry("second", orderbook.Buy, 20, 3)}, book.Resting())
+	})
+
+	t.Run("The unfilled part of an order rests in the book", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("ask", orderbook.Sell, 20, 4))
+
+		matches := book.Submit(entry("bid", orderbook.Buy, 20, 10))
+
+		assert.Equal(t, []orderbook.Match{match("bid", "ask", 4, 20)}, matches)
+		assert.Equal(t, []orderbook.Entry{entry("bid", orderbook.Buy, 20, 6)}, book.Resting())
+		_, ok := book.BestAsk()
+		assert.False(t, ok)
+	})
+
+	t.Run("Prices are compared by value", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(orderbook.Entry{OrderId: "ask", Side: orderbook.Sell, LimitPrice: decimal.RequireFromString("20.50"), Quantity: decimal.NewFromInt(1)})
+		book.Submit(orderbook.Entry{OrderId: "other", Side: orderbook.Sell, LimitPrice: decimal.RequireFromString("20.5"), Quantity: decimal.NewFromInt(1)})
+
+		matches := book.Submit(orderbook.Entry{OrderId: "bid", Side: orderbook.Buy, LimitPrice: decimal.RequireFromString("20.5"), Quantity: decimal.NewFromInt(2)})
+
+		assert.Len(t, matches, 2)
+		assert.Empty(t, book.Resting())
+	})
+
+	t.Run("Fractional quantities are matched exactly", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(orderbook.Entry{OrderId: "ask", Side: orderbook.Sell, LimitPrice: decimal.NewFromInt(20), Quantity: decimal.RequireFromString("0.75"), Precision: 2})
+
+		matches := book.Submit(orderbook.Entry{OrderId: "bid", Side: orderbook.Buy, LimitPrice: decimal.NewFromInt(20), Quantity: decimal.RequireFromString("1.5"), Precision: 2})
+
+		if assert.Len(t, matches, 1) {
+			assert.Equal(t, "0.75", matches[0].Quantity.String())
+		}
+		if resting := book.Resting(); assert.Len(t, resting, 1) {
+			assert.Equal(t, "bid", resting[0].OrderId)
+			assert.Equal(t, "0.75", resting[0].Quantity.String())
+		}
+	})
+
+	t.Run("Matches are rounded down to the coarser precision", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(orderbook.Entry{OrderId: "small", Side: orderbook.Sell, LimitPrice: decimal.NewFromInt(20), Quantity: decimal.RequireFromString("0.75"), Precision: 2})
+		book.Submit(orderbook.Entry{OrderId: "large", Side: orderbook.Sell, LimitPrice: decimal.NewFromInt(20), Quantity: decimal.RequireFromString("2.5"), Precision: 2})
+
+		matches := book.Submit(entry("bid", orderbook.Buy, 20, 2))
+
+		if assert.Len(t, matches, 1) {
+			assert.Equal(t, "large", matches[0].RestingOrderId)
+			assert.Equal(t, "2", matches[0].Quantity.String())
+		}
+		if resting := book.Resting(); assert.Len(t, resting, 2) {
+			assert.Equal(t, "small", resting[0].OrderId)
+			assert.Equal(t, "0.5", resting[1].Quantity.String())
+		}
+		assert.Equal(t, "0", book.Available(entry("other", orderbook.Buy, 20, 1)).String())
+	})
+}
+
+func TestSubmitMarket(t *testing.T) {
+	t.Run("Market order takes any price and never rests", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("ask-20", orderbook.Sell, 20, 5))
+		book.Submit(entry("ask-30", orderbook.Sell, 30, 5))
+
+		market := entry("bid", orderbook.Buy, 0, 12)
+		market.Market = true
+		matches := book.Submit(market)
+
+		assert.Equal(t, []orderbook.Match{
+			match("bid", "ask-20", 5, 20),
+			match("bid", "ask-30", 5, 30),
+		}, matches)
+		assert.Empty(t, book.Resting())
+	})
+}
+
+func TestAvailable(t *testing.T) {
+	t.Run("Available counts what crosses the entry price, up to its quantity", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("ask-20", orderbook.Sell, 20, 5))
+		book.Submit(entry("ask-21", orderbook.Sell, 21, 5))
+		book.Submit(entry("ask-22", orderbook.Sell, 22, 5))
+
+		assert.Equal(t, "10", book.Available(entry("bid", orderbook.Buy, 21, 12)).String())
+		assert.Equal(t, "12", book.Available(entry("bid", orderbook.Buy, 22, 12)).String())
+		assert.Equal(t, "0", book.Available(entry("bid", orderbook.Buy, 19, 12)).String())
+		assert.Len(t, book.Resting(), 3)
+	})
+}
+
+func TestTrigger(t *testing.T) {
+	stop := func(orderId string, side orderbook.Side, stopPrice int64) orderbook.StopEntry {
+		return orderbook.StopEntry{OrderId: orderId, Side: side, StopPrice: decimal.NewFromInt(stopPrice)}
+	}
+
+	t.Run("Stops are triggered when the price reaches them, in arrival order", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.AddStop(stop("buy-22", orderbook.Buy, 22))
+		book.AddStop(stop("buy-21", orderbook.Buy, 21))
+		book.AddStop(stop("sell-18", orderbook.Sell, 18))
+		book.AddStop(stop("buy-25", orderbook.Buy, 25))
+
+		assert.Empty(t, book.Trigger(decimal.NewFromInt(20)))
+		assert.Equal(t, []string{"buy-22", "buy-21"}, book.Trigger(decimal.NewFromInt(22)))
+		assert.Empty(t, book.Trigger(decimal.NewFromInt(22)))
+		assert.Equal(t, []string{"sell-18"}, book.Trigger(decimal.NewFromInt(18)))
+	})
+
+	t.Run("Cancelled stops are not triggered", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.AddStop(stop("sell-18", orderbook.Sell, 18))
+
+		assert.True(t, book.Cancel("sell-18"))
+		assert.Empty(t, book.Trigger(decimal.NewFromInt(10)))
+	})
+}
+
+func TestCancel(t *testing.T) {
+	t.Run("Cancelled orders are not matched", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("first", orderbook.Sell, 20, 5))
+		book.Submit(entry("second", orderbook.Sell, 20, 5))
+
+		assert.True(t, book.Cancel("first"))
+		matches := book.Submit(entry("bid", orderbook.Buy, 20, 5))
+
+		assert.Equal(t, []orderbook.Match{match("bid", "second", 5, 20)}, matches)
+		assert.Empty(t, book.Resting())
+	})
+
+	t.Run("Cancelling the last order of the best price moves the best price", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("bid-20", orderbook.Buy, 20, 5))
+		book.Submit(entry("bid-19", orderbook.Buy, 19, 5))
+
+		book.Cancel("bid-20")
+
+		bid, _ := book.BestBid()
+		assert.Equal(t, "19", bid.String())
+	})
+
+	t.Run("Cancel an order that is not resting", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Submit(entry("ask", orderbook.Sell, 20, 5))
+		book.Submit(entry("bid", orderbook.Buy, 20, 5))
+
+		assert.False(t, book.Cancel("ask"))
+		assert.False(t, book.Cancel("unknown"))
+	})
+}
+
+func TestRest(t *testing.T) {
+	t.Run("Rested orders keep their arrival order", func(t *testing.T) {
+		book := orderbook.NewOrderBook()
+		book.Rest(entry("first", orderbook.Sell, 20, 5))
+		book.Rest(entry("second", orderbook.Sell, 20, 5))
+
+		matches := book.Submit(entry("bid", orderbook.Buy, 20, 5))
+
+		assert.Equal(t, []orderbook.Match{match("bid", "first", 5, 20)}, matches)
+	})
+}
+
+func BenchmarkSubmit(b *testing.B) {
+	book := orderbook.NewOrderBook()
+	entries := make([]orderbook.Entry, 1024)
+	for i := range entries {
+		side := orderbook.Buy
+		if i%2 == 1 {
+			side = orderbook.Sell
+		}
+		// Prices around 100 so that about half of the orders cross the book.
+		entries[i] = entry(fmt.Sprint(i), side, int64(95+(i*7)%11), int64(1+i%10))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := entries[i%len(entries)]
+		e.OrderId = fmt.Sprint(i)
+		book.Submit(e)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "orders/s")
+}
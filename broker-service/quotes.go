@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"stock-trader/broker-service/quotes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// quoteProvider replays the recorded feed at QUOTE_FEED when it is set and
+// otherwise simulates the market with a random walk from the prices in
+// QUOTE_SYMBOLS (such as ACME:20.00,INIT:105.50), seeded with QUOTE_SEED.
+func quoteProvider(interval time.Duration) (quotes.Provider, error) {
+	if path := os.Getenv("QUOTE_FEED"); path != "" {
+		// Read up front so the file is closed before the replay starts.
+		feed, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return quotes.NewCSVFeed(bytes.NewReader(feed))
+	}
+
+	seed, err := strconv.ParseInt(getEnv("QUOTE_SEED", "1"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("QUOTE_SEED: %w", err)
+	}
+
+	prices := map[string]decimal.Decimal{}
+	for _, pair := range strings.Split(getEnv("QUOTE_SYMBOLS", "ACME:20.00,INIT:105.50,GLOBEX:48.25"), ",") {
+		symbol, price, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("QUOTE_SYMBOLS: %q is not symbol:price", pair)
+		}
+		if prices[strings.ToUpper(symbol)], err = decimal.NewFromString(price); err != nil {
+			return nil, fmt.Errorf("QUOTE_SYMBOLS: %w", err)
+		}
+	}
+
+	return quotes.NewRandomWalk(prices, quotes.RandomWalkOptions{
+		Seed:       seed,
+		Volatility: 0.002,
+		Spread:     0.001,
+		Interval:   interval,
+		Start:      time.Now().UTC(),
+	}), nil
+}
@@ -0,0 +1,95 @@
+package quotes
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// csvFeed replays a recorded feed with a timestamp,symbol,last,bid,ask header
+// and one quote per row, in timestamp order. Each tick returns the rows that
+// share the next timestamp.
+type csvFeed struct {
+	reader  *csv.Reader
+	pending *Quote
+}
+
+func NewCSVFeed(reader io.Reader) (Provider, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the quote feed header: %w", err)
+	}
+	if strings.Join(header, ",") != "timestamp,symbol,last,bid,ask" {
+		return nil, fmt.Errorf("quote feed header must be timestamp,symbol,last,bid,ask, got %s", strings.Join(header, ","))
+	}
+	r.FieldsPerRecord = len(header)
+
+	return &csvFeed{
+		reader: r,
+	}, nil
+}
+
+func (f *csvFeed) Tick(ctx context.Context) ([]Quote, error) {
+	quotes := []Quote{}
+	if f.pending != nil {
+		quotes = append(quotes, *f.pending)
+		f.pending = nil
+	}
+
+	for {
+		quote, err := f.read()
+		if errors.Is(err, io.EOF) {
+			if len(quotes) == 0 {
+				return nil, ErrFeedExhausted
+			}
+			return quotes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(quotes) > 0 && !quote.Timestamp.Equal(quotes[0].Timestamp) {
+			f.pending = &quote
+			return quotes, nil
+		}
+		quotes = append(quotes, quote)
+	}
+}
+
+func (f *csvFeed) read() (Quote, error) {
+	record, err := f.reader.Read()
+	if err != nil {
+		return Quote{}, err
+	}
+
+	line, _ := f.reader.FieldPos(0)
+	timestamp, err := time.Parse(time.RFC3339, record[0])
+	if err != nil {
+		return Quote{}, fmt.Errorf("quote feed line %d: %w", line, err)
+	}
+
+	prices := make([]decimal.Decimal, 3)
+	for i, value := range record[2:] {
+		if prices[i], err = decimal.NewFromString(value); err != nil {
+			return Quote{}, fmt.Errorf("quote feed line %d: %w", line, err)
+		}
+	}
+
+	return Quote{
+		Symbol:    strings.ToUpper(record[1]),
+		Last:      prices[0],
+		Bid:       prices[1],
+		Ask:       prices[2],
+		Timestamp: timestamp.UTC(),
+	}, nil
+}
@@ -0,0 +1,55 @@
+package quotes_test
+
+import (
+	"context"
+	"stock-trader/broker-service/quotes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVFeed(t *testing.T) {
+	t.Run("Each tick replays the quotes of the next timestamp", func(t *testing.T) {
+		feed, err := quotes.NewCSVFeed(strings.NewReader(`timestamp,symbol,last,bid,ask
+2026-10-19T13:30:00Z,ACME,20.00,19.99,20.01
+2026-10-19T13:30:00Z,init,105.50,105.45,105.55
+2026-10-19T13:30:01Z,ACME,20.02,20.01,20.03
+`))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		first, err := feed.Tick(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, first, 2) {
+			assert.Equal(t, "ACME", first[0].Symbol)
+			assert.Equal(t, "INIT", first[1].Symbol)
+			assert.Equal(t, "105.45", first[1].Bid.String())
+		}
+
+		second, err := feed.Tick(context.Background())
+		assert.NoError(t, err)
+		if assert.Len(t, second, 1) {
+			assert.Equal(t, "20.02", second[0].Last.String())
+			assert.Equal(t, "2026-10-19T13:30:01Z", second[0].Timestamp.Format("2006-01-02T15:04:05Z07:00"))
+		}
+
+		_, err = feed.Tick(context.Background())
+		assert.ErrorIs(t, err, quotes.ErrFeedExhausted)
+	})
+
+	t.Run("Feed with an unexpected header", func(t *testing.T) {
+		_, err := quotes.NewCSVFeed(strings.NewReader("symbol,price\nACME,20\n"))
+
+		assert.EqualError(t, err, "quote feed header must be timestamp,symbol,last,bid,ask, got symbol,price")
+	})
+
+	t.Run("Feed with an invalid price", func(t *testing.T) {
+		feed, _ := quotes.NewCSVFeed(strings.NewReader("timestamp,symbol,last,bid,ask\n2026-10-19T13:30:00Z,ACME,twenty,19.99,20.01\n"))
+
+		_, err := feed.Tick(context.Background())
+
+		assert.ErrorContains(t, err, "quote feed line 2")
+	})
+}
@@ -0,0 +1,61 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/broker-service/common"
+	"stock-trader/broker-service/quotes"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetQuoteEndpoint struct {
+	handler common.Handler[GetQuoteQuery, quotes.Quote]
+}
+
+func NewGetQuoteEndpoint(handler common.Handler[GetQuoteQuery, quotes.Quote]) *GetQuoteEndpoint {
+	return &GetQuoteEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetQuoteEndpoint) Get(c echo.Context) error {
+	query := new(GetQuoteQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	quote, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, quotes.ErrQuoteNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, quote)
+}
+
+type GetQuoteQuery struct {
+	Symbol string `param:"symbol" validate:"required,max=8"`
+}
+
+type GetQuoteHandler struct {
+	board *quotes.Board
+}
+
+func NewGetQuoteHandler(board *quotes.Board) *GetQuoteHandler {
+	return &GetQuoteHandler{
+		board: board,
+	}
+}
+
+func (h *GetQuoteHandler) Handle(ctx context.Context, query GetQuoteQuery) (quotes.Quote, error) {
+	return h.board.Get(query.Symbol)
+}
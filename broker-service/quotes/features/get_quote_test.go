@@ -0,0 +1,75 @@
+package quotes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/broker-service/infrastructure"
+	"stock-trader/broker-service/quotes"
+	features "stock-trader/broker-service/quotes/features"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newContext(path string, symbol string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = infrastructure.NewRequestValidator()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	if symbol != "" {
+		c.SetParamNames("symbol")
+		c.SetParamValues(symbol)
+	}
+	return c, rec
+}
+
+func newBoard() *quotes.Board {
+	board := quotes.NewBoard()
+	board.Update(quotes.Quote{
+		Symbol:    "ACME",
+		Last:      decimal.RequireFromString("20.02"),
+		Bid:       decimal.RequireFromString("20.01"),
+		Ask:       decimal.RequireFromString("20.03"),
+		Timestamp: time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC),
+	})
+	return board
+}
+
+func Test_GetQuoteEndpoint(t *testing.T) {
+	t.Run("Get Quote Successfully", func(t *testing.T) {
+		endpoint := features.NewGetQuoteEndpoint(features.NewGetQuoteHandler(newBoard()))
+		c, rec := newContext("/quotes/acme", "acme")
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"symbol":"ACME","last":"20.02","bid":"20.01","ask":"20.03","timestamp":"2026-10-19T13:30:00Z"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Get Quote Of Unknown Symbol", func(t *testing.T) {
+		endpoint := features.NewGetQuoteEndpoint(features.NewGetQuoteHandler(newBoard()))
+		c, _ := newContext("/quotes/INIT", "INIT")
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+}
+
+func Test_ListQuotesEndpoint(t *testing.T) {
+	t.Run("List Quotes Successfully", func(t *testing.T) {
+		endpoint := features.NewListQuotesEndpoint(features.NewListQuotesHandler(newBoard()))
+		c, rec := newContext("/quotes", "")
+
+		if assert.NoError(t, endpoint.List(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `[{"symbol":"ACME","last":"20.02","bid":"20.01","ask":"20.03","timestamp":"2026-10-19T13:30:00Z"}]`, rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package quotes
+
+import (
+	"context"
+	"net/http"
+	"stock-trader/broker-service/common"
+	"stock-trader/broker-service/quotes"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ListQuotesEndpoint struct {
+	handler common.Handler[ListQuotesQuery, []quotes.Quote]
+}
+
+func NewListQuotesEndpoint(handler common.Handler[ListQuotesQuery, []quotes.Quote]) *ListQuotesEndpoint {
+	return &ListQuotesEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *ListQuotesEndpoint) List(c echo.Context) error {
+	quotes, err := e.handler.Handle(c.Request().Context(), ListQuotesQuery{})
+	if err != nil {
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, quotes)
+}
+
+type ListQuotesQuery struct{}
+
+type ListQuotesHandler struct {
+	board *quotes.Board
+}
+
+func NewListQuotesHandler(board *quotes.Board) *ListQuotesHandler {
+	return &ListQuotesHandler{
+		board: board,
+	}
+}
+
+func (h *ListQuotesHandler) Handle(ctx context.Context, query ListQuotesQuery) ([]quotes.Quote, error) {
+	return h.board.All(), nil
+}
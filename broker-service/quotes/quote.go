@@ -0,0 +1,77 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrQuoteNotFound = errors.New("quote not found")
+
+// ErrFeedExhausted is returned by a provider that has no more quotes to give.
+var ErrFeedExhausted = errors.New("quote feed exhausted")
+
+type Quote struct {
+	Symbol    string          `json:"symbol"`
+	Last      decimal.Decimal `json:"last"`
+	Bid       decimal.Decimal `json:"bid"`
+	Ask       decimal.Decimal `json:"ask"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Provider is where quotes come from. Each tick returns the quotes that
+// changed since the previous one.
+type Provider interface {
+	Tick(ctx context.Context) ([]Quote, error)
+}
+
+// Board keeps the latest quote of every symbol. It is safe for concurrent use.
+type Board struct {
+	mu     sync.RWMutex
+	quotes map[string]Quote
+}
+
+func NewBoard() *Board {
+	return &Board{
+		quotes: map[string]Quote{},
+	}
+}
+
+func (b *Board) Update(quotes ...Quote) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, quote := range quotes {
+		b.quotes[quote.Symbol] = quote
+	}
+}
+
+func (b *Board) Get(symbol string) (Quote, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	quote, ok := b.quotes[strings.ToUpper(symbol)]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrQuoteNotFound, symbol)
+	}
+	return quote, nil
+}
+
+// All returns the latest quote of every symbol, sorted by symbol.
+func (b *Board) All() []Quote {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	quotes := []Quote{}
+	for _, quote := range b.quotes {
+		quotes = append(quotes, quote)
+	}
+	sort.Slice(quotes, func(i, j int) bool { return quotes[i].Symbol < quotes[j].Symbol })
+	return quotes
+}
@@ -0,0 +1,87 @@
+package quotes
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type RandomWalkOptions struct {
+	// Seed makes the walk repeatable: the same seed and prices always give
+	// the same quotes.
+	Seed int64
+	// Volatility is the standard deviation of the relative change of the last
+	// price on every tick.
+	Volatility float64
+	// Spread is the distance between bid and ask, relative to the last price.
+	Spread float64
+	// Interval is the time between the timestamps of two ticks, which start
+	// at Start.
+	Interval time.Duration
+	Start    time.Time
+}
+
+// randomWalk simulates the market by moving the last price of every symbol a
+// normally distributed step on every tick.
+type randomWalk struct {
+	random  *rand.Rand
+	symbols []string
+	last    map[string]decimal.Decimal
+	options RandomWalkOptions
+	ticks   int64
+}
+
+func NewRandomWalk(initialPrices map[string]decimal.Decimal, options RandomWalkOptions) Provider {
+	symbols := []string{}
+	last := map[string]decimal.Decimal{}
+	for symbol, price := range initialPrices {
+		symbols = append(symbols, symbol)
+		last[symbol] = price
+	}
+	// Symbols are walked in a fixed order so the seed alone decides the quotes.
+	sort.Strings(symbols)
+
+	return &randomWalk{
+		random:  rand.New(rand.NewSource(options.Seed)),
+		symbols: symbols,
+		last:    last,
+		options: options,
+	}
+}
+
+func (w *randomWalk) Tick(ctx context.Context) ([]Quote, error) {
+	timestamp := w.options.Start.Add(time.Duration(w.ticks) * w.options.Interval)
+	w.ticks++
+
+	minimum := decimal.New(1, -2)
+	quotes := []Quote{}
+	for _, symbol := range w.symbols {
+		step := decimal.NewFromFloat(w.random.NormFloat64() * w.options.Volatility)
+		last := w.last[symbol].Mul(decimal.NewFromInt(1).Add(step)).Round(2)
+		if last.LessThan(minimum) {
+			last = minimum
+		}
+		w.last[symbol] = last
+
+		halfSpread := last.Mul(decimal.NewFromFloat(w.options.Spread / 2)).Round(2)
+		if halfSpread.LessThan(minimum) {
+			halfSpread = minimum
+		}
+		bid := last.Sub(halfSpread)
+		if bid.LessThan(minimum) {
+			bid = minimum
+		}
+
+		quotes = append(quotes, Quote{
+			Symbol:    symbol,
+			Last:      last,
+			Bid:       bid,
+			Ask:       last.Add(halfSpread),
+			Timestamp: timestamp,
+		})
+	}
+	return quotes, nil
+}
@@ -0,0 +1,59 @@
+package quotes_test
+
+import (
+	"context"
+	"stock-trader/broker-service/quotes"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomWalk(t *testing.T) {
+	start := time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC)
+	newWalk := func(seed int64) quotes.Provider {
+		return quotes.NewRandomWalk(map[string]decimal.Decimal{
+			"ACME": decimal.NewFromInt(20),
+			"INIT": decimal.NewFromInt(100),
+		}, quotes.RandomWalkOptions{
+			Seed:       seed,
+			Volatility: 0.01,
+			Spread:     0.002,
+			Interval:   time.Second,
+			Start:      start,
+		})
+	}
+	walk := func(provider quotes.Provider, ticks int) []quotes.Quote {
+		var walked []quotes.Quote
+		for i := 0; i < ticks; i++ {
+			tick, _ := provider.Tick(context.Background())
+			walked = append(walked, tick...)
+		}
+		return walked
+	}
+
+	t.Run("The same seed walks the same quotes", func(t *testing.T) {
+		assert.Equal(t, walk(newWalk(42), 50), walk(newWalk(42), 50))
+		assert.NotEqual(t, walk(newWalk(42), 50), walk(newWalk(7), 50))
+	})
+
+	t.Run("Every tick quotes every symbol", func(t *testing.T) {
+		walked := walk(newWalk(42), 2)
+
+		if assert.Len(t, walked, 4) {
+			assert.Equal(t, []string{"ACME", "INIT", "ACME", "INIT"}, []string{walked[0].Symbol, walked[1].Symbol, walked[2].Symbol, walked[3].Symbol})
+			assert.Equal(t, start, walked[0].Timestamp)
+			assert.Equal(t, start.Add(time.Second), walked[2].Timestamp)
+		}
+	})
+
+	t.Run("Quotes stay positive with the bid below the ask", func(t *testing.T) {
+		for _, quote := range walk(newWalk(42), 1000) {
+			assert.True(t, quote.Bid.IsPositive())
+			assert.True(t, quote.Bid.LessThan(quote.Last))
+			assert.True(t, quote.Ask.GreaterThan(quote.Last))
+			assert.True(t, quote.Last.Equal(quote.Last.Round(2)))
+		}
+	})
+}
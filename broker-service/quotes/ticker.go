@@ -0,0 +1,61 @@
+package quotes
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type TickerOptions struct {
+	Interval time.Duration
+	// OnQuote is told of every quote after it is on the board.
+	OnQuote func(context.Context, Quote)
+	OnError func(error)
+}
+
+// Ticker pulls quotes from a provider into a board at a steady pace until the
+// provider runs out of quotes.
+type Ticker struct {
+	provider Provider
+	board    *Board
+	options  TickerOptions
+}
+
+func NewTicker(provider Provider, board *Board, options TickerOptions) *Ticker {
+	return &Ticker{
+		provider: provider,
+		board:    board,
+		options:  options,
+	}
+}
+
+func (t *Ticker) Run(ctx context.Context) {
+	for t.Tick(ctx) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.options.Interval):
+		}
+	}
+}
+
+// Tick moves the next quotes to the board and reports whether the provider
+// has more to give.
+func (t *Ticker) Tick(ctx context.Context) bool {
+	quotes, err := t.provider.Tick(ctx)
+	if errors.Is(err, ErrFeedExhausted) {
+		return false
+	}
+	if err != nil {
+		t.options.OnError(err)
+		return true
+	}
+
+	t.board.Update(quotes...)
+	if t.options.OnQuote != nil {
+		for _, quote := range quotes {
+			t.options.OnQuote(ctx, quote)
+		}
+	}
+	return true
+}
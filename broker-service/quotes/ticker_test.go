@@ -0,0 +1,72 @@
+package quotes_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/broker-service/quotes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicker(t *testing.T) {
+	t.Run("Tick puts the quotes on the board and tells of each one", func(t *testing.T) {
+		feed, _ := quotes.NewCSVFeed(strings.NewReader(`timestamp,symbol,last,bid,ask
+2026-10-19T13:30:00Z,ACME,20.00,19.99,20.01
+2026-10-19T13:30:01Z,ACME,20.02,20.01,20.03
+`))
+		board := quotes.NewBoard()
+		var told []string
+		ticker := quotes.NewTicker(feed, board, quotes.TickerOptions{
+			OnQuote: func(ctx context.Context, quote quotes.Quote) {
+				told = append(told, quote.Last.String())
+			},
+		})
+
+		assert.True(t, ticker.Tick(context.Background()))
+		assert.True(t, ticker.Tick(context.Background()))
+		assert.False(t, ticker.Tick(context.Background()))
+
+		assert.Equal(t, []string{"20", "20.02"}, told)
+		quote, err := board.Get("acme")
+		assert.NoError(t, err)
+		assert.Equal(t, "20.02", quote.Last.String())
+	})
+
+	t.Run("Tick goes on after the provider fails", func(t *testing.T) {
+		var failures []error
+		ticker := quotes.NewTicker(&FailingProvider{}, quotes.NewBoard(), quotes.TickerOptions{
+			OnError: func(err error) {
+				failures = append(failures, err)
+			},
+		})
+
+		assert.True(t, ticker.Tick(context.Background()))
+		assert.Len(t, failures, 1)
+	})
+}
+
+func TestBoard(t *testing.T) {
+	t.Run("Get a symbol without quotes", func(t *testing.T) {
+		_, err := quotes.NewBoard().Get("ACME")
+
+		assert.ErrorIs(t, err, quotes.ErrQuoteNotFound)
+	})
+
+	t.Run("All quotes are sorted by symbol", func(t *testing.T) {
+		board := quotes.NewBoard()
+		board.Update(quotes.Quote{Symbol: "INIT"}, quotes.Quote{Symbol: "ACME"})
+
+		all := board.All()
+
+		assert.Equal(t, "ACME", all[0].Symbol)
+		assert.Equal(t, "INIT", all[1].Symbol)
+	})
+}
+
+type FailingProvider struct{}
+
+func (p *FailingProvider) Tick(ctx context.Context) ([]quotes.Quote, error) {
+	return nil, errors.New("connection refused")
+}
@@ -0,0 +1,163 @@
+table "orders" {
+  schema = schema.broker
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "symbol" {
+    null = false
+    type = varchar(8)
+  }
+  column "side" {
+    null = false
+    type = varchar(4)
+  }
+  column "type" {
+    null = false
+    type = varchar(10)
+    default = "limit"
+  }
+  column "quantity" {
+    null = false
+    type = decimal(19,6)
+  }
+  column "precision" {
+    null = false
+    type = int
+    default = 6
+  }
+  column "limit_price" {
+    null = true
+    type = decimal(19,4)
+  }
+  column "stop_price" {
+    null = true
+    type = decimal(19,4)
+  }
+  column "triggered" {
+    null = false
+    type = boolean
+    default = false
+  }
+  column "time_in_force" {
+    null = false
+    type = varchar(3)
+    default = "gtc"
+  }
+  column "expires_at" {
+    null = true
+    type = datetime(6)
+  }
+  column "filled_quantity" {
+    null = false
+    type = decimal(19,6)
+    default = 0
+  }
+  column "status" {
+    null = false
+    type = varchar(16)
+  }
+  column "sequence" {
+    null = false
+    type = bigint
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_status_x_sequence" {
+    columns = [
+      column.status,
+      column.sequence
+    ]
+  }
+
+  index "idx_status_x_expires_at" {
+    columns = [
+      column.status,
+      column.expires_at
+    ]
+  }
+}
+
+table "portfolio_notifications" {
+  schema = schema.broker
+  column "id" {
+    null = false
+    type = bigint
+    auto_increment = true
+  }
+  column "order_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "type" {
+    null = false
+    type = varchar(16)
+  }
+  column "trade_id" {
+    null = false
+    type = varchar(64)
+    default = ""
+  }
+  column "quantity" {
+    null = true
+    type = decimal(19,6)
+  }
+  column "price" {
+    null = true
+    type = decimal(19,4)
+  }
+  column "reason" {
+    null = false
+    type = varchar(256)
+    default = ""
+  }
+  column "status" {
+    null = false
+    type = varchar(16)
+  }
+  column "attempts" {
+    null = false
+    type = int
+    default = 0
+  }
+  column "last_error" {
+    null = false
+    type = varchar(512)
+    default = ""
+  }
+  column "created_at" {
+    null = false
+    type = datetime(6)
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_status_x_id" {
+    columns = [
+      column.status,
+      column.id
+    ]
+  }
+}
+
+schema "broker" {
+  charset = "utf8mb4"
+  collate = "utf8mb4_0900_ai_ci"
+}
@@ -0,0 +1,237 @@
+package streaming
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"stock-trader/broker-service/common"
+	"stock-trader/broker-service/streaming"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+)
+
+type StreamOptions struct {
+	// HeartbeatInterval is how often an idle stream is pinged, so clients and
+	// proxies can tell it is still alive.
+	HeartbeatInterval time.Duration
+	// WriteTimeout is how long a WebSocket client may take to read a message
+	// before the connection is closed.
+	WriteTimeout time.Duration
+	// AllowedOrigins are the origins of the dashboards that may open a stream
+	// from a browser. Clients that send no origin, which browsers always do,
+	// are let through.
+	AllowedOrigins []string
+}
+
+// StreamEndpoint streams quotes and order updates over WebSocket and
+// Server-Sent Events. Both streams carry the same messages.
+type StreamEndpoint struct {
+	handler  common.Handler[SubscribeCommand, *streaming.Subscription]
+	options  StreamOptions
+	upgrader websocket.Upgrader
+}
+
+func NewStreamEndpoint(handler common.Handler[SubscribeCommand, *streaming.Subscription], options StreamOptions) *StreamEndpoint {
+	e := &StreamEndpoint{
+		handler: handler,
+		options: options,
+	}
+	e.upgrader = websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool { return e.allowed(r.Header.Get(echo.HeaderOrigin)) },
+	}
+	return e
+}
+
+func (e *StreamEndpoint) allowed(origin string) bool {
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range e.options.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *StreamEndpoint) WebSocket(c echo.Context) error {
+	subscription, err := e.subscribe(c)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	conn, err := e.upgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// The upgrader already answered the client.
+		return nil
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	// Reading is what processes the client's control frames, including the
+	// close frame, which ends the stream.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		message, err := e.next(ctx, subscription)
+		conn.SetWriteDeadline(time.Now().Add(e.options.WriteTimeout))
+
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			err = conn.WriteMessage(websocket.PingMessage, nil)
+		case errors.Is(err, streaming.ErrSlowConsumer):
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, err.Error()))
+			return nil
+		case err != nil:
+			return nil
+		default:
+			err = conn.WriteJSON(message)
+		}
+
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+func (e *StreamEndpoint) SSE(c echo.Context) error {
+	subscription, err := e.subscribe(c)
+	if err != nil {
+		return err
+	}
+	defer subscription.Close()
+
+	w := c.Response()
+	if origin := c.Request().Header.Get(echo.HeaderOrigin); origin != "" {
+		w.Header().Set(echo.HeaderAccessControlAllowOrigin, origin)
+		w.Header().Add(echo.HeaderVary, echo.HeaderOrigin)
+	}
+	w.Header().Set(echo.HeaderContentType, "text/event-stream")
+	w.Header().Set(echo.HeaderCacheControl, "no-cache")
+	w.Header().Set(echo.HeaderConnection, "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	w.Flush()
+
+	ctx := c.Request().Context()
+	for {
+		message, err := e.next(ctx, subscription)
+
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case errors.Is(err, streaming.ErrSlowConsumer):
+			writeEvent(w, "error", map[string]string{"message": err.Error()})
+			w.Flush()
+			return nil
+		case err != nil:
+			return nil
+		default:
+			err = writeEvent(w, message.Type, message)
+		}
+
+		if err != nil {
+			return nil
+		}
+		w.Flush()
+	}
+}
+
+func (e *StreamEndpoint) subscribe(c echo.Context) (*streaming.Subscription, error) {
+	if !e.allowed(c.Request().Header.Get(echo.HeaderOrigin)) {
+		return nil, echo.NewHTTPError(http.StatusForbidden, "origin not allowed")
+	}
+
+	command := new(SubscribeCommand)
+	if err := c.Bind(command); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return nil, err
+	}
+
+	subscription, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, streaming.ErrInvalidStreamToken) {
+			return nil, echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+		if errors.Is(err, streaming.ErrTooManySymbols) {
+			return nil, echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return nil, echo.NewHTTPError(500, err.Error())
+	}
+
+	return subscription, nil
+}
+
+// next waits for the next message, and returns context.DeadlineExceeded when
+// the stream was idle for a heartbeat interval.
+func (e *StreamEndpoint) next(ctx context.Context, subscription *streaming.Subscription) (streaming.Message, error) {
+	if ctx.Err() != nil {
+		return streaming.Message{}, ctx.Err()
+	}
+	heartbeat, cancel := context.WithTimeout(ctx, e.options.HeartbeatInterval)
+	defer cancel()
+	return subscription.Next(heartbeat)
+}
+
+func writeEvent(w *echo.Response, event string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+	return err
+}
+
+// SubscribeCommand follows a comma separated list of symbols and the orders
+// of a portfolio. Following the orders takes a token the portfolio service
+// issued for the portfolio. It is passed in the query, as browsers can not set
+// headers on WebSocket and Server-Sent Events requests.
+type SubscribeCommand struct {
+	PortfolioId string `query:"portfolio_id" validate:"omitempty,uuid"`
+	Token       string `query:"token" validate:"required_with=PortfolioId"`
+	Symbols     string `query:"symbols" validate:"required_without=PortfolioId,max=1024"`
+}
+
+type SubscribeHandler struct {
+	hub    *streaming.Hub
+	tokens *streaming.StreamTokens
+}
+
+func NewSubscribeHandler(hub *streaming.Hub, tokens *streaming.StreamTokens) *SubscribeHandler {
+	return &SubscribeHandler{
+		hub:    hub,
+		tokens: tokens,
+	}
+}
+
+func (h *SubscribeHandler) Handle(ctx context.Context, command SubscribeCommand) (*streaming.Subscription, error) {
+	if command.PortfolioId != "" {
+		if err := h.tokens.Verify(command.PortfolioId, command.Token); err != nil {
+			return nil, err
+		}
+	}
+
+	var symbols []string
+	if command.Symbols != "" {
+		symbols = strings.Split(command.Symbols, ",")
+	}
+	return h.hub.Subscribe(command.PortfolioId, symbols)
+}
@@ -0,0 +1,196 @@
+package streaming_test
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/broker-service/infrastructure"
+	"stock-trader/broker-service/quotes"
+	"stock-trader/broker-service/streaming"
+	features "stock-trader/broker-service/streaming/features"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newServer(hub *streaming.Hub, heartbeat time.Duration) *httptest.Server {
+	e := echo.New()
+	e.Validator = infrastructure.NewRequestValidator()
+	endpoint := features.NewStreamEndpoint(features.NewSubscribeHandler(hub, streaming.NewStreamTokens("stream-secret")), features.StreamOptions{
+		HeartbeatInterval: heartbeat,
+		WriteTimeout:      time.Second,
+		AllowedOrigins:    []string{"https://dashboard.example"},
+	})
+	e.GET("/stream/ws", endpoint.WebSocket)
+	e.GET("/stream/sse", endpoint.SSE)
+	return httptest.NewServer(e)
+}
+
+// streamToken signs a token for the portfolio the way the portfolio service does.
+func streamToken(portfolioId string) string {
+	expiry := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	mac := hmac.New(sha256.New, []byte("stream-secret"))
+	mac.Write([]byte(portfolioId + "." + expiry))
+	return expiry + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// publishUntil publishes a quote until the subscription made by the request
+// under test is in the hub and receives it.
+func publishUntil(hub *streaming.Hub, received chan struct{}) {
+	for {
+		hub.PublishQuote(quotes.Quote{Symbol: "ACME", Last: decimal.NewFromInt(20)})
+		select {
+		case <-received:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func Test_StreamEndpoint(t *testing.T) {
+	options := streaming.HubOptions{MaxSymbols: 2, MaxPendingOrderUpdates: 8}
+
+	t.Run("Stream Quotes Over WebSocket", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		server := newServer(hub, time.Minute)
+		defer server.Close()
+
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream/ws?symbols=ACME", nil)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer conn.Close()
+
+		received := make(chan struct{})
+		go publishUntil(hub, received)
+		var message streaming.Message
+		err = conn.ReadJSON(&message)
+		close(received)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "quote", message.Type)
+			assert.Equal(t, "20", message.Quote.Last.String())
+		}
+	})
+
+	t.Run("Stream Quotes Over Server-Sent Events", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		server := newServer(hub, time.Minute)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/stream/sse?symbols=ACME")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get(echo.HeaderContentType))
+
+		received := make(chan struct{})
+		go publishUntil(hub, received)
+		reader := bufio.NewReader(resp.Body)
+		event, _ := reader.ReadString('\n')
+		data, _ := reader.ReadString('\n')
+		close(received)
+
+		assert.Equal(t, "event: quote\n", event)
+		assert.Contains(t, data, `"symbol":"ACME"`)
+	})
+
+	t.Run("Idle Server-Sent Events Stream Sends Heartbeats", func(t *testing.T) {
+		server := newServer(streaming.NewHub(options), 10*time.Millisecond)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/stream/sse?symbols=ACME")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer resp.Body.Close()
+
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		assert.Equal(t, ": heartbeat\n", line)
+	})
+
+	t.Run("Stream Too Many Symbols", func(t *testing.T) {
+		server := newServer(streaming.NewHub(options), time.Minute)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/stream/sse?symbols=ACME,INIT,GLOBEX")
+
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		}
+	})
+
+	t.Run("Stream The Orders Of A Portfolio Without Its Token", func(t *testing.T) {
+		server := newServer(streaming.NewHub(options), time.Minute)
+		defer server.Close()
+		portfolioId := uuid.NewString()
+
+		tests := map[string]int{
+			"":                            http.StatusBadRequest,
+			streamToken(uuid.NewString()): http.StatusUnauthorized,
+		}
+
+		for token, status := range tests {
+			resp, err := http.Get(server.URL + "/stream/sse?portfolio_id=" + portfolioId + "&token=" + token)
+
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+				assert.Equal(t, status, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Stream The Orders Of A Portfolio With Its Token", func(t *testing.T) {
+		server := newServer(streaming.NewHub(options), 10*time.Millisecond)
+		defer server.Close()
+		portfolioId := uuid.NewString()
+
+		resp, err := http.Get(server.URL + "/stream/sse?portfolio_id=" + portfolioId + "&token=" + streamToken(portfolioId))
+
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("Stream To A Page Of Another Origin", func(t *testing.T) {
+		server := newServer(streaming.NewHub(options), time.Minute)
+		defer server.Close()
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/stream/ws?symbols=ACME"
+
+		_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://elsewhere.example"}})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		}
+
+		conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://dashboard.example"}})
+		if assert.NoError(t, err) {
+			conn.Close()
+		}
+	})
+
+	t.Run("Stream Nothing", func(t *testing.T) {
+		server := newServer(streaming.NewHub(options), time.Minute)
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/stream/sse")
+
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+}
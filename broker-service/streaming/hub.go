@@ -0,0 +1,226 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stock-trader/broker-service/order"
+	"stock-trader/broker-service/quotes"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrTooManySymbols = errors.New("too many symbols")
+
+// ErrSlowConsumer ends a subscription that fell too far behind on its order
+// updates, which unlike quotes can not be skipped.
+var ErrSlowConsumer = errors.New("subscriber is too slow")
+
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+type HubOptions struct {
+	// MaxSymbols is how many symbols a single subscription may follow.
+	MaxSymbols int
+	// MaxPendingOrderUpdates is how many order updates a subscription may fall
+	// behind before it is ended with ErrSlowConsumer.
+	MaxPendingOrderUpdates int
+}
+
+// OrderUpdate is the status of an order as streamed to its portfolio.
+type OrderUpdate struct {
+	OrderId          string            `json:"order_id"`
+	PortfolioId      string            `json:"portfolio_id"`
+	Symbol           string            `json:"symbol"`
+	Status           order.OrderStatus `json:"status"`
+	FilledQuantity   decimal.Decimal   `json:"filled_quantity"`
+	UnfilledQuantity decimal.Decimal   `json:"unfilled_quantity"`
+	Timestamp        time.Time         `json:"timestamp"`
+}
+
+func NewOrderUpdate(o order.Order) OrderUpdate {
+	return OrderUpdate{
+		OrderId:          o.Id(),
+		PortfolioId:      o.PortfolioId(),
+		Symbol:           o.Symbol(),
+		Status:           o.Status(),
+		FilledQuantity:   o.FilledQuantity(),
+		UnfilledQuantity: o.UnfilledQuantity(),
+		Timestamp:        time.Now().UTC(),
+	}
+}
+
+// Message is what a subscription receives: either a quote or an order update.
+type Message struct {
+	Type  string        `json:"type"`
+	Quote *quotes.Quote `json:"quote,omitempty"`
+	Order *OrderUpdate  `json:"order,omitempty"`
+}
+
+// Hub fans quotes and order updates out to the subscriptions that follow
+// them. Publishing never blocks: each subscription keeps only the latest quote
+// of every symbol it has not received yet, and queues order updates up to a
+// limit.
+type Hub struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	options       HubOptions
+}
+
+func NewHub(options HubOptions) *Hub {
+	return &Hub{
+		subscriptions: map[*Subscription]struct{}{},
+		options:       options,
+	}
+}
+
+// Subscribe follows the quotes of symbols and the order updates of
+// portfolioId, either of which may be empty.
+func (h *Hub) Subscribe(portfolioId string, symbols []string) (*Subscription, error) {
+	followed := map[string]bool{}
+	for _, symbol := range symbols {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			followed[symbol] = true
+		}
+	}
+	if len(followed) > h.options.MaxSymbols {
+		return nil, fmt.Errorf("%w: a subscription may follow up to %d symbols", ErrTooManySymbols, h.options.MaxSymbols)
+	}
+
+	subscription := &Subscription{
+		hub:         h,
+		portfolioId: portfolioId,
+		symbols:     followed,
+		quotes:      map[string]quotes.Quote{},
+		ready:       make(chan struct{}, 1),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscriptions[subscription] = struct{}{}
+	return subscription, nil
+}
+
+func (h *Hub) PublishQuote(quote quotes.Quote) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscriptions {
+		if subscription.symbols[quote.Symbol] {
+			subscription.offerQuote(quote)
+		}
+	}
+}
+
+func (h *Hub) PublishOrderUpdate(update OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscriptions {
+		if subscription.portfolioId != "" && subscription.portfolioId == update.PortfolioId {
+			subscription.offerOrderUpdate(update, h.options.MaxPendingOrderUpdates)
+		}
+	}
+}
+
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscriptions, subscription)
+}
+
+// Subscription is a single subscriber's view of the hub. Order updates are
+// received before quotes, and quotes in the order their symbols changed.
+type Subscription struct {
+	hub         *Hub
+	portfolioId string
+	symbols     map[string]bool
+
+	mu         sync.Mutex
+	quotes     map[string]quotes.Quote
+	changed    []string
+	updates    []OrderUpdate
+	overflowed bool
+	closed     bool
+	ready      chan struct{}
+}
+
+// Next waits for the next message until ctx is done.
+func (s *Subscription) Next(ctx context.Context) (Message, error) {
+	for {
+		if message, ok, err := s.take(); ok || err != nil {
+			return message, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return Message{}, ctx.Err()
+		case <-s.ready:
+		}
+	}
+}
+
+// Close stops the subscription from receiving anything else.
+func (s *Subscription) Close() {
+	s.hub.unsubscribe(s)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.signal()
+}
+
+func (s *Subscription) take() (Message, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.closed:
+		return Message{}, false, ErrSubscriptionClosed
+	case s.overflowed:
+		return Message{}, false, ErrSlowConsumer
+	case len(s.updates) > 0:
+		update := s.updates[0]
+		s.updates = s.updates[1:]
+		return Message{Type: "order", Order: &update}, true, nil
+	case len(s.changed) > 0:
+		quote := s.quotes[s.changed[0]]
+		delete(s.quotes, s.changed[0])
+		s.changed = s.changed[1:]
+		return Message{Type: "quote", Quote: &quote}, true, nil
+	}
+	return Message{}, false, nil
+}
+
+func (s *Subscription) offerQuote(quote quotes.Quote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A quote that was not received yet is replaced by the newer one.
+	if _, ok := s.quotes[quote.Symbol]; !ok {
+		s.changed = append(s.changed, quote.Symbol)
+	}
+	s.quotes[quote.Symbol] = quote
+	s.signal()
+}
+
+func (s *Subscription) offerOrderUpdate(update OrderUpdate, limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.updates) >= limit {
+		s.overflowed = true
+	} else {
+		s.updates = append(s.updates, update)
+	}
+	s.signal()
+}
+
+func (s *Subscription) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,105 @@
+package streaming_test
+
+import (
+	"context"
+	"stock-trader/broker-service/quotes"
+	"stock-trader/broker-service/streaming"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func quote(symbol string, last string) quotes.Quote {
+	return quotes.Quote{Symbol: symbol, Last: decimal.RequireFromString(last)}
+}
+
+func next(t *testing.T, subscription *streaming.Subscription) streaming.Message {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	message, err := subscription.Next(ctx)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return message
+}
+
+func TestHub(t *testing.T) {
+	options := streaming.HubOptions{MaxSymbols: 2, MaxPendingOrderUpdates: 2}
+
+	t.Run("Subscriptions receive the quotes of the symbols they follow", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		subscription, _ := hub.Subscribe("", []string{"acme"})
+
+		hub.PublishQuote(quote("INIT", "105"))
+		hub.PublishQuote(quote("ACME", "20"))
+
+		message := next(t, subscription)
+		assert.Equal(t, "quote", message.Type)
+		assert.Equal(t, "ACME", message.Quote.Symbol)
+	})
+
+	t.Run("A slow subscription only receives the latest quote of a symbol", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		subscription, _ := hub.Subscribe("", []string{"ACME", "INIT"})
+
+		hub.PublishQuote(quote("ACME", "20"))
+		hub.PublishQuote(quote("INIT", "105"))
+		hub.PublishQuote(quote("ACME", "21"))
+
+		assert.Equal(t, "21", next(t, subscription).Quote.Last.String())
+		assert.Equal(t, "INIT", next(t, subscription).Quote.Symbol)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := subscription.Next(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Order updates of the portfolio are received before quotes", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		portfolioId := uuid.NewString()
+		subscription, _ := hub.Subscribe(portfolioId, []string{"ACME"})
+
+		hub.PublishQuote(quote("ACME", "20"))
+		hub.PublishOrderUpdate(streaming.OrderUpdate{OrderId: "other", PortfolioId: uuid.NewString()})
+		hub.PublishOrderUpdate(streaming.OrderUpdate{OrderId: "own", PortfolioId: portfolioId})
+
+		message := next(t, subscription)
+		assert.Equal(t, "order", message.Type)
+		assert.Equal(t, "own", message.Order.OrderId)
+		assert.Equal(t, "quote", next(t, subscription).Type)
+	})
+
+	t.Run("A subscription too far behind on its order updates is ended", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		portfolioId := uuid.NewString()
+		subscription, _ := hub.Subscribe(portfolioId, nil)
+
+		for i := 0; i < 3; i++ {
+			hub.PublishOrderUpdate(streaming.OrderUpdate{PortfolioId: portfolioId})
+		}
+
+		_, err := subscription.Next(context.Background())
+		assert.ErrorIs(t, err, streaming.ErrSlowConsumer)
+	})
+
+	t.Run("Subscribe to more symbols than allowed", func(t *testing.T) {
+		_, err := streaming.NewHub(options).Subscribe("", []string{"ACME", "INIT", "GLOBEX"})
+
+		assert.EqualError(t, err, "too many symbols: a subscription may follow up to 2 symbols")
+	})
+
+	t.Run("Closed subscriptions receive nothing else", func(t *testing.T) {
+		hub := streaming.NewHub(options)
+		subscription, _ := hub.Subscribe("", []string{"ACME"})
+
+		subscription.Close()
+		hub.PublishQuote(quote("ACME", "20"))
+
+		_, err := subscription.Next(context.Background())
+		assert.ErrorIs(t, err, streaming.ErrSubscriptionClosed)
+	})
+}
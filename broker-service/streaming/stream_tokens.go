@@ -0,0 +1,52 @@
+package streaming
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidStreamToken = errors.New("invalid stream token")
+
+// StreamTokens checks the tokens that let a client follow the orders of a
+// portfolio. The portfolio service issues them as "<expiry in unix
+// seconds>.<signature>", signed with the secret the two services share. An
+// empty secret refuses every token.
+type StreamTokens struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewStreamTokens(secret string) *StreamTokens {
+	return &StreamTokens{
+		secret: []byte(secret),
+		now:    time.Now,
+	}
+}
+
+func (t *StreamTokens) Verify(portfolioId string, token string) error {
+	expiry, signature, found := strings.Cut(token, ".")
+	if len(t.secret) == 0 || !found {
+		return ErrInvalidStreamToken
+	}
+
+	expiresAt, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return ErrInvalidStreamToken
+	}
+
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(portfolioId + "." + expiry))
+	if !hmac.Equal([]byte(signature), []byte(base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))) {
+		return ErrInvalidStreamToken
+	}
+	if !t.now().Before(time.Unix(expiresAt, 0)) {
+		return fmt.Errorf("%w: the token expired", ErrInvalidStreamToken)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package streaming
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sign(secret string, portfolioId string, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(portfolioId + "." + expiry))
+	return expiry + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestStreamTokens(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tokens := NewStreamTokens("secret")
+	tokens.now = func() time.Time { return now }
+
+	tests := []struct {
+		testName string
+		tokens   *StreamTokens
+		token    string
+		valid    bool
+	}{
+		{testName: "Token of the portfolio", tokens: tokens, token: sign("secret", "portfolio-1", now.Add(time.Minute)), valid: true},
+		{testName: "Token of another portfolio", tokens: tokens, token: sign("secret", "portfolio-2", now.Add(time.Minute))},
+		{testName: "Token signed with another secret", tokens: tokens, token: sign("other", "portfolio-1", now.Add(time.Minute))},
+		{testName: "Expired token", tokens: tokens, token: sign("secret", "portfolio-1", now)},
+		{testName: "Malformed token", tokens: tokens, token: "portfolio-1"},
+		{testName: "No secret", tokens: NewStreamTokens(""), token: sign("", "portfolio-1", time.Now().Add(time.Minute))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := tc.tokens.Verify("portfolio-1", tc.token)
+
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidStreamToken)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package admin
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidImportFile is returned for files that can not be read as rows at
+// all, as opposed to files with invalid rows.
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+var importColumns = []string{"date", "type", "symbol", "quantity", "price", "commission", "amount", "reference"}
+
+// importDateLayouts are the layouts dates are read in: a day, taken at its start
+// in UTC, or an instant.
+var importDateLayouts = []string{"2006-01-02", time.RFC3339}
+
+// ImportTradesRow is a line of an import file. Every row takes the date it
+// happened on. Deposits and withdrawals take an amount; buys and sells take a
+// symbol, a quantity, the price they were executed at and the commission that
+// was paid, none if left empty. The reference becomes the trade or transfer
+// id.
+type ImportTradesRow struct {
+	Line       int             `validate:"-"`
+	Date       time.Time       `validate:"required"`
+	Type       string          `validate:"required,oneof=deposit withdrawal buy sell"`
+	Symbol     string          `validate:"required_if=Type buy,required_if=Type sell,max=8"`
+	Quantity   decimal.Decimal `validate:"required_if=Type buy,required_if=Type sell,gte=0"`
+	Price      decimal.Decimal `validate:"required_if=Type buy,required_if=Type sell,gte=0"`
+	Commission decimal.Decimal `validate:"gte=0"`
+	Amount     decimal.Decimal `validate:"required_if=Type deposit,required_if=Type withdrawal,gte=0"`
+	Reference  string          `validate:"max=64"`
+}
+
+// ImportRowFailure reports why a line of an import file could not be applied,
+// with the same validation errors requests get.
+type ImportRowFailure struct {
+	Line    int                         `json:"line"`
+	Message string                      `json:"message"`
+	Errors  []infrastructure.FieldError `json:"validation_errors,omitempty"`
+}
+
+// ReadImportRows reads an import file with a header row naming the columns,
+// in any order. Values that do not parse are reported as failures of their
+// line rather than failing the whole file.
+func ReadImportRows(file io.Reader) ([]ImportTradesRow, []ImportRowFailure, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range importColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, nil, fmt.Errorf("%w: missing column %q", ErrInvalidImportFile, column)
+		}
+	}
+
+	rows, failures := []ImportTradesRow{}, []ImportRowFailure{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			return strings.TrimSpace(record[columns[column]])
+		}
+
+		row := ImportTradesRow{
+			Line:      line,
+			Type:      strings.ToLower(value("type")),
+			Symbol:    strings.ToUpper(value("symbol")),
+			Reference: value("reference"),
+		}
+		fieldErrors := []infrastructure.FieldError{}
+		if date := value("date"); date != "" {
+			if row.Date, err = parseImportDate(date); err != nil {
+				fieldErrors = append(fieldErrors, infrastructure.FieldError{Field: "Date", Error: "Date must be a date such as 2026-10-19"})
+			}
+		}
+		if quantity := value("quantity"); quantity != "" {
+			if row.Quantity, err = decimal.NewFromString(quantity); err != nil {
+				fieldErrors = append(fieldErrors, infrastructure.FieldError{Field: "Quantity", Error: "Quantity must be a number"})
+			}
+		}
+		if price := value("price"); price != "" {
+			if row.Price, err = decimal.NewFromString(price); err != nil {
+				fieldErrors = append(fieldErrors, infrastructure.FieldError{Field: "Price", Error: "Price must be a number"})
+			}
+		}
+		if commission := value("commission"); commission != "" {
+			if row.Commission, err = decimal.NewFromString(commission); err != nil {
+				fieldErrors = append(fieldErrors, infrastructure.FieldError{Field: "Commission", Error: "Commission must be a number"})
+			}
+		}
+		if amount := value("amount"); amount != "" {
+			if row.Amount, err = decimal.NewFromString(amount); err != nil {
+				fieldErrors = append(fieldErrors, infrastructure.FieldError{Field: "Amount", Error: "Amount must be a number"})
+			}
+		}
+
+		if len(fieldErrors) > 0 {
+			failures = append(failures, ImportRowFailure{Line: line, Message: "there were validation errors", Errors: fieldErrors})
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, failures, nil
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range importDateLayouts {
+		var date time.Time
+		if date, err = time.Parse(layout, value); err == nil {
+			return date.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
+
+type ImportTradesCommand struct {
+	PortfolioId string
+	Rows        []ImportTradesRow
+	// Failures are lines that were already rejected while reading the file.
+	Failures []ImportRowFailure
+	DryRun   bool
+}
+
+type ImportTradesReport struct {
+	PortfolioId string             `json:"portfolio_id"`
+	Rows        int                `json:"rows"`
+	Applied     int                `json:"applied"`
+	Failures    []ImportRowFailure `json:"failures"`
+	DryRun      bool               `json:"dry_run"`
+}
+
+// ImportTradesHandler applies the rows of an import file to a portfolio, in
+// order, as if they had come in one after the other on their dates: trades are
+// placed as orders at their price and filled right away, paying the commission
+// of the row. Rows must be in date order and not in the future, so that the
+// journal reads as the history happened. The portfolio is only saved when
+// every row applies, so a file with any failure changes nothing.
+type ImportTradesHandler struct {
+	uow        infrastructure.GormUnitOfWork
+	portfolios func(tx *gorm.DB) portfolio.PortfolioRepository
+	validator  echo.Validator
+	loyalty    portfolio.LoyaltyProgram
+	precision  portfolio.SharePrecision
+	now        func() time.Time
+}
+
+func NewImportTradesHandler(uow infrastructure.GormUnitOfWork, portfolios func(tx *gorm.DB) portfolio.PortfolioRepository, validator echo.Validator, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision) *ImportTradesHandler {
+	return &ImportTradesHandler{
+		uow:        uow,
+		portfolios: portfolios,
+		validator:  validator,
+		loyalty:    loyalty,
+		precision:  precision,
+		now:        time.Now,
+	}
+}
+
+func (h *ImportTradesHandler) Handle(ctx context.Context, command ImportTradesCommand) (*ImportTradesReport, error) {
+	report := &ImportTradesReport{
+		PortfolioId: command.PortfolioId,
+		Rows:        len(command.Rows) + len(command.Failures),
+		Failures:    append([]ImportRowFailure{}, command.Failures...),
+		DryRun:      command.DryRun,
+	}
+
+	err := h.uow.Transaction(func(tx *gorm.DB) error {
+		repository := h.portfolios(tx)
+		p, err := repository.FindById(ctx, portfolio.PortfolioId(command.PortfolioId))
+		if err != nil {
+			return err
+		}
+
+		applied, previous := 0, time.Time{}
+		for _, row := range command.Rows {
+			if failure := h.apply(p, row, previous); failure != nil {
+				report.Failures = append(report.Failures, *failure)
+				continue
+			}
+			applied, previous = applied+1, row.Date
+		}
+
+		if len(report.Failures) > 0 || command.DryRun {
+			return nil
+		}
+		report.Applied = applied
+		return repository.Save(ctx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (h *ImportTradesHandler) apply(p *portfolio.Portfolio, row ImportTradesRow, previous time.Time) *ImportRowFailure {
+	if err := h.validator.Validate(row); err != nil {
+		failure := &ImportRowFailure{Line: row.Line, Message: err.Error()}
+		var httpErr *echo.HTTPError
+		if errors.As(err, &httpErr) {
+			if response, ok := httpErr.Message.(*infrastructure.ValidationErrorsResponse); ok {
+				failure.Message, failure.Errors = response.Message, response.Errors
+			}
+		}
+		return failure
+	}
+	if row.Date.Before(previous) {
+		return &ImportRowFailure{Line: row.Line, Message: fmt.Sprintf("date %s is before the date of the previous row", row.Date.Format(time.RFC3339))}
+	}
+	if row.Date.After(h.now()) {
+		return &ImportRowFailure{Line: row.Line, Message: fmt.Sprintf("date %s is in the future", row.Date.Format(time.RFC3339))}
+	}
+
+	reference := row.Reference
+	if reference == "" {
+		reference = uuid.NewString()
+	}
+
+	var err error
+	switch row.Type {
+	case "deposit":
+		err = p.ReceiveFundsAt(portfolio.BaseCurrency, row.Amount, row.Date)
+	case "withdrawal":
+		err = p.SendFundsAt(reference, row.Amount, row.Date)
+	case "buy", "sell":
+		err = p.ProcessHistoricalTrade(portfolio.NewOrderId(), reference, portfolio.OrderRequest{
+			Symbol:     row.Symbol,
+			Side:       portfolio.OrderSide(row.Type),
+			Quantity:   row.Quantity,
+			LimitPrice: row.Price,
+		}, row.Commission, row.Date, h.loyalty, h.precision)
+	}
+	if err != nil {
+		return &ImportRowFailure{Line: row.Line, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package admin_test
+
+import (
+	"context"
+	"database/sql"
+	"stock-trader/portfolio-service/admin"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReadImportRows(t *testing.T) {
+	t.Run("Read rows by column name", func(t *testing.T) {
+		rows, failures, err := admin.ReadImportRows(strings.NewReader(strings.Join([]string{
+			"Date,Type,Symbol,Quantity,Price,Commission,Amount,Reference",
+			"2024-03-01,deposit,,,,,1000,",
+			"2024-03-04T15:30:00-05:00,buy, acme ,10,20.5,4.95,,t-1",
+		}, "\n")))
+
+		assert.NoError(t, err)
+		assert.Empty(t, failures)
+		assert.Equal(t, []admin.ImportTradesRow{
+			{Line: 2, Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Type: "deposit", Amount: decimal.NewFromInt(1000)},
+			{Line: 3, Date: time.Date(2024, 3, 4, 20, 30, 0, 0, time.UTC), Type: "buy", Symbol: "ACME", Quantity: decimal.NewFromInt(10), Price: decimal.RequireFromString("20.5"), Commission: decimal.RequireFromString("4.95"), Reference: "t-1"},
+		}, rows)
+	})
+
+	t.Run("Report values that do not parse by line", func(t *testing.T) {
+		rows, failures, err := admin.ReadImportRows(strings.NewReader(strings.Join([]string{
+			"date,type,symbol,quantity,price,commission,amount,reference",
+			"03/01/2024,buy,ACME,ten,20,,,",
+		}, "\n")))
+
+		assert.NoError(t, err)
+		assert.Empty(t, rows)
+		assert.Equal(t, []admin.ImportRowFailure{{
+			Line:    2,
+			Message: "there were validation errors",
+			Errors: []infrastructure.FieldError{
+				{Field: "Date", Error: "Date must be a date such as 2026-10-19"},
+				{Field: "Quantity", Error: "Quantity must be a number"},
+			},
+		}}, failures)
+	})
+
+	t.Run("Reject files without the expected columns", func(t *testing.T) {
+		_, _, err := admin.ReadImportRows(strings.NewReader("type,symbol\nbuy,ACME\n"))
+
+		assert.ErrorIs(t, err, admin.ErrInvalidImportFile)
+	})
+}
+
+func Test_ImportTradesHandler(t *testing.T) {
+	day := func(day int) time.Time {
+		return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC)
+	}
+	setup := func() (*admin.ImportTradesHandler, *portfolio.Portfolio, *int) {
+		existing, _ := portfolio.OpenPortfolio("Imported")
+		existing.ClearDomainEvents()
+		saves := new(int)
+		repository := &StubPortfolioRepository{
+			findById: func(ctx context.Context, portfolioId portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return existing, nil
+			},
+			save: func(ctx context.Context, p *portfolio.Portfolio) error {
+				*saves++
+				return nil
+			},
+		}
+		program, _ := portfolio.ParseLoyaltyProgram("basic:0:5")
+		handler := admin.NewImportTradesHandler(
+			StubUnitOfWork{},
+			func(tx *gorm.DB) portfolio.PortfolioRepository { return repository },
+			infrastructure.NewRequestValidator(),
+			program,
+			portfolio.SharePrecision{},
+		)
+		return handler, existing, saves
+	}
+
+	t.Run("Apply every row through the portfolio", func(t *testing.T) {
+		handler, existing, saves := setup()
+
+		report, err := handler.Handle(context.Background(), admin.ImportTradesCommand{
+			PortfolioId: string(existing.Id()),
+			Rows: []admin.ImportTradesRow{
+				{Line: 2, Date: day(1), Type: "deposit", Amount: decimal.NewFromInt(1000)},
+				{Line: 3, Date: day(4), Type: "buy", Symbol: "ACME", Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(20), Commission: decimal.RequireFromString("4.95"), Reference: "t-1"},
+				{Line: 4, Date: day(4), Type: "sell", Symbol: "ACME", Quantity: decimal.NewFromInt(4), Price: decimal.NewFromInt(25), Reference: "t-2"},
+				{Line: 5, Date: day(8), Type: "withdrawal", Amount: decimal.NewFromInt(100)},
+			},
+		})
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, report.Failures)
+			assert.Equal(t, 4, report.Rows)
+			assert.Equal(t, 4, report.Applied)
+			assert.Equal(t, 1, *saves)
+			// The commission of each row is charged, not that of the loyalty
+			// level.
+			assert.Equal(t, "795.05", existing.Cash().String())
+			assert.Equal(t, "6", existing.Holdings()[0].Quantity.String())
+			events := existing.DomainEvents()
+			if assert.NotEmpty(t, events) {
+				assert.Equal(t, day(1), events[0].Timestamp())
+				assert.Equal(t, day(8), events[len(events)-1].Timestamp())
+			}
+		}
+	})
+
+	t.Run("Save nothing when any row fails", func(t *testing.T) {
+		handler, existing, saves := setup()
+
+		report, err := handler.Handle(context.Background(), admin.ImportTradesCommand{
+			PortfolioId: string(existing.Id()),
+			Rows: []admin.ImportTradesRow{
+				{Line: 2, Date: day(1), Type: "deposit", Amount: decimal.NewFromInt(100)},
+				{Line: 3, Date: day(2), Type: "buy", Symbol: "ACME", Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(20)},
+				{Line: 4, Date: day(2), Type: "transfer", Amount: decimal.NewFromInt(10)},
+				{Line: 5, Date: day(2), Type: "sell", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(20)},
+			},
+			Failures: []admin.ImportRowFailure{{Line: 6, Message: "there were validation errors"}},
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 5, report.Rows)
+			assert.Equal(t, 0, report.Applied)
+			assert.Equal(t, 0, *saves)
+			lines := []int{}
+			for _, failure := range report.Failures {
+				lines = append(lines, failure.Line)
+			}
+			assert.Equal(t, []int{6, 3, 4, 5}, lines)
+			assert.Contains(t, report.Failures[1].Message, "insufficient funds")
+			assert.Equal(t, []infrastructure.FieldError{{Field: "Type", Error: "Type must be one of [deposit withdrawal buy sell]"}}, report.Failures[2].Errors)
+			assert.Equal(t, []infrastructure.FieldError{{Field: "Symbol", Error: "Symbol is a required field"}}, report.Failures[3].Errors)
+		}
+	})
+
+	t.Run("Refuse rows out of date order or in the future", func(t *testing.T) {
+		handler, existing, saves := setup()
+
+		report, err := handler.Handle(context.Background(), admin.ImportTradesCommand{
+			PortfolioId: string(existing.Id()),
+			Rows: []admin.ImportTradesRow{
+				{Line: 2, Date: day(5), Type: "deposit", Amount: decimal.NewFromInt(100)},
+				{Line: 3, Date: day(4), Type: "deposit", Amount: decimal.NewFromInt(100)},
+				{Line: 4, Date: time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC), Type: "deposit", Amount: decimal.NewFromInt(100)},
+				{Line: 5, Type: "deposit", Amount: decimal.NewFromInt(100)},
+			},
+		})
+
+		if assert.NoError(t, err) && assert.Len(t, report.Failures, 3) {
+			assert.Equal(t, 0, *saves)
+			assert.Equal(t, "date 2024-03-04T00:00:00Z is before the date of the previous row", report.Failures[0].Message)
+			assert.Equal(t, "date 2999-01-01T00:00:00Z is in the future", report.Failures[1].Message)
+			assert.Equal(t, []infrastructure.FieldError{{Field: "Date", Error: "Date is a required field"}}, report.Failures[2].Errors)
+		}
+	})
+
+	t.Run("Save nothing on a dry run", func(t *testing.T) {
+		handler, existing, saves := setup()
+
+		report, err := handler.Handle(context.Background(), admin.ImportTradesCommand{
+			PortfolioId: string(existing.Id()),
+			Rows:        []admin.ImportTradesRow{{Line: 2, Date: day(1), Type: "deposit", Amount: decimal.NewFromInt(100)}},
+			DryRun:      true,
+		})
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, report.Failures)
+			assert.Equal(t, 0, *saves)
+		}
+	})
+}
+
+type StubUnitOfWork struct{}
+
+func (StubUnitOfWork) Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	return fc(nil)
+}
+
+type StubPortfolioRepository struct {
+	save     func(context.Context, *portfolio.Portfolio) error
+	findById func(context.Context, portfolio.PortfolioId) (*portfolio.Portfolio, error)
+}
+
+func (r *StubPortfolioRepository) Save(ctx context.Context, p *portfolio.Portfolio) error {
+	return r.save(ctx, p)
+}
+
+func (r *StubPortfolioRepository) FindById(ctx context.Context, portfolioId portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+	return r.findById(ctx, portfolioId)
+}
+
+func (r *StubPortfolioRepository) FindByName(ctx context.Context, name string) (*portfolio.Portfolio, error) {
+	return nil, portfolio.ErrPortfolioNotFound
+}
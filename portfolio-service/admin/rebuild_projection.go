@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type RebuildProjectionEndpoint struct {
+	handler common.Handler[RebuildProjectionCommand, *common.RebuildReport]
+}
+
+func NewRebuildProjectionEndpoint(handler common.Handler[RebuildProjectionCommand, *common.RebuildReport]) *RebuildProjectionEndpoint {
+	return &RebuildProjectionEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *RebuildProjectionEndpoint) Rebuild(c echo.Context) error {
+	command := new(RebuildProjectionCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	report, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, common.ErrUnknownProjection) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, common.ErrPartialRebuildUnsupported) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+type RebuildProjectionCommand struct {
+	Projection string     `param:"name" json:"-" validate:"required"`
+	From       *time.Time `json:"from"`
+	DryRun     bool       `json:"dry_run"`
+}
+
+type ProjectionRebuilder interface {
+	Rebuild(context.Context, string, common.RebuildOptions) (*common.RebuildReport, error)
+}
+
+type RebuildProjectionHandler struct {
+	rebuilder ProjectionRebuilder
+	progress  func(common.RebuildProgress)
+}
+
+func NewRebuildProjectionHandler(rebuilder ProjectionRebuilder, progress func(common.RebuildProgress)) *RebuildProjectionHandler {
+	return &RebuildProjectionHandler{
+		rebuilder: rebuilder,
+		progress:  progress,
+	}
+}
+
+func (h *RebuildProjectionHandler) Handle(ctx context.Context, command RebuildProjectionCommand) (*common.RebuildReport, error) {
+	options := common.RebuildOptions{
+		DryRun:   command.DryRun,
+		Progress: h.progress,
+	}
+	if command.From != nil {
+		options.From = command.From.UTC()
+	}
+
+	return h.rebuilder.Rebuild(ctx, command.Projection, options)
+}
@@ -0,0 +1,168 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/admin"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RebuildProjectionHandler(t *testing.T) {
+	t.Run("Rebuild from a timestamp reporting progress", func(t *testing.T) {
+		from := time.Date(2023, 4, 20, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+		progressReported := []common.RebuildProgress{}
+		rebuilder := &StubProjectionRebuilder{
+			rebuild: func(ctx context.Context, projection string, options common.RebuildOptions) (*common.RebuildReport, error) {
+				assert.Equal(t, "portfolio-ledger", projection)
+				assert.Equal(t, from.UTC(), options.From)
+				assert.True(t, options.DryRun)
+				options.Progress(common.RebuildProgress{Projection: projection, EventsReplayed: 10, Position: 12})
+				return &common.RebuildReport{DryRun: true}, nil
+			},
+		}
+		handler := admin.NewRebuildProjectionHandler(rebuilder, func(progress common.RebuildProgress) {
+			progressReported = append(progressReported, progress)
+		})
+
+		report, err := handler.Handle(context.Background(), admin.RebuildProjectionCommand{
+			Projection: "portfolio-ledger",
+			From:       &from,
+			DryRun:     true,
+		})
+
+		if assert.NoError(t, err) {
+			assert.True(t, report.DryRun)
+			assert.Equal(t, []common.RebuildProgress{{Projection: "portfolio-ledger", EventsReplayed: 10, Position: 12}}, progressReported)
+		}
+	})
+
+	t.Run("Rebuild from the start applying the outcome", func(t *testing.T) {
+		rebuilder := &StubProjectionRebuilder{
+			rebuild: func(ctx context.Context, projection string, options common.RebuildOptions) (*common.RebuildReport, error) {
+				assert.True(t, options.From.IsZero())
+				assert.False(t, options.DryRun)
+				return &common.RebuildReport{}, nil
+			},
+		}
+
+		_, err := admin.NewRebuildProjectionHandler(rebuilder, nil).Handle(context.Background(), admin.RebuildProjectionCommand{
+			Projection: "portfolio-summaries",
+		})
+
+		assert.NoError(t, err)
+	})
+}
+
+func Test_RebuildProjectionEndpoint(t *testing.T) {
+	newContext := func(projection string, body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/admin/projections/%s/rebuild", projection), strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("name")
+		c.SetParamValues(projection)
+		return c, rec
+	}
+
+	t.Run("Rebuild Projection Successfully", func(t *testing.T) {
+		endpoint := admin.NewRebuildProjectionEndpoint(&StubHandler[admin.RebuildProjectionCommand, *common.RebuildReport]{
+			call: func(ctx context.Context, command admin.RebuildProjectionCommand) (*common.RebuildReport, error) {
+				assert.Equal(t, "portfolio-summaries", command.Projection)
+				assert.True(t, command.DryRun)
+				assert.Equal(t, time.Date(2023, 4, 20, 0, 0, 0, 0, time.UTC), command.From.UTC())
+				return &common.RebuildReport{
+					RebuildProgress: common.RebuildProgress{Projection: command.Projection, EventsReplayed: 3, Position: 7},
+					DryRun:          true,
+					Diff:            &common.ReadModelDiff{Added: []string{"a"}, Removed: []string{}, Changed: []common.ReadModelChange{}},
+				}, nil
+			},
+		})
+		c, rec := newContext("portfolio-summaries", `{"from":"2023-04-20T00:00:00Z","dry_run":true}`)
+
+		if assert.NoError(t, endpoint.Rebuild(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"projection": "portfolio-summaries",
+				"events_replayed": 3,
+				"position": 7,
+				"dry_run": true,
+				"diff": {"added": ["a"], "removed": [], "changed": []}
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Rebuild Unknown Projection", func(t *testing.T) {
+		endpoint := admin.NewRebuildProjectionEndpoint(&StubHandler[admin.RebuildProjectionCommand, *common.RebuildReport]{
+			call: func(ctx context.Context, command admin.RebuildProjectionCommand) (*common.RebuildReport, error) {
+				return nil, fmt.Errorf("%w: %s", common.ErrUnknownProjection, command.Projection)
+			},
+		})
+		c, _ := newContext("unknown", `{}`)
+
+		err := endpoint.Rebuild(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusNotFound, err.Code)
+			assert.Equal(t, "unknown projection: unknown", err.Message)
+		}
+	})
+
+	t.Run("Rebuild Projection Keeping State From A Timestamp", func(t *testing.T) {
+		endpoint := admin.NewRebuildProjectionEndpoint(&StubHandler[admin.RebuildProjectionCommand, *common.RebuildReport]{
+			call: func(ctx context.Context, command admin.RebuildProjectionCommand) (*common.RebuildReport, error) {
+				return nil, fmt.Errorf("%w: %s keeps state across events", common.ErrPartialRebuildUnsupported, command.Projection)
+			},
+		})
+		c, _ := newContext("portfolio-summaries", `{"from":"2023-04-20T00:00:00Z"}`)
+
+		err := endpoint.Rebuild(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("Rebuild Projection With Unexpected Error", func(t *testing.T) {
+		endpoint := admin.NewRebuildProjectionEndpoint(&StubHandler[admin.RebuildProjectionCommand, *common.RebuildReport]{
+			call: func(ctx context.Context, command admin.RebuildProjectionCommand) (*common.RebuildReport, error) {
+				return nil, errors.New("unexpected error")
+			},
+		})
+		c, _ := newContext("portfolio-summaries", `{}`)
+
+		err := endpoint.Rebuild(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
+		}
+	})
+}
+
+type StubHandler[K any, V any] struct {
+	call func(context.Context, K) (V, error)
+}
+
+func (s *StubHandler[K, V]) Handle(ctx context.Context, command K) (V, error) {
+	return s.call(ctx, command)
+}
+
+type StubProjectionRebuilder struct {
+	rebuild func(context.Context, string, common.RebuildOptions) (*common.RebuildReport, error)
+}
+
+func (s *StubProjectionRebuilder) Rebuild(ctx context.Context, projection string, options common.RebuildOptions) (*common.RebuildReport, error) {
+	return s.rebuild(ctx, projection, options)
+}
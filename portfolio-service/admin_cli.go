@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"stock-trader/portfolio-service/admin"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"strings"
+	"time"
+)
+
+const adminUsage = `usage: portfolio-service admin <command> [flags]
+
+commands:
+  rebuild-projection -name <projection> [-from <RFC3339 timestamp>] [-dry-run]
+      empties the read model of a projection and replays the event journal into it,
+      or only what was projected from a timestamp on for projections which allow it
+  import-trades -portfolio <portfolio id> -file <csv file> [-dry-run]
+      applies the deposits, withdrawals and trades of a CSV file to a portfolio,
+      all of them or none, on their dates; the file has the columns date, type,
+      symbol, quantity, price, commission, amount and reference`
+
+func runAdminCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "rebuild-projection":
+		return runRebuildProjection(args[1:])
+	case "import-trades":
+		return runImportTrades(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, adminUsage)
+		return 2
+	}
+}
+
+func runRebuildProjection(args []string) int {
+	flags := flag.NewFlagSet("rebuild-projection", flag.ContinueOnError)
+	name := flags.String("name", "", "projection to rebuild")
+	from := flags.String("from", "", "only replay events from this RFC3339 timestamp on")
+	dryRun := flags.Bool("dry-run", false, "diff the rebuilt read model against the current one without applying it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	db, err := infrastructure.ConnectDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not connect to the database: %v\n", err)
+		return 1
+	}
+	taxLotMethod, err := TaxLotMethod()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	rates, err := FxRates()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	rebuilder := BuildProjectionRebuilder(db, taxLotMethod, rates)
+
+	if *name == "" {
+		fmt.Fprintf(os.Stderr, "-name is required, available projections: %s\n", strings.Join(rebuilder.Projections(), ", "))
+		return 2
+	}
+
+	command := admin.RebuildProjectionCommand{
+		Projection: *name,
+		DryRun:     *dryRun,
+	}
+	if *from != "" {
+		fromTime, err := time.Parse(time.RFC3339, *from)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "-from must be an RFC3339 timestamp: %v\n", err)
+			return 2
+		}
+		command.From = &fromTime
+	}
+
+	handler := admin.NewRebuildProjectionHandler(rebuilder, func(progress common.RebuildProgress) {
+		fmt.Fprintf(os.Stderr, "%s: replayed %d events up to position %d\n", progress.Projection, progress.EventsReplayed, progress.Position)
+	})
+
+	report, err := handler.Handle(context.Background(), command)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rebuild failed: %v\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	return 0
+}
+
+func runImportTrades(args []string) int {
+	flags := flag.NewFlagSet("import-trades", flag.ContinueOnError)
+	portfolioId := flags.String("portfolio", "", "portfolio to import into")
+	path := flags.String("file", "", "CSV file to import")
+	dryRun := flags.Bool("dry-run", false, "validate the file against the portfolio without saving anything")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *portfolioId == "" || *path == "" {
+		fmt.Fprintln(os.Stderr, "-portfolio and -file are required")
+		return 2
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer file.Close()
+
+	rows, failures, err := admin.ReadImportRows(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	db, err := infrastructure.ConnectDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not connect to the database: %v\n", err)
+		return 1
+	}
+	rates, err := FxRates()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// Imported history is not valued at today's quotes, so holdings count at
+	// cost.
+	loyalty, err := LoyaltyProgram(rates, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	precision, err := SharePrecision()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	handler := BuildImportTradesHandler(db, common.NewDomainEventDispatcher(), loyalty, precision)
+	report, err := handler.Handle(context.Background(), admin.ImportTradesCommand{
+		PortfolioId: *portfolioId,
+		Rows:        rows,
+		Failures:    failures,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import failed: %v\n", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if len(report.Failures) > 0 {
+		return 1
+	}
+	return 0
+}
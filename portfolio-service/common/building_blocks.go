@@ -5,36 +5,31 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"gorm.io/datatypes"
 )
 
 type Handler[K any, V any] interface {
 	Handle(context.Context, K) (V, error)
 }
 
+// DomainEvent is internal to the bounded context that raises it. Its shape can
+// change freely; what leaves the service is the IntegrationEvent it translates to.
 type DomainEvent interface {
 	Id() string
 	Name() string
 	Timestamp() time.Time
-	EventData() map[string]any
 }
 
-type DomainEventEntity struct {
-	Id        string            `gorm:"column:id"`
-	Timestamp time.Time         `gorm:"column:timestamp"`
-	Name      string            `gorm:"column:name"`
-	EventData datatypes.JSONMap `gorm:"column:event_data"`
-}
-
-func (DomainEventEntity) TableName() string {
-	return "event_journal"
+func NewBaseDomainEvent(name string) *BaseDomainEvent {
+	return NewBaseDomainEventAt(name, time.Now().UTC())
 }
 
-func NewBaseDomainEvent(name string) *BaseDomainEvent {
+// NewBaseDomainEventAt is an event that happened at an earlier time, as when
+// history is imported.
+func NewBaseDomainEventAt(name string, timestamp time.Time) *BaseDomainEvent {
 	return &BaseDomainEvent{
 		id:        uuid.NewString(),
 		name:      name,
-		timestamp: time.Now().UTC(),
+		timestamp: timestamp.UTC(),
 	}
 }
 
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrCommandHandlerNotRegistered = errors.New("command handler not registered")
+
+// CommandHandlerFunc is the untyped shape every command takes while travelling
+// through the bus behaviours.
+type CommandHandlerFunc func(ctx context.Context, command any) (any, error)
+
+// CommandBehaviour wraps the next step of the pipeline. Behaviours run in the
+// order they were given to NewCommandBus, the first one being the outermost.
+type CommandBehaviour func(next CommandHandlerFunc) CommandHandlerFunc
+
+type CommandBus struct {
+	handlers   map[reflect.Type]CommandHandlerFunc
+	behaviours []CommandBehaviour
+}
+
+func NewCommandBus(behaviours ...CommandBehaviour) *CommandBus {
+	return &CommandBus{
+		handlers:   map[reflect.Type]CommandHandlerFunc{},
+		behaviours: behaviours,
+	}
+}
+
+// RegisterCommandHandler registers the handler for commands of type K. The
+// factory is invoked for every command with the context prepared by the
+// behaviours, so handlers can be built on top of per-command dependencies such
+// as the current transaction.
+func RegisterCommandHandler[K any, V any](bus *CommandBus, factory func(context.Context) Handler[K, V]) {
+	commandType := reflect.TypeOf((*K)(nil)).Elem()
+	if _, ok := bus.handlers[commandType]; ok {
+		panic(fmt.Sprintf("a command handler for %s is already registered", commandType))
+	}
+
+	var handler CommandHandlerFunc = func(ctx context.Context, command any) (any, error) {
+		return factory(ctx).Handle(ctx, command.(K))
+	}
+	for i := len(bus.behaviours) - 1; i >= 0; i-- {
+		handler = bus.behaviours[i](handler)
+	}
+	bus.handlers[commandType] = handler
+}
+
+func (b *CommandBus) Dispatch(ctx context.Context, command any) (any, error) {
+	handler, ok := b.handlers[reflect.TypeOf(command)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCommandHandlerNotRegistered, CommandName(command))
+	}
+	return handler(ctx, command)
+}
+
+// CommandName is the name behaviours use to identify a command in logs, traces
+// and metrics.
+func CommandName(command any) string {
+	return reflect.TypeOf(command).Name()
+}
+
+type commandBusHandler[K any, V any] struct {
+	bus *CommandBus
+}
+
+// NewCommandBusHandler exposes the bus as a Handler, so endpoints stay unaware
+// of how their commands are dispatched.
+func NewCommandBusHandler[K any, V any](bus *CommandBus) Handler[K, V] {
+	return &commandBusHandler[K, V]{
+		bus: bus,
+	}
+}
+
+func (h *commandBusHandler[K, V]) Handle(ctx context.Context, command K) (V, error) {
+	result, err := h.bus.Dispatch(ctx, command)
+	value, _ := result.(V)
+	return value, err
+}
@@ -0,0 +1,117 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/common"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type createThing struct {
+	Name string
+}
+
+type deleteThing struct {
+	Id string
+}
+
+type handlerFunc[K any, V any] func(context.Context, K) (V, error)
+
+func (f handlerFunc[K, V]) Handle(ctx context.Context, command K) (V, error) {
+	return f(ctx, command)
+}
+
+func TestCommandBus(t *testing.T) {
+	t.Run("dispatches commands to the handler registered for their type", func(t *testing.T) {
+		bus := common.NewCommandBus()
+		common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[createThing, string] {
+			return handlerFunc[createThing, string](func(ctx context.Context, command createThing) (string, error) {
+				return "created " + command.Name, nil
+			})
+		})
+		common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[deleteThing, bool] {
+			return handlerFunc[deleteThing, bool](func(ctx context.Context, command deleteThing) (bool, error) {
+				return true, nil
+			})
+		})
+
+		created, err := common.NewCommandBusHandler[createThing, string](bus).Handle(context.Background(), createThing{Name: "thing"})
+		assert.NoError(t, err)
+		assert.Equal(t, "created thing", created)
+
+		deleted, err := common.NewCommandBusHandler[deleteThing, bool](bus).Handle(context.Background(), deleteThing{Id: "1"})
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+	})
+
+	t.Run("runs behaviours around the handler in the given order", func(t *testing.T) {
+		calls := []string{}
+		behaviour := func(name string) common.CommandBehaviour {
+			return func(next common.CommandHandlerFunc) common.CommandHandlerFunc {
+				return func(ctx context.Context, command any) (any, error) {
+					calls = append(calls, "before "+name+" "+common.CommandName(command))
+					result, err := next(ctx, command)
+					calls = append(calls, "after "+name)
+					return result, err
+				}
+			}
+		}
+		bus := common.NewCommandBus(behaviour("outer"), behaviour("inner"))
+		common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[createThing, string] {
+			calls = append(calls, "factory")
+			return handlerFunc[createThing, string](func(ctx context.Context, command createThing) (string, error) {
+				calls = append(calls, "handler")
+				return "", nil
+			})
+		})
+
+		_, err := bus.Dispatch(context.Background(), createThing{})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{
+				"before outer createThing",
+				"before inner createThing",
+				"factory",
+				"handler",
+				"after inner",
+				"after outer",
+			}, calls)
+		}
+	})
+
+	t.Run("propagates handler errors", func(t *testing.T) {
+		handlerErr := errors.New("handler failed")
+		bus := common.NewCommandBus()
+		common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[createThing, string] {
+			return handlerFunc[createThing, string](func(ctx context.Context, command createThing) (string, error) {
+				return "", handlerErr
+			})
+		})
+
+		result, err := common.NewCommandBusHandler[createThing, string](bus).Handle(context.Background(), createThing{})
+
+		assert.ErrorIs(t, err, handlerErr)
+		assert.Empty(t, result)
+	})
+
+	t.Run("returns error when no handler is registered for the command", func(t *testing.T) {
+		bus := common.NewCommandBus()
+
+		result, err := common.NewCommandBusHandler[createThing, string](bus).Handle(context.Background(), createThing{})
+
+		if assert.ErrorIs(t, err, common.ErrCommandHandlerNotRegistered) {
+			assert.Equal(t, "command handler not registered: createThing", err.Error())
+			assert.Empty(t, result)
+		}
+	})
+
+	t.Run("panics when registering a second handler for the same command", func(t *testing.T) {
+		bus := common.NewCommandBus()
+		factory := func(ctx context.Context) common.Handler[createThing, string] { return nil }
+		common.RegisterCommandHandler(bus, factory)
+
+		assert.Panics(t, func() { common.RegisterCommandHandler(bus, factory) })
+	})
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DomainEventHandler reacts to a domain event inside the transaction that
+// persists the aggregate which raised it. Returning an error rolls back the
+// whole command.
+type DomainEventHandler func(ctx context.Context, tx *gorm.DB, event DomainEvent) error
+
+// EventHandler adapts a typed handler so it can be registered for the domain
+// event name of T.
+func EventHandler[T DomainEvent](handle func(context.Context, *gorm.DB, T) error) DomainEventHandler {
+	return func(ctx context.Context, tx *gorm.DB, event DomainEvent) error {
+		typedEvent, ok := event.(T)
+		if !ok {
+			return fmt.Errorf("cannot handle domain event '%s' of type %T", event.Name(), event)
+		}
+		return handle(ctx, tx, typedEvent)
+	}
+}
+
+// DomainEventDispatcher invokes in-process handlers synchronously. Events are
+// dispatched in the order they were raised and, for each event, handlers run in
+// the order they were registered. Dispatching stops at the first failing handler.
+type DomainEventDispatcher struct {
+	handlers map[string][]DomainEventHandler
+}
+
+func NewDomainEventDispatcher() *DomainEventDispatcher {
+	return &DomainEventDispatcher{
+		handlers: map[string][]DomainEventHandler{},
+	}
+}
+
+func (d *DomainEventDispatcher) Register(domainEventName string, handler DomainEventHandler) {
+	d.handlers[domainEventName] = append(d.handlers[domainEventName], handler)
+}
+
+func (d *DomainEventDispatcher) Dispatch(ctx context.Context, tx *gorm.DB, domainEvents []DomainEvent) error {
+	for _, domainEvent := range domainEvents {
+		for _, handler := range d.handlers[domainEvent.Name()] {
+			if err := handler(ctx, tx, domainEvent); err != nil {
+				return fmt.Errorf("handling domain event '%s': %w", domainEvent.Name(), err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/common"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestDomainEventDispatcher(t *testing.T) {
+	t.Run("dispatches events in order to handlers in registration order", func(t *testing.T) {
+		calls := []string{}
+		dispatcher := common.NewDomainEventDispatcher()
+		dispatcher.Register("something-happened", common.EventHandler(func(ctx context.Context, tx *gorm.DB, event somethingHappened) error {
+			calls = append(calls, "first handler: "+event.value)
+			return nil
+		}))
+		dispatcher.Register("something-happened", common.EventHandler(func(ctx context.Context, tx *gorm.DB, event somethingHappened) error {
+			calls = append(calls, "second handler: "+event.value)
+			return nil
+		}))
+
+		err := dispatcher.Dispatch(context.Background(), nil, []common.DomainEvent{
+			somethingHappened{BaseDomainEvent: common.NewBaseDomainEvent("something-happened"), value: "a"},
+			common.NewBaseDomainEvent("without-handlers"),
+			somethingHappened{BaseDomainEvent: common.NewBaseDomainEvent("something-happened"), value: "b"},
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{
+				"first handler: a",
+				"second handler: a",
+				"first handler: b",
+				"second handler: b",
+			}, calls)
+		}
+	})
+
+	t.Run("stops at the first failing handler and returns its error", func(t *testing.T) {
+		handlerErr := errors.New("cross-aggregate rule violated")
+		calls := 0
+		dispatcher := common.NewDomainEventDispatcher()
+		dispatcher.Register("something-happened", func(ctx context.Context, tx *gorm.DB, event common.DomainEvent) error {
+			calls++
+			return handlerErr
+		})
+		dispatcher.Register("something-happened", func(ctx context.Context, tx *gorm.DB, event common.DomainEvent) error {
+			calls++
+			return nil
+		})
+
+		err := dispatcher.Dispatch(context.Background(), nil, []common.DomainEvent{
+			common.NewBaseDomainEvent("something-happened"),
+			common.NewBaseDomainEvent("something-happened"),
+		})
+
+		if assert.ErrorIs(t, err, handlerErr) {
+			assert.Equal(t, "handling domain event 'something-happened': cross-aggregate rule violated", err.Error())
+			assert.Equal(t, 1, calls)
+		}
+	})
+
+	t.Run("typed handler returns error when the domain event type does not match", func(t *testing.T) {
+		dispatcher := common.NewDomainEventDispatcher()
+		dispatcher.Register("something-happened", common.EventHandler(func(ctx context.Context, tx *gorm.DB, event somethingHappened) error {
+			return nil
+		}))
+
+		err := dispatcher.Dispatch(context.Background(), nil, []common.DomainEvent{common.NewBaseDomainEvent("something-happened")})
+
+		assert.Error(t, err)
+	})
+}
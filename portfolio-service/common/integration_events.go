@@ -0,0 +1,135 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// IntegrationEvent is the public, versioned contract written to the event journal
+// and relayed to other services. Once published, a name/version pair can only
+// gain new payload fields; breaking changes are published under a new version.
+type IntegrationEvent interface {
+	Id() string
+	Name() string
+	Version() int
+	Timestamp() time.Time
+	Payload() map[string]any
+}
+
+// NewBaseIntegrationEvent reuses the id and timestamp of the domain event it was
+// translated from, so translating the same domain event twice yields the same
+// journal entry.
+func NewBaseIntegrationEvent(source DomainEvent, name string, version int) *BaseIntegrationEvent {
+	return &BaseIntegrationEvent{
+		id:        source.Id(),
+		name:      name,
+		version:   version,
+		timestamp: source.Timestamp(),
+	}
+}
+
+type BaseIntegrationEvent struct {
+	id        string
+	name      string
+	version   int
+	timestamp time.Time
+}
+
+func (e BaseIntegrationEvent) Id() string {
+	return e.id
+}
+
+func (e BaseIntegrationEvent) Name() string {
+	return e.name
+}
+
+func (e BaseIntegrationEvent) Version() int {
+	return e.version
+}
+
+func (e BaseIntegrationEvent) Timestamp() time.Time {
+	return e.timestamp
+}
+
+type IntegrationEventTranslation func(DomainEvent) (IntegrationEvent, error)
+
+// Translation adapts a typed translation function so it can be registered for
+// the domain event name of T.
+func Translation[T DomainEvent](translate func(T) IntegrationEvent) IntegrationEventTranslation {
+	return func(event DomainEvent) (IntegrationEvent, error) {
+		typedEvent, ok := event.(T)
+		if !ok {
+			return nil, fmt.Errorf("cannot translate domain event '%s' of type %T", event.Name(), event)
+		}
+		return translate(typedEvent), nil
+	}
+}
+
+// IntegrationEventTranslator maps domain events to integration events. Domain
+// events without a registered translation are internal and are not published.
+type IntegrationEventTranslator struct {
+	translations map[string]IntegrationEventTranslation
+}
+
+func NewIntegrationEventTranslator() *IntegrationEventTranslator {
+	return &IntegrationEventTranslator{
+		translations: map[string]IntegrationEventTranslation{},
+	}
+}
+
+func (t *IntegrationEventTranslator) Register(domainEventName string, translation IntegrationEventTranslation) {
+	t.translations[domainEventName] = translation
+}
+
+func (t *IntegrationEventTranslator) Translate(domainEvents []DomainEvent) ([]IntegrationEvent, error) {
+	integrationEvents := []IntegrationEvent{}
+	for _, domainEvent := range domainEvents {
+		translation, ok := t.translations[domainEvent.Name()]
+		if !ok {
+			continue
+		}
+
+		integrationEvent, err := translation(domainEvent)
+		if err != nil {
+			return nil, err
+		}
+		integrationEvents = append(integrationEvents, integrationEvent)
+	}
+	return integrationEvents, nil
+}
+
+type IntegrationEventEntity struct {
+	Position  int64             `gorm:"column:position;->"`
+	Id        string            `gorm:"column:id"`
+	Timestamp time.Time         `gorm:"column:timestamp"`
+	Name      string            `gorm:"column:name"`
+	Version   int               `gorm:"column:version"`
+	EventData datatypes.JSONMap `gorm:"column:event_data"`
+}
+
+func (IntegrationEventEntity) TableName() string {
+	return "event_journal"
+}
+
+// DecodePayload unmarshals the event data into target, typically a struct
+// mirroring the documented payload of the event version.
+func (e IntegrationEventEntity) DecodePayload(target any) error {
+	data, err := json.Marshal(e.EventData)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func NewIntegrationEventEntity(event IntegrationEvent) *IntegrationEventEntity {
+	return &IntegrationEventEntity{
+		Id:        event.Id(),
+		Timestamp: event.Timestamp(),
+		Name:      event.Name(),
+		Version:   event.Version(),
+		EventData: datatypes.JSONMap(event.Payload()),
+	}
+}
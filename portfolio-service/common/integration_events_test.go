@@ -0,0 +1,57 @@
+package common_test
+
+import (
+	"stock-trader/portfolio-service/common"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type somethingHappened struct {
+	*common.BaseDomainEvent
+	value string
+}
+
+type somethingHappenedV2 struct {
+	*common.BaseIntegrationEvent
+	value string
+}
+
+func (e somethingHappenedV2) Payload() map[string]any {
+	return map[string]any{"value": e.value}
+}
+
+func TestIntegrationEventTranslator(t *testing.T) {
+	translator := common.NewIntegrationEventTranslator()
+	translator.Register("something-happened", common.Translation(func(event somethingHappened) common.IntegrationEvent {
+		return somethingHappenedV2{
+			BaseIntegrationEvent: common.NewBaseIntegrationEvent(event, "something-happened", 2),
+			value:                event.value,
+		}
+	}))
+
+	t.Run("translates registered domain events keeping their order", func(t *testing.T) {
+		first := somethingHappened{BaseDomainEvent: common.NewBaseDomainEvent("something-happened"), value: "first"}
+		internal := common.NewBaseDomainEvent("internal-event")
+		second := somethingHappened{BaseDomainEvent: common.NewBaseDomainEvent("something-happened"), value: "second"}
+
+		events, err := translator.Translate([]common.DomainEvent{first, internal, second})
+
+		if assert.NoError(t, err) && assert.Len(t, events, 2) {
+			assert.Equal(t, first.Id(), events[0].Id())
+			assert.Equal(t, 2, events[0].Version())
+			assert.Equal(t, map[string]any{"value": "first"}, events[0].Payload())
+			assert.Equal(t, second.Id(), events[1].Id())
+			assert.Equal(t, map[string]any{"value": "second"}, events[1].Payload())
+		}
+	})
+
+	t.Run("returns error when the domain event type does not match the translation", func(t *testing.T) {
+		events, err := translator.Translate([]common.DomainEvent{common.NewBaseDomainEvent("something-happened")})
+
+		assert.Nil(t, events)
+		if assert.Error(t, err) {
+			assert.Equal(t, "cannot translate domain event 'something-happened' of type *common.BaseDomainEvent", err.Error())
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownProjection         = errors.New("unknown projection")
+	ErrPartialRebuildUnsupported = errors.New("projection can only be rebuilt from the start of the journal")
+)
+
+// RebuildableProjector owns a single read model table which can be emptied and
+// recomputed from the journal.
+type RebuildableProjector interface {
+	Projector
+	ReadModelTable() string
+	ReadModelKey() string
+}
+
+// WorkingStateProjector is a RebuildableProjector that keeps state of its own
+// next to its read model, in tables which are emptied along with it when the
+// projection is rebuilt. Dry runs only diff the read model.
+type WorkingStateProjector interface {
+	RebuildableProjector
+	WorkingStateTables() []string
+}
+
+// PartialRebuildProjector is a RebuildableProjector whose rows each come from a
+// single event and record its journal position, so that it can be rebuilt from
+// a point in time on while keeping the rows before it.
+type PartialRebuildProjector interface {
+	RebuildableProjector
+	ReadModelPosition() string
+}
+
+type RebuildOptions struct {
+	// From only replays the journal from the first event at or after this
+	// instant, and only the rows projected from those events are emptied. Zero
+	// replays the whole journal. Only a PartialRebuildProjector accepts it: the
+	// others keep state across events, such as the lots bought before a sell,
+	// which a partial replay would lose.
+	From     time.Time
+	DryRun   bool
+	Progress func(RebuildProgress)
+}
+
+type RebuildProgress struct {
+	Projection     string `json:"projection"`
+	EventsReplayed int    `json:"events_replayed"`
+	Position       int64  `json:"position"`
+}
+
+type RebuildReport struct {
+	RebuildProgress
+	DryRun bool           `json:"dry_run"`
+	Diff   *ReadModelDiff `json:"diff,omitempty"`
+}
+
+type ReadModelDiff struct {
+	Added   []string          `json:"added"`
+	Removed []string          `json:"removed"`
+	Changed []ReadModelChange `json:"changed"`
+}
+
+type ReadModelChange struct {
+	Key    string         `json:"key"`
+	Before map[string]any `json:"before"`
+	After  map[string]any `json:"after"`
+}
+
+var errDryRunRollback = errors.New("dry run")
+
+type ProjectionRebuilder struct {
+	db         *gorm.DB
+	batchSize  int
+	projectors map[string]RebuildableProjector
+}
+
+func NewProjectionRebuilder(db *gorm.DB, projectors ...RebuildableProjector) *ProjectionRebuilder {
+	rebuilder := &ProjectionRebuilder{
+		db:         db,
+		batchSize:  500,
+		projectors: map[string]RebuildableProjector{},
+	}
+	for _, projector := range projectors {
+		rebuilder.projectors[projector.Name()] = projector
+	}
+	return rebuilder
+}
+
+func (r *ProjectionRebuilder) Projections() []string {
+	names := []string{}
+	for name := range r.projectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rebuild empties the read model of a projection, replays the journal into it
+// and moves its checkpoint to the end of the journal, all in one transaction
+// holding the checkpoint lock, so the running projection engine waits for it.
+// A dry run does the same, diffs the outcome against the current table and
+// rolls everything back. Rebuilding from a point in time only empties and
+// replays what was projected from then on.
+func (r *ProjectionRebuilder) Rebuild(ctx context.Context, projection string, options RebuildOptions) (*RebuildReport, error) {
+	projector, ok := r.projectors[projection]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProjection, projection)
+	}
+	partial, ok := projector.(PartialRebuildProjector)
+	if !options.From.IsZero() && !ok {
+		return nil, fmt.Errorf("%w: %s keeps state across events", ErrPartialRebuildUnsupported, projection)
+	}
+
+	report := &RebuildReport{
+		RebuildProgress: RebuildProgress{Projection: projection},
+		DryRun:          options.DryRun,
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		checkpoint, err := LockProjectionCheckpoint(tx, projection)
+		if err != nil {
+			return err
+		}
+
+		var before []map[string]any
+		if options.DryRun {
+			if before, err = readModelRows(tx, projector); err != nil {
+				return err
+			}
+		}
+
+		var lastPosition int64
+		if err := tx.Model(&IntegrationEventEntity{}).Select("COALESCE(MAX(position), 0)").Scan(&lastPosition).Error; err != nil {
+			return err
+		}
+
+		cursor := int64(0)
+		if options.From.IsZero() {
+			// DELETE rather than TRUNCATE: the latter commits implicitly in MySQL.
+			tables := []string{projector.ReadModelTable()}
+			if working, ok := projector.(WorkingStateProjector); ok {
+				tables = append(tables, working.WorkingStateTables()...)
+			}
+			for _, table := range tables {
+				if err := tx.Exec(fmt.Sprintf("DELETE FROM `%s`", table)).Error; err != nil {
+					return err
+				}
+			}
+		} else {
+			var fromPosition int64
+			if err := tx.Model(&IntegrationEventEntity{}).Select("COALESCE(MIN(position), ?)", lastPosition+1).Where("timestamp >= ? AND position <= ?", options.From, lastPosition).Scan(&fromPosition).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf("DELETE FROM `%s` WHERE `%s` >= ?", partial.ReadModelTable(), partial.ReadModelPosition()), fromPosition).Error; err != nil {
+				return err
+			}
+			cursor = fromPosition - 1
+		}
+		// Gaps in the replayed journal are skipped afresh, so that events
+		// committing late still reach the rebuilt read model.
+		if err := tx.Where("name = ? AND position > ?", projection, cursor).Delete(&ProjectionSkippedPosition{}).Error; err != nil {
+			return err
+		}
+
+		for {
+			var events []IntegrationEventEntity
+			if err := tx.Where("position > ? AND position <= ?", cursor, lastPosition).Order("position").Limit(r.batchSize).Find(&events).Error; err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				break
+			}
+
+			for _, event := range events {
+				if err := SkipProjectionPositions(tx, projection, cursor+1, event.Position); err != nil {
+					return err
+				}
+				if err := projector.Project(ctx, tx, event); err != nil {
+					return fmt.Errorf("projection '%s' failed at position %d (%s): %w", projection, event.Position, event.Name, err)
+				}
+				report.EventsReplayed++
+				report.Position = event.Position
+				cursor = event.Position
+			}
+
+			if options.Progress != nil {
+				options.Progress(report.RebuildProgress)
+			}
+		}
+
+		report.Position = lastPosition
+
+		if options.DryRun {
+			after, err := readModelRows(tx, projector)
+			if err != nil {
+				return err
+			}
+			report.Diff = DiffReadModel(projector.ReadModelKey(), before, after)
+			return errDryRunRollback
+		}
+
+		checkpoint.Position = lastPosition
+		checkpoint.UpdatedAt = time.Now().UTC()
+		return tx.Save(checkpoint).Error
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		return nil, err
+	}
+	return report, nil
+}
+
+func readModelRows(tx *gorm.DB, projector RebuildableProjector) ([]map[string]any, error) {
+	var rows []map[string]any
+	err := tx.Table(projector.ReadModelTable()).Order(projector.ReadModelKey()).Find(&rows).Error
+	return rows, err
+}
+
+// DiffReadModel compares two versions of a read model table, matching rows by
+// their key column.
+func DiffReadModel(key string, before []map[string]any, after []map[string]any) *ReadModelDiff {
+	diff := &ReadModelDiff{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: []ReadModelChange{},
+	}
+
+	beforeByKey := map[string]map[string]any{}
+	for _, row := range before {
+		beforeByKey[fmt.Sprint(row[key])] = row
+	}
+
+	for _, row := range after {
+		rowKey := fmt.Sprint(row[key])
+		previous, ok := beforeByKey[rowKey]
+		if !ok {
+			diff.Added = append(diff.Added, rowKey)
+			continue
+		}
+		delete(beforeByKey, rowKey)
+
+		if !sameRow(previous, row) {
+			diff.Changed = append(diff.Changed, ReadModelChange{Key: rowKey, Before: previous, After: row})
+		}
+	}
+
+	for rowKey := range beforeByKey {
+		diff.Removed = append(diff.Removed, rowKey)
+	}
+	sort.Strings(diff.Removed)
+
+	return diff
+}
+
+func sameRow(a map[string]any, b map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for column, value := range a {
+		if fmt.Sprint(value) != fmt.Sprint(b[column]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+func TestDiffReadModel(t *testing.T) {
+	before := []map[string]any{
+		{"id": "kept", "value": 1},
+		{"id": "changed", "value": 2},
+		{"id": "removed", "value": 3},
+	}
+	after := []map[string]any{
+		{"id": "kept", "value": 1},
+		{"id": "changed", "value": 20},
+		{"id": "added", "value": 4},
+	}
+
+	diff := common.DiffReadModel("id", before, after)
+
+	assert.Equal(t, []string{"added"}, diff.Added)
+	assert.Equal(t, []string{"removed"}, diff.Removed)
+	assert.Equal(t, []common.ReadModelChange{
+		{Key: "changed", Before: map[string]any{"id": "changed", "value": 2}, After: map[string]any{"id": "changed", "value": 20}},
+	}, diff.Changed)
+}
+
+// eventCountProjector counts the journal events it was fed in a row of its own
+// read model table.
+type eventCountProjector struct {
+	name string
+}
+
+func (p *eventCountProjector) Name() string {
+	return p.name
+}
+
+func (p *eventCountProjector) Project(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	return tx.Exec("INSERT INTO test_event_counts (id, events) VALUES (?, 1) ON DUPLICATE KEY UPDATE events = events + 1", p.name).Error
+}
+
+func (p *eventCountProjector) ReadModelTable() string {
+	return "test_event_counts"
+}
+
+func (p *eventCountProjector) ReadModelKey() string {
+	return "id"
+}
+
+// eventLogProjector records the journal position of every event it was fed in
+// a row of its own read model table.
+type eventLogProjector struct {
+	name string
+}
+
+func (p *eventLogProjector) Name() string {
+	return p.name
+}
+
+func (p *eventLogProjector) Project(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	return tx.Exec("INSERT INTO test_event_log (name, position) VALUES (?, ?)", p.name, event.Position).Error
+}
+
+func (p *eventLogProjector) ReadModelTable() string {
+	return "test_event_log"
+}
+
+func (p *eventLogProjector) ReadModelKey() string {
+	return "position"
+}
+
+func (p *eventLogProjector) ReadModelPosition() string {
+	return "position"
+}
+
+func TestProjectionRebuilder(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+
+	db.Exec("CREATE TABLE IF NOT EXISTS test_event_counts (id varchar(64) NOT NULL, events int NOT NULL, PRIMARY KEY (id))")
+	db.Exec("CREATE TABLE IF NOT EXISTS test_event_log (name varchar(64) NOT NULL, position bigint NOT NULL, PRIMARY KEY (name, position))")
+	db.Create(&common.IntegrationEventEntity{
+		Id:        uuid.NewString(),
+		Timestamp: time.Now().UTC(),
+		Name:      "test-event",
+		Version:   1,
+		EventData: datatypes.JSONMap{},
+	})
+
+	countOf := func(name string) int64 {
+		var events int64
+		db.Raw("SELECT events FROM test_event_counts WHERE id = ?", name).Scan(&events)
+		return events
+	}
+
+	t.Run("given an unknown projection should return error", func(t *testing.T) {
+		report, err := common.NewProjectionRebuilder(db).Rebuild(context.Background(), "unknown", common.RebuildOptions{})
+
+		assert.ErrorIs(t, err, common.ErrUnknownProjection)
+		assert.Nil(t, report)
+	})
+
+	t.Run("given a timestamp for a projection keeping state should return error", func(t *testing.T) {
+		projector := &eventCountProjector{name: fmt.Sprintf("test-rebuild-%s", randomString())}
+
+		report, err := common.NewProjectionRebuilder(db, projector).Rebuild(context.Background(), projector.name, common.RebuildOptions{
+			From: time.Now().UTC(),
+		})
+
+		assert.ErrorIs(t, err, common.ErrPartialRebuildUnsupported)
+		assert.Nil(t, report)
+	})
+
+	t.Run("given a timestamp should only replay the events from then on and keep the rows before", func(t *testing.T) {
+		projector := &eventLogProjector{name: fmt.Sprintf("test-rebuild-%s", randomString())}
+		rebuilder := common.NewProjectionRebuilder(db, projector)
+		full, _ := rebuilder.Rebuild(context.Background(), projector.name, common.RebuildOptions{})
+		from := time.Now().UTC().Add(time.Hour)
+		db.Create(&common.IntegrationEventEntity{
+			Id:        uuid.NewString(),
+			Timestamp: from.Add(time.Minute),
+			Name:      "test-event",
+			Version:   1,
+			EventData: datatypes.JSONMap{},
+		})
+
+		report, err := rebuilder.Rebuild(context.Background(), projector.name, common.RebuildOptions{From: from})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, report.EventsReplayed)
+			var rows int64
+			db.Raw("SELECT COUNT(*) FROM test_event_log WHERE name = ?", projector.name).Scan(&rows)
+			assert.Equal(t, int64(full.EventsReplayed+1), rows)
+		}
+	})
+
+	t.Run("given a projection should replay the whole journal and reset its checkpoint", func(t *testing.T) {
+		projector := &eventCountProjector{name: fmt.Sprintf("test-rebuild-%s", randomString())}
+		progressReported := 0
+
+		report, err := common.NewProjectionRebuilder(db, projector).Rebuild(context.Background(), projector.name, common.RebuildOptions{
+			Progress: func(common.RebuildProgress) { progressReported++ },
+		})
+
+		if assert.NoError(t, err) {
+			assert.Greater(t, report.EventsReplayed, 0)
+			assert.Equal(t, int64(report.EventsReplayed), countOf(projector.name))
+			assert.Greater(t, progressReported, 0)
+
+			var checkpoint common.ProjectionCheckpoint
+			db.Where("name = ?", projector.name).First(&checkpoint)
+			assert.Equal(t, report.Position, checkpoint.Position)
+		}
+	})
+
+	t.Run("given a dry run should report the diff and leave the read model untouched", func(t *testing.T) {
+		projector := &eventCountProjector{name: fmt.Sprintf("test-rebuild-%s", randomString())}
+		db.Exec("INSERT INTO test_event_counts (id, events) VALUES (?, 0)", projector.name)
+
+		report, err := common.NewProjectionRebuilder(db, projector).Rebuild(context.Background(), projector.name, common.RebuildOptions{
+			DryRun: true,
+		})
+
+		if assert.NoError(t, err) {
+			assert.True(t, report.DryRun)
+			if assert.Len(t, report.Diff.Changed, 1) {
+				assert.Equal(t, projector.name, report.Diff.Changed[0].Key)
+			}
+			assert.Equal(t, int64(0), countOf(projector.name))
+		}
+	})
+}
@@ -0,0 +1,304 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Projector derives a read model from the event journal. Project is called once
+// per journal event, in journal order, inside the transaction that moves the
+// projection checkpoint, so a read model never diverges from its checkpoint.
+// The exception is events committed after their position was skipped, which
+// are projected late, out of journal order, once they show up.
+type Projector interface {
+	Name() string
+	Project(ctx context.Context, tx *gorm.DB, event IntegrationEventEntity) error
+}
+
+type ProjectionCheckpoint struct {
+	Name      string    `gorm:"column:name;primaryKey"`
+	Position  int64     `gorm:"column:position"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (ProjectionCheckpoint) TableName() string {
+	return "projection_checkpoints"
+}
+
+// ProjectionSkippedPosition is a journal position a projection moved past while
+// it was missing. It is watched until the event shows up or the skip retention
+// runs out.
+type ProjectionSkippedPosition struct {
+	Name      string    `gorm:"column:name;primaryKey"`
+	Position  int64     `gorm:"column:position;primaryKey"`
+	SkippedAt time.Time `gorm:"column:skipped_at"`
+}
+
+func (ProjectionSkippedPosition) TableName() string {
+	return "projection_skipped_positions"
+}
+
+type ProjectionOptions struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// GapTimeout is how long a projection waits for a missing journal position
+	// before skipping it. Positions are assigned on insert, so a transaction
+	// committing late leaves a temporary gap, while a rolled back one leaves a
+	// permanent gap.
+	GapTimeout time.Duration
+	// SkipRetention is how long skipped positions are watched for events
+	// committing late, before their transactions are taken as rolled back.
+	SkipRetention time.Duration
+	OnError       func(projection string, err error)
+}
+
+func DefaultProjectionOptions() ProjectionOptions {
+	return ProjectionOptions{
+		BatchSize:     100,
+		PollInterval:  time.Second,
+		GapTimeout:    5 * time.Second,
+		SkipRetention: time.Hour,
+		OnError:       func(string, error) {},
+	}
+}
+
+// ProjectionEngine tails the event journal and feeds it to its projectors. Each
+// projector runs in its own goroutine from its own checkpoint, so projections
+// progress in parallel and a failing one does not hold back the others.
+type ProjectionEngine struct {
+	db      *gorm.DB
+	options ProjectionOptions
+	runners []*projectionRunner
+	mutex   sync.Mutex
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewProjectionEngine(db *gorm.DB, options ProjectionOptions, projectors ...Projector) *ProjectionEngine {
+	engine := &ProjectionEngine{
+		db:      db,
+		options: options,
+	}
+	for _, projector := range projectors {
+		engine.runners = append(engine.runners, &projectionRunner{
+			db:        db,
+			projector: projector,
+			options:   options,
+		})
+	}
+	return engine
+}
+
+// Start runs every projection from its last checkpoint until Stop is called or
+// ctx is cancelled. Starting an already running engine does nothing.
+func (e *ProjectionEngine) Start(ctx context.Context) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.cancel != nil {
+		return
+	}
+
+	ctx, e.cancel = context.WithCancel(ctx)
+	for _, runner := range e.runners {
+		e.wg.Add(1)
+		go func(runner *projectionRunner) {
+			defer e.wg.Done()
+			runner.run(ctx)
+		}(runner)
+	}
+}
+
+// Stop waits for in-flight batches to finish. The engine can be started again
+// and resumes every projection from its checkpoint.
+func (e *ProjectionEngine) Stop() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.cancel == nil {
+		return
+	}
+
+	e.cancel()
+	e.wg.Wait()
+	e.cancel = nil
+}
+
+// CatchUp projects synchronously until every projection has reached the end of
+// the journal.
+func (e *ProjectionEngine) CatchUp(ctx context.Context) error {
+	for _, runner := range e.runners {
+		for {
+			processed, err := runner.runOnce(ctx)
+			if err != nil {
+				return err
+			}
+			if processed < e.options.BatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+type projectionRunner struct {
+	db        *gorm.DB
+	projector Projector
+	options   ProjectionOptions
+	gaps      gapDetector
+}
+
+func (r *projectionRunner) run(ctx context.Context) {
+	for {
+		processed, err := r.runOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.options.OnError(r.projector.Name(), err)
+		}
+
+		if err == nil && processed == r.options.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.options.PollInterval):
+		}
+	}
+}
+
+func (r *projectionRunner) runOnce(ctx context.Context) (int, error) {
+	processed := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		checkpoint, err := LockProjectionCheckpoint(tx, r.projector.Name())
+		if err != nil {
+			return err
+		}
+
+		late, err := r.projectLate(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		var events []IntegrationEventEntity
+		if err := tx.Where("position > ?", checkpoint.Position).Order("position").Limit(r.options.BatchSize).Find(&events).Error; err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if next := checkpoint.Position + 1; event.Position != next {
+				if !r.gaps.canSkip(next, time.Now(), r.options.GapTimeout) {
+					break
+				}
+				if err := SkipProjectionPositions(tx, r.projector.Name(), next, event.Position); err != nil {
+					return err
+				}
+			}
+
+			if err := r.projector.Project(ctx, tx, event); err != nil {
+				return fmt.Errorf("projection '%s' failed at position %d (%s): %w", r.projector.Name(), event.Position, event.Name, err)
+			}
+			checkpoint.Position = event.Position
+			processed++
+		}
+
+		if processed == 0 && late == 0 {
+			return nil
+		}
+
+		checkpoint.UpdatedAt = time.Now().UTC()
+		return tx.Save(checkpoint).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return processed, nil
+}
+
+// projectLate projects the events that showed up at positions skipped before,
+// and stops watching the positions still missing after the skip retention.
+func (r *projectionRunner) projectLate(ctx context.Context, tx *gorm.DB) (int, error) {
+	var skipped []ProjectionSkippedPosition
+	if err := tx.Where("name = ?", r.projector.Name()).Find(&skipped).Error; err != nil {
+		return 0, err
+	}
+	if len(skipped) == 0 {
+		return 0, nil
+	}
+
+	positions := []int64{}
+	for _, position := range skipped {
+		positions = append(positions, position.Position)
+	}
+	var events []IntegrationEventEntity
+	if err := tx.Where("position IN ?", positions).Order("position").Find(&events).Error; err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := r.projector.Project(ctx, tx, event); err != nil {
+			return 0, fmt.Errorf("projection '%s' failed at late position %d (%s): %w", r.projector.Name(), event.Position, event.Name, err)
+		}
+		if err := tx.Where("name = ? AND position = ?", r.projector.Name(), event.Position).Delete(&ProjectionSkippedPosition{}).Error; err != nil {
+			return 0, err
+		}
+	}
+
+	expired := time.Now().UTC().Add(-r.options.SkipRetention)
+	if err := tx.Where("name = ? AND skipped_at < ?", r.projector.Name(), expired).Delete(&ProjectionSkippedPosition{}).Error; err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// SkipProjectionPositions records every position from the first one up to the
+// last one, excluded, as skipped by the projection.
+func SkipProjectionPositions(tx *gorm.DB, projection string, first int64, last int64) error {
+	skipped := []ProjectionSkippedPosition{}
+	for position := first; position < last; position++ {
+		skipped = append(skipped, ProjectionSkippedPosition{Name: projection, Position: position, SkippedAt: time.Now().UTC()})
+	}
+	if len(skipped) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&skipped).Error
+}
+
+// LockProjectionCheckpoint returns the checkpoint of a projection, creating it
+// if needed, and locks it until tx ends so a projection is only ever advanced by
+// one writer.
+func LockProjectionCheckpoint(tx *gorm.DB, projection string) (*ProjectionCheckpoint, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ProjectionCheckpoint{
+		Name:      projection,
+		UpdatedAt: time.Now().UTC(),
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoint := &ProjectionCheckpoint{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", projection).First(checkpoint).Error; err != nil {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+type gapDetector struct {
+	position int64
+	since    time.Time
+}
+
+// canSkip reports whether the missing position has been missing for longer
+// than timeout, starting the clock the first time a position is reported.
+func (g *gapDetector) canSkip(position int64, now time.Time, timeout time.Duration) bool {
+	if g.position != position {
+		g.position = position
+		g.since = now
+	}
+	return now.Sub(g.since) >= timeout
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGapDetector(t *testing.T) {
+	now := time.Date(2023, 4, 20, 10, 0, 0, 0, time.UTC)
+
+	t.Run("waits for a missing position until the timeout elapses", func(t *testing.T) {
+		gaps := gapDetector{}
+
+		assert.False(t, gaps.canSkip(5, now, time.Second))
+		assert.False(t, gaps.canSkip(5, now.Add(999*time.Millisecond), time.Second))
+		assert.True(t, gaps.canSkip(5, now.Add(time.Second), time.Second))
+	})
+
+	t.Run("restarts the clock for a different missing position", func(t *testing.T) {
+		gaps := gapDetector{}
+
+		gaps.canSkip(5, now, time.Second)
+
+		assert.False(t, gaps.canSkip(8, now.Add(2*time.Second), time.Second))
+		assert.True(t, gaps.canSkip(8, now.Add(3*time.Second), time.Second))
+	})
+
+	t.Run("skips immediately without timeout", func(t *testing.T) {
+		gaps := gapDetector{}
+
+		assert.True(t, gaps.canSkip(5, now, 0))
+	})
+}
@@ -0,0 +1,147 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type recordingProjector struct {
+	name   string
+	marker string
+	mutex  sync.Mutex
+	seen   []string
+	err    error
+}
+
+func (p *recordingProjector) Name() string {
+	return p.name
+}
+
+func (p *recordingProjector) Project(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	if p.err != nil {
+		return p.err
+	}
+	if event.Name == p.marker {
+		p.mutex.Lock()
+		defer p.mutex.Unlock()
+		p.seen = append(p.seen, event.Id)
+	}
+	return nil
+}
+
+func (p *recordingProjector) seenEvents() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string{}, p.seen...)
+}
+
+func TestProjectionEngine(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+
+	options := common.DefaultProjectionOptions()
+	options.PollInterval = 10 * time.Millisecond
+	options.GapTimeout = 0
+
+	appendEvent := func(name string) string {
+		id := uuid.NewString()
+		db.Create(&common.IntegrationEventEntity{
+			Id:        id,
+			Timestamp: time.Now().UTC(),
+			Name:      name,
+			Version:   1,
+			EventData: datatypes.JSONMap{},
+		})
+		return id
+	}
+
+	t.Run("given journal events should project them in order and store the checkpoint", func(t *testing.T) {
+		marker := "test-event-" + randomString()
+		first, second := appendEvent(marker), appendEvent(marker)
+		projector := &recordingProjector{name: "test-projection-" + randomString(), marker: marker}
+
+		err := common.NewProjectionEngine(db, options, projector).CatchUp(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{first, second}, projector.seenEvents())
+
+			var checkpoint common.ProjectionCheckpoint
+			var lastEvent common.IntegrationEventEntity
+			db.Where("name = ?", projector.name).First(&checkpoint)
+			db.Where("id = ?", second).First(&lastEvent)
+			assert.GreaterOrEqual(t, checkpoint.Position, lastEvent.Position)
+		}
+	})
+
+	t.Run("given a stopped engine should resume from the checkpoint", func(t *testing.T) {
+		marker := "test-event-" + randomString()
+		first := appendEvent(marker)
+		projector := &recordingProjector{name: "test-projection-" + randomString(), marker: marker}
+		engine := common.NewProjectionEngine(db, options, projector)
+
+		engine.Start(context.Background())
+		assert.Eventually(t, func() bool { return len(projector.seenEvents()) == 1 }, 5*time.Second, 10*time.Millisecond)
+		engine.Stop()
+
+		second := appendEvent(marker)
+		engine.Start(context.Background())
+		assert.Eventually(t, func() bool { return len(projector.seenEvents()) == 2 }, 5*time.Second, 10*time.Millisecond)
+		engine.Stop()
+
+		assert.Equal(t, []string{first, second}, projector.seenEvents())
+	})
+
+	t.Run("given a position skipped while its transaction was open should project the event once it commits", func(t *testing.T) {
+		marker := "test-event-" + randomString()
+		projector := &recordingProjector{name: "test-projection-" + randomString(), marker: marker}
+		engine := common.NewProjectionEngine(db, options, projector)
+		engine.CatchUp(context.Background())
+
+		open := db.Begin()
+		late := uuid.NewString()
+		open.Create(&common.IntegrationEventEntity{Id: late, Timestamp: time.Now().UTC(), Name: marker, Version: 1, EventData: datatypes.JSONMap{}})
+		early := appendEvent(marker)
+
+		err := engine.CatchUp(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{early}, projector.seenEvents())
+
+		open.Commit()
+		err = engine.CatchUp(context.Background())
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{early, late}, projector.seenEvents())
+			var skipped int64
+			db.Model(&common.ProjectionSkippedPosition{}).Where("name = ?", projector.name).Count(&skipped)
+			assert.Zero(t, skipped)
+		}
+	})
+
+	t.Run("given a failing projector should not move its checkpoint", func(t *testing.T) {
+		appendEvent("test-event-" + randomString())
+		projectorErr := errors.New("projector failed")
+		projector := &recordingProjector{name: "test-projection-" + randomString(), err: projectorErr}
+
+		err := common.NewProjectionEngine(db, options, projector).CatchUp(context.Background())
+
+		if assert.ErrorIs(t, err, projectorErr) {
+			var checkpoint common.ProjectionCheckpoint
+			result := db.Where("name = ?", projector.name).Find(&checkpoint)
+			assert.True(t, result.RowsAffected == 0 || checkpoint.Position == 0)
+		}
+	})
+}
+
+func randomString() string {
+	return strings.Split(uuid.NewString(), "-")[0]
+}
@@ -0,0 +1,99 @@
+package corporateactions
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var ErrCorporateActionNotFound = errors.New("corporate action not found")
+
+var ErrInvalidCorporateAction = errors.New("invalid corporate action")
+
+type CorporateActionType string
+
+const (
+	Split        CorporateActionType = "split"
+	ReverseSplit CorporateActionType = "reverse-split"
+	Dividend     CorporateActionType = "dividend"
+)
+
+type CorporateActionState string
+
+const (
+	// ActionScheduled means the ex-date has not come yet.
+	ActionScheduled CorporateActionState = "scheduled"
+	// ActionApplying means the holders of the symbol on the ex-date are known
+	// and some of them still have to be adjusted.
+	ActionApplying CorporateActionState = "applying"
+	ActionApplied  CorporateActionState = "applied"
+)
+
+// CorporateAction changes every holding of a symbol from its ex-date on. A
+// split or reverse split turns every Denominator shares into Numerator shares,
+// and a dividend pays AmountPerShare in cash for every share held.
+type CorporateAction struct {
+	Id             string               `gorm:"column:id;primaryKey" json:"action_id"`
+	Type           CorporateActionType  `gorm:"column:type" json:"type"`
+	Symbol         string               `gorm:"column:symbol" json:"symbol"`
+	ExDate         time.Time            `gorm:"column:ex_date" json:"ex_date"`
+	Numerator      int64                `gorm:"column:numerator" json:"numerator,omitempty"`
+	Denominator    int64                `gorm:"column:denominator" json:"denominator,omitempty"`
+	AmountPerShare decimal.NullDecimal  `gorm:"column:amount_per_share" json:"amount_per_share,omitempty"`
+	State          CorporateActionState `gorm:"column:state" json:"state"`
+	Holders        int                  `gorm:"column:holders" json:"holders"`
+	CreatedAt      time.Time            `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time            `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (CorporateAction) TableName() string {
+	return "corporate_actions"
+}
+
+// NewCorporateAction checks the ratio fits the kind of split, or the amount of
+// the dividend. The ex-date is kept as a UTC date.
+func NewCorporateAction(actionType CorporateActionType, symbol string, exDate time.Time, numerator int64, denominator int64, amountPerShare decimal.Decimal, now time.Time) (*CorporateAction, error) {
+	if symbol == "" {
+		return nil, fmt.Errorf("%w: symbol must be set", ErrInvalidCorporateAction)
+	}
+
+	action := &CorporateAction{
+		Id:        uuid.NewString(),
+		Type:      actionType,
+		Symbol:    symbol,
+		ExDate:    time.Date(exDate.Year(), exDate.Month(), exDate.Day(), 0, 0, 0, 0, time.UTC),
+		State:     ActionScheduled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	switch actionType {
+	case Split, ReverseSplit:
+		if numerator <= 0 || denominator <= 0 {
+			return nil, fmt.Errorf("%w: split ratio must be two positive numbers", ErrInvalidCorporateAction)
+		}
+		if actionType == Split && numerator <= denominator {
+			return nil, fmt.Errorf("%w: a split must give more shares than it takes", ErrInvalidCorporateAction)
+		}
+		if actionType == ReverseSplit && numerator >= denominator {
+			return nil, fmt.Errorf("%w: a reverse split must give fewer shares than it takes", ErrInvalidCorporateAction)
+		}
+		action.Numerator, action.Denominator = numerator, denominator
+	case Dividend:
+		if !amountPerShare.IsPositive() {
+			return nil, fmt.Errorf("%w: dividend per share must be greater than zero", ErrInvalidCorporateAction)
+		}
+		action.AmountPerShare = decimal.NewNullDecimal(amountPerShare)
+	default:
+		return nil, fmt.Errorf("%w: type must be one of split, reverse-split or dividend", ErrInvalidCorporateAction)
+	}
+	return action, nil
+}
+
+// IsDue tells whether the ex-date has come.
+func (a *CorporateAction) IsDue(now time.Time) bool {
+	return !a.ExDate.After(now.UTC())
+}
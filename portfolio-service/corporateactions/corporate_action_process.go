@@ -0,0 +1,166 @@
+package corporateactions
+
+import (
+	"context"
+	"fmt"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// CorporateActionProcess registers corporate actions and applies them to the
+// portfolios holding their symbol. Every step runs in the transaction its
+// repositories were built with.
+type CorporateActionProcess struct {
+	portfolios portfolio.PortfolioRepository
+	actions    CorporateActionRepository
+	orders     sagas.PlaceOrderSagaRepository
+	now        func() time.Time
+}
+
+func NewCorporateActionProcess(portfolios portfolio.PortfolioRepository, actions CorporateActionRepository, orders sagas.PlaceOrderSagaRepository) *CorporateActionProcess {
+	return &CorporateActionProcess{
+		portfolios: portfolios,
+		actions:    actions,
+		orders:     orders,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Register records an action. It is applied by the CorporateActionRunner once
+// its ex-date has come.
+func (p *CorporateActionProcess) Register(ctx context.Context, actionType CorporateActionType, symbol string, exDate time.Time, numerator int64, denominator int64, amountPerShare decimal.Decimal) (string, error) {
+	action, err := NewCorporateAction(actionType, symbol, exDate, numerator, denominator, amountPerShare, p.now())
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.actions.Save(ctx, action); err != nil {
+		return "", err
+	}
+
+	return action.Id, nil
+}
+
+// Start records the holders of a due action and the quantities they held when
+// it went ex, so that the action applies to the same shares however long
+// applying it takes. A split also cancels the orders for the symbol placed
+// before its ex-date, as their quantities and limit prices are those of the
+// shares before the split. It does nothing to an action that is not due or
+// already started.
+func (p *CorporateActionProcess) Start(ctx context.Context, actionId string) error {
+	action, err := p.actions.FindById(ctx, actionId)
+	if err != nil {
+		return err
+	}
+	if action.State != ActionScheduled || !action.IsDue(p.now()) {
+		return nil
+	}
+
+	holders, err := p.actions.RecordHolders(ctx, action)
+	if err != nil {
+		return err
+	}
+
+	if action.Type == Split || action.Type == ReverseSplit {
+		if err := p.cancelOrders(ctx, action); err != nil {
+			return err
+		}
+	}
+
+	action.State = ActionApplying
+	action.Holders = holders
+	action.UpdatedAt = p.now()
+	return p.actions.Save(ctx, action)
+}
+
+// cancelOrders asks the place order saga to cancel the pending orders for the
+// symbol of the split at the broker, which releases what they reserve.
+func (p *CorporateActionProcess) cancelOrders(ctx context.Context, action *CorporateAction) error {
+	pending, err := p.orders.FindPending(ctx, action.Symbol, action.ExDate)
+	if err != nil {
+		return err
+	}
+	for _, saga := range pending {
+		saga.RequestCancellation(fmt.Sprintf("cancelled by a split of %s", action.Symbol), p.now())
+		if err := p.orders.Save(ctx, &saga); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTo adjusts one holder of the action for the shares it held when the
+// action went ex. A portfolio already adjusted is left as it is, so applying
+// an action twice changes nothing.
+func (p *CorporateActionProcess) ApplyTo(ctx context.Context, actionId string, portfolioId string) error {
+	action, err := p.actions.FindById(ctx, actionId)
+	if err != nil {
+		return err
+	}
+
+	application, err := p.actions.ClaimApplication(ctx, actionId, portfolioId, p.now())
+	if err != nil || application == nil {
+		return err
+	}
+
+	holder, err := p.portfolios.FindById(ctx, portfolio.PortfolioId(portfolioId))
+	if err != nil {
+		return err
+	}
+
+	// Holders recorded before their quantities were kept are adjusted for what
+	// they hold now.
+	held := holder.HeldShares(action.Symbol)
+	if application.Quantity.Valid {
+		held = portfolio.HeldShares{Quantity: application.Quantity.Decimal, Currency: application.Currency}
+	}
+
+	switch action.Type {
+	case Split, ReverseSplit:
+		err = holder.SplitShares(action.Id, action.Symbol, action.Numerator, action.Denominator, held)
+	case Dividend:
+		err = holder.ReceiveDividend(action.Id, action.Symbol, action.AmountPerShare.Decimal, held)
+	default:
+		err = fmt.Errorf("unknown corporate action type %q", action.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.portfolios.Save(ctx, holder)
+}
+
+// Complete marks the action applied once no holder is left to adjust, and
+// reports whether it was.
+func (p *CorporateActionProcess) Complete(ctx context.Context, actionId string) (bool, error) {
+	action, err := p.actions.FindById(ctx, actionId)
+	if err != nil {
+		return false, err
+	}
+	if action.State != ActionApplying {
+		return action.State == ActionApplied, nil
+	}
+
+	pending, err := p.actions.FindPendingHolders(ctx, actionId, 1)
+	if err != nil || len(pending) > 0 {
+		return false, err
+	}
+
+	action.State = ActionApplied
+	action.UpdatedAt = p.now()
+	return true, p.actions.Save(ctx, action)
+}
+
+// PendingHolders returns the holders still to adjust.
+func (p *CorporateActionProcess) PendingHolders(ctx context.Context, actionId string, limit int) ([]string, error) {
+	return p.actions.FindPendingHolders(ctx, actionId, limit)
+}
+
+// DueActions returns the actions whose ex-date has come and that are not
+// applied yet.
+func (p *CorporateActionProcess) DueActions(ctx context.Context, limit int) ([]string, error) {
+	return p.actions.FindDue(ctx, p.now(), limit)
+}
@@ -0,0 +1,324 @@
+package corporateactions_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"stock-trader/portfolio-service/corporateactions"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCorporateActionRunner(t *testing.T) {
+	// setup gives two portfolios holding 10 ACME bought at 20, and one holding
+	// none.
+	setup := func() (*StubPortfolioRepository, *InMemoryCorporateActionRepository, []*portfolio.Portfolio) {
+		portfolios := &StubPortfolioRepository{portfolios: map[portfolio.PortfolioId]*portfolio.Portfolio{}}
+		actions := &InMemoryCorporateActionRepository{portfolios: portfolios, actions: map[string]corporateactions.CorporateAction{}, applications: map[string]map[string]corporateactions.Application{}}
+		all := []*portfolio.Portfolio{}
+		for i, symbol := range []string{"ACME", "ACME", "OTHER"} {
+			holder, _ := portfolio.OpenPortfolio(fmt.Sprintf("Holder %d", i))
+			holder.ReceiveFunds(decimal.NewFromInt(1000))
+			orderId := portfolio.NewOrderId()
+			holder.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: symbol, Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+			holder.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+			portfolios.Save(context.Background(), holder)
+			all = append(all, holder)
+		}
+		return portfolios, actions, all
+	}
+
+	newRunner := func(portfolios *StubPortfolioRepository, actions *InMemoryCorporateActionRepository, orders *InMemoryPlaceOrderSagaRepository) (*corporateactions.CorporateActionRunner, *corporateactions.CorporateActionProcess, *[]error) {
+		errs := &[]error{}
+		process := corporateactions.NewCorporateActionProcess(portfolios, actions, orders)
+		runner := corporateactions.NewCorporateActionRunner(
+			StubUnitOfWork{},
+			func(tx *gorm.DB) *corporateactions.CorporateActionProcess { return process },
+			corporateactions.CorporateActionRunnerOptions{
+				BatchSize: 10,
+				OnError:   func(actionId string, err error) { *errs = append(*errs, err) },
+			},
+		)
+		return runner, process, errs
+	}
+
+	today := time.Now().UTC()
+
+	t.Run("A split applies to every holder of the symbol", func(t *testing.T) {
+		portfolios, actions, holders := setup()
+		runner, process, errs := newRunner(portfolios, actions, &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}})
+		actionId, _ := process.Register(context.Background(), corporateactions.Split, "ACME", today, 2, 1, decimal.Zero)
+
+		runner.Tick(context.Background())
+
+		assert.Empty(t, *errs)
+		action := actions.actions[actionId]
+		assert.Equal(t, corporateactions.ActionApplied, action.State)
+		assert.Equal(t, 2, action.Holders)
+		for _, holder := range holders[:2] {
+			assert.Equal(t, []portfolio.Holding{{Symbol: "ACME", Currency: portfolio.BaseCurrency, Quantity: decimal.NewFromInt(20), Cost: decimal.NewFromInt(200)}}, holder.Holdings())
+		}
+		assert.Equal(t, "10", holders[2].Holdings()[0].Quantity.String())
+	})
+
+	t.Run("A dividend is paid once to every holder of the symbol", func(t *testing.T) {
+		portfolios, actions, holders := setup()
+		runner, process, _ := newRunner(portfolios, actions, &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}})
+		actionId, _ := process.Register(context.Background(), corporateactions.Dividend, "ACME", today, 0, 0, decimal.RequireFromString("0.5"))
+
+		runner.Tick(context.Background())
+		runner.Tick(context.Background())
+		err := process.ApplyTo(context.Background(), actionId, string(holders[0].Id()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "805", holders[0].Cash().String())
+		assert.Equal(t, "805", holders[1].Cash().String())
+		assert.Equal(t, "800", holders[2].Cash().String())
+	})
+
+	t.Run("A holder is adjusted for the shares it held when the action started", func(t *testing.T) {
+		portfolios, actions, holders := setup()
+		_, process, _ := newRunner(portfolios, actions, &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}})
+		actionId, _ := process.Register(context.Background(), corporateactions.Split, "ACME", today, 2, 1, decimal.Zero)
+		assert.NoError(t, process.Start(context.Background(), actionId))
+		sellId := portfolio.NewOrderId()
+		holders[0].PlaceOrder(sellId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(4), LimitPrice: decimal.NewFromInt(10)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		holders[0].ProcessTrade(sellId, "trade-2", decimal.NewFromInt(4), decimal.NewFromInt(10), portfolio.LoyaltyProgram{})
+
+		err := process.ApplyTo(context.Background(), actionId, string(holders[0].Id()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "16", holders[0].Holdings()[0].Quantity.String())
+	})
+
+	t.Run("A split cancels the orders for the symbol placed before its ex-date", func(t *testing.T) {
+		portfolios, actions, holders := setup()
+		orders := &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}}
+		_, process, _ := newRunner(portfolios, actions, orders)
+		for _, symbol := range []string{"ACME", "OTHER"} {
+			saga := sagas.NewPlaceOrderSaga(holders[0].Id(), portfolio.NewOrderId(), portfolio.OrderRequest{Symbol: symbol, Side: portfolio.Sell, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(25)}, 0, today.AddDate(0, 0, -1), 48*time.Hour)
+			orders.Save(context.Background(), saga)
+		}
+		later := sagas.NewPlaceOrderSaga(holders[1].Id(), portfolio.NewOrderId(), portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(10)}, 0, today, 48*time.Hour)
+		orders.Save(context.Background(), later)
+		actionId, _ := process.Register(context.Background(), corporateactions.Split, "ACME", today, 2, 1, decimal.Zero)
+
+		err := process.Start(context.Background(), actionId)
+
+		assert.NoError(t, err)
+		for _, saga := range orders.sagas {
+			cancelled := saga.Symbol == "ACME" && saga.OrderId != later.OrderId
+			assert.Equal(t, cancelled, saga.Reason == "cancelled by a split of ACME", saga.Symbol)
+			assert.Equal(t, cancelled, saga.IsExpired(time.Now().UTC()), saga.Symbol)
+		}
+	})
+
+	t.Run("An action is not applied before its ex-date", func(t *testing.T) {
+		portfolios, actions, holders := setup()
+		runner, process, _ := newRunner(portfolios, actions, &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}})
+		actionId, _ := process.Register(context.Background(), corporateactions.Split, "ACME", today.AddDate(0, 0, 1), 2, 1, decimal.Zero)
+
+		runner.Tick(context.Background())
+
+		assert.Equal(t, corporateactions.ActionScheduled, actions.actions[actionId].State)
+		assert.Equal(t, "10", holders[0].Holdings()[0].Quantity.String())
+	})
+
+	t.Run("Holders that fail are adjusted on a later tick", func(t *testing.T) {
+		portfolios, actions, holders := setup()
+		runner, process, errs := newRunner(portfolios, actions, &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}})
+		actionId, _ := process.Register(context.Background(), corporateactions.Split, "ACME", today, 2, 1, decimal.Zero)
+		portfolios.failLoading = holders[1].Id()
+
+		runner.Tick(context.Background())
+
+		assert.Len(t, *errs, 1)
+		assert.Equal(t, corporateactions.ActionApplying, actions.actions[actionId].State)
+		assert.Equal(t, "20", holders[0].Holdings()[0].Quantity.String())
+
+		portfolios.failLoading = ""
+		runner.Tick(context.Background())
+
+		assert.Equal(t, corporateactions.ActionApplied, actions.actions[actionId].State)
+		assert.Equal(t, "20", holders[0].Holdings()[0].Quantity.String())
+		assert.Equal(t, "20", holders[1].Holdings()[0].Quantity.String())
+	})
+}
+
+func TestNewCorporateAction(t *testing.T) {
+	exDate := time.Date(2026, 10, 19, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		testName       string
+		actionType     corporateactions.CorporateActionType
+		numerator      int64
+		denominator    int64
+		amountPerShare decimal.Decimal
+		expectedError  string
+	}{
+		{"A split giving fewer shares", corporateactions.Split, 1, 2, decimal.Zero, "invalid corporate action: a split must give more shares than it takes"},
+		{"A reverse split giving more shares", corporateactions.ReverseSplit, 2, 1, decimal.Zero, "invalid corporate action: a reverse split must give fewer shares than it takes"},
+		{"A split without a ratio", corporateactions.Split, 0, 0, decimal.Zero, "invalid corporate action: split ratio must be two positive numbers"},
+		{"A dividend without an amount", corporateactions.Dividend, 0, 0, decimal.Zero, "invalid corporate action: dividend per share must be greater than zero"},
+		{"An unknown type", "merger", 0, 0, decimal.Zero, "invalid corporate action: type must be one of split, reverse-split or dividend"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			_, err := corporateactions.NewCorporateAction(tc.actionType, "ACME", exDate, tc.numerator, tc.denominator, tc.amountPerShare, exDate)
+
+			assert.ErrorIs(t, err, corporateactions.ErrInvalidCorporateAction)
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+
+	t.Run("The ex-date is kept as a date", func(t *testing.T) {
+		action, err := corporateactions.NewCorporateAction(corporateactions.ReverseSplit, "ACME", exDate, 1, 10, decimal.Zero, exDate)
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), action.ExDate)
+			assert.True(t, action.IsDue(exDate))
+			assert.False(t, action.IsDue(exDate.AddDate(0, 0, -1)))
+		}
+	})
+}
+
+// StubPortfolioRepository hands out the portfolios it was given, so the tests
+// can look at them after each step.
+type StubPortfolioRepository struct {
+	portfolios  map[portfolio.PortfolioId]*portfolio.Portfolio
+	failLoading portfolio.PortfolioId
+}
+
+func (r *StubPortfolioRepository) FindById(ctx context.Context, portfolioId portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+	if portfolioId == r.failLoading {
+		return nil, errors.New("connection lost")
+	}
+	found, ok := r.portfolios[portfolioId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", portfolio.ErrPortfolioNotFound, portfolioId)
+	}
+	return found, nil
+}
+
+func (r *StubPortfolioRepository) FindByName(ctx context.Context, name string) (*portfolio.Portfolio, error) {
+	return nil, nil
+}
+
+func (r *StubPortfolioRepository) Save(ctx context.Context, saved *portfolio.Portfolio) error {
+	saved.ClearDomainEvents()
+	r.portfolios[saved.Id()] = saved
+	return nil
+}
+
+// InMemoryCorporateActionRepository keeps the applications of each action by
+// portfolio id, recording the quantities held when the action starts. Claims
+// are not rolled back, so a holder that fails to load fails its claim instead.
+type InMemoryCorporateActionRepository struct {
+	portfolios   *StubPortfolioRepository
+	actions      map[string]corporateactions.CorporateAction
+	applications map[string]map[string]corporateactions.Application
+}
+
+func (r *InMemoryCorporateActionRepository) FindById(ctx context.Context, actionId string) (*corporateactions.CorporateAction, error) {
+	action, ok := r.actions[actionId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", corporateactions.ErrCorporateActionNotFound, actionId)
+	}
+	return &action, nil
+}
+
+func (r *InMemoryCorporateActionRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	due := []string{}
+	for id, action := range r.actions {
+		if action.State != corporateactions.ActionApplied && action.IsDue(now) {
+			due = append(due, id)
+		}
+	}
+	return due, nil
+}
+
+func (r *InMemoryCorporateActionRepository) Save(ctx context.Context, action *corporateactions.CorporateAction) error {
+	r.actions[action.Id] = *action
+	return nil
+}
+
+func (r *InMemoryCorporateActionRepository) RecordHolders(ctx context.Context, action *corporateactions.CorporateAction) (int, error) {
+	holders := map[string]corporateactions.Application{}
+	for id, holder := range r.portfolios.portfolios {
+		if holding, ok := holder.Holding(action.Symbol); ok {
+			holders[string(id)] = corporateactions.Application{ActionId: action.Id, PortfolioId: string(id), Quantity: decimal.NewNullDecimal(holding.Quantity), Currency: holding.Currency}
+		}
+	}
+	r.applications[action.Id] = holders
+	return len(holders), nil
+}
+
+func (r *InMemoryCorporateActionRepository) FindPendingHolders(ctx context.Context, actionId string, limit int) ([]string, error) {
+	pending := []string{}
+	for id, application := range r.applications[actionId] {
+		if application.AppliedAt == nil {
+			pending = append(pending, id)
+		}
+	}
+	sort.Strings(pending)
+	return pending, nil
+}
+
+func (r *InMemoryCorporateActionRepository) ClaimApplication(ctx context.Context, actionId string, portfolioId string, now time.Time) (*corporateactions.Application, error) {
+	application, ok := r.applications[actionId][portfolioId]
+	if !ok || application.AppliedAt != nil {
+		return nil, nil
+	}
+	if portfolio.PortfolioId(portfolioId) == r.portfolios.failLoading {
+		return nil, errors.New("connection lost")
+	}
+	application.AppliedAt = &now
+	r.applications[actionId][portfolioId] = application
+	return &application, nil
+}
+
+type InMemoryPlaceOrderSagaRepository struct {
+	sagas map[string]sagas.PlaceOrderSaga
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) FindByOrderId(ctx context.Context, orderId string) (*sagas.PlaceOrderSaga, error) {
+	saga, ok := r.sagas[orderId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", sagas.ErrPlaceOrderSagaNotFound, orderId)
+	}
+	return &saga, nil
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]sagas.PlaceOrderSaga, error) {
+	return nil, nil
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) FindPending(ctx context.Context, symbol string, before time.Time) ([]sagas.PlaceOrderSaga, error) {
+	pending := []sagas.PlaceOrderSaga{}
+	for _, saga := range r.sagas {
+		if saga.Symbol == symbol && saga.IsPending() && saga.CreatedAt.Before(before) {
+			pending = append(pending, saga)
+		}
+	}
+	return pending, nil
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) Save(ctx context.Context, saga *sagas.PlaceOrderSaga) error {
+	r.sagas[saga.OrderId] = *saga
+	return nil
+}
+
+type StubUnitOfWork struct{}
+
+func (StubUnitOfWork) Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	return fc(nil)
+}
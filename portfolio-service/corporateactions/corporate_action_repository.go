@@ -0,0 +1,188 @@
+package corporateactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const holderBatchSize = 500
+
+// Application is the adjustment of one portfolio by a corporate action.
+// Quantity is what the portfolio held of the symbol when the action went ex,
+// in Currency, and is unset for applications recorded before it was kept.
+// AppliedAt is unset until the portfolio was adjusted.
+type Application struct {
+	ActionId    string              `gorm:"column:action_id;primaryKey"`
+	PortfolioId string              `gorm:"column:portfolio_id;primaryKey"`
+	Quantity    decimal.NullDecimal `gorm:"column:quantity"`
+	Currency    portfolio.Currency  `gorm:"column:currency"`
+	AppliedAt   *time.Time          `gorm:"column:applied_at"`
+}
+
+func (Application) TableName() string {
+	return "corporate_action_applications"
+}
+
+type CorporateActionRepository interface {
+	FindById(context.Context, string) (*CorporateAction, error)
+	FindDue(context.Context, time.Time, int) ([]string, error)
+	Save(context.Context, *CorporateAction) error
+	RecordHolders(context.Context, *CorporateAction) (int, error)
+	FindPendingHolders(context.Context, string, int) ([]string, error)
+	ClaimApplication(context.Context, string, string, time.Time) (*Application, error)
+}
+
+type mySQLCorporateActionRepository struct {
+	db *gorm.DB
+}
+
+func NewCorporateActionRepository(db *gorm.DB) CorporateActionRepository {
+	return &mySQLCorporateActionRepository{
+		db: db,
+	}
+}
+
+// FindById locks the action until the surrounding transaction ends.
+func (r *mySQLCorporateActionRepository) FindById(ctx context.Context, actionId string) (*CorporateAction, error) {
+	action := &CorporateAction{}
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", actionId).First(action).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrCorporateActionNotFound, actionId)
+		}
+		return nil, err
+	}
+	return action, nil
+}
+
+// FindDue returns the ids of the actions whose ex-date has come and that are
+// not applied yet, earliest ex-date first.
+func (r *mySQLCorporateActionRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&CorporateAction{}).
+		Where("state IN ? AND ex_date <= ?", []CorporateActionState{ActionScheduled, ActionApplying}, now.UTC()).
+		Order("ex_date, created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *mySQLCorporateActionRepository) Save(ctx context.Context, action *CorporateAction) error {
+	return r.db.WithContext(ctx).Save(action).Error
+}
+
+// RecordHolders records a pending application for every portfolio that held
+// the symbol of the action when its ex-date began, with the quantity it held,
+// and returns how many there are. The holdings are replayed from the journal,
+// so an action registered after its ex-date applies to whoever held the symbol
+// then rather than whoever holds it now.
+func (r *mySQLCorporateActionRepository) RecordHolders(ctx context.Context, action *CorporateAction) (int, error) {
+	held := map[string]*Application{}
+	var after int64
+	for {
+		var events []common.IntegrationEventEntity
+		err := r.db.WithContext(ctx).
+			Where("name IN ?", []string{"trade-processed", "shares-split"}).
+			Where("JSON_UNQUOTE(JSON_EXTRACT(event_data, '$.symbol')) = ?", action.Symbol).
+			Where("timestamp < ?", action.ExDate).
+			Where("position > ?", after).
+			Order("position").
+			Limit(holderBatchSize).
+			Find(&events).Error
+		if err != nil {
+			return 0, err
+		}
+		for _, event := range events {
+			if err := holdAfter(held, action.Id, event); err != nil {
+				return 0, err
+			}
+			after = event.Position
+		}
+		if len(events) < holderBatchSize {
+			break
+		}
+	}
+
+	applications := []Application{}
+	for _, application := range held {
+		if application.Quantity.Decimal.IsPositive() {
+			applications = append(applications, *application)
+		}
+	}
+	if len(applications) == 0 {
+		return 0, nil
+	}
+	sort.Slice(applications, func(i, j int) bool { return applications[i].PortfolioId < applications[j].PortfolioId })
+
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&applications, holderBatchSize)
+	return int(result.RowsAffected), result.Error
+}
+
+// holdAfter sets the quantity a portfolio holds after a trade or split of the
+// symbol.
+func holdAfter(held map[string]*Application, actionId string, event common.IntegrationEventEntity) error {
+	var payload struct {
+		PortfolioId     string             `json:"portfolioId"`
+		Currency        portfolio.Currency `json:"currency"`
+		Quantity        decimal.Decimal    `json:"quantity"`
+		HoldingQuantity decimal.Decimal    `json:"holdingQuantity"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	application, ok := held[payload.PortfolioId]
+	if !ok {
+		application = &Application{ActionId: actionId, PortfolioId: payload.PortfolioId, Currency: portfolio.BaseCurrency}
+		held[payload.PortfolioId] = application
+	}
+	switch event.Name {
+	case "trade-processed":
+		application.Quantity = decimal.NewNullDecimal(payload.HoldingQuantity)
+		// Trades recorded before portfolios held other currencies carry none.
+		if payload.Currency != "" {
+			application.Currency = payload.Currency
+		}
+	case "shares-split":
+		application.Quantity = decimal.NewNullDecimal(payload.Quantity)
+	}
+	return nil
+}
+
+func (r *mySQLCorporateActionRepository) FindPendingHolders(ctx context.Context, actionId string, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&Application{}).
+		Where("action_id = ? AND applied_at IS NULL", actionId).
+		Order("portfolio_id").
+		Limit(limit).
+		Pluck("portfolio_id", &ids).Error
+	return ids, err
+}
+
+// ClaimApplication marks the application as applied and returns it, or nil
+// when it was applied already. Rolling back the surrounding transaction makes
+// it pending again.
+func (r *mySQLCorporateActionRepository) ClaimApplication(ctx context.Context, actionId string, portfolioId string, now time.Time) (*Application, error) {
+	application := &Application{}
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("action_id = ? AND portfolio_id = ? AND applied_at IS NULL", actionId, portfolioId).
+		Take(application).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	application.AppliedAt = &now
+	return application, r.db.WithContext(ctx).Model(application).Update("applied_at", now).Error
+}
@@ -0,0 +1,98 @@
+package corporateactions
+
+import (
+	"context"
+	"stock-trader/portfolio-service/infrastructure"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CorporateActionRunnerOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	OnError      func(actionId string, err error)
+}
+
+// CorporateActionRunner applies due corporate actions, adjusting each holder
+// in a transaction of its own. All its state lives in the corporate action
+// tables, so a restarted service resumes the actions where they were left.
+type CorporateActionRunner struct {
+	uow     infrastructure.GormUnitOfWork
+	process func(tx *gorm.DB) *CorporateActionProcess
+	options CorporateActionRunnerOptions
+}
+
+func NewCorporateActionRunner(
+	uow infrastructure.GormUnitOfWork,
+	process func(tx *gorm.DB) *CorporateActionProcess,
+	options CorporateActionRunnerOptions,
+) *CorporateActionRunner {
+	return &CorporateActionRunner{
+		uow:     uow,
+		process: process,
+		options: options,
+	}
+}
+
+func (r *CorporateActionRunner) Run(ctx context.Context) {
+	for {
+		r.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.options.PollInterval):
+		}
+	}
+}
+
+// Tick applies every due action to up to a batch of its holders. Holders that
+// fail are retried on a later tick.
+func (r *CorporateActionRunner) Tick(ctx context.Context) {
+	var due []string
+	err := r.uow.Transaction(func(tx *gorm.DB) error {
+		var err error
+		due, err = r.process(tx).DueActions(ctx, r.options.BatchSize)
+		return err
+	})
+	if err != nil {
+		r.options.OnError("", err)
+		return
+	}
+
+	for _, actionId := range due {
+		if err := r.apply(ctx, actionId); err != nil {
+			r.options.OnError(actionId, err)
+		}
+	}
+}
+
+func (r *CorporateActionRunner) apply(ctx context.Context, actionId string) error {
+	var holders []string
+	err := r.uow.Transaction(func(tx *gorm.DB) error {
+		if err := r.process(tx).Start(ctx, actionId); err != nil {
+			return err
+		}
+		var err error
+		holders, err = r.process(tx).PendingHolders(ctx, actionId, r.options.BatchSize)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, portfolioId := range holders {
+		err := r.uow.Transaction(func(tx *gorm.DB) error {
+			return r.process(tx).ApplyTo(ctx, actionId, portfolioId)
+		})
+		if err != nil {
+			r.options.OnError(actionId, err)
+		}
+	}
+
+	return r.uow.Transaction(func(tx *gorm.DB) error {
+		_, err := r.process(tx).Complete(ctx, actionId)
+		return err
+	})
+}
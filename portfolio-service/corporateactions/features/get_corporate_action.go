@@ -0,0 +1,61 @@
+package corporateactions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/corporateactions"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetCorporateActionEndpoint struct {
+	handler common.Handler[GetCorporateActionQuery, *corporateactions.CorporateAction]
+}
+
+func NewGetCorporateActionEndpoint(handler common.Handler[GetCorporateActionQuery, *corporateactions.CorporateAction]) *GetCorporateActionEndpoint {
+	return &GetCorporateActionEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetCorporateActionEndpoint) Get(c echo.Context) error {
+	query := new(GetCorporateActionQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	action, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, corporateactions.ErrCorporateActionNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, action)
+}
+
+type GetCorporateActionQuery struct {
+	ActionId string `param:"id" validate:"required,uuid"`
+}
+
+type GetCorporateActionHandler struct {
+	actionRepository corporateactions.CorporateActionRepository
+}
+
+func NewGetCorporateActionHandler(repository corporateactions.CorporateActionRepository) *GetCorporateActionHandler {
+	return &GetCorporateActionHandler{
+		actionRepository: repository,
+	}
+}
+
+func (h *GetCorporateActionHandler) Handle(ctx context.Context, query GetCorporateActionQuery) (*corporateactions.CorporateAction, error) {
+	return h.actionRepository.FindById(ctx, query.ActionId)
+}
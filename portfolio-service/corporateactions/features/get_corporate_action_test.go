@@ -0,0 +1,85 @@
+package corporateactions_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/corporateactions"
+	features "stock-trader/portfolio-service/corporateactions/features"
+	"stock-trader/portfolio-service/infrastructure"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetCorporateActionEndpoint(t *testing.T) {
+	newContext := func(actionId string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/admin/corporate-actions/"+actionId, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(actionId)
+		return c, rec
+	}
+
+	t.Run("Get Corporate Action Successfully", func(t *testing.T) {
+		actionId := uuid.NewString()
+		at := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+		endpoint := features.NewGetCorporateActionEndpoint(&StubHandler[features.GetCorporateActionQuery, *corporateactions.CorporateAction]{
+			call: func(ctx context.Context, query features.GetCorporateActionQuery) (*corporateactions.CorporateAction, error) {
+				assert.Equal(t, actionId, query.ActionId)
+				return &corporateactions.CorporateAction{
+					Id:          actionId,
+					Type:        corporateactions.ReverseSplit,
+					Symbol:      "ACME",
+					ExDate:      time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+					Numerator:   1,
+					Denominator: 10,
+					State:       corporateactions.ActionApplied,
+					Holders:     3,
+					CreatedAt:   at,
+					UpdatedAt:   at,
+				}, nil
+			},
+		})
+		c, rec := newContext(actionId)
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"action_id": "`+actionId+`",
+				"type": "reverse-split",
+				"symbol": "ACME",
+				"ex_date": "2026-10-19T00:00:00Z",
+				"numerator": 1,
+				"denominator": 10,
+				"amount_per_share": null,
+				"state": "applied",
+				"holders": 3,
+				"created_at": "2026-10-19T10:00:00Z",
+				"updated_at": "2026-10-19T10:00:00Z"
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Get Corporate Action Not Found", func(t *testing.T) {
+		endpoint := features.NewGetCorporateActionEndpoint(&StubHandler[features.GetCorporateActionQuery, *corporateactions.CorporateAction]{
+			call: func(ctx context.Context, query features.GetCorporateActionQuery) (*corporateactions.CorporateAction, error) {
+				return nil, fmt.Errorf("%w: %s", corporateactions.ErrCorporateActionNotFound, query.ActionId)
+			},
+		})
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package corporateactions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/corporateactions"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+const exDateLayout = "2006-01-02"
+
+type RegisterCorporateActionEndpoint struct {
+	handler common.Handler[RegisterCorporateActionCommand, string]
+}
+
+func NewRegisterCorporateActionEndpoint(handler common.Handler[RegisterCorporateActionCommand, string]) *RegisterCorporateActionEndpoint {
+	return &RegisterCorporateActionEndpoint{
+		handler: handler,
+	}
+}
+
+// Register answers as soon as the action is recorded. It is applied once its
+// ex-date has come, and its progress is served by GET /corporate-actions/:id.
+func (e *RegisterCorporateActionEndpoint) Register(c echo.Context) error {
+	command := new(RegisterCorporateActionCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	actionId, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, corporateactions.ErrInvalidCorporateAction) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, struct {
+		ActionId string `json:"action_id"`
+	}{
+		ActionId: actionId,
+	})
+}
+
+// RegisterCorporateActionCommand takes a ratio for splits and reverse splits,
+// and an amount per share for dividends.
+type RegisterCorporateActionCommand struct {
+	Type           string          `json:"type" validate:"required,oneof=split reverse-split dividend"`
+	Symbol         string          `json:"symbol" validate:"required,max=8"`
+	ExDate         string          `json:"ex_date" validate:"required,datetime=2006-01-02"`
+	Numerator      int64           `json:"numerator" validate:"gte=0"`
+	Denominator    int64           `json:"denominator" validate:"gte=0"`
+	AmountPerShare decimal.Decimal `json:"amount_per_share"`
+}
+
+type RegisterCorporateActionHandler struct {
+	process *corporateactions.CorporateActionProcess
+}
+
+func NewRegisterCorporateActionHandler(process *corporateactions.CorporateActionProcess) *RegisterCorporateActionHandler {
+	return &RegisterCorporateActionHandler{
+		process: process,
+	}
+}
+
+func (h *RegisterCorporateActionHandler) Handle(ctx context.Context, command RegisterCorporateActionCommand) (string, error) {
+	exDate, err := time.Parse(exDateLayout, command.ExDate)
+	if err != nil {
+		return "", err
+	}
+
+	return h.process.Register(ctx, corporateactions.CorporateActionType(command.Type), command.Symbol, exDate, command.Numerator, command.Denominator, command.AmountPerShare)
+}
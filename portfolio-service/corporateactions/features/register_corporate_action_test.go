@@ -0,0 +1,90 @@
+package corporateactions_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/corporateactions"
+	features "stock-trader/portfolio-service/corporateactions/features"
+	"stock-trader/portfolio-service/infrastructure"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegisterCorporateActionEndpoint(t *testing.T) {
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/admin/corporate-actions", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return e.NewContext(req, rec), rec
+	}
+
+	t.Run("Register A Dividend Successfully", func(t *testing.T) {
+		actionId := uuid.NewString()
+		endpoint := features.NewRegisterCorporateActionEndpoint(&StubHandler[features.RegisterCorporateActionCommand, string]{
+			call: func(ctx context.Context, command features.RegisterCorporateActionCommand) (string, error) {
+				assert.Equal(t, "dividend", command.Type)
+				assert.Equal(t, "ACME", command.Symbol)
+				assert.Equal(t, "2026-11-02", command.ExDate)
+				assert.Equal(t, "0.35", command.AmountPerShare.String())
+				return actionId, nil
+			},
+		})
+		c, rec := newContext(`{"type":"dividend","symbol":"ACME","ex_date":"2026-11-02","amount_per_share":"0.35"}`)
+
+		if assert.NoError(t, endpoint.Register(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.JSONEq(t, `{"action_id":"`+actionId+`"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Register An Action With An Invalid Ex-Date", func(t *testing.T) {
+		endpoint := features.NewRegisterCorporateActionEndpoint(nil)
+		c, _ := newContext(`{"type":"split","symbol":"ACME","ex_date":"02/11/2026","numerator":2,"denominator":1}`)
+
+		err := endpoint.Register(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusBadRequest, err.Code)
+			assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+				Message: "there were validation errors",
+				Errors: []infrastructure.FieldError{
+					{Field: "ExDate", Error: "ExDate does not match the 2006-01-02 format"},
+				},
+			}, err.Message)
+		}
+	})
+
+	t.Run("Register A Split With A Ratio That Does Not Fit", func(t *testing.T) {
+		endpoint := features.NewRegisterCorporateActionEndpoint(&StubHandler[features.RegisterCorporateActionCommand, string]{
+			call: func(ctx context.Context, command features.RegisterCorporateActionCommand) (string, error) {
+				return "", fmt.Errorf("%w: a split must give more shares than it takes", corporateactions.ErrInvalidCorporateAction)
+			},
+		})
+		c, _ := newContext(`{"type":"split","symbol":"ACME","ex_date":"2026-11-02","numerator":1,"denominator":2}`)
+
+		err := endpoint.Register(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusBadRequest, err.Code)
+			assert.Equal(t, "invalid corporate action: a split must give more shares than it takes", err.Message)
+		}
+	})
+}
+
+type StubHandler[K any, V any] struct {
+	call func(context.Context, K) (V, error)
+}
+
+func (s *StubHandler[K, V]) Handle(ctx context.Context, command K) (V, error) {
+	return s.call(ctx, command)
+}
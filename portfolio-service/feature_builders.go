@@ -1,20 +1,243 @@
 package main
 
 import (
+	"context"
+	"stock-trader/portfolio-service/admin"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/corporateactions"
+	corporateaction_features "stock-trader/portfolio-service/corporateactions/features"
 	"stock-trader/portfolio-service/infrastructure"
 	"stock-trader/portfolio-service/portfolio"
 	portfolio_features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"stock-trader/portfolio-service/portfolio/risk"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"stock-trader/portfolio-service/portfolio/snapshots"
+	"stock-trader/portfolio-service/portfolio/statements"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"stock-trader/portfolio-service/wiretransfers"
+	wiretransfer_features "stock-trader/portfolio-service/wiretransfers/features"
 
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
-func BuildOpenPortfolioFeature(db *gorm.DB) echo.HandlerFunc {
-	return infrastructure.WithTransaction(db, func(tx *gorm.DB) echo.HandlerFunc {
-		return portfolio_features.NewOpenPortfolioEndpoint(
-			portfolio_features.NewOpenPortfolioHandler(
-				portfolio.NewPortfolioRepository(tx),
-			),
-		).Open
+func BuildOpenPortfolioFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[portfolio_features.OpenPortfolioCommand, portfolio.PortfolioId] {
+		return portfolio_features.NewOpenPortfolioHandler(
+			portfolio.NewPortfolioRepository(infrastructure.DBFromContext(ctx, db), dispatcher),
+		)
 	})
+
+	return portfolio_features.NewOpenPortfolioEndpoint(
+		common.NewCommandBusHandler[portfolio_features.OpenPortfolioCommand, portfolio.PortfolioId](bus),
+	).Open
+}
+
+func BuildReceiveFundsFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[portfolio_features.ReceiveFundsCommand, decimal.Decimal] {
+		return portfolio_features.NewReceiveFundsHandler(
+			portfolio.NewPortfolioRepository(infrastructure.DBFromContext(ctx, db), dispatcher),
+		)
+	})
+
+	return portfolio_features.NewReceiveFundsEndpoint(
+		common.NewCommandBusHandler[portfolio_features.ReceiveFundsCommand, decimal.Decimal](bus),
+	).Receive
+}
+
+func BuildPlaceOrderFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision, limits risk.Limits, quotes valuation.QuoteSource, rates fx.RateSource, collar decimal.Decimal) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[portfolio_features.PlaceOrderCommand, portfolio_features.PlaceOrderResult] {
+		tx := infrastructure.DBFromContext(ctx, db)
+		snapshot := valuation.QuotesFromContext(ctx, quotes)
+		return portfolio_features.NewPlaceOrderHandler(
+			BuildPlaceOrderProcess(tx, dispatcher, loyalty, precision, BuildRiskChecks(tx, limits, snapshot, rates)),
+			snapshot,
+			collar,
+		)
+	})
+
+	// Only the concentration check values the holdings, which are read without
+	// locking the portfolio the order then locks.
+	var portfolios portfolio.PortfolioReader
+	if limits.MaxConcentration.IsPositive() {
+		portfolios = portfolio.NewPortfolioReader(db)
+	}
+	handler := portfolio_features.NewQuotePrefetchingHandler(portfolios, quotes, common.NewCommandBusHandler[portfolio_features.PlaceOrderCommand, portfolio_features.PlaceOrderResult](bus))
+
+	return portfolio_features.NewPlaceOrderEndpoint(handler).Place
+}
+
+func BuildProcessTradeFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[portfolio_features.ProcessTradeCommand, struct{}] {
+		return portfolio_features.NewProcessTradeHandler(
+			BuildPlaceOrderProcess(infrastructure.DBFromContext(ctx, db), dispatcher, loyalty, precision, nil),
+		)
+	})
+
+	return portfolio_features.NewProcessTradeEndpoint(
+		common.NewCommandBusHandler[portfolio_features.ProcessTradeCommand, struct{}](bus),
+	).Process
+}
+
+func BuildHandleOrderCancellationFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[portfolio_features.HandleOrderCancellationCommand, struct{}] {
+		return portfolio_features.NewHandleOrderCancellationHandler(
+			BuildPlaceOrderProcess(infrastructure.DBFromContext(ctx, db), dispatcher, loyalty, precision, nil),
+		)
+	})
+
+	return portfolio_features.NewHandleOrderCancellationEndpoint(
+		common.NewCommandBusHandler[portfolio_features.HandleOrderCancellationCommand, struct{}](bus),
+	).Handle
+}
+
+func BuildGetOrderFeature(db *gorm.DB) echo.HandlerFunc {
+	return portfolio_features.NewGetOrderEndpoint(
+		portfolio_features.NewGetOrderHandler(
+			sagas.NewPlaceOrderSagaRepository(db),
+		),
+	).Get
+}
+
+func BuildRequestFundsFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[wiretransfer_features.RequestFundsCommand, string] {
+		return wiretransfer_features.NewRequestFundsHandler(
+			BuildWireTransferProcess(infrastructure.DBFromContext(ctx, db), dispatcher),
+		)
+	})
+
+	return wiretransfer_features.NewRequestFundsEndpoint(
+		common.NewCommandBusHandler[wiretransfer_features.RequestFundsCommand, string](bus),
+	).Request
+}
+
+func BuildGetWireTransferFeature(db *gorm.DB) echo.HandlerFunc {
+	return wiretransfer_features.NewGetWireTransferEndpoint(
+		wiretransfer_features.NewGetWireTransferHandler(
+			wiretransfers.NewWireTransferRepository(db),
+		),
+	).Get
+}
+
+func BuildRegisterCorporateActionFeature(bus *common.CommandBus, db *gorm.DB, dispatcher *common.DomainEventDispatcher) echo.HandlerFunc {
+	common.RegisterCommandHandler(bus, func(ctx context.Context) common.Handler[corporateaction_features.RegisterCorporateActionCommand, string] {
+		return corporateaction_features.NewRegisterCorporateActionHandler(
+			BuildCorporateActionProcess(infrastructure.DBFromContext(ctx, db), dispatcher),
+		)
+	})
+
+	return corporateaction_features.NewRegisterCorporateActionEndpoint(
+		common.NewCommandBusHandler[corporateaction_features.RegisterCorporateActionCommand, string](bus),
+	).Register
+}
+
+func BuildGetCorporateActionFeature(db *gorm.DB) echo.HandlerFunc {
+	return corporateaction_features.NewGetCorporateActionEndpoint(
+		corporateaction_features.NewGetCorporateActionHandler(
+			corporateactions.NewCorporateActionRepository(db),
+		),
+	).Get
+}
+
+func BuildGetPortfolioFeature(db *gorm.DB) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioEndpoint(
+		portfolio_features.NewGetPortfolioHandler(
+			readmodels.NewPortfolioSummaryRepository(db),
+		),
+	).Get
+}
+
+func BuildIssueStreamTokenFeature(db *gorm.DB, issuer *infrastructure.StreamTokenIssuer) echo.HandlerFunc {
+	return portfolio_features.NewIssueStreamTokenEndpoint(
+		portfolio_features.NewIssueStreamTokenHandler(
+			readmodels.NewPortfolioSummaryRepository(db),
+			issuer,
+		),
+	).Issue
+}
+
+func BuildGetPortfolioValuationFeature(db *gorm.DB, quotes valuation.QuoteSource, rates fx.RateSource) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioValuationEndpoint(
+		portfolio_features.NewGetPortfolioValuationHandler(
+			portfolio.NewPortfolioReader(db),
+			quotes,
+			rates,
+		),
+	).Get
+}
+
+func BuildListPortfoliosFeature(db *gorm.DB) echo.HandlerFunc {
+	return portfolio_features.NewListPortfoliosEndpoint(
+		portfolio_features.NewListPortfoliosHandler(
+			readmodels.NewPortfolioSummaryRepository(db),
+		),
+	).List
+}
+
+func BuildGetPortfolioLedgerFeature(db *gorm.DB) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioLedgerEndpoint(
+		portfolio_features.NewGetPortfolioLedgerHandler(
+			readmodels.NewLedgerRepository(db),
+		),
+	).Get
+}
+
+func BuildGetPortfolioPnLFeature(db *gorm.DB, quotes valuation.QuoteSource, rates fx.RateSource, method taxlots.Method) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioPnLEndpoint(
+		portfolio_features.NewGetPortfolioPnLHandler(
+			taxlots.NewTaxLotRepository(db),
+			quotes,
+			rates,
+			method,
+		),
+	).Get
+}
+
+func BuildGetPortfolioPerformanceFeature(db *gorm.DB, rates fx.RateSource) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioPerformanceEndpoint(
+		portfolio_features.NewGetPortfolioPerformanceHandler(
+			snapshots.NewSnapshotHistorySource(db, rates),
+		),
+	).Get
+}
+
+func BuildGetPortfolioSnapshotsFeature(db *gorm.DB) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioSnapshotsEndpoint(
+		portfolio_features.NewGetPortfolioSnapshotsHandler(
+			snapshots.NewSnapshotRepository(db),
+		),
+	).Get
+}
+
+func BuildGetPortfolioStatementFeature(db *gorm.DB) echo.HandlerFunc {
+	return portfolio_features.NewGetPortfolioStatementEndpoint(
+		portfolio_features.NewGetPortfolioStatementHandler(
+			readmodels.NewPortfolioSummaryRepository(db),
+			statements.NewStatementSource(db),
+		),
+	).Get
+}
+
+func BuildRebuildProjectionFeature(rebuilder admin.ProjectionRebuilder, logger echo.Logger) echo.HandlerFunc {
+	return admin.NewRebuildProjectionEndpoint(
+		admin.NewRebuildProjectionHandler(rebuilder, func(progress common.RebuildProgress) {
+			logger.Infof("%s: replayed %d events up to position %d", progress.Projection, progress.EventsReplayed, progress.Position)
+		}),
+	).Rebuild
+}
+
+func BuildImportTradesHandler(db *gorm.DB, dispatcher *common.DomainEventDispatcher, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision) *admin.ImportTradesHandler {
+	return admin.NewImportTradesHandler(
+		db,
+		func(tx *gorm.DB) portfolio.PortfolioRepository {
+			return portfolio.NewPortfolioRepository(tx, dispatcher)
+		},
+		infrastructure.NewRequestValidator(),
+		loyalty,
+		precision,
+	)
 }
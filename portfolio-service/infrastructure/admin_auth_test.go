@@ -0,0 +1,44 @@
+package infrastructure
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAuth(t *testing.T) {
+	tests := []struct {
+		testName      string
+		token         string
+		authorization string
+		expectedCode  int
+	}{
+		{testName: "Valid token", token: "secret", authorization: "Bearer secret", expectedCode: http.StatusOK},
+		{testName: "Wrong token", token: "secret", authorization: "Bearer wrong", expectedCode: http.StatusUnauthorized},
+		{testName: "Missing token", token: "secret", authorization: "", expectedCode: http.StatusBadRequest},
+		{testName: "No token configured", token: "", authorization: "Bearer anything", expectedCode: http.StatusUnauthorized},
+	}
+
+	for name, auth := range map[string]func(string) echo.MiddlewareFunc{"Admin": AdminAuth, "Service": ServiceAuth} {
+		for _, tc := range tests {
+			t.Run(name+" "+tc.testName, func(t *testing.T) {
+				e := echo.New()
+				e.GET("/protected", func(c echo.Context) error {
+					return c.NoContent(http.StatusOK)
+				}, auth(tc.token))
+				req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+				if tc.authorization != "" {
+					req.Header.Set(echo.HeaderAuthorization, tc.authorization)
+				}
+				rec := httptest.NewRecorder()
+
+				e.ServeHTTP(rec, req)
+
+				assert.Equal(t, tc.expectedCode, rec.Code)
+			})
+		}
+	}
+}
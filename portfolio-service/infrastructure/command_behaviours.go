@@ -0,0 +1,92 @@
+package infrastructure
+
+import (
+	"context"
+	"expvar"
+	"stock-trader/portfolio-service/common"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+type transactionContextKey struct{}
+
+// DBFromContext returns the transaction opened by TransactionBehaviour, or db
+// when the command runs outside of one.
+func DBFromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(transactionContextKey{}).(*gorm.DB); ok && tx != nil {
+		return tx
+	}
+	return db
+}
+
+func TransactionBehaviour(uow GormUnitOfWork) common.CommandBehaviour {
+	return func(next common.CommandHandlerFunc) common.CommandHandlerFunc {
+		return func(ctx context.Context, command any) (any, error) {
+			var result any
+			err := uow.Transaction(func(tx *gorm.DB) error {
+				var err error
+				result, err = next(context.WithValue(ctx, transactionContextKey{}, tx), command)
+				return err
+			})
+			return result, err
+		}
+	}
+}
+
+func LoggingBehaviour(logger echo.Logger) common.CommandBehaviour {
+	return func(next common.CommandHandlerFunc) common.CommandHandlerFunc {
+		return func(ctx context.Context, command any) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, command)
+			if err != nil {
+				logger.Errorf("command %s failed after %s: %v", common.CommandName(command), time.Since(start), err)
+			} else {
+				logger.Infof("command %s handled in %s", common.CommandName(command), time.Since(start))
+			}
+			return result, err
+		}
+	}
+}
+
+func TracingBehaviour(tracer trace.Tracer) common.CommandBehaviour {
+	return func(next common.CommandHandlerFunc) common.CommandHandlerFunc {
+		return func(ctx context.Context, command any) (any, error) {
+			ctx, span := tracer.Start(ctx, "command "+common.CommandName(command),
+				trace.WithAttributes(attribute.String("command.name", common.CommandName(command))))
+			defer span.End()
+
+			result, err := next(ctx, command)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return result, err
+		}
+	}
+}
+
+// CommandMetrics are published through expvar under "commands", keyed by
+// command name: <name>.handled, <name>.failed and <name>.duration_ms.
+var CommandMetrics = expvar.NewMap("commands")
+
+func MetricsBehaviour(metrics *expvar.Map) common.CommandBehaviour {
+	return func(next common.CommandHandlerFunc) common.CommandHandlerFunc {
+		return func(ctx context.Context, command any) (any, error) {
+			start := time.Now()
+			result, err := next(ctx, command)
+
+			name := common.CommandName(command)
+			metrics.Add(name+".handled", 1)
+			metrics.Add(name+".duration_ms", time.Since(start).Milliseconds())
+			if err != nil {
+				metrics.Add(name+".failed", 1)
+			}
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type someCommand struct {
+	Name string `validate:"required"`
+}
+
+func TestTransactionBehaviour(t *testing.T) {
+	t.Run("runs the command inside a transaction", func(t *testing.T) {
+		mock := &MockGormUnitOfWork{}
+		db := &gorm.DB{}
+		var handlerDB *gorm.DB
+
+		handler := TransactionBehaviour(mock)(func(ctx context.Context, command any) (any, error) {
+			handlerDB = DBFromContext(ctx, db)
+			return "result", nil
+		})
+
+		result, err := handler(context.Background(), someCommand{})
+
+		if assert.NoError(t, err) {
+			assert.True(t, mock.called)
+			assert.Equal(t, "result", result)
+			assert.Same(t, db, handlerDB, "falls back to db since the mock transaction is nil")
+		}
+	})
+
+	t.Run("returns the handler error so the transaction is rolled back", func(t *testing.T) {
+		handlerErr := errors.New("handler failed")
+
+		handler := TransactionBehaviour(&MockGormUnitOfWork{})(func(ctx context.Context, command any) (any, error) {
+			return nil, handlerErr
+		})
+
+		_, err := handler(context.Background(), someCommand{})
+
+		assert.ErrorIs(t, err, handlerErr)
+	})
+}
+
+func TestMetricsBehaviour(t *testing.T) {
+	metrics := new(expvar.Map).Init()
+	failing := true
+	handler := MetricsBehaviour(metrics)(func(ctx context.Context, command any) (any, error) {
+		if failing {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	})
+
+	handler(context.Background(), someCommand{})
+	failing = false
+	handler(context.Background(), someCommand{})
+
+	assert.Equal(t, "2", metrics.Get("someCommand.handled").String())
+	assert.Equal(t, "1", metrics.Get("someCommand.failed").String())
+	assert.NotNil(t, metrics.Get("someCommand.duration_ms"))
+}
@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+// StreamTokenIssuer signs the tokens that let a client follow the orders of a
+// portfolio on the broker's stream, as "<expiry in unix seconds>.<signature>".
+// The broker checks them with the secret the two services share.
+type StreamTokenIssuer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewStreamTokenIssuer(secret string, ttl time.Duration) *StreamTokenIssuer {
+	return &StreamTokenIssuer{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// Issue returns a token for the portfolio and when it expires.
+func (i *StreamTokenIssuer) Issue(portfolioId string, now time.Time) (string, time.Time) {
+	expiresAt := now.Add(i.ttl).Truncate(time.Second)
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(portfolioId + "." + expiry))
+	return expiry + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), expiresAt
+}
@@ -2,12 +2,14 @@ package infrastructure
 
 import (
 	"net/http"
+	"reflect"
 
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	en_translations "github.com/go-playground/validator/v10/translations/en"
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 )
 
 type ValidationErrorsResponse struct {
@@ -29,6 +31,10 @@ func NewRequestValidator() *requestValidator {
 	trans := newTranslator()
 	validator := validator.New()
 	en_translations.RegisterDefaultTranslations(validator, trans)
+	// Money amounts are validated by value, e.g. `validate:"gt=0"`.
+	validator.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(decimal.Decimal).InexactFloat64()
+	}, decimal.Decimal{})
 	return &requestValidator{
 		validator: validator,
 		trans:     trans,
@@ -2,10 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
 	"net/http"
+	"os"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/corporateactions"
 	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"stock-trader/portfolio-service/wiretransfers"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
@@ -14,6 +27,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdminCommand(os.Args[2:]))
+	}
+
 	e := echo.New()
 
 	ctx := context.Background()
@@ -42,12 +59,190 @@ func main() {
 		panic("Could not connect to the database")
 	}
 
+	dispatcher := common.NewDomainEventDispatcher()
+
+	taxLotMethod, err := TaxLotMethod()
+	if err != nil {
+		panic(err)
+	}
+
+	projectionOptions := common.DefaultProjectionOptions()
+	projectionOptions.OnError = func(projection string, err error) {
+		e.Logger.Errorf("projection %s: %v", projection, err)
+	}
+	rates, err := FxRates()
+	if err != nil {
+		panic(err)
+	}
+
+	projections := BuildProjectionEngine(db, taxLotMethod, rates, projectionOptions)
+	projections.Start(ctx)
+	defer projections.Stop()
+
+	brokerURL := os.Getenv("BROKER_URL")
+	if brokerURL == "" {
+		brokerURL = "http://broker-service:8081"
+	}
+	quotes := valuation.NewQuoteCache(valuation.NewHTTPQuoteSource(brokerURL, &http.Client{Timeout: 2 * time.Second}), 5*time.Second)
+	loyalty, err := LoyaltyProgram(rates, quotes)
+	if err != nil {
+		panic(err)
+	}
+	precision, err := SharePrecision()
+	if err != nil {
+		panic(err)
+	}
+	collar, err := MarketOrderCollar()
+	if err != nil {
+		panic(err)
+	}
+
+	placeOrderSagas := BuildPlaceOrderSagaRunner(db, dispatcher, loyalty, precision, sagas.NewHTTPBroker(brokerURL, os.Getenv("BROKER_ORDER_TOKEN"), &http.Client{Timeout: 10 * time.Second}), sagas.PlaceOrderSagaRunnerOptions{
+		PollInterval: time.Second,
+		BatchSize:    50,
+		OnError: func(orderId string, err error) {
+			e.Logger.Errorf("place order saga %s: %v", orderId, err)
+		},
+	})
+	sagaCtx, stopSagas := context.WithCancel(ctx)
+	defer stopSagas()
+	go placeOrderSagas.Run(sagaCtx)
+	wireTransfers := BuildWireTransferRunner(db, dispatcher, wiretransfers.WireTransferRunnerOptions{
+		PollInterval: time.Second,
+		BatchSize:    50,
+		OnError: func(transferId string, err error) {
+			e.Logger.Errorf("wire transfer %s: %v", transferId, err)
+		},
+	})
+	go wireTransfers.Run(sagaCtx)
+	corporateActions := BuildCorporateActionRunner(db, dispatcher, corporateactions.CorporateActionRunnerOptions{
+		PollInterval: time.Minute,
+		BatchSize:    50,
+		OnError: func(actionId string, err error) {
+			e.Logger.Errorf("corporate action %s: %v", actionId, err)
+		},
+	})
+	go corporateActions.Run(sagaCtx)
+
+	riskLimits, err := RiskLimits()
+	if err != nil {
+		panic(err)
+	}
+
+	marketClose, snapshotBackfill, err := SnapshotSettings()
+	if err != nil {
+		panic(err)
+	}
+	snapshotJob := BuildSnapshotJob(db, quotes, rates, marketClose, snapshotBackfill, func(portfolioId string, err error) {
+		e.Logger.Errorf("snapshot of portfolio %s: %v", portfolioId, err)
+	})
+	go snapshotJob.Run(sagaCtx)
+
+	streamTokens, err := StreamTokenIssuer()
+	if err != nil {
+		panic(err)
+	}
+
+	bus := common.NewCommandBus(
+		infrastructure.TracingBehaviour(tracer),
+		infrastructure.LoggingBehaviour(e.Logger),
+		infrastructure.MetricsBehaviour(infrastructure.CommandMetrics),
+		infrastructure.TransactionBehaviour(db),
+	)
+
 	e.GET("/", func(ctx echo.Context) error {
 		_, span := tracer.Start(ctx.Request().Context(), "hello-portfolio")
 		defer span.End()
 		return ctx.String(http.StatusOK, "Hello from portfolio-service!")
 	})
-	e.POST("/portfolios", BuildOpenPortfolioFeature(db))
+	e.POST("/portfolios", BuildOpenPortfolioFeature(bus, db, dispatcher))
+	e.GET("/portfolios", BuildListPortfoliosFeature(db))
+	e.GET("/portfolios/:id", BuildGetPortfolioFeature(db))
+	e.GET("/portfolios/:id/valuation", BuildGetPortfolioValuationFeature(db, quotes, rates))
+	e.GET("/portfolios/:id/ledger", BuildGetPortfolioLedgerFeature(db))
+	e.GET("/portfolios/:id/pnl", BuildGetPortfolioPnLFeature(db, quotes, rates, taxLotMethod))
+	e.GET("/portfolios/:id/performance", BuildGetPortfolioPerformanceFeature(db, rates))
+	e.GET("/portfolios/:id/snapshots", BuildGetPortfolioSnapshotsFeature(db))
+	e.GET("/portfolios/:id/statement", BuildGetPortfolioStatementFeature(db))
+	// Stream tokens are issued to the dashboard backend alone, which signs its
+	// users in and only asks for tokens of the portfolios they own.
+	e.POST("/portfolios/:id/stream-tokens", BuildIssueStreamTokenFeature(db, streamTokens), infrastructure.ServiceAuth(os.Getenv("DASHBOARD_TOKEN")))
+	e.POST("/portfolios/:id/orders", BuildPlaceOrderFeature(bus, db, dispatcher, loyalty, precision, riskLimits, quotes, rates, collar))
+	e.POST("/transfers", BuildRequestFundsFeature(bus, db, dispatcher))
+	e.GET("/transfers/:id", BuildGetWireTransferFeature(db))
+	e.GET("/orders/:id", BuildGetOrderFeature(db))
+	// Fills and cancellations are reported by the broker alone.
+	brokerAuth := infrastructure.ServiceAuth(os.Getenv("BROKER_CALLBACK_TOKEN"))
+	e.POST("/orders/:id/trades", BuildProcessTradeFeature(bus, db, dispatcher, loyalty, precision), brokerAuth)
+	e.POST("/orders/:id/cancellations", BuildHandleOrderCancellationFeature(bus, db, dispatcher, loyalty, precision), brokerAuth)
+
+	adminRoutes := e.Group("/admin", infrastructure.AdminAuth(os.Getenv("ADMIN_TOKEN")))
+	adminRoutes.GET("/debug/vars", echo.WrapHandler(expvar.Handler()))
+	// Cash reaches portfolios through wire transfers. Operators credit it
+	// directly only to correct a balance, such as for a deposit made by hand.
+	adminRoutes.POST("/portfolios/:id/funds", BuildReceiveFundsFeature(bus, db, dispatcher))
+	adminRoutes.POST("/corporate-actions", BuildRegisterCorporateActionFeature(bus, db, dispatcher))
+	adminRoutes.GET("/corporate-actions/:id", BuildGetCorporateActionFeature(db))
+	adminRoutes.POST("/projections/:name/rebuild", BuildRebuildProjectionFeature(BuildProjectionRebuilder(db, taxLotMethod, rates), e.Logger))
 
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// LoyaltyProgram is the program set by LOYALTY_TIERS, the default one unless
+// set. Holdings count at the last quotes cached, and amounts in other
+// currencies at the rates.
+func LoyaltyProgram(rates fx.RateSource, quotes valuation.QuoteSource) (portfolio.LoyaltyProgram, error) {
+	program := portfolio.DefaultLoyaltyProgram()
+	if spec := os.Getenv("LOYALTY_TIERS"); spec != "" {
+		parsed, err := portfolio.ParseLoyaltyProgram(spec)
+		if err != nil {
+			return portfolio.LoyaltyProgram{}, err
+		}
+		program = parsed
+	}
+	return program.WithRates(func(currency portfolio.Currency) (decimal.Decimal, error) {
+		return rates.Rate(context.Background(), currency, portfolio.BaseCurrency)
+	}).WithPrices(valuation.LastPrices(quotes)), nil
+}
+
+// SharePrecision is the precision set by SHARE_PRECISION, whole shares for
+// every symbol unless set.
+func SharePrecision() (portfolio.SharePrecision, error) {
+	if spec := os.Getenv("SHARE_PRECISION"); spec != "" {
+		return portfolio.ParseSharePrecision(spec)
+	}
+	return portfolio.SharePrecision{}, nil
+}
+
+// StreamTokenIssuer signs stream tokens valid for an hour with the secret set by
+// STREAM_TOKEN_SECRET, which must be set.
+func StreamTokenIssuer() (*infrastructure.StreamTokenIssuer, error) {
+	secret := os.Getenv("STREAM_TOKEN_SECRET")
+	if secret == "" {
+		return nil, errors.New("STREAM_TOKEN_SECRET must be set to sign stream tokens")
+	}
+	return infrastructure.NewStreamTokenIssuer(secret, time.Hour), nil
+}
+
+// MarketOrderCollar is the share of their price set by MARKET_ORDER_COLLAR that
+// market and stop buy orders reserve above it, 5% unless set.
+func MarketOrderCollar() (decimal.Decimal, error) {
+	spec := os.Getenv("MARKET_ORDER_COLLAR")
+	if spec == "" {
+		return decimal.RequireFromString("0.05"), nil
+	}
+	collar, err := decimal.NewFromString(spec)
+	if err != nil || collar.IsNegative() || collar.GreaterThan(decimal.NewFromInt(1)) {
+		return decimal.Zero, fmt.Errorf("MARKET_ORDER_COLLAR must be a share of the price between 0 and 1, got %q", spec)
+	}
+	return collar, nil
+}
+
+// FxRates are the rates in the file set by FX_RATES_FILE. Unless set, only the
+// base currency can be converted.
+func FxRates() (fx.RateSource, error) {
+	if path := os.Getenv("FX_RATES_FILE"); path != "" {
+		return fx.LoadRates(path)
+	}
+	return fx.NewRates(portfolio.BaseCurrency, nil), nil
+}
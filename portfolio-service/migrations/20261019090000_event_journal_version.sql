@@ -0,0 +1,2 @@
+-- Modify "event_journal" table
+ALTER TABLE `portfolio`.`event_journal` ADD COLUMN `version` int NOT NULL DEFAULT 1;
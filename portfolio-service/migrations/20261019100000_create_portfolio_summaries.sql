@@ -0,0 +1,2 @@
+-- Create "portfolio_summaries" table
+CREATE TABLE `portfolio`.`portfolio_summaries` (`id` varchar(36) NOT NULL, `name` varchar(30) NOT NULL, `status` varchar(16) NOT NULL, `cash` decimal(19,4) NOT NULL DEFAULT 0.0000, `holdings_count` int NOT NULL DEFAULT 0, `total_value` decimal(19,4) NOT NULL DEFAULT 0.0000, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_status_x_name` (`status`, `name`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
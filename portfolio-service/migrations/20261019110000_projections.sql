@@ -0,0 +1,4 @@
+-- Modify "event_journal" table
+ALTER TABLE `portfolio`.`event_journal` ADD COLUMN `position` bigint NOT NULL AUTO_INCREMENT, ADD UNIQUE INDEX `idx_position` (`position`);
+-- Create "projection_checkpoints" table
+CREATE TABLE `portfolio`.`projection_checkpoints` (`name` varchar(64) NOT NULL, `position` bigint NOT NULL DEFAULT 0, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`name`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
@@ -0,0 +1,4 @@
+-- Modify "portfolios" table
+ALTER TABLE `portfolio`.`portfolios` ADD COLUMN `cash` decimal(19,4) NOT NULL DEFAULT 0.0000, ADD COLUMN `holdings` json NOT NULL DEFAULT (json_array()), ADD COLUMN `pending_orders` json NOT NULL DEFAULT (json_array());
+-- Create "place_order_sagas" table
+CREATE TABLE `portfolio`.`place_order_sagas` (`order_id` varchar(36) NOT NULL, `portfolio_id` varchar(36) NOT NULL, `symbol` varchar(8) NOT NULL, `side` varchar(4) NOT NULL, `quantity` bigint NOT NULL, `limit_price` decimal(19,4) NOT NULL, `filled_quantity` bigint NOT NULL DEFAULT 0, `state` varchar(16) NOT NULL, `reason` varchar(256) NOT NULL DEFAULT "", `processed_trades` json NOT NULL DEFAULT (json_array()), `submit_attempts` int NOT NULL DEFAULT 0, `deadline` datetime(6) NOT NULL, `created_at` datetime(6) NOT NULL, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`order_id`), INDEX `idx_state_x_deadline` (`state`, `deadline`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
@@ -0,0 +1,2 @@
+-- Create "wire_transfers" table
+CREATE TABLE `portfolio`.`wire_transfers` (`id` varchar(36) NOT NULL, `sender_portfolio_id` varchar(36) NOT NULL, `receiver_portfolio_id` varchar(36) NOT NULL, `amount` decimal(19,4) NOT NULL, `state` varchar(16) NOT NULL, `reason` varchar(256) NOT NULL DEFAULT "", `steps` json NOT NULL DEFAULT (json_array()), `failed_attempts` int NOT NULL DEFAULT 0, `created_at` datetime(6) NOT NULL, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_state_x_created_at` (`state`, `created_at`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
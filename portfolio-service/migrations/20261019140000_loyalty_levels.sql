@@ -0,0 +1,4 @@
+-- Modify "portfolios" table
+ALTER TABLE `portfolio`.`portfolios` ADD COLUMN `loyalty` varchar(16) NOT NULL DEFAULT "basic";
+-- Modify "portfolio_summaries" table
+ALTER TABLE `portfolio`.`portfolio_summaries` ADD COLUMN `loyalty` varchar(16) NOT NULL DEFAULT "basic" AFTER `total_value`;
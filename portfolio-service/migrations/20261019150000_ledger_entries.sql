@@ -0,0 +1,2 @@
+-- Create "ledger_entries" table
+CREATE TABLE `portfolio`.`ledger_entries` (`id` varchar(36) NOT NULL, `portfolio_id` varchar(36) NOT NULL, `position` bigint NOT NULL, `type` varchar(16) NOT NULL, `order_id` varchar(36) NOT NULL DEFAULT "", `trade_id` varchar(64) NOT NULL DEFAULT "", `transfer_id` varchar(36) NOT NULL DEFAULT "", `symbol` varchar(8) NOT NULL DEFAULT "", `quantity` bigint NOT NULL DEFAULT 0, `price` decimal(19,4) NULL, `commission` decimal(19,4) NOT NULL DEFAULT 0.0000, `amount` decimal(19,4) NOT NULL, `cash_balance` decimal(19,4) NOT NULL, `timestamp` datetime(6) NOT NULL, PRIMARY KEY (`id`), UNIQUE INDEX `idx_portfolio_id_x_position` (`portfolio_id`, `position`), INDEX `idx_portfolio_id_x_timestamp` (`portfolio_id`, `timestamp`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
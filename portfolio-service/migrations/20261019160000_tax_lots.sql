@@ -0,0 +1,4 @@
+-- Create "tax_lots" table
+CREATE TABLE `portfolio`.`tax_lots` (`id` varchar(36) NOT NULL, `portfolio_id` varchar(36) NOT NULL, `symbol` varchar(8) NOT NULL, `position` bigint NOT NULL, `quantity` bigint NOT NULL, `cost` decimal(19,4) NOT NULL, `remaining_quantity` bigint NOT NULL, `remaining_cost` decimal(19,4) NOT NULL, `opened_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_portfolio_id_x_symbol_x_position` (`portfolio_id`, `symbol`, `position`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
+-- Create "realised_gains" table
+CREATE TABLE `portfolio`.`realised_gains` (`id` varchar(36) NOT NULL, `portfolio_id` varchar(36) NOT NULL, `symbol` varchar(8) NOT NULL, `order_id` varchar(36) NOT NULL, `trade_id` varchar(64) NOT NULL, `quantity` bigint NOT NULL, `proceeds` decimal(19,4) NOT NULL, `cost_basis` decimal(19,4) NOT NULL, `gain` decimal(19,4) NOT NULL, `realised_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_portfolio_id_x_realised_at` (`portfolio_id`, `realised_at`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
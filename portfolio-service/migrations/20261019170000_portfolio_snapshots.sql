@@ -0,0 +1,2 @@
+-- Create "portfolio_snapshots" table
+CREATE TABLE `portfolio`.`portfolio_snapshots` (`portfolio_id` varchar(36) NOT NULL, `date` date NOT NULL, `cash` decimal(19,4) NOT NULL, `reserved_cash` decimal(19,4) NOT NULL, `holdings_value` decimal(19,4) NOT NULL, `total_value` decimal(19,4) NOT NULL, `positions` json NOT NULL DEFAULT (json_array()), `price_source` varchar(16) NOT NULL, `stale` bool NOT NULL DEFAULT 0, `journal_position` bigint NOT NULL, `taken_at` datetime(6) NOT NULL, PRIMARY KEY (`portfolio_id`, `date`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
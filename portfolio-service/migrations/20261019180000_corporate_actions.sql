@@ -0,0 +1,6 @@
+-- Create "corporate_actions" table
+CREATE TABLE `portfolio`.`corporate_actions` (`id` varchar(36) NOT NULL, `type` varchar(16) NOT NULL, `symbol` varchar(8) NOT NULL, `ex_date` date NOT NULL, `numerator` bigint NOT NULL DEFAULT 0, `denominator` bigint NOT NULL DEFAULT 0, `amount_per_share` decimal(19,4) NULL, `state` varchar(16) NOT NULL, `holders` int NOT NULL DEFAULT 0, `created_at` datetime(6) NOT NULL, `updated_at` datetime(6) NOT NULL, PRIMARY KEY (`id`), INDEX `idx_state_x_ex_date` (`state`, `ex_date`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
+-- Create "corporate_action_applications" table
+CREATE TABLE `portfolio`.`corporate_action_applications` (`action_id` varchar(36) NOT NULL, `portfolio_id` varchar(36) NOT NULL, `applied_at` datetime(6) NULL, PRIMARY KEY (`action_id`, `portfolio_id`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
+-- Modify "tax_lots" table
+ALTER TABLE `portfolio`.`tax_lots` ADD COLUMN `split_position` bigint NOT NULL DEFAULT 0;
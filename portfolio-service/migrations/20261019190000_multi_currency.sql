@@ -0,0 +1,4 @@
+-- Modify "portfolios" table
+ALTER TABLE `portfolio`.`portfolios` ADD COLUMN `foreign_cash` json NOT NULL DEFAULT (json_object()) AFTER `cash`;
+-- Modify "ledger_entries" table
+ALTER TABLE `portfolio`.`ledger_entries` ADD COLUMN `currency` varchar(3) NOT NULL DEFAULT "USD" AFTER `price`;
@@ -0,0 +1,10 @@
+-- Modify "place_order_sagas" table
+ALTER TABLE `portfolio`.`place_order_sagas` MODIFY COLUMN `quantity` decimal(19,6) NOT NULL, MODIFY COLUMN `filled_quantity` decimal(19,6) NOT NULL DEFAULT 0;
+-- Modify "ledger_entries" table
+ALTER TABLE `portfolio`.`ledger_entries` MODIFY COLUMN `quantity` decimal(19,6) NULL;
+-- Entries without shares had a quantity of zero
+UPDATE `portfolio`.`ledger_entries` SET `quantity` = NULL WHERE `quantity` = 0;
+-- Modify "tax_lots" table
+ALTER TABLE `portfolio`.`tax_lots` MODIFY COLUMN `quantity` decimal(19,6) NOT NULL, MODIFY COLUMN `remaining_quantity` decimal(19,6) NOT NULL;
+-- Modify "realised_gains" table
+ALTER TABLE `portfolio`.`realised_gains` MODIFY COLUMN `quantity` decimal(19,6) NOT NULL;
@@ -0,0 +1,2 @@
+-- Create "projection_skipped_positions" table
+CREATE TABLE `portfolio`.`projection_skipped_positions` (`name` varchar(64) NOT NULL, `position` bigint NOT NULL, `skipped_at` datetime(6) NOT NULL, PRIMARY KEY (`name`, `position`)) CHARSET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
@@ -0,0 +1,2 @@
+-- Modify "place_order_sagas" table
+ALTER TABLE `portfolio`.`place_order_sagas` ADD COLUMN `broker_filled_quantity` decimal(19,6) NOT NULL DEFAULT 0 AFTER `filled_quantity`;
@@ -0,0 +1,4 @@
+-- Modify "place_order_sagas" table
+ALTER TABLE `portfolio`.`place_order_sagas` ADD COLUMN `precision` int NOT NULL DEFAULT 0 AFTER `limit_price`;
+-- Sagas started before keep the finest precision, which leaves their fills as they were
+UPDATE `portfolio`.`place_order_sagas` SET `precision` = 6;
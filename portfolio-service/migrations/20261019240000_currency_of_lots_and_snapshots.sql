@@ -0,0 +1,9 @@
+-- Modify "tax_lots" table
+ALTER TABLE `portfolio`.`tax_lots` ADD COLUMN `currency` varchar(3) NOT NULL DEFAULT "USD" AFTER `symbol`;
+-- Modify "realised_gains" table
+ALTER TABLE `portfolio`.`realised_gains` ADD COLUMN `currency` varchar(3) NOT NULL DEFAULT "USD" AFTER `symbol`;
+-- Lots and gains share the id of the trade they were projected from, which knows its currency
+UPDATE `portfolio`.`tax_lots` JOIN `portfolio`.`event_journal` ON `event_journal`.`id` = `tax_lots`.`id` SET `tax_lots`.`currency` = JSON_UNQUOTE(JSON_EXTRACT(`event_journal`.`event_data`, '$.currency')) WHERE JSON_UNQUOTE(JSON_EXTRACT(`event_journal`.`event_data`, '$.currency')) <> '';
+UPDATE `portfolio`.`realised_gains` JOIN `portfolio`.`event_journal` ON `event_journal`.`id` = `realised_gains`.`id` SET `realised_gains`.`currency` = JSON_UNQUOTE(JSON_EXTRACT(`event_journal`.`event_data`, '$.currency')) WHERE JSON_UNQUOTE(JSON_EXTRACT(`event_journal`.`event_data`, '$.currency')) <> '';
+-- Modify "portfolio_snapshots" table
+ALTER TABLE `portfolio`.`portfolio_snapshots` ADD COLUMN `cash_balances` json NOT NULL DEFAULT (json_array()) AFTER `reserved_cash`;
@@ -0,0 +1,2 @@
+-- Modify "event_journal" table
+ALTER TABLE `portfolio`.`event_journal` ADD COLUMN `portfolio_id` varchar(36) AS (json_unquote(json_extract(`event_data`, _utf8mb4'$.portfolioId'))) VIRTUAL NULL, ADD INDEX `idx_portfolio_id_x_position` (`portfolio_id`, `position`);
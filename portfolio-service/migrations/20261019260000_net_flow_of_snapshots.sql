@@ -0,0 +1,2 @@
+-- Modify "portfolio_snapshots" table
+ALTER TABLE `portfolio`.`portfolio_snapshots` ADD COLUMN `net_flow` decimal(19,4) NULL AFTER `total_value`;
@@ -0,0 +1,2 @@
+-- Modify "corporate_action_applications" table
+ALTER TABLE `portfolio`.`corporate_action_applications` ADD COLUMN `quantity` decimal(19,6) NULL AFTER `portfolio_id`, ADD COLUMN `currency` varchar(3) NOT NULL DEFAULT "" AFTER `quantity`;
@@ -0,0 +1,2 @@
+-- Modify "place_order_sagas" table
+ALTER TABLE `portfolio`.`place_order_sagas` ADD COLUMN `type` varchar(16) NOT NULL DEFAULT "limit" AFTER `side`, ADD COLUMN `stop_price` decimal(19,4) NOT NULL DEFAULT 0 AFTER `limit_price`;
@@ -0,0 +1,2 @@
+-- Modify "place_order_sagas" table
+ALTER TABLE `portfolio`.`place_order_sagas` ADD COLUMN `time_in_force` varchar(8) NOT NULL DEFAULT "day" AFTER `type`, MODIFY COLUMN `deadline` datetime(6) NULL;
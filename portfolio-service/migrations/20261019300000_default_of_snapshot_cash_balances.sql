@@ -0,0 +1,4 @@
+-- Modify "portfolio_snapshots" table
+ALTER TABLE `portfolio`.`portfolio_snapshots` MODIFY COLUMN `cash_balances` json NOT NULL DEFAULT (json_object());
+-- Snapshots taken before the cash balances were recorded got an empty array
+UPDATE `portfolio`.`portfolio_snapshots` SET `cash_balances` = json_object() WHERE json_type(`cash_balances`) = 'ARRAY';
@@ -0,0 +1,76 @@
+package portfolio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Currency is an ISO 4217 currency code, such as USD or EUR.
+type Currency string
+
+// BaseCurrency is the currency portfolios settle wire transfers and commissions
+// in, and the one cash is in unless told otherwise.
+const BaseCurrency Currency = "USD"
+
+// cashPlaces is the precision cash is kept in, whatever its currency.
+const cashPlaces = 4
+
+// roundCash rounds an amount half away from zero to the precision of cash.
+func roundCash(amount decimal.Decimal) decimal.Decimal {
+	if amount.Exponent() >= -cashPlaces {
+		return amount
+	}
+	return amount.Round(cashPlaces)
+}
+
+// CurrencyRates tells how much of the base currency one unit of a currency
+// buys.
+type CurrencyRates func(currency Currency) (decimal.Decimal, error)
+
+// ParseCurrency reads a three letter currency code in any case. An empty code
+// is the base currency.
+func ParseCurrency(value string) (Currency, error) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if code == "" {
+		return BaseCurrency, nil
+	}
+	if len(code) != 3 || strings.Trim(code, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return "", fmt.Errorf("currency must be a three letter code, got %q", value)
+	}
+	return Currency(code), nil
+}
+
+// CashBalances is the cash of a portfolio in each currency it holds some of.
+type CashBalances map[Currency]decimal.Decimal
+
+func (b CashBalances) in(currency Currency) decimal.Decimal {
+	if amount, ok := b[currency]; ok {
+		return amount
+	}
+	return decimal.Zero
+}
+
+// add keeps balances that drop to zero out of the map, except for the base
+// currency.
+func (b CashBalances) add(currency Currency, amount decimal.Decimal) {
+	balance := b.in(currency).Add(amount)
+	if balance.IsZero() && currency != BaseCurrency {
+		delete(b, currency)
+		return
+	}
+	b[currency] = balance
+}
+
+// foreign returns a copy of the balances in other currencies than the base
+// one.
+func (b CashBalances) foreign() CashBalances {
+	foreign := CashBalances{}
+	for currency, amount := range b {
+		if currency != BaseCurrency {
+			foreign[currency] = amount
+		}
+	}
+	return foreign
+}
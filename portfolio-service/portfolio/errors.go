@@ -20,3 +20,19 @@ func NewPortfolioWithSameNameAlreadyOpened(name string) error {
 		portfolioName: name,
 	}
 }
+
+var ErrPortfolioNotFound = errors.New("portfolio not found")
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+var ErrInsufficientShares = errors.New("insufficient shares")
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+var ErrInvalidOrder = errors.New("invalid order")
+
+var ErrInvalidQuantity = errors.New("invalid quantity")
+
+var ErrInvalidTrade = errors.New("invalid trade")
+
+var ErrOrderNotFound = errors.New("order not found")
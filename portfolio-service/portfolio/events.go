@@ -2,6 +2,8 @@ package portfolio
 
 import (
 	"stock-trader/portfolio-service/common"
+
+	"github.com/shopspring/decimal"
 )
 
 type baseDomainEvent = common.BaseDomainEvent
@@ -9,14 +11,416 @@ type baseDomainEvent = common.BaseDomainEvent
 type PortfolioOpened struct {
 	*baseDomainEvent
 	portfolioId string
+	name        string
 }
 
 func (p PortfolioOpened) PortfolioId() string {
 	return p.portfolioId
 }
 
-func (p PortfolioOpened) EventData() map[string]any {
-	return map[string]any{
-		"portfolioId": p.portfolioId,
-	}
+func (p PortfolioOpened) PortfolioName() string {
+	return p.name
+}
+
+type FundsReceived struct {
+	*baseDomainEvent
+	portfolioId string
+	currency    Currency
+	amount      decimal.Decimal
+	balance     PortfolioBalance
+}
+
+func (e FundsReceived) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e FundsReceived) Currency() Currency {
+	return e.currency
+}
+
+func (e FundsReceived) Amount() decimal.Decimal {
+	return e.amount
+}
+
+func (e FundsReceived) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type FundsSent struct {
+	*baseDomainEvent
+	portfolioId string
+	transferId  string
+	amount      decimal.Decimal
+	balance     PortfolioBalance
+}
+
+func (e FundsSent) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e FundsSent) TransferId() string {
+	return e.transferId
+}
+
+func (e FundsSent) Amount() decimal.Decimal {
+	return e.amount
+}
+
+func (e FundsSent) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type RefundAccepted struct {
+	*baseDomainEvent
+	portfolioId string
+	transferId  string
+	amount      decimal.Decimal
+	balance     PortfolioBalance
+}
+
+func (e RefundAccepted) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e RefundAccepted) TransferId() string {
+	return e.transferId
+}
+
+func (e RefundAccepted) Amount() decimal.Decimal {
+	return e.amount
+}
+
+func (e RefundAccepted) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type OrderPlaced struct {
+	*baseDomainEvent
+	portfolioId string
+	orderId     string
+	symbol      string
+	side        OrderSide
+	orderType   OrderType
+	timeInForce TimeInForce
+	quantity    decimal.Decimal
+	limitPrice  decimal.Decimal
+	stopPrice   decimal.Decimal
+	currency    Currency
+	commission  decimal.Decimal
+	balance     PortfolioBalance
+}
+
+func (e OrderPlaced) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e OrderPlaced) OrderId() string {
+	return e.orderId
+}
+
+func (e OrderPlaced) Symbol() string {
+	return e.symbol
+}
+
+func (e OrderPlaced) Side() OrderSide {
+	return e.side
+}
+
+func (e OrderPlaced) Quantity() decimal.Decimal {
+	return e.quantity
+}
+
+func (e OrderPlaced) OrderType() OrderType {
+	return e.orderType
+}
+
+func (e OrderPlaced) TimeInForce() TimeInForce {
+	return e.timeInForce
+}
+
+func (e OrderPlaced) LimitPrice() decimal.Decimal {
+	return e.limitPrice
+}
+
+func (e OrderPlaced) StopPrice() decimal.Decimal {
+	return e.stopPrice
+}
+
+func (e OrderPlaced) Currency() Currency {
+	return e.currency
+}
+
+// Commission is reserved with the order and charged by its first fill.
+func (e OrderPlaced) Commission() decimal.Decimal {
+	return e.commission
+}
+
+func (e OrderPlaced) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type OrderRejected struct {
+	*baseDomainEvent
+	portfolioId string
+	orderId     string
+	symbol      string
+	side        OrderSide
+	quantity    decimal.Decimal
+	limitPrice  decimal.Decimal
+	currency    Currency
+	reasons     []RejectionReason
+}
+
+func (e OrderRejected) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e OrderRejected) OrderId() string {
+	return e.orderId
+}
+
+func (e OrderRejected) Symbol() string {
+	return e.symbol
+}
+
+func (e OrderRejected) Side() OrderSide {
+	return e.side
+}
+
+func (e OrderRejected) Quantity() decimal.Decimal {
+	return e.quantity
+}
+
+func (e OrderRejected) LimitPrice() decimal.Decimal {
+	return e.limitPrice
+}
+
+func (e OrderRejected) Currency() Currency {
+	return e.currency
+}
+
+func (e OrderRejected) Reasons() []RejectionReason {
+	return e.reasons
+}
+
+type TradeProcessed struct {
+	*baseDomainEvent
+	portfolioId     string
+	orderId         string
+	tradeId         string
+	symbol          string
+	side            OrderSide
+	quantity        decimal.Decimal
+	price           decimal.Decimal
+	currency        Currency
+	commission      decimal.Decimal
+	holdingQuantity decimal.Decimal
+	balance         PortfolioBalance
+}
+
+func (e TradeProcessed) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e TradeProcessed) OrderId() string {
+	return e.orderId
+}
+
+func (e TradeProcessed) TradeId() string {
+	return e.tradeId
+}
+
+func (e TradeProcessed) Symbol() string {
+	return e.symbol
+}
+
+func (e TradeProcessed) Side() OrderSide {
+	return e.side
+}
+
+func (e TradeProcessed) Quantity() decimal.Decimal {
+	return e.quantity
+}
+
+func (e TradeProcessed) Price() decimal.Decimal {
+	return e.price
+}
+
+func (e TradeProcessed) Currency() Currency {
+	return e.currency
+}
+
+// Commission is what the trade charged, only ever non zero for the first fill
+// of an order.
+func (e TradeProcessed) Commission() decimal.Decimal {
+	return e.commission
+}
+
+// HoldingQuantity is the quantity held in the symbol after the trade.
+func (e TradeProcessed) HoldingQuantity() decimal.Decimal {
+	return e.holdingQuantity
+}
+
+func (e TradeProcessed) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type OrderFailureAcknowledged struct {
+	*baseDomainEvent
+	portfolioId      string
+	orderId          string
+	symbol           string
+	side             OrderSide
+	unfilledQuantity decimal.Decimal
+	reason           string
+	balance          PortfolioBalance
+}
+
+func (e OrderFailureAcknowledged) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e OrderFailureAcknowledged) OrderId() string {
+	return e.orderId
+}
+
+func (e OrderFailureAcknowledged) Symbol() string {
+	return e.symbol
+}
+
+func (e OrderFailureAcknowledged) Side() OrderSide {
+	return e.side
+}
+
+func (e OrderFailureAcknowledged) UnfilledQuantity() decimal.Decimal {
+	return e.unfilledQuantity
+}
+
+func (e OrderFailureAcknowledged) Reason() string {
+	return e.reason
+}
+
+func (e OrderFailureAcknowledged) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type LoyaltyLevelChanged struct {
+	*baseDomainEvent
+	portfolioId   string
+	previousLevel LoyaltyLevel
+	level         LoyaltyLevel
+	commission    decimal.Decimal
+}
+
+func (e LoyaltyLevelChanged) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e LoyaltyLevelChanged) PreviousLevel() LoyaltyLevel {
+	return e.previousLevel
+}
+
+func (e LoyaltyLevelChanged) Level() LoyaltyLevel {
+	return e.level
+}
+
+// Commission is what the new level charges per order.
+func (e LoyaltyLevelChanged) Commission() decimal.Decimal {
+	return e.commission
+}
+
+type SharesSplit struct {
+	*baseDomainEvent
+	portfolioId      string
+	actionId         string
+	symbol           string
+	numerator        int64
+	denominator      int64
+	previousQuantity decimal.Decimal
+	quantity         decimal.Decimal
+	precision        int32
+	balance          PortfolioBalance
+}
+
+func (e SharesSplit) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e SharesSplit) ActionId() string {
+	return e.actionId
+}
+
+func (e SharesSplit) Symbol() string {
+	return e.symbol
+}
+
+// Numerator and Denominator are the ratio of the split: a 2-for-1 split is 2
+// over 1, a 1-for-10 reverse split is 1 over 10.
+func (e SharesSplit) Numerator() int64 {
+	return e.numerator
+}
+
+func (e SharesSplit) Denominator() int64 {
+	return e.denominator
+}
+
+func (e SharesSplit) PreviousQuantity() decimal.Decimal {
+	return e.previousQuantity
+}
+
+func (e SharesSplit) Quantity() decimal.Decimal {
+	return e.quantity
+}
+
+// Precision is the decimal places the new quantity was rounded down to.
+func (e SharesSplit) Precision() int32 {
+	return e.precision
+}
+
+func (e SharesSplit) Balance() PortfolioBalance {
+	return e.balance
+}
+
+type DividendReceived struct {
+	*baseDomainEvent
+	portfolioId    string
+	actionId       string
+	symbol         string
+	quantity       decimal.Decimal
+	amountPerShare decimal.Decimal
+	currency       Currency
+	amount         decimal.Decimal
+	balance        PortfolioBalance
+}
+
+func (e DividendReceived) PortfolioId() string {
+	return e.portfolioId
+}
+
+func (e DividendReceived) ActionId() string {
+	return e.actionId
+}
+
+func (e DividendReceived) Symbol() string {
+	return e.symbol
+}
+
+func (e DividendReceived) Quantity() decimal.Decimal {
+	return e.quantity
+}
+
+func (e DividendReceived) AmountPerShare() decimal.Decimal {
+	return e.amountPerShare
+}
+
+func (e DividendReceived) Currency() Currency {
+	return e.currency
+}
+
+func (e DividendReceived) Amount() decimal.Decimal {
+	return e.amount
+}
+
+func (e DividendReceived) Balance() PortfolioBalance {
+	return e.balance
 }
@@ -0,0 +1,61 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/sagas"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetOrderEndpoint struct {
+	handler common.Handler[GetOrderQuery, *sagas.PlaceOrderSaga]
+}
+
+func NewGetOrderEndpoint(handler common.Handler[GetOrderQuery, *sagas.PlaceOrderSaga]) *GetOrderEndpoint {
+	return &GetOrderEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetOrderEndpoint) Get(c echo.Context) error {
+	query := new(GetOrderQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	order, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, sagas.ErrPlaceOrderSagaNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, order)
+}
+
+type GetOrderQuery struct {
+	OrderId string `param:"id" validate:"required,uuid"`
+}
+
+type GetOrderHandler struct {
+	sagaRepository sagas.PlaceOrderSagaRepository
+}
+
+func NewGetOrderHandler(repository sagas.PlaceOrderSagaRepository) *GetOrderHandler {
+	return &GetOrderHandler{
+		sagaRepository: repository,
+	}
+}
+
+func (h *GetOrderHandler) Handle(ctx context.Context, query GetOrderQuery) (*sagas.PlaceOrderSaga, error) {
+	return h.sagaRepository.FindByOrderId(ctx, query.OrderId)
+}
@@ -0,0 +1,61 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetPortfolioEndpoint struct {
+	handler common.Handler[GetPortfolioQuery, *readmodels.PortfolioSummary]
+}
+
+func NewGetPortfolioEndpoint(handler common.Handler[GetPortfolioQuery, *readmodels.PortfolioSummary]) *GetPortfolioEndpoint {
+	return &GetPortfolioEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetPortfolioEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	summary, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, readmodels.ErrPortfolioSummaryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+type GetPortfolioQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+}
+
+type GetPortfolioHandler struct {
+	summaryRepository readmodels.PortfolioSummaryRepository
+}
+
+func NewGetPortfolioHandler(repository readmodels.PortfolioSummaryRepository) *GetPortfolioHandler {
+	return &GetPortfolioHandler{
+		summaryRepository: repository,
+	}
+}
+
+func (h *GetPortfolioHandler) Handle(ctx context.Context, query GetPortfolioQuery) (*readmodels.PortfolioSummary, error) {
+	return h.summaryRepository.FindById(ctx, query.PortfolioId)
+}
@@ -0,0 +1,109 @@
+package portfolio
+
+import (
+	"context"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultLedgerPageSize = 50
+	reportDateLayout      = "2006-01-02"
+)
+
+type GetPortfolioLedgerEndpoint struct {
+	handler common.Handler[GetPortfolioLedgerQuery, *readmodels.LedgerPage]
+}
+
+func NewGetPortfolioLedgerEndpoint(handler common.Handler[GetPortfolioLedgerQuery, *readmodels.LedgerPage]) *GetPortfolioLedgerEndpoint {
+	return &GetPortfolioLedgerEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetPortfolioLedgerEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioLedgerQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	page, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetPortfolioLedgerQuery takes from and to as UTC dates, both inclusive.
+type GetPortfolioLedgerQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Symbol      string `query:"symbol" validate:"omitempty,max=8"`
+	Type        string `query:"type" validate:"omitempty,oneof=deposit transfer refund buy sell dividend"`
+	Currency    string `query:"currency" validate:"omitempty,alpha,len=3"`
+	Limit       int    `query:"limit" validate:"gte=0,lte=500"`
+	Offset      int    `query:"offset" validate:"gte=0"`
+}
+
+type GetPortfolioLedgerHandler struct {
+	ledgerRepository readmodels.LedgerRepository
+}
+
+func NewGetPortfolioLedgerHandler(repository readmodels.LedgerRepository) *GetPortfolioLedgerHandler {
+	return &GetPortfolioLedgerHandler{
+		ledgerRepository: repository,
+	}
+}
+
+func (h *GetPortfolioLedgerHandler) Handle(ctx context.Context, query GetPortfolioLedgerQuery) (*readmodels.LedgerPage, error) {
+	filter := readmodels.LedgerFilter{
+		PortfolioId: query.PortfolioId,
+		Symbol:      strings.ToUpper(strings.TrimSpace(query.Symbol)),
+		Type:        readmodels.LedgerEntryType(query.Type),
+		Currency:    strings.ToUpper(query.Currency),
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultLedgerPageSize
+	}
+	var err error
+	if filter.From, filter.To, err = dateRange(query.From, query.To); err != nil {
+		return nil, err
+	}
+
+	return h.ledgerRepository.List(ctx, filter)
+}
+
+// dateRange turns inclusive UTC dates into a half open range of instants.
+// Empty dates leave their end of the range open.
+func dateRange(from string, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	if from != "" {
+		parsed, err := time.Parse(reportDateLayout, from)
+		if err != nil {
+			return start, end, err
+		}
+		start = parsed
+	}
+	if to != "" {
+		parsed, err := time.Parse(reportDateLayout, to)
+		if err != nil {
+			return start, end, err
+		}
+		end = parsed.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
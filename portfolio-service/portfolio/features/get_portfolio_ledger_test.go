@@ -0,0 +1,183 @@
+package portfolio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPortfolioLedgerHandler(t *testing.T) {
+	portfolioId := uuid.NewString()
+	tests := []struct {
+		testName       string
+		query          features.GetPortfolioLedgerQuery
+		expectedFilter readmodels.LedgerFilter
+	}{
+		{
+			testName:       "Without filters uses default page size",
+			query:          features.GetPortfolioLedgerQuery{PortfolioId: portfolioId},
+			expectedFilter: readmodels.LedgerFilter{PortfolioId: portfolioId, Limit: 50},
+		},
+		{
+			testName: "With a date range including the last day",
+			query:    features.GetPortfolioLedgerQuery{PortfolioId: portfolioId, From: "2026-10-01", To: "2026-10-31", Symbol: " acme ", Type: "buy", Currency: "eur", Limit: 10, Offset: 20},
+			expectedFilter: readmodels.LedgerFilter{
+				PortfolioId: portfolioId,
+				From:        time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+				To:          time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC),
+				Symbol:      "ACME",
+				Type:        readmodels.LedgerBuy,
+				Currency:    "EUR",
+				Limit:       10,
+				Offset:      20,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			page := &readmodels.LedgerPage{}
+			handler := features.NewGetPortfolioLedgerHandler(&StubLedgerRepository{
+				list: func(ctx context.Context, filter readmodels.LedgerFilter) (*readmodels.LedgerPage, error) {
+					assert.Equal(t, tc.expectedFilter, filter)
+					return page, nil
+				},
+			})
+
+			result, err := handler.Handle(context.Background(), tc.query)
+
+			assert.NoError(t, err)
+			assert.Same(t, page, result)
+		})
+	}
+}
+
+func Test_GetPortfolioLedgerEndpoint(t *testing.T) {
+	newContext := func(portfolioId string, queryString string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/portfolios/"+portfolioId+"/ledger?"+queryString, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Get Portfolio Ledger Successfully", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		endpoint := features.NewGetPortfolioLedgerEndpoint(&StubHandler[features.GetPortfolioLedgerQuery, *readmodels.LedgerPage]{
+			call: func(ctx context.Context, query features.GetPortfolioLedgerQuery) (*readmodels.LedgerPage, error) {
+				assert.Equal(t, features.GetPortfolioLedgerQuery{PortfolioId: portfolioId, Symbol: "ACME", Type: "buy"}, query)
+				return &readmodels.LedgerPage{
+					Items: []readmodels.LedgerEntry{{
+						Id:          "an-id",
+						PortfolioId: portfolioId,
+						Type:        readmodels.LedgerBuy,
+						OrderId:     "an-order",
+						TradeId:     "a-trade",
+						Symbol:      "ACME",
+						Quantity:    decimal.NewNullDecimal(decimal.NewFromInt(10)),
+						Price:       decimal.NewNullDecimal(decimal.NewFromInt(20)),
+						Currency:    "USD",
+						Commission:  decimal.RequireFromString("9.99"),
+						Amount:      decimal.RequireFromString("-209.99"),
+						CashBalance: decimal.RequireFromString("790.01"),
+						Timestamp:   time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+					}},
+					Total: 1,
+					Limit: 50,
+				}, nil
+			},
+		})
+		c, rec := newContext(portfolioId, "symbol=ACME&type=buy")
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"items": [{
+					"id": "an-id",
+					"portfolio_id": "`+portfolioId+`",
+					"type": "buy",
+					"order_id": "an-order",
+					"trade_id": "a-trade",
+					"symbol": "ACME",
+					"quantity": "10",
+					"price": "20",
+					"currency": "USD",
+					"commission": "9.99",
+					"amount": "-209.99",
+					"cash_balance": "790.01",
+					"timestamp": "2026-10-19T10:00:00Z"
+				}],
+				"total": 1,
+				"limit": 50,
+				"offset": 0
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Get Portfolio Ledger with validation errors", func(t *testing.T) {
+		tests := []struct {
+			testName           string
+			queryString        string
+			field              string
+			validationResponse string
+		}{
+			{
+				testName:           "Unknown type",
+				queryString:        "type=split",
+				field:              "Type",
+				validationResponse: "Type must be one of [deposit transfer refund buy sell dividend]",
+			},
+			{
+				testName:           "Invalid date",
+				queryString:        "from=19/10/2026",
+				field:              "From",
+				validationResponse: "From does not match the 2006-01-02 format",
+			},
+		}
+
+		endpoint := features.NewGetPortfolioLedgerEndpoint(nil)
+
+		for _, tc := range tests {
+			t.Run(tc.testName, func(t *testing.T) {
+				c, _ := newContext(uuid.NewString(), tc.queryString)
+
+				err := endpoint.Get(c)
+
+				if assert.Error(t, err) {
+					err := err.(*echo.HTTPError)
+					assert.Equal(t, http.StatusBadRequest, err.Code)
+					assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+						Message: "there were validation errors",
+						Errors: []infrastructure.FieldError{
+							{
+								Field: tc.field,
+								Error: tc.validationResponse,
+							},
+						},
+					}, err.Message)
+				}
+			})
+		}
+	})
+}
+
+type StubLedgerRepository struct {
+	list func(context.Context, readmodels.LedgerFilter) (*readmodels.LedgerPage, error)
+}
+
+func (r *StubLedgerRepository) List(ctx context.Context, filter readmodels.LedgerFilter) (*readmodels.LedgerPage, error) {
+	return r.list(ctx, filter)
+}
@@ -0,0 +1,99 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPerformanceDays = 30
+	maxPerformanceDays     = 3660
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+type GetPortfolioPerformanceEndpoint struct {
+	handler common.Handler[GetPortfolioPerformanceQuery, performance.Performance]
+}
+
+func NewGetPortfolioPerformanceEndpoint(handler common.Handler[GetPortfolioPerformanceQuery, performance.Performance]) *GetPortfolioPerformanceEndpoint {
+	return &GetPortfolioPerformanceEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetPortfolioPerformanceEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioPerformanceQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	result, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, ErrInvalidPeriod) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetPortfolioPerformanceQuery takes from and to as UTC dates, both inclusive.
+// The period ends today and spans 30 days unless told otherwise.
+type GetPortfolioPerformanceQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type GetPortfolioPerformanceHandler struct {
+	history performance.HistorySource
+	now     func() time.Time
+}
+
+func NewGetPortfolioPerformanceHandler(history performance.HistorySource) *GetPortfolioPerformanceHandler {
+	return &GetPortfolioPerformanceHandler{
+		history: history,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (h *GetPortfolioPerformanceHandler) Handle(ctx context.Context, query GetPortfolioPerformanceQuery) (performance.Performance, error) {
+	from, end, err := dateRange(query.From, query.To)
+	if err != nil {
+		return performance.Performance{}, err
+	}
+	if end.IsZero() {
+		end = h.now().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if from.IsZero() {
+		from = end.AddDate(0, 0, -defaultPerformanceDays)
+	}
+	to := end.AddDate(0, 0, -1)
+
+	if !from.Before(end) {
+		return performance.Performance{}, fmt.Errorf("%w: from must not be after to", ErrInvalidPeriod)
+	}
+	if days := int(end.Sub(from).Hours() / 24); days > maxPerformanceDays {
+		return performance.Performance{}, fmt.Errorf("%w: periods are limited to %d days", ErrInvalidPeriod, maxPerformanceDays)
+	}
+
+	history, err := h.history.History(ctx, query.PortfolioId, from, to)
+	if err != nil {
+		return performance.Performance{}, err
+	}
+	return performance.Measure(query.PortfolioId, history), nil
+}
@@ -0,0 +1,136 @@
+package portfolio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPortfolioPerformanceHandler(t *testing.T) {
+	t.Run("Measure the returns of the period", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		handler := features.NewGetPortfolioPerformanceHandler(StubHistorySource(func(ctx context.Context, id string, from time.Time, to time.Time) (performance.History, error) {
+			assert.Equal(t, portfolioId, id)
+			assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), from)
+			assert.Equal(t, time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC), to)
+			return performance.History{StartValue: decimal.NewFromInt(100), Days: []performance.DailyValuation{
+				{Date: from, Value: decimal.NewFromInt(110), NetFlow: decimal.Zero},
+				{Date: to, Value: decimal.NewFromInt(121), NetFlow: decimal.Zero},
+			}}, nil
+		}))
+
+		result, err := handler.Handle(context.Background(), features.GetPortfolioPerformanceQuery{PortfolioId: portfolioId, From: "2026-10-01", To: "2026-10-02"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0.21", result.TimeWeightedReturn.String())
+		assert.Len(t, result.Series, 2)
+	})
+
+	t.Run("Default to the last 30 days", func(t *testing.T) {
+		handler := features.NewGetPortfolioPerformanceHandler(StubHistorySource(func(ctx context.Context, id string, from time.Time, to time.Time) (performance.History, error) {
+			assert.Equal(t, 29*24*time.Hour, to.Sub(from))
+			return performance.History{StartValue: decimal.Zero}, nil
+		}))
+
+		_, err := handler.Handle(context.Background(), features.GetPortfolioPerformanceQuery{PortfolioId: uuid.NewString()})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("Reject periods ending before they start", func(t *testing.T) {
+		handler := features.NewGetPortfolioPerformanceHandler(StubHistorySource(nil))
+
+		_, err := handler.Handle(context.Background(), features.GetPortfolioPerformanceQuery{PortfolioId: uuid.NewString(), From: "2026-10-02", To: "2026-10-01"})
+
+		assert.ErrorIs(t, err, features.ErrInvalidPeriod)
+	})
+
+	t.Run("Reject periods that are too long", func(t *testing.T) {
+		handler := features.NewGetPortfolioPerformanceHandler(StubHistorySource(nil))
+
+		_, err := handler.Handle(context.Background(), features.GetPortfolioPerformanceQuery{PortfolioId: uuid.NewString(), From: "2006-01-01", To: "2026-10-01"})
+
+		assert.ErrorIs(t, err, features.ErrInvalidPeriod)
+	})
+}
+
+func Test_GetPortfolioPerformanceEndpoint(t *testing.T) {
+	portfolioId := uuid.NewString()
+	newContext := func(url string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Get Portfolio Performance Successfully", func(t *testing.T) {
+		date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		measured := performance.Measure(portfolioId, performance.History{StartValue: decimal.NewFromInt(100), Days: []performance.DailyValuation{
+			{Date: date, Value: decimal.NewFromInt(105), NetFlow: decimal.Zero},
+		}})
+		endpoint := features.NewGetPortfolioPerformanceEndpoint(&StubHandler[features.GetPortfolioPerformanceQuery, performance.Performance]{
+			call: func(ctx context.Context, query features.GetPortfolioPerformanceQuery) (performance.Performance, error) {
+				assert.Equal(t, features.GetPortfolioPerformanceQuery{PortfolioId: portfolioId, From: "2026-10-01", To: "2026-10-01"}, query)
+				return measured, nil
+			},
+		})
+		c, rec := newContext("/portfolios/" + portfolioId + "/performance?from=2026-10-01&to=2026-10-01")
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"portfolio_id": "`+portfolioId+`",
+				"from": "2026-10-01T00:00:00Z",
+				"to": "2026-10-01T00:00:00Z",
+				"start_value": "100",
+				"end_value": "105",
+				"net_flows": "0",
+				"time_weighted_return": "0.05",
+				"money_weighted_return": "0.05",
+				"annualised_money_weighted_return": "`+measured.AnnualisedMoneyWeightedReturn.Decimal.String()+`",
+				"series": [{
+					"date": "2026-10-01T00:00:00Z",
+					"value": "105",
+					"net_flow": "0",
+					"return": "0.05",
+					"cumulative_return": "0.05"
+				}]
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Reject invalid periods", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioPerformanceEndpoint(&StubHandler[features.GetPortfolioPerformanceQuery, performance.Performance]{
+			call: func(ctx context.Context, query features.GetPortfolioPerformanceQuery) (performance.Performance, error) {
+				return performance.Performance{}, features.ErrInvalidPeriod
+			},
+		})
+		c, _ := newContext("/portfolios/" + portfolioId + "/performance?from=2026-10-02&to=2026-10-01")
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+}
+
+type StubHistorySource func(ctx context.Context, portfolioId string, from time.Time, to time.Time) (performance.History, error)
+
+func (s StubHistorySource) History(ctx context.Context, portfolioId string, from time.Time, to time.Time) (performance.History, error) {
+	return s(ctx, portfolioId, from, to)
+}
@@ -0,0 +1,89 @@
+package portfolio
+
+import (
+	"context"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetPortfolioPnLEndpoint struct {
+	handler common.Handler[GetPortfolioPnLQuery, taxlots.PnLReport]
+}
+
+func NewGetPortfolioPnLEndpoint(handler common.Handler[GetPortfolioPnLQuery, taxlots.PnLReport]) *GetPortfolioPnLEndpoint {
+	return &GetPortfolioPnLEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetPortfolioPnLEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioPnLQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	report, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetPortfolioPnLQuery takes from and to as UTC dates, both inclusive. They
+// only select the realised gains; unrealised gains are always as of now.
+type GetPortfolioPnLQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Symbol      string `query:"symbol" validate:"omitempty,max=8"`
+}
+
+type GetPortfolioPnLHandler struct {
+	taxLots taxlots.TaxLotRepository
+	quotes  valuation.QuoteSource
+	rates   fx.RateSource
+	method  taxlots.Method
+}
+
+func NewGetPortfolioPnLHandler(repository taxlots.TaxLotRepository, quotes valuation.QuoteSource, rates fx.RateSource, method taxlots.Method) *GetPortfolioPnLHandler {
+	return &GetPortfolioPnLHandler{
+		taxLots: repository,
+		quotes:  quotes,
+		rates:   rates,
+		method:  method,
+	}
+}
+
+func (h *GetPortfolioPnLHandler) Handle(ctx context.Context, query GetPortfolioPnLQuery) (taxlots.PnLReport, error) {
+	filter := taxlots.PnLFilter{
+		PortfolioId: query.PortfolioId,
+		Symbol:      strings.ToUpper(strings.TrimSpace(query.Symbol)),
+	}
+	var err error
+	if filter.From, filter.To, err = dateRange(query.From, query.To); err != nil {
+		return taxlots.PnLReport{}, err
+	}
+
+	realised, err := h.taxLots.Realised(ctx, filter)
+	if err != nil {
+		return taxlots.PnLReport{}, err
+	}
+	open, err := h.taxLots.Open(ctx, filter)
+	if err != nil {
+		return taxlots.PnLReport{}, err
+	}
+
+	return taxlots.NewPnLReport(ctx, query.PortfolioId, h.method, realised, open, h.quotes, h.rates)
+}
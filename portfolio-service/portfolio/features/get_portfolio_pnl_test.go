@@ -0,0 +1,150 @@
+package portfolio_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPortfolioPnLHandler(t *testing.T) {
+	t.Run("Report realised gains of the period and open lots at the latest quotes", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		expectedFilter := taxlots.PnLFilter{
+			PortfolioId: portfolioId,
+			From:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:          time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			Symbol:      "ACME",
+		}
+		handler := features.NewGetPortfolioPnLHandler(&StubTaxLotRepository{
+			realised: func(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.RealisedTotal, error) {
+				assert.Equal(t, expectedFilter, filter)
+				return []taxlots.RealisedTotal{{Symbol: "ACME", Currency: "USD", Quantity: decimal.NewFromInt(5), Proceeds: decimal.NewFromInt(150), CostBasis: decimal.NewFromInt(100), Gain: decimal.NewFromInt(50)}}, nil
+			},
+			open: func(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.OpenPosition, error) {
+				assert.Equal(t, expectedFilter, filter)
+				return []taxlots.OpenPosition{{Symbol: "ACME", Currency: "USD", Quantity: decimal.NewFromInt(5), Cost: decimal.NewFromInt(100)}}, nil
+			},
+		}, StubQuoteSource{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(30)}}, fx.NewRates(portfolio.BaseCurrency, nil), taxlots.LIFO)
+
+		report, err := handler.Handle(context.Background(), features.GetPortfolioPnLQuery{PortfolioId: portfolioId, From: "2026-01-01", To: "2026-12-31", Symbol: "acme"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, taxlots.LIFO, report.Method)
+		assert.Equal(t, "50", report.Realised.String())
+		assert.Equal(t, "50", report.Unrealised.String())
+	})
+
+	t.Run("Fail when the lots can not be read", func(t *testing.T) {
+		handler := features.NewGetPortfolioPnLHandler(&StubTaxLotRepository{
+			realised: func(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.RealisedTotal, error) {
+				return nil, errors.New("database is down")
+			},
+		}, StubQuoteSource{}, fx.NewRates(portfolio.BaseCurrency, nil), taxlots.FIFO)
+
+		_, err := handler.Handle(context.Background(), features.GetPortfolioPnLQuery{PortfolioId: uuid.NewString()})
+
+		assert.EqualError(t, err, "database is down")
+	})
+}
+
+func Test_GetPortfolioPnLEndpoint(t *testing.T) {
+	t.Run("Get Portfolio PnL Successfully", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		endpoint := features.NewGetPortfolioPnLEndpoint(&StubHandler[features.GetPortfolioPnLQuery, taxlots.PnLReport]{
+			call: func(ctx context.Context, query features.GetPortfolioPnLQuery) (taxlots.PnLReport, error) {
+				assert.Equal(t, features.GetPortfolioPnLQuery{PortfolioId: portfolioId, From: "2026-01-01"}, query)
+				return taxlots.PnLReport{
+					PortfolioId: portfolioId,
+					Method:      taxlots.FIFO,
+					Currency:    portfolio.BaseCurrency,
+					Realised:    decimal.NewFromInt(50),
+					Unrealised:  decimal.Zero,
+					Symbols: []taxlots.SymbolPnL{{
+						Symbol:       "ACME",
+						Currency:     "USD",
+						QuantitySold: decimal.NewFromInt(5),
+						Proceeds:     decimal.NewFromInt(150),
+						CostBasis:    decimal.NewFromInt(100),
+						Realised:     decimal.NewFromInt(50),
+						OpenCost:     decimal.Zero,
+						MarketValue:  decimal.Zero,
+						Unrealised:   decimal.Zero,
+						Rate:         decimal.NewFromInt(1),
+					}},
+				}, nil
+			},
+		})
+
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/portfolios/"+portfolioId+"/pnl?from=2026-01-01", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"portfolio_id": "`+portfolioId+`",
+				"method": "fifo",
+				"currency": "USD",
+				"realised_pnl": "50",
+				"unrealised_pnl": "0",
+				"symbols": [{
+					"symbol": "ACME",
+					"currency": "USD",
+					"quantity_sold": "5",
+					"proceeds": "150",
+					"cost_basis": "100",
+					"realised_pnl": "50",
+					"open_quantity": "0",
+					"open_cost": "0",
+					"price": null,
+					"market_value": "0",
+					"unrealised_pnl": "0",
+					"rate": "1",
+					"stale": false
+				}],
+				"stale": false
+			}`, rec.Body.String())
+		}
+	})
+}
+
+type StubTaxLotRepository struct {
+	realised func(context.Context, taxlots.PnLFilter) ([]taxlots.RealisedTotal, error)
+	open     func(context.Context, taxlots.PnLFilter) ([]taxlots.OpenPosition, error)
+}
+
+func (r *StubTaxLotRepository) Realised(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.RealisedTotal, error) {
+	return r.realised(ctx, filter)
+}
+
+func (r *StubTaxLotRepository) Open(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.OpenPosition, error) {
+	return r.open(ctx, filter)
+}
+
+type StubQuoteSource map[string]valuation.Quote
+
+func (s StubQuoteSource) Quote(ctx context.Context, symbol string) (valuation.Quote, error) {
+	quote, ok := s[symbol]
+	if !ok {
+		return valuation.Quote{}, valuation.ErrQuoteNotFound
+	}
+	return quote, nil
+}
@@ -0,0 +1,78 @@
+package portfolio
+
+import (
+	"context"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/snapshots"
+
+	"github.com/labstack/echo/v4"
+)
+
+const defaultSnapshotPageSize = 50
+
+type GetPortfolioSnapshotsEndpoint struct {
+	handler common.Handler[GetPortfolioSnapshotsQuery, *snapshots.SnapshotPage]
+}
+
+func NewGetPortfolioSnapshotsEndpoint(handler common.Handler[GetPortfolioSnapshotsQuery, *snapshots.SnapshotPage]) *GetPortfolioSnapshotsEndpoint {
+	return &GetPortfolioSnapshotsEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetPortfolioSnapshotsEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioSnapshotsQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	page, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetPortfolioSnapshotsQuery takes from and to as market dates, both
+// inclusive.
+type GetPortfolioSnapshotsQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+	From        string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To          string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Limit       int    `query:"limit" validate:"gte=0,lte=500"`
+	Offset      int    `query:"offset" validate:"gte=0"`
+}
+
+type GetPortfolioSnapshotsHandler struct {
+	snapshotRepository snapshots.SnapshotRepository
+}
+
+func NewGetPortfolioSnapshotsHandler(repository snapshots.SnapshotRepository) *GetPortfolioSnapshotsHandler {
+	return &GetPortfolioSnapshotsHandler{
+		snapshotRepository: repository,
+	}
+}
+
+func (h *GetPortfolioSnapshotsHandler) Handle(ctx context.Context, query GetPortfolioSnapshotsQuery) (*snapshots.SnapshotPage, error) {
+	filter := snapshots.SnapshotFilter{
+		PortfolioId: query.PortfolioId,
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultSnapshotPageSize
+	}
+	var err error
+	if filter.From, filter.To, err = dateRange(query.From, query.To); err != nil {
+		return nil, err
+	}
+
+	return h.snapshotRepository.List(ctx, filter)
+}
@@ -0,0 +1,138 @@
+package portfolio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/snapshots"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func Test_GetPortfolioSnapshotsHandler(t *testing.T) {
+	t.Run("List the snapshots of the period", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		handler := features.NewGetPortfolioSnapshotsHandler(&StubSnapshotRepository{
+			list: func(ctx context.Context, filter snapshots.SnapshotFilter) (*snapshots.SnapshotPage, error) {
+				assert.Equal(t, snapshots.SnapshotFilter{
+					PortfolioId: portfolioId,
+					From:        time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+					To:          time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+					Limit:       50,
+				}, filter)
+				return &snapshots.SnapshotPage{Items: []snapshots.Snapshot{}, Limit: filter.Limit}, nil
+			},
+		})
+
+		page, err := handler.Handle(context.Background(), features.GetPortfolioSnapshotsQuery{PortfolioId: portfolioId, From: "2026-10-01", To: "2026-10-19"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 50, page.Limit)
+	})
+}
+
+func Test_GetPortfolioSnapshotsEndpoint(t *testing.T) {
+	portfolioId := uuid.NewString()
+	newContext := func(url string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Get Portfolio Snapshots Successfully", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioSnapshotsEndpoint(&StubHandler[features.GetPortfolioSnapshotsQuery, *snapshots.SnapshotPage]{
+			call: func(ctx context.Context, query features.GetPortfolioSnapshotsQuery) (*snapshots.SnapshotPage, error) {
+				assert.Equal(t, features.GetPortfolioSnapshotsQuery{PortfolioId: portfolioId, From: "2026-10-19", Limit: 1}, query)
+				return &snapshots.SnapshotPage{
+					Items: []snapshots.Snapshot{{
+						PortfolioId:  portfolioId,
+						Date:         time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+						Cash:         decimal.NewFromInt(800),
+						ReservedCash: decimal.Zero,
+						CashBalances: datatypes.NewJSONType([]snapshots.SnapshotCash{
+							{Currency: portfolio.BaseCurrency, Cash: decimal.NewFromInt(800), ReservedCash: decimal.Zero},
+						}),
+						HoldingsValue: decimal.NewFromInt(250),
+						TotalValue:    decimal.NewFromInt(1050),
+						NetFlow:       decimal.NewNullDecimal(decimal.Zero),
+						Positions: datatypes.NewJSONType([]snapshots.SnapshotPosition{
+							{Symbol: "ACME", Currency: portfolio.BaseCurrency, Quantity: decimal.NewFromInt(10), Price: decimal.NewFromInt(25), MarketValue: decimal.NewFromInt(250)},
+						}),
+						PriceSource:     snapshots.PricedAtQuotes,
+						JournalPosition: 7,
+						TakenAt:         time.Date(2026, 10, 19, 20, 0, 1, 0, time.UTC),
+					}},
+					Total: 1,
+					Limit: 1,
+				}, nil
+			},
+		})
+		c, rec := newContext("/portfolios/" + portfolioId + "/snapshots?from=2026-10-19&limit=1")
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"items": [{
+					"portfolio_id": "`+portfolioId+`",
+					"date": "2026-10-19T00:00:00Z",
+					"cash": "800",
+					"reserved_cash": "0",
+					"cash_balances": [{"currency": "USD", "cash": "800", "reserved_cash": "0"}],
+					"holdings_value": "250",
+					"total_value": "1050",
+					"net_flow": "0",
+					"positions": [{"symbol": "ACME", "currency": "USD", "quantity": "10", "price": "25", "market_value": "250", "stale": false}],
+					"price_source": "quotes",
+					"stale": false,
+					"taken_at": "2026-10-19T20:00:01Z"
+				}],
+				"total": 1,
+				"limit": 1,
+				"offset": 0
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Reject invalid dates", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioSnapshotsEndpoint(&StubHandler[features.GetPortfolioSnapshotsQuery, *snapshots.SnapshotPage]{})
+		c, _ := newContext("/portfolios/" + portfolioId + "/snapshots?from=19-10-2026")
+
+		err := endpoint.Get(c)
+
+		assert.Error(t, err)
+	})
+}
+
+type StubSnapshotRepository struct {
+	list func(context.Context, snapshots.SnapshotFilter) (*snapshots.SnapshotPage, error)
+}
+
+func (r *StubSnapshotRepository) Save(ctx context.Context, snapshot *snapshots.Snapshot) error {
+	return nil
+}
+
+func (r *StubSnapshotRepository) Progress(ctx context.Context) ([]snapshots.SnapshotProgress, error) {
+	return nil, nil
+}
+
+func (r *StubSnapshotRepository) List(ctx context.Context, filter snapshots.SnapshotFilter) (*snapshots.SnapshotPage, error) {
+	return r.list(ctx, filter)
+}
+
+func (r *StubSnapshotRepository) Span(ctx context.Context, portfolioId string, from time.Time, to time.Time) ([]snapshots.Snapshot, error) {
+	return nil, nil
+}
@@ -0,0 +1,102 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"stock-trader/portfolio-service/portfolio/statements"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetPortfolioStatementEndpoint struct {
+	handler common.Handler[GetPortfolioStatementQuery, *statements.Statement]
+}
+
+func NewGetPortfolioStatementEndpoint(handler common.Handler[GetPortfolioStatementQuery, *statements.Statement]) *GetPortfolioStatementEndpoint {
+	return &GetPortfolioStatementEndpoint{
+		handler: handler,
+	}
+}
+
+// Get streams the statement as it is read. Failures past the first line can
+// only cut the statement short, since the response has started by then.
+func (e *GetPortfolioStatementEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioStatementQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	statement, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, readmodels.ErrPortfolioSummaryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, ErrInvalidPeriod) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	format, write := "json", statement.WriteJSON
+	contentType := echo.MIMEApplicationJSONCharsetUTF8
+	if query.Format == "csv" {
+		format, write = "csv", statement.WriteCSV
+		contentType = "text/csv; charset=UTF-8"
+	}
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, contentType)
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="statement-%s-%s-%s.%s"`, query.PortfolioId, query.From, query.To, format))
+	response.WriteHeader(http.StatusOK)
+	return write(c.Request().Context(), response)
+}
+
+// GetPortfolioStatementQuery takes from and to as UTC dates, both inclusive.
+// Statements are JSON unless CSV is asked for.
+type GetPortfolioStatementQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+	From        string `query:"from" validate:"required,datetime=2006-01-02"`
+	To          string `query:"to" validate:"required,datetime=2006-01-02"`
+	Format      string `query:"format" validate:"omitempty,oneof=csv json"`
+}
+
+type GetPortfolioStatementHandler struct {
+	summaryRepository readmodels.PortfolioSummaryRepository
+	source            statements.StatementSource
+}
+
+func NewGetPortfolioStatementHandler(repository readmodels.PortfolioSummaryRepository, source statements.StatementSource) *GetPortfolioStatementHandler {
+	return &GetPortfolioStatementHandler{
+		summaryRepository: repository,
+		source:            source,
+	}
+}
+
+func (h *GetPortfolioStatementHandler) Handle(ctx context.Context, query GetPortfolioStatementQuery) (*statements.Statement, error) {
+	from, to, err := dateRange(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidPeriod)
+	}
+
+	if _, err := h.summaryRepository.FindById(ctx, query.PortfolioId); err != nil {
+		return nil, err
+	}
+
+	openingBalances, err := h.source.OpeningBalances(ctx, query.PortfolioId, from)
+	if err != nil {
+		return nil, err
+	}
+	return statements.NewStatement(query.PortfolioId, from, to, openingBalances, h.source), nil
+}
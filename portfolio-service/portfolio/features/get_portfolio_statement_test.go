@@ -0,0 +1,142 @@
+package portfolio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"stock-trader/portfolio-service/portfolio/statements"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPortfolioStatementHandler(t *testing.T) {
+	existing := &StubPortfolioSummaryRepository{
+		findById: func(ctx context.Context, portfolioId string) (*readmodels.PortfolioSummary, error) {
+			return &readmodels.PortfolioSummary{Id: portfolioId}, nil
+		},
+	}
+
+	t.Run("Open the statement at the balance before the period", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		handler := features.NewGetPortfolioStatementHandler(existing, &StubStatementSource{
+			openingBalances: func(ctx context.Context, id string, from time.Time) (map[string]decimal.Decimal, error) {
+				assert.Equal(t, portfolioId, id)
+				assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), from)
+				return map[string]decimal.Decimal{"EUR": decimal.NewFromInt(100)}, nil
+			},
+		})
+
+		statement, err := handler.Handle(context.Background(), features.GetPortfolioStatementQuery{PortfolioId: portfolioId, From: "2026-10-01", To: "2026-10-31"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "100", statement.OpeningBalances["EUR"].String())
+			assert.Equal(t, "0", statement.OpeningBalances["USD"].String())
+			assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), statement.To)
+		}
+	})
+
+	t.Run("Reject periods ending before they start", func(t *testing.T) {
+		handler := features.NewGetPortfolioStatementHandler(existing, &StubStatementSource{})
+
+		_, err := handler.Handle(context.Background(), features.GetPortfolioStatementQuery{PortfolioId: uuid.NewString(), From: "2026-10-31", To: "2026-10-01"})
+
+		assert.ErrorIs(t, err, features.ErrInvalidPeriod)
+	})
+
+	t.Run("Fail for unknown portfolios", func(t *testing.T) {
+		handler := features.NewGetPortfolioStatementHandler(&StubPortfolioSummaryRepository{
+			findById: func(ctx context.Context, portfolioId string) (*readmodels.PortfolioSummary, error) {
+				return nil, readmodels.ErrPortfolioSummaryNotFound
+			},
+		}, &StubStatementSource{})
+
+		_, err := handler.Handle(context.Background(), features.GetPortfolioStatementQuery{PortfolioId: uuid.NewString(), From: "2026-10-01", To: "2026-10-31"})
+
+		assert.ErrorIs(t, err, readmodels.ErrPortfolioSummaryNotFound)
+	})
+}
+
+func Test_GetPortfolioStatementEndpoint(t *testing.T) {
+	portfolioId := uuid.NewString()
+	source := &StubStatementSource{
+		lines: func(ctx context.Context, id string, from time.Time, to time.Time, visit func(statements.Line) error) error {
+			return visit(statements.Line{LedgerEntry: readmodels.LedgerEntry{
+				Id:          "e1",
+				PortfolioId: portfolioId,
+				Type:        readmodels.LedgerDeposit,
+				Currency:    "USD",
+				Commission:  decimal.Zero,
+				Amount:      decimal.NewFromInt(50),
+				CashBalance: decimal.NewFromInt(150),
+				Timestamp:   time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC),
+			}})
+		},
+	}
+	endpoint := features.NewGetPortfolioStatementEndpoint(&StubHandler[features.GetPortfolioStatementQuery, *statements.Statement]{
+		call: func(ctx context.Context, query features.GetPortfolioStatementQuery) (*statements.Statement, error) {
+			return statements.NewStatement(query.PortfolioId, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), map[string]decimal.Decimal{"USD": decimal.NewFromInt(100)}, source), nil
+		},
+	})
+	newContext := func(url string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Get Portfolio Statement as CSV", func(t *testing.T) {
+		c, rec := newContext("/portfolios/" + portfolioId + "/statement?from=2026-10-01&to=2026-10-31&format=csv")
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/csv; charset=UTF-8", rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, `attachment; filename="statement-`+portfolioId+`-2026-10-01-2026-10-31.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Contains(t, rec.Body.String(), "2026-10-05T09:00:00Z,deposit,,,,,,,USD,0,50,,150\n")
+		}
+	})
+
+	t.Run("Get Portfolio Statement as JSON by default", func(t *testing.T) {
+		c, rec := newContext("/portfolios/" + portfolioId + "/statement?from=2026-10-01&to=2026-10-31")
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+			assert.Contains(t, rec.Body.String(), `"closing_balances":{"USD":"150"}`)
+		}
+	})
+
+	t.Run("Require a period and a known format", func(t *testing.T) {
+		c, _ := newContext("/portfolios/" + portfolioId + "/statement?from=2026-10-01&format=pdf")
+
+		err := endpoint.Get(c)
+
+		if assert.IsType(t, &echo.HTTPError{}, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+		}
+	})
+}
+
+type StubStatementSource struct {
+	openingBalances func(context.Context, string, time.Time) (map[string]decimal.Decimal, error)
+	lines           func(context.Context, string, time.Time, time.Time, func(statements.Line) error) error
+}
+
+func (s *StubStatementSource) OpeningBalances(ctx context.Context, portfolioId string, from time.Time) (map[string]decimal.Decimal, error) {
+	return s.openingBalances(ctx, portfolioId, from)
+}
+
+func (s *StubStatementSource) Lines(ctx context.Context, portfolioId string, from time.Time, to time.Time, visit func(statements.Line) error) error {
+	return s.lines(ctx, portfolioId, from, to, visit)
+}
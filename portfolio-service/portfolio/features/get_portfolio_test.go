@@ -0,0 +1,149 @@
+package portfolio_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPortfolioHandler(t *testing.T) {
+	t.Run("Get portfolio successfully", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		summary := &readmodels.PortfolioSummary{Id: portfolioId, Name: "A portfolio name"}
+		handler := features.NewGetPortfolioHandler(&StubPortfolioSummaryRepository{
+			findById: func(ctx context.Context, id string) (*readmodels.PortfolioSummary, error) {
+				assert.Equal(t, portfolioId, id)
+				return summary, nil
+			},
+		})
+
+		result, err := handler.Handle(context.Background(), features.GetPortfolioQuery{PortfolioId: portfolioId})
+
+		assert.NoError(t, err)
+		assert.Same(t, summary, result)
+	})
+}
+
+func Test_GetPortfolioEndpoint(t *testing.T) {
+	newContext := func(portfolioId string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/portfolios/"+portfolioId, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Get Portfolio Successfully", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		updatedAt := time.Date(2023, 4, 20, 10, 0, 0, 0, time.UTC)
+		endpoint := features.NewGetPortfolioEndpoint(&StubHandler[features.GetPortfolioQuery, *readmodels.PortfolioSummary]{
+			call: func(ctx context.Context, query features.GetPortfolioQuery) (*readmodels.PortfolioSummary, error) {
+				return &readmodels.PortfolioSummary{
+					Id:            query.PortfolioId,
+					Name:          "A Portfolio name",
+					Status:        readmodels.PortfolioStatusOpen,
+					Cash:          decimal.RequireFromString("100.5"),
+					HoldingsCount: 2,
+					TotalValue:    decimal.RequireFromString("250"),
+					Loyalty:       "bronze",
+					UpdatedAt:     updatedAt,
+				}, nil
+			},
+		})
+		c, rec := newContext(portfolioId)
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"portfolio_id": "`+portfolioId+`",
+				"name": "A Portfolio name",
+				"status": "open",
+				"cash": "100.5",
+				"holdings_count": 2,
+				"total_value": "250",
+				"loyalty": "bronze",
+				"updated_at": "2023-04-20T10:00:00Z"
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Get Portfolio Not Found", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioEndpoint(&StubHandler[features.GetPortfolioQuery, *readmodels.PortfolioSummary]{
+			call: func(ctx context.Context, query features.GetPortfolioQuery) (*readmodels.PortfolioSummary, error) {
+				return nil, readmodels.ErrPortfolioSummaryNotFound
+			},
+		})
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusNotFound, err.Code)
+			assert.Equal(t, `portfolio not found`, err.Message)
+		}
+	})
+
+	t.Run("Get Portfolio With Unexpected Error", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioEndpoint(&StubHandler[features.GetPortfolioQuery, *readmodels.PortfolioSummary]{
+			call: func(ctx context.Context, query features.GetPortfolioQuery) (*readmodels.PortfolioSummary, error) {
+				return nil, errors.New("unexpected error")
+			},
+		})
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("Get Portfolio with invalid id", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioEndpoint(nil)
+		c, _ := newContext("not-a-uuid")
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusBadRequest, err.Code)
+			assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+				Message: "there were validation errors",
+				Errors: []infrastructure.FieldError{
+					{
+						Field: "PortfolioId",
+						Error: "PortfolioId must be a valid UUID",
+					},
+				},
+			}, err.Message)
+		}
+	})
+}
+
+type StubPortfolioSummaryRepository struct {
+	findById func(context.Context, string) (*readmodels.PortfolioSummary, error)
+	list     func(context.Context, readmodels.PortfolioSummaryFilter) (*readmodels.PortfolioSummaryPage, error)
+}
+
+func (r *StubPortfolioSummaryRepository) FindById(ctx context.Context, portfolioId string) (*readmodels.PortfolioSummary, error) {
+	return r.findById(ctx, portfolioId)
+}
+
+func (r *StubPortfolioSummaryRepository) List(ctx context.Context, filter readmodels.PortfolioSummaryFilter) (*readmodels.PortfolioSummaryPage, error) {
+	return r.list(ctx, filter)
+}
@@ -0,0 +1,83 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/valuation"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetPortfolioValuationEndpoint struct {
+	handler common.Handler[GetPortfolioValuationQuery, valuation.Valuation]
+}
+
+func NewGetPortfolioValuationEndpoint(handler common.Handler[GetPortfolioValuationQuery, valuation.Valuation]) *GetPortfolioValuationEndpoint {
+	return &GetPortfolioValuationEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetPortfolioValuationEndpoint) Get(c echo.Context) error {
+	query := new(GetPortfolioValuationQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	result, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, portfolio.ErrPortfolioNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, fx.ErrRateNotFound) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetPortfolioValuationQuery values the portfolio in the base currency unless
+// told otherwise.
+type GetPortfolioValuationQuery struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+	Currency    string `query:"currency" validate:"omitempty,alpha,len=3"`
+}
+
+type GetPortfolioValuationHandler struct {
+	portfolioRepository portfolio.PortfolioReader
+	quotes              valuation.QuoteSource
+	rates               fx.RateSource
+}
+
+func NewGetPortfolioValuationHandler(repository portfolio.PortfolioReader, quotes valuation.QuoteSource, rates fx.RateSource) *GetPortfolioValuationHandler {
+	return &GetPortfolioValuationHandler{
+		portfolioRepository: repository,
+		quotes:              quotes,
+		rates:               rates,
+	}
+}
+
+func (h *GetPortfolioValuationHandler) Handle(ctx context.Context, query GetPortfolioValuationQuery) (valuation.Valuation, error) {
+	p, err := h.portfolioRepository.FindById(ctx, portfolio.PortfolioId(query.PortfolioId))
+	if err != nil {
+		return valuation.Valuation{}, err
+	}
+
+	currency, err := portfolio.ParseCurrency(query.Currency)
+	if err != nil {
+		return valuation.Valuation{}, err
+	}
+
+	return valuation.Value(ctx, p, h.quotes, h.rates, currency)
+}
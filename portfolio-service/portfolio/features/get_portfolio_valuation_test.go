@@ -0,0 +1,154 @@
+package portfolio_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetPortfolioValuationHandler(t *testing.T) {
+	t.Run("Value a portfolio", func(t *testing.T) {
+		opened, _ := portfolio.OpenPortfolio("A portfolio name")
+		opened.ReceiveFunds(decimal.NewFromInt(100))
+		handler := features.NewGetPortfolioValuationHandler(&StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				assert.Equal(t, opened.Id(), id)
+				return opened, nil
+			},
+		}, nil, fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{"EUR": decimal.RequireFromString("0.8")}))
+
+		result, err := handler.Handle(context.Background(), features.GetPortfolioValuationQuery{PortfolioId: string(opened.Id()), Currency: "eur"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, portfolio.Currency("EUR"), result.Currency)
+		assert.Equal(t, "80", result.TotalValue.String())
+	})
+}
+
+func Test_GetPortfolioValuationEndpoint(t *testing.T) {
+	newContext := func(portfolioId string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/portfolios/"+portfolioId+"/valuation", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Get Portfolio Valuation Successfully", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		endpoint := features.NewGetPortfolioValuationEndpoint(&StubHandler[features.GetPortfolioValuationQuery, valuation.Valuation]{
+			call: func(ctx context.Context, query features.GetPortfolioValuationQuery) (valuation.Valuation, error) {
+				return valuation.Valuation{
+					PortfolioId:   portfolio.PortfolioId(query.PortfolioId),
+					Currency:      portfolio.BaseCurrency,
+					Cash:          decimal.NewFromInt(100),
+					ReservedCash:  decimal.Zero,
+					HoldingsValue: decimal.NewFromInt(250),
+					TotalValue:    decimal.NewFromInt(350),
+					UnrealisedPnL: decimal.NewFromInt(50),
+					CashBalances: []valuation.CashBalance{{
+						Currency:     portfolio.BaseCurrency,
+						Cash:         decimal.NewFromInt(100),
+						ReservedCash: decimal.Zero,
+						Rate:         decimal.NewFromInt(1),
+						Value:        decimal.NewFromInt(100),
+					}},
+					Positions: []valuation.Position{{
+						Symbol:        "ACME",
+						Currency:      portfolio.BaseCurrency,
+						Quantity:      decimal.NewFromInt(10),
+						AverageCost:   decimal.NewFromInt(20),
+						Cost:          decimal.NewFromInt(200),
+						Rate:          decimal.NewFromInt(1),
+						MarketValue:   decimal.NewFromInt(250),
+						UnrealisedPnL: decimal.NewFromInt(50),
+						Weight:        decimal.RequireFromString("0.7143"),
+						Stale:         true,
+					}},
+					Stale: true,
+				}, nil
+			},
+		})
+		c, rec := newContext(portfolioId)
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, fmt.Sprintf(`{
+				"portfolio_id": %q,
+				"currency": "USD",
+				"cash": "100",
+				"reserved_cash": "0",
+				"holdings_value": "250",
+				"total_value": "350",
+				"unrealised_pnl": "50",
+				"cash_balances": [{
+					"currency": "USD",
+					"cash": "100",
+					"reserved_cash": "0",
+					"rate": "1",
+					"value": "100"
+				}],
+				"positions": [{
+					"symbol": "ACME",
+					"currency": "USD",
+					"quantity": "10",
+					"average_cost": "20",
+					"cost": "200",
+					"price": null,
+					"priced_at": null,
+					"rate": "1",
+					"market_value": "250",
+					"unrealised_pnl": "50",
+					"weight": "0.7143",
+					"stale": true
+				}],
+				"stale": true
+			}`, portfolioId), rec.Body.String())
+		}
+	})
+
+	t.Run("Get Valuation Of Unknown Portfolio", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioValuationEndpoint(&StubHandler[features.GetPortfolioValuationQuery, valuation.Valuation]{
+			call: func(ctx context.Context, query features.GetPortfolioValuationQuery) (valuation.Valuation, error) {
+				return valuation.Valuation{}, portfolio.ErrPortfolioNotFound
+			},
+		})
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("Get Valuation In A Currency Without Rates", func(t *testing.T) {
+		endpoint := features.NewGetPortfolioValuationEndpoint(&StubHandler[features.GetPortfolioValuationQuery, valuation.Valuation]{
+			call: func(ctx context.Context, query features.GetPortfolioValuationQuery) (valuation.Valuation, error) {
+				return valuation.Valuation{}, fx.ErrRateNotFound
+			},
+		})
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		}
+	})
+}
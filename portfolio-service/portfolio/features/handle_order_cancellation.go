@@ -0,0 +1,66 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/sagas"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HandleOrderCancellationEndpoint struct {
+	handler common.Handler[HandleOrderCancellationCommand, struct{}]
+}
+
+func NewHandleOrderCancellationEndpoint(handler common.Handler[HandleOrderCancellationCommand, struct{}]) *HandleOrderCancellationEndpoint {
+	return &HandleOrderCancellationEndpoint{
+		handler: handler,
+	}
+}
+
+// Handle is called by the broker when it cancels the unfilled part of an order.
+func (e *HandleOrderCancellationEndpoint) Handle(c echo.Context) error {
+	command := new(HandleOrderCancellationCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	_, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, sagas.ErrPlaceOrderSagaNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, sagas.ErrPlaceOrderSagaFinished) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type HandleOrderCancellationCommand struct {
+	OrderId string `param:"id" validate:"required,uuid"`
+	Reason  string `json:"reason" validate:"required,max=255"`
+}
+
+type HandleOrderCancellationHandler struct {
+	process *sagas.PlaceOrderProcess
+}
+
+func NewHandleOrderCancellationHandler(process *sagas.PlaceOrderProcess) *HandleOrderCancellationHandler {
+	return &HandleOrderCancellationHandler{
+		process: process,
+	}
+}
+
+func (h *HandleOrderCancellationHandler) Handle(ctx context.Context, command HandleOrderCancellationCommand) (struct{}, error) {
+	return struct{}{}, h.process.HandleCancellation(ctx, command.OrderId, command.Reason)
+}
@@ -0,0 +1,80 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IssueStreamTokenEndpoint struct {
+	handler common.Handler[IssueStreamTokenCommand, StreamToken]
+}
+
+func NewIssueStreamTokenEndpoint(handler common.Handler[IssueStreamTokenCommand, StreamToken]) *IssueStreamTokenEndpoint {
+	return &IssueStreamTokenEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *IssueStreamTokenEndpoint) Issue(c echo.Context) error {
+	command := new(IssueStreamTokenCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	token, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, readmodels.ErrPortfolioSummaryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, token)
+}
+
+// IssueStreamTokenCommand asks for a token to follow the orders of a portfolio
+// on the broker's stream, passed to it as the token query parameter. Callers
+// must have checked that whoever the token is for owns the portfolio.
+type IssueStreamTokenCommand struct {
+	PortfolioId string `param:"id" validate:"required,uuid"`
+}
+
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type IssueStreamTokenHandler struct {
+	summaryRepository readmodels.PortfolioSummaryRepository
+	issuer            *infrastructure.StreamTokenIssuer
+	now               func() time.Time
+}
+
+func NewIssueStreamTokenHandler(repository readmodels.PortfolioSummaryRepository, issuer *infrastructure.StreamTokenIssuer) *IssueStreamTokenHandler {
+	return &IssueStreamTokenHandler{
+		summaryRepository: repository,
+		issuer:            issuer,
+		now:               func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (h *IssueStreamTokenHandler) Handle(ctx context.Context, command IssueStreamTokenCommand) (StreamToken, error) {
+	if _, err := h.summaryRepository.FindById(ctx, command.PortfolioId); err != nil {
+		return StreamToken{}, err
+	}
+
+	token, expiresAt := h.issuer.Issue(command.PortfolioId, h.now())
+	return StreamToken{Token: token, ExpiresAt: expiresAt}, nil
+}
@@ -0,0 +1,61 @@
+package portfolio_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IssueStreamTokenHandler(t *testing.T) {
+	t.Run("Issue a token for an existing portfolio", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		handler := features.NewIssueStreamTokenHandler(&StubPortfolioSummaryRepository{
+			findById: func(ctx context.Context, id string) (*readmodels.PortfolioSummary, error) {
+				return &readmodels.PortfolioSummary{Id: id}, nil
+			},
+		}, infrastructure.NewStreamTokenIssuer("secret", time.Hour))
+
+		token, err := handler.Handle(context.Background(), features.IssueStreamTokenCommand{PortfolioId: portfolioId})
+
+		if assert.NoError(t, err) {
+			assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+			assert.True(t, strings.HasPrefix(token.Token, strconv.FormatInt(token.ExpiresAt.Unix(), 10)+"."))
+			other, _ := infrastructure.NewStreamTokenIssuer("secret", time.Hour).Issue(uuid.NewString(), token.ExpiresAt.Add(-time.Hour))
+			assert.NotEqual(t, token.Token, other)
+		}
+	})
+}
+
+func Test_IssueStreamTokenEndpoint(t *testing.T) {
+	t.Run("Issue A Token For An Unknown Portfolio", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		endpoint := features.NewIssueStreamTokenEndpoint(&StubHandler[features.IssueStreamTokenCommand, features.StreamToken]{
+			call: func(ctx context.Context, command features.IssueStreamTokenCommand) (features.StreamToken, error) {
+				return features.StreamToken{}, fmt.Errorf("%w: %s", readmodels.ErrPortfolioSummaryNotFound, command.PortfolioId)
+			},
+		})
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/portfolios/"+portfolioId+"/stream-tokens", nil), httptest.NewRecorder())
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+
+		err := endpoint.Issue(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package portfolio
+
+import (
+	"context"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+
+	"github.com/labstack/echo/v4"
+)
+
+const defaultPortfoliosPageSize = 20
+
+type ListPortfoliosEndpoint struct {
+	handler common.Handler[ListPortfoliosQuery, *readmodels.PortfolioSummaryPage]
+}
+
+func NewListPortfoliosEndpoint(handler common.Handler[ListPortfoliosQuery, *readmodels.PortfolioSummaryPage]) *ListPortfoliosEndpoint {
+	return &ListPortfoliosEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *ListPortfoliosEndpoint) List(c echo.Context) error {
+	query := new(ListPortfoliosQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	page, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+type ListPortfoliosQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=open closed"`
+	Limit  int    `query:"limit" validate:"gte=0,lte=100"`
+	Offset int    `query:"offset" validate:"gte=0"`
+}
+
+type ListPortfoliosHandler struct {
+	summaryRepository readmodels.PortfolioSummaryRepository
+}
+
+func NewListPortfoliosHandler(repository readmodels.PortfolioSummaryRepository) *ListPortfoliosHandler {
+	return &ListPortfoliosHandler{
+		summaryRepository: repository,
+	}
+}
+
+func (h *ListPortfoliosHandler) Handle(ctx context.Context, query ListPortfoliosQuery) (*readmodels.PortfolioSummaryPage, error) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultPortfoliosPageSize
+	}
+
+	return h.summaryRepository.List(ctx, readmodels.PortfolioSummaryFilter{
+		Status: query.Status,
+		Limit:  limit,
+		Offset: query.Offset,
+	})
+}
@@ -0,0 +1,135 @@
+package portfolio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ListPortfoliosHandler(t *testing.T) {
+	tests := []struct {
+		testName       string
+		query          features.ListPortfoliosQuery
+		expectedFilter readmodels.PortfolioSummaryFilter
+	}{
+		{
+			testName:       "Without paging uses default page size",
+			query:          features.ListPortfoliosQuery{},
+			expectedFilter: readmodels.PortfolioSummaryFilter{Limit: 20},
+		},
+		{
+			testName:       "With status and paging",
+			query:          features.ListPortfoliosQuery{Status: "open", Limit: 5, Offset: 10},
+			expectedFilter: readmodels.PortfolioSummaryFilter{Status: "open", Limit: 5, Offset: 10},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			page := &readmodels.PortfolioSummaryPage{}
+			handler := features.NewListPortfoliosHandler(&StubPortfolioSummaryRepository{
+				list: func(ctx context.Context, filter readmodels.PortfolioSummaryFilter) (*readmodels.PortfolioSummaryPage, error) {
+					assert.Equal(t, tc.expectedFilter, filter)
+					return page, nil
+				},
+			})
+
+			result, err := handler.Handle(context.Background(), tc.query)
+
+			assert.NoError(t, err)
+			assert.Same(t, page, result)
+		})
+	}
+}
+
+func Test_ListPortfoliosEndpoint(t *testing.T) {
+	t.Run("List Portfolios Successfully", func(t *testing.T) {
+		endpoint := features.NewListPortfoliosEndpoint(&StubHandler[features.ListPortfoliosQuery, *readmodels.PortfolioSummaryPage]{
+			call: func(ctx context.Context, query features.ListPortfoliosQuery) (*readmodels.PortfolioSummaryPage, error) {
+				assert.Equal(t, features.ListPortfoliosQuery{Status: "open", Limit: 1}, query)
+				return &readmodels.PortfolioSummaryPage{
+					Items:  []readmodels.PortfolioSummary{{Id: "an-id", Name: "A Portfolio name", Status: "open"}},
+					Total:  3,
+					Limit:  1,
+					Offset: 0,
+				}, nil
+			},
+		})
+
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/portfolios?status=open&limit=1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		if assert.NoError(t, endpoint.List(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"items":[{"portfolio_id":"an-id","name":"A Portfolio name","status":"open"`)
+			assert.Contains(t, rec.Body.String(), `"total":3,"limit":1,"offset":0`)
+		}
+	})
+
+	t.Run("List Portfolios with validation errors", func(t *testing.T) {
+		tests := []struct {
+			testName           string
+			queryString        string
+			field              string
+			validationResponse string
+		}{
+			{
+				testName:           "Unknown status",
+				queryString:        "status=pending",
+				field:              "Status",
+				validationResponse: "Status must be one of [open closed]",
+			},
+			{
+				testName:           "Page too big",
+				queryString:        "limit=101",
+				field:              "Limit",
+				validationResponse: "Limit must be 100 or less",
+			},
+			{
+				testName:           "Negative offset",
+				queryString:        "offset=-1",
+				field:              "Offset",
+				validationResponse: "Offset must be 0 or greater",
+			},
+		}
+
+		endpoint := features.NewListPortfoliosEndpoint(nil)
+
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+
+		for _, tc := range tests {
+			t.Run(tc.testName, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/portfolios?"+tc.queryString, nil)
+				rec := httptest.NewRecorder()
+				c := e.NewContext(req, rec)
+
+				err := endpoint.List(c)
+
+				if assert.Error(t, err) {
+					err := err.(*echo.HTTPError)
+					assert.Equal(t, http.StatusBadRequest, err.Code)
+					assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+						Message: "there were validation errors",
+						Errors: []infrastructure.FieldError{
+							{
+								Field: tc.field,
+								Error: tc.validationResponse,
+							},
+						},
+					}, err.Message)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+type PlaceOrderEndpoint struct {
+	handler common.Handler[PlaceOrderCommand, PlaceOrderResult]
+}
+
+func NewPlaceOrderEndpoint(handler common.Handler[PlaceOrderCommand, PlaceOrderResult]) *PlaceOrderEndpoint {
+	return &PlaceOrderEndpoint{
+		handler: handler,
+	}
+}
+
+// Place answers as soon as the order is reserved in the portfolio. The order
+// reaches the broker afterwards and its progress is served by GET /orders/:id.
+// Orders refused by the risk checks are answered with every reason they were
+// refused for.
+func (e *PlaceOrderEndpoint) Place(c echo.Context) error {
+	command := new(PlaceOrderCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	result, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, portfolio.ErrPortfolioNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, portfolio.ErrInsufficientFunds) || errors.Is(err, portfolio.ErrInsufficientShares) || errors.Is(err, portfolio.ErrCurrencyMismatch) || errors.Is(err, portfolio.ErrInvalidQuantity) || errors.Is(err, portfolio.ErrInvalidOrder) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	if len(result.Rejections) > 0 {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, &OrderRejectedResponse{
+			Message: "order rejected by risk checks",
+			OrderId: result.OrderId,
+			Reasons: result.Rejections,
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, struct {
+		OrderId portfolio.OrderId `json:"order_id"`
+	}{
+		OrderId: result.OrderId,
+	})
+}
+
+// OrderRejectedResponse is the body of orders refused by the risk checks. The
+// order id is the one the refused attempt is recorded with.
+type OrderRejectedResponse struct {
+	Message string                      `json:"message"`
+	OrderId portfolio.OrderId           `json:"order_id"`
+	Reasons []portfolio.RejectionReason `json:"reasons"`
+}
+
+// PlaceOrderCommand places a limit day order unless another type or time in
+// force is set. Which of the limit and stop prices are required depends on the
+// type.
+type PlaceOrderCommand struct {
+	PortfolioId string          `param:"id" validate:"required,uuid"`
+	Symbol      string          `json:"symbol" validate:"required,max=8"`
+	Side        string          `json:"side" validate:"required,oneof=buy sell"`
+	Type        string          `json:"type" validate:"omitempty,oneof=market limit stop stop-limit"`
+	TimeInForce string          `json:"time_in_force" validate:"omitempty,oneof=day gtc ioc fok"`
+	Quantity    decimal.Decimal `json:"quantity" validate:"gt=0"`
+	LimitPrice  decimal.Decimal `json:"limit_price" validate:"omitempty,gt=0"`
+	StopPrice   decimal.Decimal `json:"stop_price" validate:"omitempty,gt=0"`
+	Currency    string          `json:"currency" validate:"omitempty,alpha,len=3"`
+}
+
+// PlaceOrderResult is the order placed or, when the risk checks refused it, the
+// rejected attempt and the reasons it was refused for.
+type PlaceOrderResult struct {
+	OrderId    portfolio.OrderId
+	Rejections []portfolio.RejectionReason
+}
+
+type PlaceOrderHandler struct {
+	process *sagas.PlaceOrderProcess
+	quotes  valuation.QuoteSource
+	collar  decimal.Decimal
+}
+
+// NewPlaceOrderHandler prices market and stop orders with the quotes, and lets
+// buy orders among them reserve cash for fills up to the collar, a share of
+// their price, above it.
+func NewPlaceOrderHandler(process *sagas.PlaceOrderProcess, quotes valuation.QuoteSource, collar decimal.Decimal) *PlaceOrderHandler {
+	return &PlaceOrderHandler{
+		process: process,
+		quotes:  quotes,
+		collar:  collar,
+	}
+}
+
+func (h *PlaceOrderHandler) Handle(ctx context.Context, command PlaceOrderCommand) (PlaceOrderResult, error) {
+	request := portfolio.OrderRequest{
+		Symbol:      strings.ToUpper(strings.TrimSpace(command.Symbol)),
+		Side:        portfolio.OrderSide(command.Side),
+		Type:        portfolio.OrderType(command.Type),
+		TimeInForce: portfolio.TimeInForce(command.TimeInForce),
+		Quantity:    command.Quantity,
+		LimitPrice:  command.LimitPrice,
+		StopPrice:   command.StopPrice,
+		Currency:    portfolio.Currency(command.Currency),
+	}
+
+	reservePrice, err := h.reservePrice(ctx, request)
+	if err != nil {
+		return PlaceOrderResult{}, err
+	}
+	request.ReservePrice = reservePrice
+
+	orderId, rejections, err := h.process.Begin(ctx, portfolio.PortfolioId(command.PortfolioId), request)
+	return PlaceOrderResult{OrderId: orderId, Rejections: rejections}, err
+}
+
+// reservePrice is what an order without a limit price is valued at: its stop
+// price, or the quote of the symbol for market orders. Buy orders raise it by
+// the collar, so that the reservation covers the market moving before they
+// fill.
+func (h *PlaceOrderHandler) reservePrice(ctx context.Context, request portfolio.OrderRequest) (decimal.Decimal, error) {
+	var price decimal.Decimal
+	switch request.Type {
+	case portfolio.Stop:
+		price = request.StopPrice
+	case portfolio.Market:
+		quote, err := h.quotes.Quote(ctx, request.Symbol)
+		if errors.Is(err, valuation.ErrQuoteNotFound) {
+			return decimal.Zero, fmt.Errorf("%w: there is no quote of %s to price a market order at", portfolio.ErrInvalidOrder, request.Symbol)
+		}
+		if err != nil {
+			return decimal.Zero, err
+		}
+		price = quote.Last
+		if request.Side == portfolio.Buy && quote.Ask.IsPositive() {
+			price = quote.Ask
+		}
+		if request.Side == portfolio.Sell && quote.Bid.IsPositive() {
+			price = quote.Bid
+		}
+	default:
+		return decimal.Zero, nil
+	}
+
+	if request.Side == portfolio.Buy {
+		price = price.Mul(decimal.NewFromInt(1).Add(h.collar)).RoundCeil(4)
+	}
+	return price, nil
+}
+
+// QuotePrefetchingHandler fetches the quotes of market orders, and of the
+// holdings of the portfolio when given the portfolios, before handing the
+// command on, so that the order is priced and the portfolio valued without
+// fetching quotes while the transaction holds it locked.
+type QuotePrefetchingHandler struct {
+	portfolios portfolio.PortfolioReader
+	quotes     valuation.QuoteSource
+	next       common.Handler[PlaceOrderCommand, PlaceOrderResult]
+}
+
+func NewQuotePrefetchingHandler(portfolios portfolio.PortfolioReader, quotes valuation.QuoteSource, next common.Handler[PlaceOrderCommand, PlaceOrderResult]) *QuotePrefetchingHandler {
+	return &QuotePrefetchingHandler{
+		portfolios: portfolios,
+		quotes:     quotes,
+		next:       next,
+	}
+}
+
+// Handle leaves a portfolio that can not be found to the next handler, which
+// answers for it.
+func (h *QuotePrefetchingHandler) Handle(ctx context.Context, command PlaceOrderCommand) (PlaceOrderResult, error) {
+	symbols := []string{}
+	if portfolio.OrderType(command.Type) == portfolio.Market {
+		symbols = append(symbols, strings.ToUpper(strings.TrimSpace(command.Symbol)))
+	}
+	if h.portfolios != nil {
+		if owner, err := h.portfolios.FindById(ctx, portfolio.PortfolioId(command.PortfolioId)); err == nil {
+			for _, holding := range owner.Holdings() {
+				symbols = append(symbols, holding.Symbol)
+			}
+		}
+	}
+
+	snapshot := valuation.FetchQuotes(ctx, h.quotes, symbols)
+	return h.next.Handle(valuation.ContextWithQuotes(ctx, snapshot), command)
+}
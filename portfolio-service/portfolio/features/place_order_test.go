@@ -0,0 +1,272 @@
+package portfolio_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PlaceOrderHandler(t *testing.T) {
+	t.Run("Place order successfully", func(t *testing.T) {
+		owner, _ := portfolio.OpenPortfolio("A portfolio name")
+		owner.ReceiveFunds(decimal.NewFromInt(1000))
+		repo := &StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return owner, nil
+			},
+			save: func(ctx context.Context, p *portfolio.Portfolio) error {
+				return nil
+			},
+		}
+		sagaRepo := &StubPlaceOrderSagaRepository{}
+		handler := features.NewPlaceOrderHandler(sagas.NewPlaceOrderProcess(repo, sagaRepo, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil), StubQuoteSource{}, decimal.Zero)
+
+		result, err := handler.Handle(context.Background(), features.PlaceOrderCommand{
+			PortfolioId: string(owner.Id()),
+			Symbol:      "ACME",
+			Side:        "buy",
+			Quantity:    decimal.NewFromInt(10),
+			LimitPrice:  decimal.NewFromInt(20),
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "800", owner.Cash().String())
+			if assert.Len(t, sagaRepo.saved, 1) {
+				assert.Equal(t, string(result.OrderId), sagaRepo.saved[0].OrderId)
+				assert.Equal(t, sagas.PlaceOrderReserved, sagaRepo.saved[0].State)
+			}
+		}
+	})
+
+	t.Run("Market buy orders reserve cash at the ask raised by the collar", func(t *testing.T) {
+		owner, _ := portfolio.OpenPortfolio("A portfolio name")
+		owner.ReceiveFunds(decimal.NewFromInt(1000))
+		repo := &StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return owner, nil
+			},
+			save: func(ctx context.Context, p *portfolio.Portfolio) error {
+				return nil
+			},
+		}
+		sagaRepo := &StubPlaceOrderSagaRepository{}
+		quotes := StubQuoteSource{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(19), Bid: decimal.NewFromInt(19), Ask: decimal.NewFromInt(20)}}
+		handler := features.NewPlaceOrderHandler(sagas.NewPlaceOrderProcess(repo, sagaRepo, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil), quotes, decimal.RequireFromString("0.05"))
+
+		_, err := handler.Handle(context.Background(), features.PlaceOrderCommand{
+			PortfolioId: string(owner.Id()),
+			Symbol:      "acme",
+			Side:        "buy",
+			Type:        "market",
+			Quantity:    decimal.NewFromInt(10),
+		})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "790", owner.Cash().String())
+			if assert.Len(t, sagaRepo.saved, 1) {
+				assert.Equal(t, portfolio.Market, sagaRepo.saved[0].Type)
+				assert.True(t, sagaRepo.saved[0].LimitPrice.IsZero())
+			}
+		}
+	})
+
+	t.Run("Market orders without a quote are refused", func(t *testing.T) {
+		owner, _ := portfolio.OpenPortfolio("A portfolio name")
+		owner.ReceiveFunds(decimal.NewFromInt(1000))
+		repo := &StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return owner, nil
+			},
+		}
+		handler := features.NewPlaceOrderHandler(sagas.NewPlaceOrderProcess(repo, &StubPlaceOrderSagaRepository{}, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil), StubQuoteSource{}, decimal.Zero)
+
+		_, err := handler.Handle(context.Background(), features.PlaceOrderCommand{
+			PortfolioId: string(owner.Id()),
+			Symbol:      "ACME",
+			Side:        "buy",
+			Type:        "market",
+			Quantity:    decimal.NewFromInt(10),
+		})
+
+		assert.ErrorIs(t, err, portfolio.ErrInvalidOrder)
+		assert.Equal(t, "1000", owner.Cash().String())
+	})
+}
+
+func Test_QuotePrefetchingHandler(t *testing.T) {
+	t.Run("Quotes of the holdings are fetched before the command is handled", func(t *testing.T) {
+		owner, _ := portfolio.OpenPortfolio("A portfolio name")
+		owner.ReceiveFunds(decimal.NewFromInt(1000))
+		owner.PlaceOrder("order-1", portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		owner.ProcessTrade("order-1", "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+		repo := &StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return owner, nil
+			},
+		}
+		quotes := StubQuoteSource{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25)}}
+		var prefetched valuation.QuoteSource
+		handler := features.NewQuotePrefetchingHandler(repo, quotes, &StubHandler[features.PlaceOrderCommand, features.PlaceOrderResult]{
+			call: func(ctx context.Context, command features.PlaceOrderCommand) (features.PlaceOrderResult, error) {
+				prefetched = valuation.QuotesFromContext(ctx, nil)
+				return features.PlaceOrderResult{}, nil
+			},
+		})
+
+		_, err := handler.Handle(context.Background(), features.PlaceOrderCommand{PortfolioId: string(owner.Id()), Symbol: "INIT"})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, valuation.QuoteSnapshot{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25)}}, prefetched)
+		}
+	})
+}
+
+func Test_PlaceOrderEndpoint(t *testing.T) {
+	newContext := func(portfolioId string, body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/portfolios/"+portfolioId+"/orders", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Place Order Successfully", func(t *testing.T) {
+		orderId := portfolio.NewOrderId()
+		endpoint := features.NewPlaceOrderEndpoint(&StubHandler[features.PlaceOrderCommand, features.PlaceOrderResult]{
+			call: func(ctx context.Context, command features.PlaceOrderCommand) (features.PlaceOrderResult, error) {
+				assert.Equal(t, "ACME", command.Symbol)
+				assert.Equal(t, "20.5", command.LimitPrice.String())
+				return features.PlaceOrderResult{OrderId: orderId}, nil
+			},
+		})
+		c, rec := newContext(uuid.NewString(), `{"symbol":"ACME","side":"buy","quantity":10,"limit_price":"20.5"}`)
+
+		if assert.NoError(t, endpoint.Place(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.JSONEq(t, `{"order_id":"`+string(orderId)+`"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Place Order Without Enough Funds", func(t *testing.T) {
+		endpoint := features.NewPlaceOrderEndpoint(&StubHandler[features.PlaceOrderCommand, features.PlaceOrderResult]{
+			call: func(ctx context.Context, command features.PlaceOrderCommand) (features.PlaceOrderResult, error) {
+				return features.PlaceOrderResult{}, fmt.Errorf("%w: 200.00 needed, 100.00 available", portfolio.ErrInsufficientFunds)
+			},
+		})
+		c, _ := newContext(uuid.NewString(), `{"symbol":"ACME","side":"buy","quantity":10,"limit_price":20}`)
+
+		err := endpoint.Place(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusUnprocessableEntity, err.Code)
+			assert.Equal(t, "insufficient funds: 200.00 needed, 100.00 available", err.Message)
+		}
+	})
+
+	t.Run("Place Order Below The Minimum Increment", func(t *testing.T) {
+		endpoint := features.NewPlaceOrderEndpoint(&StubHandler[features.PlaceOrderCommand, features.PlaceOrderResult]{
+			call: func(ctx context.Context, command features.PlaceOrderCommand) (features.PlaceOrderResult, error) {
+				assert.Equal(t, "0.005", command.Quantity.String())
+				return features.PlaceOrderResult{}, fmt.Errorf("%w: ACME is traded in increments of 0.01, 0.005 is below the minimum", portfolio.ErrInvalidQuantity)
+			},
+		})
+		c, _ := newContext(uuid.NewString(), `{"symbol":"ACME","side":"buy","quantity":"0.005","limit_price":20}`)
+
+		err := endpoint.Place(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("Place Order Rejected By Risk Checks", func(t *testing.T) {
+		orderId := portfolio.NewOrderId()
+		reasons := []portfolio.RejectionReason{
+			{Check: "restricted_symbol", Message: "ACME is restricted from trading"},
+			{Check: "max_order_notional", Message: "order is worth 200.00 USD, above the limit of 100.00 USD", Limit: decimal.NewNullDecimal(decimal.NewFromInt(100)), Value: decimal.NewNullDecimal(decimal.NewFromInt(200))},
+		}
+		endpoint := features.NewPlaceOrderEndpoint(&StubHandler[features.PlaceOrderCommand, features.PlaceOrderResult]{
+			call: func(ctx context.Context, command features.PlaceOrderCommand) (features.PlaceOrderResult, error) {
+				return features.PlaceOrderResult{OrderId: orderId, Rejections: reasons}, nil
+			},
+		})
+		c, _ := newContext(uuid.NewString(), `{"symbol":"ACME","side":"buy","quantity":10,"limit_price":20}`)
+
+		err := endpoint.Place(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusUnprocessableEntity, err.Code)
+			body, _ := json.Marshal(err.Message)
+			assert.JSONEq(t, `{
+				"message": "order rejected by risk checks",
+				"order_id": "`+string(orderId)+`",
+				"reasons": [
+					{"check": "restricted_symbol", "message": "ACME is restricted from trading", "limit": null, "value": null},
+					{"check": "max_order_notional", "message": "order is worth 200.00 USD, above the limit of 100.00 USD", "limit": "100", "value": "200"}
+				]
+			}`, string(body))
+		}
+	})
+
+	t.Run("Place Order With Validation Errors", func(t *testing.T) {
+		endpoint := features.NewPlaceOrderEndpoint(nil)
+		c, _ := newContext(uuid.NewString(), `{"symbol":"ACME","side":"hold","quantity":0,"limit_price":20}`)
+
+		err := endpoint.Place(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusBadRequest, err.Code)
+			assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+				Message: "there were validation errors",
+				Errors: []infrastructure.FieldError{
+					{Field: "Side", Error: "Side must be one of [buy sell]"},
+					{Field: "Quantity", Error: "Quantity must be greater than 0"},
+				},
+			}, err.Message)
+		}
+	})
+}
+
+type StubPlaceOrderSagaRepository struct {
+	saved         []sagas.PlaceOrderSaga
+	findByOrderId func(context.Context, string) (*sagas.PlaceOrderSaga, error)
+}
+
+func (r *StubPlaceOrderSagaRepository) FindByOrderId(ctx context.Context, orderId string) (*sagas.PlaceOrderSaga, error) {
+	return r.findByOrderId(ctx, orderId)
+}
+
+func (r *StubPlaceOrderSagaRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]sagas.PlaceOrderSaga, error) {
+	return nil, nil
+}
+
+func (r *StubPlaceOrderSagaRepository) FindPending(ctx context.Context, symbol string, before time.Time) ([]sagas.PlaceOrderSaga, error) {
+	return nil, nil
+}
+
+func (r *StubPlaceOrderSagaRepository) Save(ctx context.Context, saga *sagas.PlaceOrderSaga) error {
+	r.saved = append(r.saved, *saga)
+	return nil
+}
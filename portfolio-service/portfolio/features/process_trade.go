@@ -0,0 +1,74 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+type ProcessTradeEndpoint struct {
+	handler common.Handler[ProcessTradeCommand, struct{}]
+}
+
+func NewProcessTradeEndpoint(handler common.Handler[ProcessTradeCommand, struct{}]) *ProcessTradeEndpoint {
+	return &ProcessTradeEndpoint{
+		handler: handler,
+	}
+}
+
+// Process is called by the broker for every fill of an order. Fills delivered
+// more than once are accepted and ignored.
+func (e *ProcessTradeEndpoint) Process(c echo.Context) error {
+	command := new(ProcessTradeCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	_, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, sagas.ErrPlaceOrderSagaNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		if errors.Is(err, sagas.ErrPlaceOrderSagaFinished) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		if errors.Is(err, portfolio.ErrInvalidQuantity) || errors.Is(err, portfolio.ErrInvalidTrade) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+type ProcessTradeCommand struct {
+	OrderId  string          `param:"id" validate:"required,uuid"`
+	TradeId  string          `json:"trade_id" validate:"required,max=64"`
+	Quantity decimal.Decimal `json:"quantity" validate:"gt=0"`
+	Price    decimal.Decimal `json:"price" validate:"gt=0"`
+}
+
+type ProcessTradeHandler struct {
+	process *sagas.PlaceOrderProcess
+}
+
+func NewProcessTradeHandler(process *sagas.PlaceOrderProcess) *ProcessTradeHandler {
+	return &ProcessTradeHandler{
+		process: process,
+	}
+}
+
+func (h *ProcessTradeHandler) Handle(ctx context.Context, command ProcessTradeCommand) (struct{}, error) {
+	return struct{}{}, h.process.HandleTrade(ctx, command.OrderId, command.TradeId, command.Quantity, command.Price)
+}
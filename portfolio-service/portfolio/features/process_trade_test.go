@@ -0,0 +1,99 @@
+package portfolio_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProcessTradeEndpoint(t *testing.T) {
+	newContext := func(orderId string, body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/orders/"+orderId+"/trades", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(orderId)
+		return c, rec
+	}
+
+	t.Run("Process Trade Successfully", func(t *testing.T) {
+		orderId := uuid.NewString()
+		endpoint := features.NewProcessTradeEndpoint(&StubHandler[features.ProcessTradeCommand, struct{}]{
+			call: func(ctx context.Context, command features.ProcessTradeCommand) (struct{}, error) {
+				assert.Equal(t, orderId, command.OrderId)
+				assert.Equal(t, "trade-1", command.TradeId)
+				assert.Equal(t, "19.5", command.Price.String())
+				return struct{}{}, nil
+			},
+		})
+		c, rec := newContext(orderId, `{"trade_id":"trade-1","quantity":5,"price":"19.5"}`)
+
+		if assert.NoError(t, endpoint.Process(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		}
+	})
+
+	t.Run("Process Trade Of Unknown, Finished Or Invalid Order", func(t *testing.T) {
+		tests := []struct {
+			err    error
+			status int
+		}{
+			{err: sagas.ErrPlaceOrderSagaNotFound, status: http.StatusNotFound},
+			{err: sagas.ErrPlaceOrderSagaFinished, status: http.StatusConflict},
+			{err: portfolio.ErrInvalidTrade, status: http.StatusUnprocessableEntity},
+		}
+
+		for _, tc := range tests {
+			endpoint := features.NewProcessTradeEndpoint(&StubHandler[features.ProcessTradeCommand, struct{}]{
+				call: func(ctx context.Context, command features.ProcessTradeCommand) (struct{}, error) {
+					return struct{}{}, fmt.Errorf("%w: %s", tc.err, command.OrderId)
+				},
+			})
+			c, _ := newContext(uuid.NewString(), `{"trade_id":"trade-1","quantity":5,"price":19}`)
+
+			err := endpoint.Process(c)
+
+			if assert.Error(t, err) {
+				assert.Equal(t, tc.status, err.(*echo.HTTPError).Code)
+			}
+		}
+	})
+}
+
+func Test_HandleOrderCancellationEndpoint(t *testing.T) {
+	t.Run("Handle Order Cancellation Successfully", func(t *testing.T) {
+		orderId := uuid.NewString()
+		endpoint := features.NewHandleOrderCancellationEndpoint(&StubHandler[features.HandleOrderCancellationCommand, struct{}]{
+			call: func(ctx context.Context, command features.HandleOrderCancellationCommand) (struct{}, error) {
+				assert.Equal(t, "expired", command.Reason)
+				return struct{}{}, nil
+			},
+		})
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/orders/"+orderId+"/cancellations", strings.NewReader(`{"reason":"expired"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(orderId)
+
+		if assert.NoError(t, endpoint.Handle(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+type ReceiveFundsEndpoint struct {
+	handler common.Handler[ReceiveFundsCommand, decimal.Decimal]
+}
+
+func NewReceiveFundsEndpoint(handler common.Handler[ReceiveFundsCommand, decimal.Decimal]) *ReceiveFundsEndpoint {
+	return &ReceiveFundsEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *ReceiveFundsEndpoint) Receive(c echo.Context) error {
+	command := new(ReceiveFundsCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	cash, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		if errors.Is(err, portfolio.ErrPortfolioNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Cash decimal.Decimal `json:"cash"`
+	}{
+		Cash: cash,
+	})
+}
+
+type ReceiveFundsCommand struct {
+	PortfolioId string          `param:"id" validate:"required,uuid"`
+	Amount      decimal.Decimal `json:"amount" validate:"gt=0"`
+	Currency    string          `json:"currency" validate:"omitempty,alpha,len=3"`
+}
+
+type ReceiveFundsHandler struct {
+	portfolioRepository portfolio.PortfolioRepository
+}
+
+func NewReceiveFundsHandler(repository portfolio.PortfolioRepository) *ReceiveFundsHandler {
+	return &ReceiveFundsHandler{
+		portfolioRepository: repository,
+	}
+}
+
+// Handle returns the cash available in the currency of the funds after
+// receiving them.
+func (h *ReceiveFundsHandler) Handle(ctx context.Context, command ReceiveFundsCommand) (decimal.Decimal, error) {
+	currency, err := portfolio.ParseCurrency(command.Currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	funded, err := h.portfolioRepository.FindById(ctx, portfolio.PortfolioId(command.PortfolioId))
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if err = funded.ReceiveFundsIn(currency, command.Amount); err != nil {
+		return decimal.Zero, err
+	}
+
+	if err = h.portfolioRepository.Save(ctx, funded); err != nil {
+		return decimal.Zero, err
+	}
+
+	return funded.CashIn(currency), nil
+}
@@ -0,0 +1,120 @@
+package portfolio_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	features "stock-trader/portfolio-service/portfolio/features"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReceiveFundsHandler(t *testing.T) {
+	t.Run("Receive funds successfully", func(t *testing.T) {
+		funded, _ := portfolio.OpenPortfolio("A portfolio name")
+		repo := &StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return funded, nil
+			},
+			save: func(ctx context.Context, p *portfolio.Portfolio) error {
+				return nil
+			},
+		}
+		handler := features.NewReceiveFundsHandler(repo)
+
+		cash, err := handler.Handle(context.Background(), features.ReceiveFundsCommand{
+			PortfolioId: string(funded.Id()),
+			Amount:      decimal.RequireFromString("150.5"),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "150.5", cash.String())
+		assert.Equal(t, 1, repo.callsToSave)
+	})
+
+	t.Run("Receive funds in an unknown portfolio", func(t *testing.T) {
+		repo := &StubPortfolioRepository{
+			findById: func(ctx context.Context, id portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+				return nil, fmt.Errorf("%w: %s", portfolio.ErrPortfolioNotFound, id)
+			},
+		}
+		handler := features.NewReceiveFundsHandler(repo)
+
+		_, err := handler.Handle(context.Background(), features.ReceiveFundsCommand{
+			PortfolioId: uuid.NewString(),
+			Amount:      decimal.NewFromInt(100),
+		})
+
+		assert.ErrorIs(t, err, portfolio.ErrPortfolioNotFound)
+		assert.Equal(t, 0, repo.callsToSave)
+	})
+}
+
+func Test_ReceiveFundsEndpoint(t *testing.T) {
+	newContext := func(portfolioId string, body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/portfolios/"+portfolioId+"/funds", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(portfolioId)
+		return c, rec
+	}
+
+	t.Run("Receive Funds Successfully", func(t *testing.T) {
+		endpoint := features.NewReceiveFundsEndpoint(&StubHandler[features.ReceiveFundsCommand, decimal.Decimal]{
+			call: func(ctx context.Context, command features.ReceiveFundsCommand) (decimal.Decimal, error) {
+				return command.Amount, nil
+			},
+		})
+		c, rec := newContext(uuid.NewString(), `{"amount":"250.75"}`)
+
+		if assert.NoError(t, endpoint.Receive(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"cash":"250.75"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Receive Funds In Unknown Portfolio", func(t *testing.T) {
+		endpoint := features.NewReceiveFundsEndpoint(&StubHandler[features.ReceiveFundsCommand, decimal.Decimal]{
+			call: func(ctx context.Context, command features.ReceiveFundsCommand) (decimal.Decimal, error) {
+				return decimal.Zero, fmt.Errorf("%w: %s", portfolio.ErrPortfolioNotFound, command.PortfolioId)
+			},
+		})
+		c, _ := newContext(uuid.NewString(), `{"amount":100}`)
+
+		err := endpoint.Receive(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+
+	t.Run("Receive Funds With Non Positive Amount", func(t *testing.T) {
+		endpoint := features.NewReceiveFundsEndpoint(nil)
+		c, _ := newContext(uuid.NewString(), `{"amount":"-10"}`)
+
+		err := endpoint.Receive(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusBadRequest, err.Code)
+			assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+				Message: "there were validation errors",
+				Errors: []infrastructure.FieldError{
+					{Field: "Amount", Error: "Amount must be greater than 0"},
+				},
+			}, err.Message)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"stock-trader/portfolio-service/portfolio"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ratePlaces is the precision of cross rates.
+const ratePlaces = 8
+
+// RateSource is the port to foreign exchange rates. A rate is how much of the
+// quote currency one unit of the base currency buys.
+type RateSource interface {
+	Rate(ctx context.Context, base portfolio.Currency, quote portfolio.Currency) (decimal.Decimal, error)
+}
+
+// Rates quotes every currency it knows against a single reference currency,
+// and crosses them through it.
+type Rates struct {
+	reference portfolio.Currency
+	rates     map[portfolio.Currency]decimal.Decimal
+}
+
+func NewRates(reference portfolio.Currency, rates map[portfolio.Currency]decimal.Decimal) *Rates {
+	known := map[portfolio.Currency]decimal.Decimal{reference: decimal.NewFromInt(1)}
+	for currency, rate := range rates {
+		known[currency] = rate
+	}
+	return &Rates{
+		reference: reference,
+		rates:     known,
+	}
+}
+
+// LoadRates reads rates from a JSON file such as
+//
+//	{"reference": "USD", "rates": {"EUR": "0.92", "GBP": "0.79"}}
+func LoadRates(path string) (*Rates, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Reference string                     `json:"reference"`
+		Rates     map[string]decimal.Decimal `json:"rates"`
+	}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("rates file %s: %w", path, err)
+	}
+
+	reference, err := portfolio.ParseCurrency(file.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("rates file %s: %w", path, err)
+	}
+	rates := map[portfolio.Currency]decimal.Decimal{}
+	for code, rate := range file.Rates {
+		currency, err := portfolio.ParseCurrency(code)
+		if err != nil {
+			return nil, fmt.Errorf("rates file %s: %w", path, err)
+		}
+		if !rate.IsPositive() {
+			return nil, fmt.Errorf("rates file %s: rate of %s must be greater than zero", path, currency)
+		}
+		rates[currency] = rate
+	}
+	return NewRates(reference, rates), nil
+}
+
+func (r *Rates) Rate(ctx context.Context, base portfolio.Currency, quote portfolio.Currency) (decimal.Decimal, error) {
+	if base == quote {
+		return decimal.NewFromInt(1), nil
+	}
+
+	baseRate, ok := r.rates[base]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w: %s/%s", ErrRateNotFound, base, quote)
+	}
+	quoteRate, ok := r.rates[quote]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("%w: %s/%s", ErrRateNotFound, base, quote)
+	}
+	return quoteRate.DivRound(baseRate, ratePlaces), nil
+}
@@ -0,0 +1,49 @@
+package fx_test
+
+import (
+	"context"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRates(t *testing.T) {
+	rates, err := fx.LoadRates("testdata/rates.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tests := []struct {
+		base     portfolio.Currency
+		quote    portfolio.Currency
+		expected string
+	}{
+		{"USD", "EUR", "0.8"},
+		{"EUR", "USD", "1.25"},
+		{"EUR", "GBP", "0.9375"},
+		{"GBP", "GBP", "1"},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.base)+"/"+string(tc.quote), func(t *testing.T) {
+			rate, err := rates.Rate(context.Background(), tc.base, tc.quote)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, rate.String())
+		})
+	}
+
+	t.Run("A currency without a rate", func(t *testing.T) {
+		_, err := rates.Rate(context.Background(), "EUR", "JPY")
+
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+		assert.EqualError(t, err, "exchange rate not found: EUR/JPY")
+	})
+}
+
+func TestLoadRates(t *testing.T) {
+	_, err := fx.LoadRates("testdata/missing.json")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package portfolio
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Holding is a position in a symbol, valued at what was paid for it in the
+// listing currency of the symbol. Precision is the finest fraction of a share,
+// in decimal places, any of its shares were bought in.
+type Holding struct {
+	Symbol    string          `json:"symbol"`
+	Currency  Currency        `json:"currency"`
+	Quantity  decimal.Decimal `json:"quantity"`
+	Precision int32           `json:"precision"`
+	Cost      decimal.Decimal `json:"cost"`
+}
+
+func (h Holding) AverageCost() decimal.Decimal {
+	if h.Quantity.IsZero() {
+		return decimal.Zero
+	}
+	return h.Cost.Div(h.Quantity)
+}
+
+func (h Holding) bought(quantity decimal.Decimal, cost decimal.Decimal, precision int32) Holding {
+	h.Quantity = h.Quantity.Add(quantity)
+	h.Cost = h.Cost.Add(cost)
+	if precision > h.Precision {
+		h.Precision = precision
+	}
+	return h
+}
+
+func (h Holding) sold(quantity decimal.Decimal) (Holding, error) {
+	if quantity.GreaterThan(h.Quantity) {
+		return h, fmt.Errorf("%w: %s %s sold, %s held", ErrInsufficientShares, quantity, h.Symbol, h.Quantity)
+	}
+	if quantity.Equal(h.Quantity) {
+		h.Quantity, h.Cost = decimal.Zero, decimal.Zero
+		return h, nil
+	}
+	h.Cost = h.Cost.Sub(h.AverageCost().Mul(quantity))
+	h.Quantity = h.Quantity.Sub(quantity)
+	return h, nil
+}
+
+// HeldShares is what a portfolio held of a symbol at some point in time, such
+// as when a corporate action went ex, and the currency it was held in.
+type HeldShares struct {
+	Quantity decimal.Decimal
+	Currency Currency
+}
+
+// PortfolioBalance is the state of the portfolio right after a domain event was
+// raised, carried by the events so that read sides don't need to rebuild it.
+// PortfolioBalance is in the base currency, except for ForeignCash,
+// ForeignReservedCash and ForeignBookValue which hold the available cash,
+// reserved cash and book value in every other currency.
+type PortfolioBalance struct {
+	Cash                decimal.Decimal
+	ReservedCash        decimal.Decimal
+	ForeignCash         CashBalances
+	ForeignReservedCash CashBalances
+	HoldingsCount       int
+	// BookValue is the part of the portfolio in the base currency valued at
+	// cost: cash, reserved cash and what was paid for the holdings.
+	BookValue        decimal.Decimal
+	ForeignBookValue CashBalances
+}
@@ -0,0 +1,380 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"stock-trader/portfolio-service/common"
+
+	"github.com/shopspring/decimal"
+)
+
+type baseIntegrationEvent = common.BaseIntegrationEvent
+
+// PortfolioOpenedV1 is published as 'portfolio-opened' version 1.
+//
+//	{"portfolioId": "<uuid>", "name": "<portfolio name>"}
+type PortfolioOpenedV1 struct {
+	*baseIntegrationEvent
+	portfolioId string
+	name        string
+}
+
+func (e PortfolioOpenedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId": e.portfolioId,
+		"name":        e.name,
+	}
+}
+
+// Amounts are published as decimal strings. Events changing the funds or
+// holdings of a portfolio carry its balance right after the change, in the base
+// currency. Portfolios with cash in other currencies also carry foreignCash,
+// and foreignReservedCash while orders reserve some. Portfolios with cash or
+// holdings in other currencies carry their book value in them as
+// foreignBookValue:
+//
+//	"balance": {"cash": "<decimal>", "reservedCash": "<decimal>", "holdingsCount": <int>, "bookValue": "<decimal>",
+//	 "foreignCash": {"<currency>": "<decimal>"}, "foreignReservedCash": {"<currency>": "<decimal>"},
+//	 "foreignBookValue": {"<currency>": "<decimal>"}}
+func balancePayload(balance PortfolioBalance) map[string]any {
+	payload := map[string]any{
+		"cash":          balance.Cash.String(),
+		"reservedCash":  balance.ReservedCash.String(),
+		"holdingsCount": balance.HoldingsCount,
+		"bookValue":     balance.BookValue.String(),
+	}
+	if len(balance.ForeignCash) > 0 {
+		foreignCash := map[string]any{}
+		for currency, amount := range balance.ForeignCash {
+			foreignCash[string(currency)] = amount.String()
+		}
+		payload["foreignCash"] = foreignCash
+	}
+	if len(balance.ForeignReservedCash) > 0 {
+		foreignReservedCash := map[string]any{}
+		for currency, amount := range balance.ForeignReservedCash {
+			foreignReservedCash[string(currency)] = amount.String()
+		}
+		payload["foreignReservedCash"] = foreignReservedCash
+	}
+	if len(balance.ForeignBookValue) > 0 {
+		foreignBookValue := map[string]any{}
+		for currency, amount := range balance.ForeignBookValue {
+			foreignBookValue[string(currency)] = amount.String()
+		}
+		payload["foreignBookValue"] = foreignBookValue
+	}
+	return payload
+}
+
+// Quantities are published as JSON numbers, which are whole for symbols traded
+// in whole shares.
+func quantityPayload(quantity decimal.Decimal) json.Number {
+	return json.Number(quantity.String())
+}
+
+func nullDecimalPayload(amount decimal.NullDecimal) any {
+	if !amount.Valid {
+		return nil
+	}
+	return amount.Decimal.String()
+}
+
+// FundsReceivedV1 is published as 'funds-received' version 1.
+//
+//	{"portfolioId": "<uuid>", "currency": "<currency>", "amount": "<decimal>", "balance": {...}}
+type FundsReceivedV1 struct {
+	*baseIntegrationEvent
+	event FundsReceived
+}
+
+func (e FundsReceivedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId": e.event.PortfolioId(),
+		"currency":    string(e.event.Currency()),
+		"amount":      e.event.Amount().String(),
+		"balance":     balancePayload(e.event.Balance()),
+	}
+}
+
+// FundsSentV1 is published as 'funds-sent' version 1.
+//
+//	{"portfolioId": "<uuid>", "transferId": "<uuid>", "amount": "<decimal>", "balance": {...}}
+type FundsSentV1 struct {
+	*baseIntegrationEvent
+	event FundsSent
+}
+
+func (e FundsSentV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId": e.event.PortfolioId(),
+		"transferId":  e.event.TransferId(),
+		"amount":      e.event.Amount().String(),
+		"balance":     balancePayload(e.event.Balance()),
+	}
+}
+
+// RefundAcceptedV1 is published as 'refund-accepted' version 1.
+//
+//	{"portfolioId": "<uuid>", "transferId": "<uuid>", "amount": "<decimal>", "balance": {...}}
+type RefundAcceptedV1 struct {
+	*baseIntegrationEvent
+	event RefundAccepted
+}
+
+func (e RefundAcceptedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId": e.event.PortfolioId(),
+		"transferId":  e.event.TransferId(),
+		"amount":      e.event.Amount().String(),
+		"balance":     balancePayload(e.event.Balance()),
+	}
+}
+
+// OrderPlacedV1 is published as 'order-placed' version 1. The prices and
+// commission are in the currency of the order, and prices the type does not
+// take are "0".
+//
+//	{"portfolioId": "<uuid>", "orderId": "<uuid>", "symbol": "<symbol>", "side": "buy|sell",
+//	 "type": "market|limit|stop|stop-limit", "timeInForce": "day|gtc|ioc|fok", "quantity": <number>,
+//	 "limitPrice": "<decimal>", "stopPrice": "<decimal>",
+//	 "currency": "<currency>", "commission": "<decimal>", "balance": {...}}
+type OrderPlacedV1 struct {
+	*baseIntegrationEvent
+	event OrderPlaced
+}
+
+func (e OrderPlacedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId": e.event.PortfolioId(),
+		"orderId":     e.event.OrderId(),
+		"symbol":      e.event.Symbol(),
+		"side":        string(e.event.Side()),
+		"type":        string(e.event.OrderType()),
+		"timeInForce": string(e.event.TimeInForce()),
+		"quantity":    quantityPayload(e.event.Quantity()),
+		"limitPrice":  e.event.LimitPrice().String(),
+		"stopPrice":   e.event.StopPrice().String(),
+		"currency":    string(e.event.Currency()),
+		"commission":  e.event.Commission().String(),
+		"balance":     balancePayload(e.event.Balance()),
+	}
+}
+
+// OrderRejectedV1 is published as 'order-rejected' version 1, when the risk
+// checks refuse an order. The limit price is in the currency of the order, and
+// the limit and value of a reason, when given, in the base currency.
+//
+//	{"portfolioId": "<uuid>", "orderId": "<uuid>", "symbol": "<symbol>", "side": "buy|sell",
+//	 "quantity": <number>, "limitPrice": "<decimal>", "currency": "<currency>",
+//	 "reasons": [{"check": "<check>", "message": "<text>", "limit": "<decimal>|null", "value": "<decimal>|null"}]}
+type OrderRejectedV1 struct {
+	*baseIntegrationEvent
+	event OrderRejected
+}
+
+func (e OrderRejectedV1) Payload() map[string]any {
+	reasons := []any{}
+	for _, reason := range e.event.Reasons() {
+		reasons = append(reasons, map[string]any{
+			"check":   reason.Check,
+			"message": reason.Message,
+			"limit":   nullDecimalPayload(reason.Limit),
+			"value":   nullDecimalPayload(reason.Value),
+		})
+	}
+	return map[string]any{
+		"portfolioId": e.event.PortfolioId(),
+		"orderId":     e.event.OrderId(),
+		"symbol":      e.event.Symbol(),
+		"side":        string(e.event.Side()),
+		"quantity":    quantityPayload(e.event.Quantity()),
+		"limitPrice":  e.event.LimitPrice().String(),
+		"currency":    string(e.event.Currency()),
+		"reasons":     reasons,
+	}
+}
+
+// TradeProcessedV1 is published as 'trade-processed' version 1. holdingQuantity
+// is the quantity held in the symbol after the trade, and the price and
+// commission are in the currency of the order.
+//
+//	{"portfolioId": "<uuid>", "orderId": "<uuid>", "tradeId": "<id>", "symbol": "<symbol>", "side": "buy|sell",
+//	 "quantity": <number>, "price": "<decimal>", "currency": "<currency>", "commission": "<decimal>",
+//	 "holdingQuantity": <number>, "balance": {...}}
+type TradeProcessedV1 struct {
+	*baseIntegrationEvent
+	event TradeProcessed
+}
+
+func (e TradeProcessedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId":     e.event.PortfolioId(),
+		"orderId":         e.event.OrderId(),
+		"tradeId":         e.event.TradeId(),
+		"symbol":          e.event.Symbol(),
+		"side":            string(e.event.Side()),
+		"quantity":        quantityPayload(e.event.Quantity()),
+		"price":           e.event.Price().String(),
+		"currency":        string(e.event.Currency()),
+		"commission":      e.event.Commission().String(),
+		"holdingQuantity": quantityPayload(e.event.HoldingQuantity()),
+		"balance":         balancePayload(e.event.Balance()),
+	}
+}
+
+// OrderFailureAcknowledgedV1 is published as 'order-failure-acknowledged' version 1.
+//
+//	{"portfolioId": "<uuid>", "orderId": "<uuid>", "symbol": "<symbol>", "side": "buy|sell",
+//	 "unfilledQuantity": <number>, "reason": "<text>", "balance": {...}}
+type OrderFailureAcknowledgedV1 struct {
+	*baseIntegrationEvent
+	event OrderFailureAcknowledged
+}
+
+func (e OrderFailureAcknowledgedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId":      e.event.PortfolioId(),
+		"orderId":          e.event.OrderId(),
+		"symbol":           e.event.Symbol(),
+		"side":             string(e.event.Side()),
+		"unfilledQuantity": quantityPayload(e.event.UnfilledQuantity()),
+		"reason":           e.event.Reason(),
+		"balance":          balancePayload(e.event.Balance()),
+	}
+}
+
+// LoyaltyLevelChangedV1 is published as 'loyalty-level-changed' version 1.
+//
+//	{"portfolioId": "<uuid>", "previousLevel": "<level>", "level": "<level>", "commission": "<decimal>"}
+type LoyaltyLevelChangedV1 struct {
+	*baseIntegrationEvent
+	event LoyaltyLevelChanged
+}
+
+func (e LoyaltyLevelChangedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId":   e.event.PortfolioId(),
+		"previousLevel": string(e.event.PreviousLevel()),
+		"level":         string(e.event.Level()),
+		"commission":    e.event.Commission().String(),
+	}
+}
+
+// SharesSplitV1 is published as 'shares-split' version 1.
+//
+//	{"portfolioId": "<uuid>", "actionId": "<uuid>", "symbol": "<symbol>", "numerator": <int>, "denominator": <int>,
+//	 "previousQuantity": <number>, "quantity": <number>, "precision": <int>, "balance": {...}}
+type SharesSplitV1 struct {
+	*baseIntegrationEvent
+	event SharesSplit
+}
+
+func (e SharesSplitV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId":      e.event.PortfolioId(),
+		"actionId":         e.event.ActionId(),
+		"symbol":           e.event.Symbol(),
+		"numerator":        e.event.Numerator(),
+		"denominator":      e.event.Denominator(),
+		"previousQuantity": quantityPayload(e.event.PreviousQuantity()),
+		"quantity":         quantityPayload(e.event.Quantity()),
+		"precision":        e.event.Precision(),
+		"balance":          balancePayload(e.event.Balance()),
+	}
+}
+
+// DividendReceivedV1 is published as 'dividend-received' version 1.
+//
+//	{"portfolioId": "<uuid>", "actionId": "<uuid>", "symbol": "<symbol>", "quantity": <number>,
+//	 "amountPerShare": "<decimal>", "currency": "<currency>", "amount": "<decimal>", "balance": {...}}
+type DividendReceivedV1 struct {
+	*baseIntegrationEvent
+	event DividendReceived
+}
+
+func (e DividendReceivedV1) Payload() map[string]any {
+	return map[string]any{
+		"portfolioId":    e.event.PortfolioId(),
+		"actionId":       e.event.ActionId(),
+		"symbol":         e.event.Symbol(),
+		"quantity":       quantityPayload(e.event.Quantity()),
+		"amountPerShare": e.event.AmountPerShare().String(),
+		"currency":       string(e.event.Currency()),
+		"amount":         e.event.Amount().String(),
+		"balance":        balancePayload(e.event.Balance()),
+	}
+}
+
+// NewIntegrationEventTranslator returns the translations of the portfolio domain
+// events that are part of the service's outbound contract.
+func NewIntegrationEventTranslator() *common.IntegrationEventTranslator {
+	translator := common.NewIntegrationEventTranslator()
+	translator.Register("portfolio-opened", common.Translation(func(event PortfolioOpened) common.IntegrationEvent {
+		return PortfolioOpenedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "portfolio-opened", 1),
+			portfolioId:          event.PortfolioId(),
+			name:                 event.PortfolioName(),
+		}
+	}))
+	translator.Register("funds-received", common.Translation(func(event FundsReceived) common.IntegrationEvent {
+		return FundsReceivedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "funds-received", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("funds-sent", common.Translation(func(event FundsSent) common.IntegrationEvent {
+		return FundsSentV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "funds-sent", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("refund-accepted", common.Translation(func(event RefundAccepted) common.IntegrationEvent {
+		return RefundAcceptedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "refund-accepted", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("order-placed", common.Translation(func(event OrderPlaced) common.IntegrationEvent {
+		return OrderPlacedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "order-placed", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("order-rejected", common.Translation(func(event OrderRejected) common.IntegrationEvent {
+		return OrderRejectedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "order-rejected", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("trade-processed", common.Translation(func(event TradeProcessed) common.IntegrationEvent {
+		return TradeProcessedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "trade-processed", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("order-failure-acknowledged", common.Translation(func(event OrderFailureAcknowledged) common.IntegrationEvent {
+		return OrderFailureAcknowledgedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "order-failure-acknowledged", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("loyalty-level-changed", common.Translation(func(event LoyaltyLevelChanged) common.IntegrationEvent {
+		return LoyaltyLevelChangedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "loyalty-level-changed", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("shares-split", common.Translation(func(event SharesSplit) common.IntegrationEvent {
+		return SharesSplitV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "shares-split", 1),
+			event:                event,
+		}
+	}))
+	translator.Register("dividend-received", common.Translation(func(event DividendReceived) common.IntegrationEvent {
+		return DividendReceivedV1{
+			baseIntegrationEvent: common.NewBaseIntegrationEvent(event, "dividend-received", 1),
+			event:                event,
+		}
+	}))
+	return translator
+}
@@ -0,0 +1,202 @@
+package portfolio_test
+
+import (
+	"encoding/json"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntegrationEventTranslator(t *testing.T) {
+	t.Run("portfolio opened is published as version 1 of portfolio-opened", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		domainEvent := newPortfolio.DomainEvents()[0]
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 1) {
+			event := integrationEvents[0]
+			assert.IsType(t, portfolio.PortfolioOpenedV1{}, event)
+			assert.Equal(t, domainEvent.Id(), event.Id())
+			assert.Equal(t, domainEvent.Timestamp(), event.Timestamp())
+			assert.Equal(t, "portfolio-opened", event.Name())
+			assert.Equal(t, 1, event.Version())
+			assert.Equal(t, map[string]any{"portfolioId": string(newPortfolio.Id()), "name": "A Portfolio Name"}, event.Payload())
+		}
+	})
+
+	t.Run("order placed is published as version 1 of order-placed with the balance", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		newPortfolio.ReceiveFunds(decimal.NewFromInt(1000))
+		orderId := portfolio.NewOrderId()
+		newPortfolio.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.RequireFromString("20.5")}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 3) {
+			event := integrationEvents[2]
+			assert.IsType(t, portfolio.OrderPlacedV1{}, event)
+			assert.Equal(t, "order-placed", event.Name())
+			assert.Equal(t, 1, event.Version())
+			assert.Equal(t, map[string]any{
+				"portfolioId": string(newPortfolio.Id()),
+				"orderId":     string(orderId),
+				"symbol":      "ACME",
+				"side":        "buy",
+				"type":        "limit",
+				"timeInForce": "day",
+				"quantity":    json.Number("10"),
+				"limitPrice":  "20.5",
+				"stopPrice":   "0",
+				"currency":    "USD",
+				"commission":  "0",
+				"balance": map[string]any{
+					"cash":          "795",
+					"reservedCash":  "205",
+					"holdingsCount": 0,
+					"bookValue":     "1000",
+				},
+			}, event.Payload())
+		}
+	})
+
+	t.Run("funds received in another currency carry the foreign cash in the balance", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		newPortfolio.ReceiveFundsIn("EUR", decimal.NewFromInt(500))
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 2) {
+			event := integrationEvents[1]
+			assert.IsType(t, portfolio.FundsReceivedV1{}, event)
+			assert.Equal(t, map[string]any{
+				"portfolioId": string(newPortfolio.Id()),
+				"amount":      "500",
+				"currency":    "EUR",
+				"balance": map[string]any{
+					"cash":             "0",
+					"reservedCash":     "0",
+					"holdingsCount":    0,
+					"bookValue":        "0",
+					"foreignCash":      map[string]any{"EUR": "500"},
+					"foreignBookValue": map[string]any{"EUR": "500"},
+				},
+			}, event.Payload())
+		}
+	})
+
+	t.Run("orders in another currency carry the foreign reserved cash in the balance", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		newPortfolio.ReceiveFundsIn("EUR", decimal.NewFromInt(500))
+		newPortfolio.PlaceOrder(portfolio.NewOrderId(), portfolio.OrderRequest{Symbol: "SAP", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20), Currency: "EUR"}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 3) {
+			balance := integrationEvents[2].Payload()["balance"].(map[string]any)
+			assert.Equal(t, map[string]any{"EUR": "300"}, balance["foreignCash"])
+			assert.Equal(t, map[string]any{"EUR": "200"}, balance["foreignReservedCash"])
+		}
+	})
+
+	t.Run("loyalty level changed is published as version 1 of loyalty-level-changed", func(t *testing.T) {
+		program, _ := portfolio.ParseLoyaltyProgram("basic:0:10,bronze:100:5")
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		newPortfolio.ReceiveFunds(decimal.NewFromInt(1000))
+		orderId := portfolio.NewOrderId()
+		newPortfolio.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)}, program, portfolio.SharePrecision{})
+		newPortfolio.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), program)
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 5) {
+			assert.Equal(t, "10", integrationEvents[3].Payload()["commission"])
+			event := integrationEvents[4]
+			assert.IsType(t, portfolio.LoyaltyLevelChangedV1{}, event)
+			assert.Equal(t, "loyalty-level-changed", event.Name())
+			assert.Equal(t, 1, event.Version())
+			assert.Equal(t, map[string]any{
+				"portfolioId":   string(newPortfolio.Id()),
+				"previousLevel": "basic",
+				"level":         "bronze",
+				"commission":    "5",
+			}, event.Payload())
+		}
+	})
+
+	t.Run("dividend received is published as version 1 of dividend-received with the balance", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		newPortfolio.ReceiveFunds(decimal.NewFromInt(1000))
+		orderId := portfolio.NewOrderId()
+		newPortfolio.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		newPortfolio.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+		newPortfolio.ReceiveDividend("action-1", "ACME", decimal.RequireFromString("0.5"), newPortfolio.HeldShares("ACME"))
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 5) {
+			event := integrationEvents[4]
+			assert.IsType(t, portfolio.DividendReceivedV1{}, event)
+			assert.Equal(t, "dividend-received", event.Name())
+			assert.Equal(t, 1, event.Version())
+			assert.Equal(t, map[string]any{
+				"portfolioId":    string(newPortfolio.Id()),
+				"actionId":       "action-1",
+				"symbol":         "ACME",
+				"quantity":       json.Number("10"),
+				"amountPerShare": "0.5",
+				"currency":       "USD",
+				"amount":         "5",
+				"balance": map[string]any{
+					"cash":          "805",
+					"reservedCash":  "0",
+					"holdingsCount": 1,
+					"bookValue":     "1005",
+				},
+			}, event.Payload())
+		}
+	})
+
+	t.Run("order rejected is published as version 1 of order-rejected with the reasons", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A Portfolio Name")
+		orderId := portfolio.NewOrderId()
+		newPortfolio.RejectOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.RequireFromString("20.5")}, []portfolio.RejectionReason{
+			{Check: "restricted_symbol", Message: "ACME is restricted from trading"},
+			{Check: "max_order_notional", Message: "order is worth 205.00 USD, above the limit of 100.00 USD", Limit: decimal.NewNullDecimal(decimal.NewFromInt(100)), Value: decimal.NewNullDecimal(decimal.NewFromInt(205))},
+		})
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
+
+		if assert.NoError(t, err) && assert.Len(t, integrationEvents, 2) {
+			event := integrationEvents[1]
+			assert.IsType(t, portfolio.OrderRejectedV1{}, event)
+			assert.Equal(t, "order-rejected", event.Name())
+			assert.Equal(t, 1, event.Version())
+			assert.Equal(t, map[string]any{
+				"portfolioId": string(newPortfolio.Id()),
+				"orderId":     string(orderId),
+				"symbol":      "ACME",
+				"side":        "buy",
+				"quantity":    json.Number("10"),
+				"limitPrice":  "20.5",
+				"currency":    "USD",
+				"reasons": []any{
+					map[string]any{"check": "restricted_symbol", "message": "ACME is restricted from trading", "limit": nil, "value": nil},
+					map[string]any{"check": "max_order_notional", "message": "order is worth 205.00 USD, above the limit of 100.00 USD", "limit": "100", "value": "205"},
+				},
+			}, event.Payload())
+		}
+	})
+
+	t.Run("domain events without a translation are not published", func(t *testing.T) {
+		internalEvent := common.NewBaseDomainEvent("some-internal-event")
+
+		integrationEvents, err := portfolio.NewIntegrationEventTranslator().Translate([]common.DomainEvent{internalEvent})
+
+		assert.NoError(t, err)
+		assert.Empty(t, integrationEvents)
+	})
+}
@@ -0,0 +1,190 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+type LoyaltyLevel string
+
+const (
+	Basic    LoyaltyLevel = "basic"
+	Bronze   LoyaltyLevel = "bronze"
+	Silver   LoyaltyLevel = "silver"
+	Gold     LoyaltyLevel = "gold"
+	Platinum LoyaltyLevel = "platinum"
+)
+
+func (l LoyaltyLevel) valid() bool {
+	switch l {
+	case Basic, Bronze, Silver, Gold, Platinum:
+		return true
+	}
+	return false
+}
+
+// LoyaltyTier is reached once a portfolio is worth at least its threshold, and charges its commission on every order placed from then on.
+type LoyaltyTier struct {
+	Level      LoyaltyLevel
+	Threshold  decimal.Decimal
+	Commission decimal.Decimal
+}
+
+// LoyaltyProgram is the list of loyalty tiers, from the lowest threshold to the
+// highest. The zero value charges no commission and keeps every portfolio at
+// the basic level. Thresholds are met by the value of the portfolio in the base
+// currency: its cash and its holdings at the prices of the program, converted
+// at the rates of the program.
+type LoyaltyProgram struct {
+	tiers  []LoyaltyTier
+	rates  CurrencyRates
+	prices SharePrices
+}
+
+// SharePrices tells the last known price of a share of a symbol, in its listing
+// currency, and false when there is none. It must not fetch prices, since the
+// portfolio is valued while it is locked.
+type SharePrices func(symbol string) (decimal.Decimal, bool)
+
+func NewLoyaltyProgram(tiers ...LoyaltyTier) (LoyaltyProgram, error) {
+	if len(tiers) == 0 {
+		return LoyaltyProgram{}, errors.New("loyalty program needs at least one tier")
+	}
+	if !tiers[0].Threshold.IsZero() {
+		return LoyaltyProgram{}, errors.New("the first loyalty tier must have a zero threshold")
+	}
+	levels := map[LoyaltyLevel]bool{}
+	for i, tier := range tiers {
+		if !tier.Level.valid() {
+			return LoyaltyProgram{}, fmt.Errorf("unknown loyalty level %q", tier.Level)
+		}
+		if levels[tier.Level] {
+			return LoyaltyProgram{}, fmt.Errorf("loyalty level %s appears more than once", tier.Level)
+		}
+		levels[tier.Level] = true
+		if i > 0 && !tier.Threshold.GreaterThan(tiers[i-1].Threshold) {
+			return LoyaltyProgram{}, fmt.Errorf("loyalty level %s must have a higher threshold than %s", tier.Level, tiers[i-1].Level)
+		}
+		if tier.Commission.IsNegative() {
+			return LoyaltyProgram{}, fmt.Errorf("loyalty level %s cannot have a negative commission", tier.Level)
+		}
+	}
+	return LoyaltyProgram{tiers: append([]LoyaltyTier{}, tiers...)}, nil
+}
+
+// DefaultLoyaltyProgram has the tiers of IBM's Stock Trader.
+func DefaultLoyaltyProgram() LoyaltyProgram {
+	program, _ := NewLoyaltyProgram(
+		LoyaltyTier{Level: Basic, Threshold: decimal.Zero, Commission: decimal.RequireFromString("9.99")},
+		LoyaltyTier{Level: Bronze, Threshold: decimal.NewFromInt(10_000), Commission: decimal.RequireFromString("8.99")},
+		LoyaltyTier{Level: Silver, Threshold: decimal.NewFromInt(50_000), Commission: decimal.RequireFromString("7.99")},
+		LoyaltyTier{Level: Gold, Threshold: decimal.NewFromInt(100_000), Commission: decimal.RequireFromString("6.99")},
+		LoyaltyTier{Level: Platinum, Threshold: decimal.NewFromInt(1_000_000), Commission: decimal.RequireFromString("5.99")},
+	)
+	return program
+}
+
+// ParseLoyaltyProgram reads tiers written as comma separated
+// level:threshold:commission triples, e.g. "basic:0:9.99,bronze:10000:8.99".
+func ParseLoyaltyProgram(spec string) (LoyaltyProgram, error) {
+	tiers := []LoyaltyTier{}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) != 3 {
+			return LoyaltyProgram{}, fmt.Errorf("loyalty tier %q must be written as level:threshold:commission", field)
+		}
+		threshold, err := decimal.NewFromString(parts[1])
+		if err != nil {
+			return LoyaltyProgram{}, fmt.Errorf("loyalty tier %q has an invalid threshold: %w", field, err)
+		}
+		commission, err := decimal.NewFromString(parts[2])
+		if err != nil {
+			return LoyaltyProgram{}, fmt.Errorf("loyalty tier %q has an invalid commission: %w", field, err)
+		}
+		tiers = append(tiers, LoyaltyTier{
+			Level:      LoyaltyLevel(strings.ToLower(parts[0])),
+			Threshold:  threshold,
+			Commission: commission,
+		})
+	}
+	return NewLoyaltyProgram(tiers...)
+}
+
+// WithRates returns the program converting cash and holdings in other
+// currencies at the rates. Without rates, they do not count towards any
+// threshold.
+func (p LoyaltyProgram) WithRates(rates CurrencyRates) LoyaltyProgram {
+	p.rates = rates
+	return p
+}
+
+// WithPrices returns the program valuing holdings at the prices. Without
+// prices, or for symbols they have none for, holdings count at what was paid
+// for them.
+func (p LoyaltyProgram) WithPrices(prices SharePrices) LoyaltyProgram {
+	p.prices = prices
+	return p
+}
+
+// worth is what the cash and holdings are worth in the base currency. Amounts
+// in a currency the program has no rate for are left out, as a trade that
+// already happened can not be refused for it.
+func (p LoyaltyProgram) worth(cash CashBalances, holdings map[string]Holding) decimal.Decimal {
+	amounts := CashBalances{}
+	for currency, amount := range cash {
+		amounts.add(currency, amount)
+	}
+	for _, holding := range holdings {
+		value := holding.Cost
+		if p.prices != nil {
+			if price, ok := p.prices(holding.Symbol); ok {
+				value = roundCash(price.Mul(holding.Quantity))
+			}
+		}
+		amounts.add(holding.Currency, value)
+	}
+
+	worth := decimal.Zero
+	for currency, amount := range amounts {
+		if currency == BaseCurrency {
+			worth = worth.Add(amount)
+			continue
+		}
+		if p.rates == nil {
+			continue
+		}
+		if rate, err := p.rates(currency); err == nil {
+			worth = worth.Add(roundCash(amount.Mul(rate)))
+		}
+	}
+	return worth
+}
+
+// Level is the highest level whose threshold the value reaches.
+func (p LoyaltyProgram) Level(value decimal.Decimal) LoyaltyLevel {
+	level := Basic
+	for _, tier := range p.tiers {
+		if value.LessThan(tier.Threshold) {
+			break
+		}
+		level = tier.Level
+	}
+	return level
+}
+
+// Commission is what the level charges per order. Levels that are not part of
+// the program charge the commission of the lowest tier.
+func (p LoyaltyProgram) Commission(level LoyaltyLevel) decimal.Decimal {
+	if len(p.tiers) == 0 {
+		return decimal.Zero
+	}
+	for _, tier := range p.tiers {
+		if tier.Level == level {
+			return tier.Commission
+		}
+	}
+	return p.tiers[0].Commission
+}
@@ -0,0 +1,58 @@
+package portfolio_test
+
+import (
+	"stock-trader/portfolio-service/portfolio"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoyaltyProgram(t *testing.T) {
+	t.Run("Levels are reached at their threshold", func(t *testing.T) {
+		program := portfolio.DefaultLoyaltyProgram()
+
+		assert.Equal(t, portfolio.Basic, program.Level(decimal.Zero))
+		assert.Equal(t, portfolio.Basic, program.Level(decimal.RequireFromString("9999.99")))
+		assert.Equal(t, portfolio.Bronze, program.Level(decimal.NewFromInt(10_000)))
+		assert.Equal(t, portfolio.Gold, program.Level(decimal.NewFromInt(999_999)))
+		assert.Equal(t, portfolio.Platinum, program.Level(decimal.NewFromInt(5_000_000)))
+	})
+
+	t.Run("Commissions come from the tier of the level", func(t *testing.T) {
+		program := portfolio.DefaultLoyaltyProgram()
+
+		assert.Equal(t, "9.99", program.Commission(portfolio.Basic).String())
+		assert.Equal(t, "5.99", program.Commission(portfolio.Platinum).String())
+	})
+
+	t.Run("Levels missing from the program charge the lowest tier", func(t *testing.T) {
+		program, err := portfolio.ParseLoyaltyProgram("basic:0:5, gold:1000:1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, portfolio.Gold, program.Level(decimal.NewFromInt(20_000)))
+		assert.Equal(t, "5", program.Commission(portfolio.Silver).String())
+	})
+
+	t.Run("The zero program charges no commission", func(t *testing.T) {
+		program := portfolio.LoyaltyProgram{}
+
+		assert.Equal(t, portfolio.Basic, program.Level(decimal.NewFromInt(5_000_000)))
+		assert.True(t, program.Commission(portfolio.Basic).IsZero())
+	})
+
+	t.Run("Parse invalid programs", func(t *testing.T) {
+		for spec, message := range map[string]string{
+			"basic:0":                        `loyalty tier "basic:0" must be written as level:threshold:commission`,
+			"bronze:10:1":                    "the first loyalty tier must have a zero threshold",
+			"basic:0:1,diamond:10:1":         `unknown loyalty level "diamond"`,
+			"basic:0:1,basic:10:1":           "loyalty level basic appears more than once",
+			"basic:0:1,gold:10:1,silver:5:1": "loyalty level silver must have a higher threshold than gold",
+			"basic:0:-1":                     "loyalty level basic cannot have a negative commission",
+		} {
+			_, err := portfolio.ParseLoyaltyProgram(spec)
+
+			assert.EqualError(t, err, message, spec)
+		}
+	})
+}
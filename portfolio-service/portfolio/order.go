@@ -0,0 +1,234 @@
+package portfolio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type OrderId string
+
+func NewOrderId() OrderId {
+	return OrderId(uuid.NewString())
+}
+
+type OrderSide string
+
+const (
+	Buy  OrderSide = "buy"
+	Sell OrderSide = "sell"
+)
+
+// OrderType is how the broker prices an order. Market orders fill at the
+// market and limit orders at their limit price or better. Stop and stop-limit
+// orders turn into market and limit orders once the market reaches their stop
+// price.
+type OrderType string
+
+const (
+	Market    OrderType = "market"
+	Limit     OrderType = "limit"
+	Stop      OrderType = "stop"
+	StopLimit OrderType = "stop-limit"
+)
+
+// TimeInForce is how long the broker keeps an order in its book. Day orders
+// expire at market close and good-till-cancelled ones after the horizon of the
+// broker, while the broker cancels what it can not fill of immediate-or-cancel
+// orders at once, and of fill-or-kill ones unless it fills them entirely.
+type TimeInForce string
+
+const (
+	Day               TimeInForce = "day"
+	GoodTillCancelled TimeInForce = "gtc"
+	ImmediateOrCancel TimeInForce = "ioc"
+	FillOrKill        TimeInForce = "fok"
+)
+
+// Rests tells whether the broker keeps the order in its book until it is
+// filled or expires, rather than settling it as soon as it receives it.
+func (t TimeInForce) Rests() bool {
+	return t == Day || t == GoodTillCancelled
+}
+
+// OrderRequest is what a portfolio owner asks for when placing an order. The
+// quantity may be a fraction of a share for symbols traded in fractions. Prices
+// are in the listing currency of the symbol, the base currency unless set.
+// Orders without a limit price are valued at their ReservePrice, which buy
+// orders reserve cash at. It is set from a quote or the stop price when the
+// order is placed.
+type OrderRequest struct {
+	Symbol       string
+	Side         OrderSide
+	Type         OrderType
+	TimeInForce  TimeInForce
+	Quantity     decimal.Decimal
+	LimitPrice   decimal.Decimal
+	StopPrice    decimal.Decimal
+	ReservePrice decimal.Decimal
+	Currency     Currency
+}
+
+// Validate returns the request with its symbol, type, time in force and
+// currency normalised, or why it can not be placed. Requests without a type
+// are limit orders, and those without a time in force day orders.
+func (r OrderRequest) Validate() (OrderRequest, error) {
+	r.Symbol = strings.ToUpper(strings.TrimSpace(r.Symbol))
+	if len(r.Symbol) == 0 || len(r.Symbol) > 8 {
+		return r, fmt.Errorf("%w: symbol must be between 1 and 8 characters long", ErrInvalidOrder)
+	}
+	if r.Side != Buy && r.Side != Sell {
+		return r, fmt.Errorf("%w: order side must be either buy or sell", ErrInvalidOrder)
+	}
+	if !r.Quantity.IsPositive() {
+		return r, fmt.Errorf("%w: order quantity must be greater than zero", ErrInvalidOrder)
+	}
+	if r.Type == "" {
+		r.Type = Limit
+	}
+	if err := r.validatePrices(); err != nil {
+		return r, err
+	}
+	if r.TimeInForce == "" {
+		r.TimeInForce = Day
+	}
+	if r.TimeInForce != Day && r.TimeInForce != GoodTillCancelled && r.TimeInForce != ImmediateOrCancel && r.TimeInForce != FillOrKill {
+		return r, fmt.Errorf("%w: time in force must be one of day, gtc, ioc or fok", ErrInvalidOrder)
+	}
+	if r.LimitPrice.IsPositive() {
+		r.ReservePrice = decimal.Zero
+	}
+	currency, err := ParseCurrency(string(r.Currency))
+	if err != nil {
+		return r, err
+	}
+	r.Currency = currency
+	return r, nil
+}
+
+func (r OrderRequest) validatePrices() error {
+	var takesLimitPrice, takesStopPrice bool
+	switch r.Type {
+	case Market:
+	case Limit:
+		takesLimitPrice = true
+	case Stop:
+		takesStopPrice = true
+	case StopLimit:
+		takesLimitPrice, takesStopPrice = true, true
+	default:
+		return fmt.Errorf("%w: order type must be one of market, limit, stop or stop-limit", ErrInvalidOrder)
+	}
+
+	if takesLimitPrice && !r.LimitPrice.IsPositive() {
+		return fmt.Errorf("%w: %s orders require a limit price greater than zero", ErrInvalidOrder, r.Type)
+	}
+	if !takesLimitPrice && !r.LimitPrice.IsZero() {
+		return fmt.Errorf("%w: %s orders do not take a limit price", ErrInvalidOrder, r.Type)
+	}
+	if takesStopPrice && !r.StopPrice.IsPositive() {
+		return fmt.Errorf("%w: %s orders require a stop price greater than zero", ErrInvalidOrder, r.Type)
+	}
+	if !takesStopPrice && !r.StopPrice.IsZero() {
+		return fmt.Errorf("%w: %s orders do not take a stop price", ErrInvalidOrder, r.Type)
+	}
+	if !takesLimitPrice && !r.ReservePrice.IsPositive() {
+		return fmt.Errorf("%w: %s orders require a price to reserve cash at", ErrInvalidOrder, r.Type)
+	}
+	return nil
+}
+
+// Price is what the order is worth a share: its limit price, or the price
+// orders without one reserve cash at.
+func (r OrderRequest) Price() decimal.Decimal {
+	if r.LimitPrice.IsPositive() {
+		return r.LimitPrice
+	}
+	return r.ReservePrice
+}
+
+// validateQuantity rejects quantities below the increment of the places, or
+// that are not a multiple of it.
+func validateQuantity(symbol string, quantity decimal.Decimal, places int32) error {
+	increment := ShareIncrement(places)
+	if quantity.LessThan(increment) {
+		return fmt.Errorf("%w: %s is traded in increments of %s, %s is below the minimum", ErrInvalidQuantity, symbol, increment, quantity)
+	}
+	if !quantity.Mod(increment).IsZero() {
+		return fmt.Errorf("%w: %s is traded in increments of %s, %s is not a multiple of it", ErrInvalidQuantity, symbol, increment, quantity)
+	}
+	return nil
+}
+
+// pendingOrder is an order the broker has not finished with. Buy orders keep
+// their unfilled quantity at limit price reserved from cash, and sell orders
+// keep their unfilled quantity reserved from the holding. The commission stays
+// reserved from cash until the first fill charges it. Cash is reserved in the
+// currency of the order, and fills are in the share precision the order was
+// placed with.
+type pendingOrder struct {
+	Id             OrderId         `json:"id"`
+	Symbol         string          `json:"symbol"`
+	Side           OrderSide       `json:"side"`
+	Type           OrderType       `json:"type,omitempty"`
+	TimeInForce    TimeInForce     `json:"time_in_force,omitempty"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	FilledQuantity decimal.Decimal `json:"filled_quantity"`
+	Precision      int32           `json:"precision"`
+	LimitPrice     decimal.Decimal `json:"limit_price"`
+	StopPrice      decimal.Decimal `json:"stop_price"`
+	ReservePrice   decimal.Decimal `json:"reserve_price"`
+	Currency       Currency        `json:"currency"`
+	Commission     decimal.Decimal `json:"commission"`
+}
+
+// request is the order as it was placed. Orders placed before they had a type
+// and a time in force are limit day orders.
+func (o pendingOrder) request() OrderRequest {
+	orderType := o.Type
+	if orderType == "" {
+		orderType = Limit
+	}
+	timeInForce := o.TimeInForce
+	if timeInForce == "" {
+		timeInForce = Day
+	}
+	return OrderRequest{
+		Symbol:       o.Symbol,
+		Side:         o.Side,
+		Type:         orderType,
+		TimeInForce:  timeInForce,
+		Quantity:     o.Quantity,
+		LimitPrice:   o.LimitPrice,
+		StopPrice:    o.StopPrice,
+		ReservePrice: o.ReservePrice,
+		Currency:     o.Currency,
+	}
+}
+
+func (o pendingOrder) unfilledQuantity() decimal.Decimal {
+	return o.Quantity.Sub(o.FilledQuantity)
+}
+
+// reservedCash rounds what buy orders reserve up to the precision of cash, so
+// that no fill at the limit price costs more than was reserved for it. Orders
+// without a limit price reserve at their reserve price.
+func (o pendingOrder) reservedCash() decimal.Decimal {
+	reserved := decimal.Zero
+	if o.FilledQuantity.IsZero() {
+		reserved = reserved.Add(o.Commission)
+	}
+	if o.Side == Buy {
+		reserved = reserved.Add(o.request().Price().Mul(o.unfilledQuantity()).RoundCeil(cashPlaces))
+	}
+	return reserved
+}
+
+func (o pendingOrder) reservedShares() decimal.Decimal {
+	if o.Side != Sell {
+		return decimal.Zero
+	}
+	return o.unfilledQuantity()
+}
@@ -0,0 +1,437 @@
+package performance
+
+import (
+	"context"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DailyValuation is what a portfolio was worth at the end of a UTC day, along
+// with the cash that came in (positive) or went out (negative) during it, both
+// in the base currency.
+type DailyValuation struct {
+	Date    time.Time
+	Value   decimal.Decimal
+	NetFlow decimal.Decimal
+}
+
+// History is one valuation for every day of a period, along with the value of
+// the portfolio at the end of the day before the period started.
+type History struct {
+	StartValue decimal.Decimal
+	Days       []DailyValuation
+}
+
+type HistorySource interface {
+	History(ctx context.Context, portfolioId string, from time.Time, to time.Time) (History, error)
+}
+
+type journalHistorySource struct {
+	db        *gorm.DB
+	rates     fx.RateSource
+	batchSize int
+}
+
+// NewJournalHistorySource rebuilds daily valuations by replaying the journal
+// events of the portfolio. Holdings are valued at the price of their latest
+// trade, the only price the journal knows, and converted to the base currency
+// at the rates.
+func NewJournalHistorySource(db *gorm.DB, rates fx.RateSource) HistorySource {
+	return &journalHistorySource{
+		db:        db,
+		rates:     rates,
+		batchSize: 500,
+	}
+}
+
+func (s *journalHistorySource) History(ctx context.Context, portfolioId string, from time.Time, to time.Time) (History, error) {
+	replay := newValuationReplay()
+	if err := replayJournal(ctx, s.db, s.batchSize, portfolioId, 0, day(to).AddDate(0, 0, 1), replay.apply); err != nil {
+		return History{}, err
+	}
+	return replay.history(ctx, s.rates, from, to)
+}
+
+// JournalState is a portfolio as its journal events left it. Cash is kept in
+// every currency the portfolio holds some of, and holdings are marked at the
+// price of their latest trade, in the currency they were traded in. Flows are
+// the cash that came in and went out since the portfolio was opened. Position
+// is the journal position of the latest event, zero before the portfolio was
+// opened.
+type JournalState struct {
+	Position     int64
+	Cash         portfolio.CashBalances
+	ReservedCash portfolio.CashBalances
+	Holdings     map[string]decimal.Decimal
+	Prices       map[string]decimal.Decimal
+	Currencies   map[string]portfolio.Currency
+	Flows        portfolio.CashBalances
+}
+
+// Value is what the state is worth in the base currency at the rates.
+func (s JournalState) Value(ctx context.Context, rates fx.RateSource) (decimal.Decimal, error) {
+	value := decimal.Zero
+	for _, balances := range []portfolio.CashBalances{s.Cash, s.ReservedCash} {
+		for currency, amount := range balances {
+			converted, err := Convert(ctx, rates, amount, currency)
+			if err != nil {
+				return decimal.Zero, err
+			}
+			value = value.Add(converted)
+		}
+	}
+	for symbol, quantity := range s.Holdings {
+		converted, err := Convert(ctx, rates, s.Prices[symbol].Mul(quantity), s.Currencies[symbol])
+		if err != nil {
+			return decimal.Zero, err
+		}
+		value = value.Add(converted)
+	}
+	return value, nil
+}
+
+// FlowSince is the cash that came in (positive) or went out (negative) between
+// an earlier state and this one, in the base currency at the rates.
+func (s JournalState) FlowSince(ctx context.Context, rates fx.RateSource, previous JournalState) (decimal.Decimal, error) {
+	flows := portfolio.CashBalances{}
+	for currency, amount := range s.Flows {
+		flows[currency] = flows[currency].Add(amount)
+	}
+	for currency, amount := range previous.Flows {
+		flows[currency] = flows[currency].Sub(amount)
+	}
+
+	flow := decimal.Zero
+	for currency, amount := range flows {
+		converted, err := Convert(ctx, rates, amount, currency)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		flow = flow.Add(converted)
+	}
+	return flow, nil
+}
+
+// Convert turns an amount in a currency into the base currency at the rates,
+// rounded to the precision of cash. Amounts without a currency are in the base
+// currency.
+func Convert(ctx context.Context, rates fx.RateSource, amount decimal.Decimal, currency portfolio.Currency) (decimal.Decimal, error) {
+	if currency == "" || currency == portfolio.BaseCurrency {
+		return amount, nil
+	}
+	rate, err := rates.Rate(ctx, currency, portfolio.BaseCurrency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate).Round(valuePlaces), nil
+}
+
+// valuePlaces is the precision of amounts converted to the base currency.
+const valuePlaces = 4
+
+// JournalStates replays the journal events of a portfolio once and returns
+// its state as of each of the instants, which must be in order. Events at an
+// instant are left out of its state.
+func JournalStates(ctx context.Context, db *gorm.DB, portfolioId string, instants []time.Time) ([]JournalState, error) {
+	states := make([]JournalState, 0, len(instants))
+	if len(instants) == 0 {
+		return states, nil
+	}
+
+	replay := newValuationReplay()
+	err := replayJournal(ctx, db, 500, portfolioId, 0, instants[len(instants)-1], func(event common.IntegrationEventEntity) error {
+		for len(states) < len(instants) && !event.Timestamp.Before(instants[len(states)]) {
+			states = append(states, replay.state())
+		}
+		return replay.apply(event)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for len(states) < len(instants) {
+		states = append(states, replay.state())
+	}
+	return states, nil
+}
+
+// ReplayDays values the days the journal events of a portfolio after a state
+// close, up to until. The state is the portfolio at the end of date, so the
+// events of that day it does not account for count toward the next one.
+func ReplayDays(ctx context.Context, db *gorm.DB, rates fx.RateSource, portfolioId string, state JournalState, date time.Time, until time.Time) ([]DailyValuation, error) {
+	replay := newSeededReplay(state, date)
+	if err := replayJournal(ctx, db, 500, portfolioId, state.Position, until, replay.apply); err != nil {
+		return nil, err
+	}
+	return replay.days(ctx, rates, time.Time{})
+}
+
+// replayJournal visits the journal events of a portfolio after a position and
+// before until, in journal order. Events are found through the portfolio_id the
+// journal indexes out of their data, and batched by position rather than by
+// their primary key, which is not in journal order.
+func replayJournal(ctx context.Context, db *gorm.DB, batchSize int, portfolioId string, after int64, until time.Time, visit func(common.IntegrationEventEntity) error) error {
+	for {
+		var events []common.IntegrationEventEntity
+		err := db.WithContext(ctx).
+			Where("portfolio_id = ?", portfolioId).
+			Where("position > ?", after).
+			Where("timestamp < ?", until).
+			Order("position").
+			Limit(batchSize).
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := visit(event); err != nil {
+				return err
+			}
+			after = event.Position
+		}
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
+
+type dayClose struct {
+	date  time.Time
+	state JournalState
+	flows portfolio.CashBalances
+}
+
+// valuationReplay follows the settled cash and the holdings of a portfolio
+// through its journal events, closing a day whenever an event of a later day
+// comes in. Amounts are kept in their own currency until the days are valued.
+// No day closes before the floor.
+type valuationReplay struct {
+	position     int64
+	cash         portfolio.CashBalances
+	reservedCash portfolio.CashBalances
+	quantities   map[string]decimal.Decimal
+	marks        map[string]decimal.Decimal
+	currencies   map[string]portfolio.Currency
+	floor        time.Time
+	current      time.Time
+	flows        portfolio.CashBalances
+	totals       portfolio.CashBalances
+	closes       []dayClose
+}
+
+func newValuationReplay() *valuationReplay {
+	return &valuationReplay{
+		cash:         portfolio.CashBalances{},
+		reservedCash: portfolio.CashBalances{},
+		quantities:   map[string]decimal.Decimal{},
+		marks:        map[string]decimal.Decimal{},
+		currencies:   map[string]portfolio.Currency{},
+		flows:        portfolio.CashBalances{},
+		totals:       portfolio.CashBalances{},
+	}
+}
+
+// newSeededReplay picks up from the state of a portfolio at the end of date.
+func newSeededReplay(state JournalState, date time.Time) *valuationReplay {
+	r := newValuationReplay()
+	r.position = state.Position
+	r.floor = day(date).AddDate(0, 0, 1)
+	for currency, amount := range state.Cash {
+		r.cash[currency] = amount
+	}
+	for currency, amount := range state.ReservedCash {
+		r.reservedCash[currency] = amount
+	}
+	for currency, amount := range state.Flows {
+		r.totals[currency] = amount
+	}
+	for symbol, quantity := range state.Holdings {
+		r.quantities[symbol] = quantity
+		r.marks[symbol] = state.Prices[symbol]
+		r.currencies[symbol] = state.Currencies[symbol]
+	}
+	return r
+}
+
+func (r *valuationReplay) apply(event common.IntegrationEventEntity) error {
+	var payload struct {
+		Amount          decimal.Decimal    `json:"amount"`
+		Currency        portfolio.Currency `json:"currency"`
+		Symbol          string             `json:"symbol"`
+		Price           decimal.Decimal    `json:"price"`
+		HoldingQuantity decimal.Decimal    `json:"holdingQuantity"`
+		Quantity        decimal.Decimal    `json:"quantity"`
+		Numerator       int64              `json:"numerator"`
+		Denominator     int64              `json:"denominator"`
+		Balance         *struct {
+			Cash                decimal.Decimal        `json:"cash"`
+			ReservedCash        decimal.Decimal        `json:"reservedCash"`
+			ForeignCash         portfolio.CashBalances `json:"foreignCash"`
+			ForeignReservedCash portfolio.CashBalances `json:"foreignReservedCash"`
+		} `json:"balance"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+	// Events recorded before portfolios held other currencies carry none.
+	if payload.Currency == "" {
+		payload.Currency = portfolio.BaseCurrency
+	}
+
+	eventDay := day(event.Timestamp)
+	if eventDay.Before(r.floor) {
+		eventDay = r.floor
+	}
+	if !r.current.IsZero() && eventDay.After(r.current) {
+		r.closeDay()
+	}
+	r.current = eventDay
+	r.position = event.Position
+
+	switch event.Name {
+	case "funds-received":
+		r.flow(payload.Currency, payload.Amount)
+	case "refund-accepted":
+		r.flow(portfolio.BaseCurrency, payload.Amount)
+	case "funds-sent":
+		r.flow(portfolio.BaseCurrency, payload.Amount.Neg())
+	case "trade-processed":
+		r.quantities[payload.Symbol] = payload.HoldingQuantity
+		r.marks[payload.Symbol] = payload.Price
+		r.currencies[payload.Symbol] = payload.Currency
+	case "shares-split":
+		// The last trade price is carried over to the new shares, so that the
+		// split alone does not move the value.
+		r.quantities[payload.Symbol] = payload.Quantity
+		r.marks[payload.Symbol] = r.marks[payload.Symbol].Mul(decimal.NewFromInt(payload.Denominator)).Div(decimal.NewFromInt(payload.Numerator))
+	}
+	if payload.Balance != nil {
+		r.cash = balances(payload.Balance.Cash, payload.Balance.ForeignCash)
+		r.reservedCash = balances(payload.Balance.ReservedCash, payload.Balance.ForeignReservedCash)
+	}
+	return nil
+}
+
+func (r *valuationReplay) flow(currency portfolio.Currency, amount decimal.Decimal) {
+	r.flows[currency] = r.flows[currency].Add(amount)
+	r.totals[currency] = r.totals[currency].Add(amount)
+}
+
+// balances puts the base currency amount of a balance together with the
+// amounts in other currencies.
+func balances(base decimal.Decimal, foreign portfolio.CashBalances) portfolio.CashBalances {
+	balances := portfolio.CashBalances{portfolio.BaseCurrency: base}
+	for currency, amount := range foreign {
+		balances[currency] = amount
+	}
+	return balances
+}
+
+func (r *valuationReplay) state() JournalState {
+	state := JournalState{
+		Position:     r.position,
+		Cash:         portfolio.CashBalances{},
+		ReservedCash: portfolio.CashBalances{},
+		Holdings:     map[string]decimal.Decimal{},
+		Prices:       map[string]decimal.Decimal{},
+		Currencies:   map[string]portfolio.Currency{},
+		Flows:        portfolio.CashBalances{},
+	}
+	for currency, amount := range r.cash {
+		state.Cash[currency] = amount
+	}
+	for currency, amount := range r.reservedCash {
+		state.ReservedCash[currency] = amount
+	}
+	for currency, amount := range r.totals {
+		state.Flows[currency] = amount
+	}
+	for symbol, quantity := range r.quantities {
+		if quantity.IsPositive() {
+			state.Holdings[symbol] = quantity
+			state.Prices[symbol] = r.marks[symbol]
+			state.Currencies[symbol] = r.currencies[symbol]
+		}
+	}
+	return state
+}
+
+func (r *valuationReplay) closeDay() {
+	r.closes = append(r.closes, dayClose{date: r.current, state: r.state(), flows: r.flows})
+	r.flows = portfolio.CashBalances{}
+}
+
+// history spreads the closed days over every day of the period. Days are
+// valued in the base currency at the rates.
+func (r *valuationReplay) history(ctx context.Context, rates fx.RateSource, from time.Time, to time.Time) (History, error) {
+	days, err := r.days(ctx, rates, from)
+	if err != nil {
+		return History{}, err
+	}
+	return Spread(days, from, to), nil
+}
+
+// days values the closed days in the base currency at the rates, from the
+// latest one before from on.
+func (r *valuationReplay) days(ctx context.Context, rates fx.RateSource, from time.Time) ([]DailyValuation, error) {
+	if !r.current.IsZero() {
+		r.closeDay()
+		r.current = time.Time{}
+	}
+
+	first := 0
+	for first+1 < len(r.closes) && r.closes[first+1].date.Before(day(from)) {
+		first++
+	}
+	days := make([]DailyValuation, 0, len(r.closes)-first)
+	for _, close := range r.closes[first:] {
+		valuation := DailyValuation{Date: close.date, NetFlow: decimal.Zero}
+		var err error
+		if valuation.Value, err = close.state.Value(ctx, rates); err != nil {
+			return nil, err
+		}
+		for currency, flow := range close.flows {
+			converted, err := Convert(ctx, rates, flow, currency)
+			if err != nil {
+				return nil, err
+			}
+			valuation.NetFlow = valuation.NetFlow.Add(converted)
+		}
+		days = append(days, valuation)
+	}
+	return days, nil
+}
+
+// Spread lays valuations, oldest first, over every day of a period, carrying
+// the last known value over days without one. The latest valuation before the
+// period is the value it starts from, zero without one.
+func Spread(valuations []DailyValuation, from time.Time, to time.Time) History {
+	from, to = day(from), day(to)
+	history := History{StartValue: decimal.Zero, Days: []DailyValuation{}}
+	next := 0
+	for next < len(valuations) && day(valuations[next].Date).Before(from) {
+		history.StartValue = valuations[next].Value
+		next++
+	}
+
+	value := history.StartValue
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		valuation := DailyValuation{Date: date, Value: value, NetFlow: decimal.Zero}
+		if next < len(valuations) && day(valuations[next].Date).Equal(date) {
+			valuation.Value = valuations[next].Value
+			valuation.NetFlow = valuations[next].NetFlow
+			next++
+		}
+		value = valuation.Value
+		history.Days = append(history.Days, valuation)
+	}
+	return history
+}
+
+func day(instant time.Time) time.Time {
+	return instant.UTC().Truncate(24 * time.Hour)
+}
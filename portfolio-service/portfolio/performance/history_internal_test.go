@@ -0,0 +1,201 @@
+package performance
+
+import (
+	"context"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+var rates = fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{
+	"EUR": decimal.RequireFromString("0.8"),
+})
+
+func journalEvent(name string, timestamp time.Time, payload map[string]any) common.IntegrationEventEntity {
+	return common.IntegrationEventEntity{
+		Id:        uuid.NewString(),
+		Timestamp: timestamp,
+		Name:      name,
+		Version:   1,
+		EventData: datatypes.JSONMap(payload),
+	}
+}
+
+func balance(cash string, reservedCash string) map[string]any {
+	return map[string]any{"cash": cash, "reservedCash": reservedCash, "holdingsCount": 0, "bookValue": "0"}
+}
+
+func TestValuationReplay(t *testing.T) {
+	at := func(day int, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC)
+	}
+	events := []common.IntegrationEventEntity{
+		journalEvent("portfolio-opened", at(1, 9), map[string]any{"portfolioId": "a-portfolio", "name": "A portfolio"}),
+		journalEvent("funds-received", at(1, 10), map[string]any{"portfolioId": "a-portfolio", "amount": "1000", "balance": balance("1000", "0")}),
+		journalEvent("order-placed", at(3, 10), map[string]any{"portfolioId": "a-portfolio", "balance": balance("800", "200")}),
+		journalEvent("trade-processed", at(3, 11), map[string]any{"portfolioId": "a-portfolio", "symbol": "ACME", "price": "20", "quantity": 10, "holdingQuantity": 10, "balance": balance("800", "0")}),
+		journalEvent("funds-sent", at(4, 10), map[string]any{"portfolioId": "a-portfolio", "amount": "100", "balance": balance("700", "0")}),
+		journalEvent("trade-processed", at(6, 11), map[string]any{"portfolioId": "a-portfolio", "symbol": "ACME", "price": "25", "quantity": 5, "holdingQuantity": 5, "balance": balance("825", "0")}),
+	}
+
+	replay := newValuationReplay()
+	for _, event := range events {
+		assert.NoError(t, replay.apply(event))
+	}
+
+	state := replay.state()
+	assert.Equal(t, "825", state.Cash[portfolio.BaseCurrency].String())
+	assert.Equal(t, "5", state.Holdings["ACME"].String())
+	value, err := state.Value(context.Background(), rates)
+	assert.NoError(t, err)
+	assert.Equal(t, "950", value.String())
+
+	history, err := replay.history(context.Background(), rates, at(2, 0), at(5, 23))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "1000", history.StartValue.String())
+	values, flows := []string{}, []string{}
+	for _, day := range history.Days {
+		values = append(values, day.Value.String())
+		flows = append(flows, day.NetFlow.String())
+	}
+	// Day 3 values the shares at the price they were bought at, day 4 sends 100
+	// away, and day 6 is after the period.
+	assert.Equal(t, []string{"1000", "1000", "900", "900"}, values)
+	assert.Equal(t, []string{"0", "0", "-100", "0"}, flows)
+	assert.Equal(t, at(2, 0), history.Days[0].Date)
+}
+
+func TestValuationReplayOfCorporateActions(t *testing.T) {
+	at := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	events := []common.IntegrationEventEntity{
+		journalEvent("funds-received", at, map[string]any{"portfolioId": "a-portfolio", "amount": "1000", "balance": balance("1000", "0")}),
+		journalEvent("trade-processed", at, map[string]any{"portfolioId": "a-portfolio", "symbol": "ACME", "price": "20", "quantity": 10, "holdingQuantity": 10, "balance": balance("800", "0")}),
+		journalEvent("shares-split", at, map[string]any{"portfolioId": "a-portfolio", "symbol": "ACME", "numerator": 4, "denominator": 1, "previousQuantity": 10, "quantity": 40, "balance": balance("800", "0")}),
+		journalEvent("dividend-received", at, map[string]any{"portfolioId": "a-portfolio", "symbol": "ACME", "quantity": 40, "amount": "10", "balance": balance("810", "0")}),
+	}
+
+	replay := newValuationReplay()
+	for _, event := range events {
+		assert.NoError(t, replay.apply(event))
+	}
+
+	// The split leaves the value as it was, and the dividend adds to it without
+	// being a flow.
+	state := replay.state()
+	assert.Equal(t, "40", state.Holdings["ACME"].String())
+	assert.Equal(t, "5", state.Prices["ACME"].String())
+	value, err := state.Value(context.Background(), rates)
+	assert.NoError(t, err)
+	assert.Equal(t, "1010", value.String())
+}
+
+func TestValuationReplayInOtherCurrencies(t *testing.T) {
+	at := func(day int) time.Time {
+		return time.Date(2026, 10, day, 10, 0, 0, 0, time.UTC)
+	}
+	foreign := func(cash string, reservedCash string) map[string]any {
+		payload := balance("0", "0")
+		payload["foreignCash"] = map[string]any{"EUR": cash}
+		if reservedCash != "0" {
+			payload["foreignReservedCash"] = map[string]any{"EUR": reservedCash}
+		}
+		return payload
+	}
+	events := []common.IntegrationEventEntity{
+		journalEvent("funds-received", at(1), map[string]any{"portfolioId": "a-portfolio", "amount": "800", "currency": "EUR", "balance": foreign("800", "0")}),
+		journalEvent("order-placed", at(2), map[string]any{"portfolioId": "a-portfolio", "currency": "EUR", "balance": foreign("600", "200")}),
+		journalEvent("trade-processed", at(2), map[string]any{"portfolioId": "a-portfolio", "symbol": "SAP", "price": "20", "currency": "EUR", "quantity": 10, "holdingQuantity": 10, "balance": foreign("600", "0")}),
+	}
+
+	replay := newValuationReplay()
+	for _, event := range events {
+		assert.NoError(t, replay.apply(event))
+	}
+
+	history, err := replay.history(context.Background(), rates, at(1), at(2))
+
+	// Euros are worth 1.25 dollars each, in cash, in flows and in shares.
+	if assert.NoError(t, err) && assert.Len(t, history.Days, 2) {
+		assert.Equal(t, "1000", history.Days[0].Value.String())
+		assert.Equal(t, "1000", history.Days[0].NetFlow.String())
+		assert.Equal(t, "1000", history.Days[1].Value.String())
+		assert.Equal(t, "0", history.Days[1].NetFlow.String())
+	}
+
+	_, err = replay.history(context.Background(), fx.NewRates(portfolio.BaseCurrency, nil), at(1), at(2))
+
+	assert.ErrorIs(t, err, fx.ErrRateNotFound)
+}
+
+func TestSeededValuationReplay(t *testing.T) {
+	at := func(day int, hour int) time.Time {
+		return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC)
+	}
+	seed := JournalState{
+		Position:     2,
+		Cash:         portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(800)},
+		ReservedCash: portfolio.CashBalances{},
+		Holdings:     map[string]decimal.Decimal{"ACME": decimal.NewFromInt(10)},
+		Prices:       map[string]decimal.Decimal{"ACME": decimal.NewFromInt(22)},
+		Currencies:   map[string]portfolio.Currency{"ACME": portfolio.BaseCurrency},
+		Flows:        portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(1000)},
+	}
+	events := []common.IntegrationEventEntity{
+		journalEvent("funds-received", at(1, 22), map[string]any{"portfolioId": "a-portfolio", "amount": "100", "balance": balance("900", "0")}),
+		journalEvent("funds-sent", at(3, 10), map[string]any{"portfolioId": "a-portfolio", "amount": "50", "balance": balance("850", "0")}),
+	}
+
+	replay := newSeededReplay(seed, at(1, 0))
+	for _, event := range events {
+		assert.NoError(t, replay.apply(event))
+	}
+
+	state := replay.state()
+	flow, err := state.FlowSince(context.Background(), rates, seed)
+	assert.NoError(t, err)
+	assert.Equal(t, "50", flow.String())
+	assert.Equal(t, "1050", state.Flows[portfolio.BaseCurrency].String())
+
+	days, err := replay.days(context.Background(), rates, time.Time{})
+
+	// Funds received after the close of the 1st count toward the 2nd, and the
+	// shares keep the price of the seed.
+	if assert.NoError(t, err) && assert.Len(t, days, 2) {
+		assert.Equal(t, at(2, 0), days[0].Date)
+		assert.Equal(t, "1120", days[0].Value.String())
+		assert.Equal(t, "100", days[0].NetFlow.String())
+		assert.Equal(t, at(3, 0), days[1].Date)
+		assert.Equal(t, "1070", days[1].Value.String())
+		assert.Equal(t, "-50", days[1].NetFlow.String())
+	}
+}
+
+func TestSpread(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+	}
+	valuations := []DailyValuation{
+		{Date: date(1), Value: decimal.NewFromInt(1000), NetFlow: decimal.NewFromInt(1000)},
+		{Date: date(2), Value: decimal.NewFromInt(1010), NetFlow: decimal.Zero},
+		{Date: date(4), Value: decimal.NewFromInt(1100), NetFlow: decimal.NewFromInt(100)},
+	}
+
+	history := Spread(valuations, date(2), date(5))
+
+	assert.Equal(t, "1000", history.StartValue.String())
+	values, flows := []string{}, []string{}
+	for _, day := range history.Days {
+		values = append(values, day.Value.String())
+		flows = append(flows, day.NetFlow.String())
+	}
+	assert.Equal(t, []string{"1010", "1010", "1100", "1100"}, values)
+	assert.Equal(t, []string{"0", "0", "100", "0"}, flows)
+}
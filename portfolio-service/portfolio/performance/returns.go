@@ -0,0 +1,138 @@
+package performance
+
+import (
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// returnPlaces is the precision returns are reported with, as fractions: 0.0125
+// is 1.25%.
+const returnPlaces = 6
+
+type DailyReturn struct {
+	Date             time.Time       `json:"date"`
+	Value            decimal.Decimal `json:"value"`
+	NetFlow          decimal.Decimal `json:"net_flow"`
+	Return           decimal.Decimal `json:"return"`
+	CumulativeReturn decimal.Decimal `json:"cumulative_return"`
+}
+
+// Performance compares the value of a portfolio at both ends of a period, with
+// deposits and withdrawals taken out in two ways. The time-weighted return
+// chains daily returns, so it does not depend on when cash came in. The
+// money-weighted return is the internal rate of return of the cash flows, so
+// it does; it is left empty when the flows have no rate.
+type Performance struct {
+	PortfolioId                   string              `json:"portfolio_id"`
+	From                          time.Time           `json:"from"`
+	To                            time.Time           `json:"to"`
+	StartValue                    decimal.Decimal     `json:"start_value"`
+	EndValue                      decimal.Decimal     `json:"end_value"`
+	NetFlows                      decimal.Decimal     `json:"net_flows"`
+	TimeWeightedReturn            decimal.Decimal     `json:"time_weighted_return"`
+	MoneyWeightedReturn           decimal.NullDecimal `json:"money_weighted_return"`
+	AnnualisedMoneyWeightedReturn decimal.NullDecimal `json:"annualised_money_weighted_return"`
+	Series                        []DailyReturn       `json:"series"`
+}
+
+// Measure computes the returns of a history. Flows are taken to happen at the
+// start of their day, so the return of a day is its closing value over the
+// previous closing value plus the day's net flow. Days starting from nothing
+// have no return.
+func Measure(portfolioId string, history History) Performance {
+	performance := Performance{
+		PortfolioId:        portfolioId,
+		StartValue:         history.StartValue,
+		EndValue:           history.StartValue,
+		NetFlows:           decimal.Zero,
+		TimeWeightedReturn: decimal.Zero,
+		Series:             []DailyReturn{},
+	}
+	if len(history.Days) == 0 {
+		return performance
+	}
+	performance.From = history.Days[0].Date
+	performance.To = history.Days[len(history.Days)-1].Date
+
+	one := decimal.NewFromInt(1)
+	growth, previous := one, history.StartValue
+	for _, day := range history.Days {
+		daily := DailyReturn{
+			Date:    day.Date,
+			Value:   day.Value,
+			NetFlow: day.NetFlow,
+			Return:  decimal.Zero,
+		}
+		if base := previous.Add(day.NetFlow); base.IsPositive() {
+			dayGrowth := day.Value.DivRound(base, 2*returnPlaces)
+			growth = growth.Mul(dayGrowth).Round(2 * returnPlaces)
+			daily.Return = dayGrowth.Sub(one).Round(returnPlaces)
+		}
+		daily.CumulativeReturn = growth.Sub(one).Round(returnPlaces)
+
+		performance.NetFlows = performance.NetFlows.Add(day.NetFlow)
+		performance.Series = append(performance.Series, daily)
+		previous = day.Value
+	}
+	performance.EndValue = previous
+	performance.TimeWeightedReturn = growth.Sub(one).Round(returnPlaces)
+
+	if rate, ok := dailyInternalRate(history); ok {
+		days := float64(len(history.Days))
+		performance.MoneyWeightedReturn = decimal.NewNullDecimal(decimal.NewFromFloat(math.Pow(1+rate, days) - 1).Round(returnPlaces))
+		performance.AnnualisedMoneyWeightedReturn = decimal.NewNullDecimal(decimal.NewFromFloat(math.Pow(1+rate, 365) - 1).Round(returnPlaces))
+	}
+	return performance
+}
+
+// dailyInternalRate finds the daily rate at which the start value and the
+// flows, compounded to the end of the period, are worth the end value.
+func dailyInternalRate(history History) (float64, bool) {
+	type flow struct {
+		amount float64
+		days   float64
+	}
+	end := float64(len(history.Days))
+	flows := []flow{{amount: history.StartValue.InexactFloat64(), days: end}}
+	for i, day := range history.Days {
+		if !day.NetFlow.IsZero() {
+			flows = append(flows, flow{amount: day.NetFlow.InexactFloat64(), days: end - float64(i)})
+		}
+	}
+	endValue := history.Days[len(history.Days)-1].Value.InexactFloat64()
+
+	invested := false
+	for _, f := range flows {
+		invested = invested || f.amount > 0
+	}
+	if !invested {
+		return 0, false
+	}
+
+	surplus := func(rate float64) float64 {
+		compounded := 0.0
+		for _, f := range flows {
+			compounded += f.amount * math.Pow(1+rate, f.days)
+		}
+		return endValue - compounded
+	}
+
+	// With deposits only, the surplus falls as the rate grows. Bisect between a
+	// near total loss and a 10% gain every day, as long as the surplus changes
+	// sign in between; higher rates overflow over long periods.
+	low, high := -0.99, 0.1
+	if surplus(low) < 0 || surplus(high) > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		middle := (low + high) / 2
+		if surplus(middle) > 0 {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2, true
+}
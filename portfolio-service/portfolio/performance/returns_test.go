@@ -0,0 +1,74 @@
+package performance_test
+
+import (
+	"stock-trader/portfolio-service/portfolio/performance"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func history(startValue int64, days ...[2]int64) performance.History {
+	history := performance.History{StartValue: decimal.NewFromInt(startValue)}
+	date := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	for _, day := range days {
+		history.Days = append(history.Days, performance.DailyValuation{
+			Date:    date,
+			Value:   decimal.NewFromInt(day[0]),
+			NetFlow: decimal.NewFromInt(day[1]),
+		})
+		date = date.AddDate(0, 0, 1)
+	}
+	return history
+}
+
+func TestMeasure(t *testing.T) {
+	t.Run("Time-weighted return ignores when deposits came in", func(t *testing.T) {
+		// Up 10% on the first day, flat on the second one with a large deposit.
+		result := performance.Measure("a-portfolio", history(100, [2]int64{110, 0}, [2]int64{1110, 1000}))
+
+		assert.Equal(t, "0.1", result.TimeWeightedReturn.String())
+		assert.Equal(t, "1000", result.NetFlows.String())
+		assert.Equal(t, "1110", result.EndValue.String())
+		if assert.Len(t, result.Series, 2) {
+			assert.Equal(t, "0.1", result.Series[0].Return.String())
+			assert.Equal(t, "0", result.Series[1].Return.String())
+			assert.Equal(t, "0.1", result.Series[1].CumulativeReturn.String())
+		}
+		if assert.True(t, result.MoneyWeightedReturn.Valid) {
+			assert.InDelta(t, 0.016724, result.MoneyWeightedReturn.Decimal.InexactFloat64(), 0.000002)
+		}
+	})
+
+	t.Run("Without flows both returns agree", func(t *testing.T) {
+		result := performance.Measure("a-portfolio", history(10000, [2]int64{10200, 0}, [2]int64{10404, 0}))
+
+		assert.Equal(t, "0.0404", result.TimeWeightedReturn.String())
+		assert.Equal(t, "0.0404", result.MoneyWeightedReturn.Decimal.String())
+		assert.True(t, result.AnnualisedMoneyWeightedReturn.Decimal.GreaterThan(decimal.NewFromInt(1000)))
+	})
+
+	t.Run("Days starting from nothing have no return", func(t *testing.T) {
+		result := performance.Measure("a-portfolio", history(0, [2]int64{0, 0}, [2]int64{100, 100}, [2]int64{105, 0}))
+
+		assert.Equal(t, "0", result.Series[0].Return.String())
+		assert.Equal(t, "0", result.Series[1].Return.String())
+		assert.Equal(t, "0.05", result.Series[2].Return.String())
+		assert.Equal(t, "0.05", result.TimeWeightedReturn.String())
+	})
+
+	t.Run("A portfolio that was never funded has no money-weighted return", func(t *testing.T) {
+		result := performance.Measure("a-portfolio", history(0, [2]int64{0, 0}))
+
+		assert.True(t, result.TimeWeightedReturn.IsZero())
+		assert.False(t, result.MoneyWeightedReturn.Valid)
+	})
+
+	t.Run("An empty history has no series", func(t *testing.T) {
+		result := performance.Measure("a-portfolio", performance.History{StartValue: decimal.Zero})
+
+		assert.Empty(t, result.Series)
+		assert.True(t, result.From.IsZero())
+	})
+}
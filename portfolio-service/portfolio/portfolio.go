@@ -3,19 +3,27 @@ package portfolio
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"stock-trader/portfolio-service/common"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type PortfolioId string
 
 type Portfolio struct {
-	domainEvents []common.DomainEvent
-	id           PortfolioId
-	name         string
+	domainEvents  []common.DomainEvent
+	id            PortfolioId
+	name          string
+	cash          CashBalances
+	holdings      map[string]Holding
+	pendingOrders map[OrderId]pendingOrder
+	loyalty       LoyaltyLevel
 }
 
 func OpenPortfolio(name string) (*Portfolio, error) {
@@ -26,14 +34,19 @@ func OpenPortfolio(name string) (*Portfolio, error) {
 	}
 
 	portfolio := &Portfolio{
-		id:   PortfolioId(uuid.NewString()),
-		name: trimmedName,
+		id:            PortfolioId(uuid.NewString()),
+		name:          trimmedName,
+		cash:          CashBalances{BaseCurrency: decimal.Zero},
+		holdings:      map[string]Holding{},
+		pendingOrders: map[OrderId]pendingOrder{},
+		loyalty:       Basic,
 	}
 
 	portfolio.domainEvents = append(portfolio.domainEvents, PortfolioOpened{
 		baseDomainEvent: common.NewBaseDomainEvent("portfolio-opened"),
 		portfolioId:     string(portfolio.id),
-	},)
+		name:            portfolio.name,
+	})
 
 	return portfolio, nil
 
@@ -47,6 +60,534 @@ func (p Portfolio) Name() string {
 	return p.name
 }
 
+// Cash is the cash in the base currency available to place new orders,
+// excluding what is reserved by pending buy orders.
+func (p Portfolio) Cash() decimal.Decimal {
+	return p.cash.in(BaseCurrency)
+}
+
+// CashIn is the cash in the currency available to place new orders.
+func (p Portfolio) CashIn(currency Currency) decimal.Decimal {
+	return p.cash.in(currency)
+}
+
+// CashBalances returns the available cash in every currency the portfolio
+// holds some of, always including the base currency.
+func (p Portfolio) CashBalances() CashBalances {
+	balances := CashBalances{}
+	for currency, amount := range p.cash {
+		balances[currency] = amount
+	}
+	return balances
+}
+
+func (p Portfolio) ReservedCash() decimal.Decimal {
+	return p.ReservedCashIn(BaseCurrency)
+}
+
+// ReservedCashBalances returns the cash reserved by pending orders in every
+// currency they reserve some in.
+func (p Portfolio) ReservedCashBalances() CashBalances {
+	balances := CashBalances{}
+	for _, order := range p.pendingOrders {
+		balances.add(order.Currency, order.reservedCash())
+	}
+	return balances
+}
+
+func (p Portfolio) ReservedCashIn(currency Currency) decimal.Decimal {
+	reserved := decimal.Zero
+	for _, order := range p.pendingOrders {
+		if order.Currency == currency {
+			reserved = reserved.Add(order.reservedCash())
+		}
+	}
+	return reserved
+}
+
+func (p Portfolio) Loyalty() LoyaltyLevel {
+	return p.loyalty
+}
+
+func (p Portfolio) Holdings() []Holding {
+	holdings := []Holding{}
+	for _, holding := range p.holdings {
+		holdings = append(holdings, holding)
+	}
+	sort.Slice(holdings, func(i, j int) bool { return holdings[i].Symbol < holdings[j].Symbol })
+	return holdings
+}
+
+func (p Portfolio) Holding(symbol string) (Holding, bool) {
+	holding, ok := p.holdings[symbol]
+	return holding, ok
+}
+
+// AvailableShares is the quantity of a holding that is not reserved by pending
+// sell orders.
+func (p Portfolio) AvailableShares(symbol string) decimal.Decimal {
+	available := p.holdings[symbol].Quantity
+	for _, order := range p.pendingOrders {
+		if order.Symbol == symbol {
+			available = available.Sub(order.reservedShares())
+		}
+	}
+	return available
+}
+
+// PendingOrder returns the order as it was placed, as long as the broker has
+// not finished with it.
+func (p Portfolio) PendingOrder(orderId OrderId) (OrderRequest, bool) {
+	order, ok := p.pendingOrders[orderId]
+	return order.request(), ok
+}
+
+func (p Portfolio) Balance() PortfolioBalance {
+	balance := PortfolioBalance{
+		Cash:                p.Cash(),
+		ReservedCash:        p.ReservedCash(),
+		ForeignCash:         p.cash.foreign(),
+		ForeignReservedCash: p.ReservedCashBalances().foreign(),
+		HoldingsCount:       len(p.holdings),
+	}
+	bookValue := p.ReservedCashBalances()
+	for currency, amount := range p.cash {
+		bookValue.add(currency, amount)
+	}
+	for _, holding := range p.holdings {
+		bookValue.add(holding.Currency, holding.Cost)
+	}
+	balance.BookValue = bookValue.in(BaseCurrency)
+	balance.ForeignBookValue = bookValue.foreign()
+	return balance
+}
+
+func (p *Portfolio) ReceiveFunds(amount decimal.Decimal) error {
+	return p.ReceiveFundsIn(BaseCurrency, amount)
+}
+
+// ReceiveFundsIn credits cash in the currency, which is kept apart from the
+// cash in other currencies.
+func (p *Portfolio) ReceiveFundsIn(currency Currency, amount decimal.Decimal) error {
+	return p.ReceiveFundsAt(currency, amount, time.Now())
+}
+
+// ReceiveFundsAt credits cash in the currency as of an earlier time, as when
+// history is imported.
+func (p *Portfolio) ReceiveFundsAt(currency Currency, amount decimal.Decimal, at time.Time) error {
+	if !amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+	currency, err := ParseCurrency(string(currency))
+	if err != nil {
+		return err
+	}
+
+	p.cash.add(currency, amount)
+
+	p.domainEvents = append(p.domainEvents, FundsReceived{
+		baseDomainEvent: common.NewBaseDomainEventAt("funds-received", at),
+		portfolioId:     string(p.id),
+		currency:        currency,
+		amount:          amount,
+		balance:         p.Balance(),
+	})
+
+	return nil
+}
+
+// SendFunds debits a wire transfer from the cash in the base currency available
+// to place orders.
+func (p *Portfolio) SendFunds(transferId string, amount decimal.Decimal) error {
+	return p.SendFundsAt(transferId, amount, time.Now())
+}
+
+// SendFundsAt debits a wire transfer as of an earlier time, as when history is
+// imported.
+func (p *Portfolio) SendFundsAt(transferId string, amount decimal.Decimal, at time.Time) error {
+	if !amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+	if p.Cash().LessThan(amount) {
+		return fmt.Errorf("%w: %s needed, %s available", ErrInsufficientFunds, amount.StringFixed(2), p.Cash().StringFixed(2))
+	}
+
+	p.cash.add(BaseCurrency, amount.Neg())
+
+	p.domainEvents = append(p.domainEvents, FundsSent{
+		baseDomainEvent: common.NewBaseDomainEventAt("funds-sent", at),
+		portfolioId:     string(p.id),
+		transferId:      transferId,
+		amount:          amount,
+		balance:         p.Balance(),
+	})
+
+	return nil
+}
+
+// AcceptRefund credits back a wire transfer the receiver could not take.
+func (p *Portfolio) AcceptRefund(transferId string, amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+
+	p.cash.add(BaseCurrency, amount)
+
+	p.domainEvents = append(p.domainEvents, RefundAccepted{
+		baseDomainEvent: common.NewBaseDomainEvent("refund-accepted"),
+		portfolioId:     string(p.id),
+		transferId:      transferId,
+		amount:          amount,
+		balance:         p.Balance(),
+	})
+
+	return nil
+}
+
+// PlaceOrder reserves what the order needs until the broker fills or fails it:
+// cash at limit price for buy orders, shares for sell orders, and cash for the
+// commission of the portfolio's loyalty level in both cases. Cash is reserved
+// in the listing currency of the symbol, which is the currency of the order.
+// The quantity must be a multiple of the share increment of the symbol.
+func (p *Portfolio) PlaceOrder(orderId OrderId, request OrderRequest, program LoyaltyProgram, precision SharePrecision) error {
+	return p.placeOrder(orderId, request, program.Commission(p.loyalty), precision, time.Now())
+}
+
+func (p *Portfolio) placeOrder(orderId OrderId, request OrderRequest, commission decimal.Decimal, precision SharePrecision, at time.Time) error {
+	request, err := request.Validate()
+	if err != nil {
+		return err
+	}
+	places := precision.Places(request.Symbol)
+	if err := validateQuantity(request.Symbol, request.Quantity, places); err != nil {
+		return err
+	}
+
+	if _, ok := p.pendingOrders[orderId]; ok {
+		return fmt.Errorf("order %s was already placed", orderId)
+	}
+
+	order := pendingOrder{
+		Id:           orderId,
+		Symbol:       request.Symbol,
+		Side:         request.Side,
+		Type:         request.Type,
+		TimeInForce:  request.TimeInForce,
+		Quantity:     request.Quantity,
+		Precision:    places,
+		LimitPrice:   request.LimitPrice,
+		StopPrice:    request.StopPrice,
+		ReservePrice: request.ReservePrice,
+		Currency:     request.Currency,
+		Commission:   commission,
+	}
+
+	if holding, ok := p.holdings[order.Symbol]; ok && holding.Currency != order.Currency {
+		return fmt.Errorf("%w: %s is held in %s, not %s", ErrCurrencyMismatch, order.Symbol, holding.Currency, order.Currency)
+	}
+
+	if order.Side == Sell {
+		if available := p.AvailableShares(order.Symbol); available.LessThan(order.Quantity) {
+			return fmt.Errorf("%w: %s %s needed, %s available", ErrInsufficientShares, order.Quantity, order.Symbol, available)
+		}
+	}
+	if available := p.cash.in(order.Currency); available.LessThan(order.reservedCash()) {
+		return fmt.Errorf("%w: %s %s needed, %s available", ErrInsufficientFunds, order.reservedCash().StringFixed(2), order.Currency, available.StringFixed(2))
+	}
+	p.cash.add(order.Currency, order.reservedCash().Neg())
+
+	p.pendingOrders[orderId] = order
+
+	p.domainEvents = append(p.domainEvents, OrderPlaced{
+		baseDomainEvent: common.NewBaseDomainEventAt("order-placed", at),
+		portfolioId:     string(p.id),
+		orderId:         string(orderId),
+		symbol:          order.Symbol,
+		side:            order.Side,
+		orderType:       order.Type,
+		timeInForce:     order.TimeInForce,
+		quantity:        order.Quantity,
+		limitPrice:      order.LimitPrice,
+		stopPrice:       order.StopPrice,
+		currency:        order.Currency,
+		commission:      order.Commission,
+		balance:         p.Balance(),
+	})
+
+	return nil
+}
+
+// RejectOrder records an order the risk checks refused, along with their
+// reasons, so that refused attempts can be audited. Nothing is reserved for it.
+func (p *Portfolio) RejectOrder(orderId OrderId, request OrderRequest, reasons []RejectionReason) error {
+	request, err := request.Validate()
+	if err != nil {
+		return err
+	}
+	if len(reasons) == 0 {
+		return errors.New("an order can only be rejected for a reason")
+	}
+
+	p.domainEvents = append(p.domainEvents, OrderRejected{
+		baseDomainEvent: common.NewBaseDomainEvent("order-rejected"),
+		portfolioId:     string(p.id),
+		orderId:         string(orderId),
+		symbol:          request.Symbol,
+		side:            request.Side,
+		quantity:        request.Quantity,
+		limitPrice:      request.LimitPrice,
+		currency:        request.Currency,
+		reasons:         append([]RejectionReason{}, reasons...),
+	})
+
+	return nil
+}
+
+// ProcessTrade settles a fill of a pending order. Buy fills below the limit
+// price give the difference back to the available cash, while fills beyond the
+// limit price, which the reservation does not cover, are refused. Fills of
+// market and stop orders, which have no limit price, take what they cost beyond
+// their reserve price from the available cash. The first fill charges the
+// commission reserved by the order, and every fill may move the portfolio to
+// another loyalty level. Fills are rounded down to the share precision of
+// the order, and what they are worth to the precision of cash.
+func (p *Portfolio) ProcessTrade(orderId OrderId, tradeId string, quantity decimal.Decimal, price decimal.Decimal, program LoyaltyProgram) error {
+	return p.processTrade(orderId, tradeId, quantity, price, program, time.Now())
+}
+
+// ProcessHistoricalTrade records a trade that was executed at an earlier time,
+// as when history is imported. The request is placed as an order and filled
+// whole at its limit price right away, charging the commission that was paid
+// rather than that of the loyalty level.
+func (p *Portfolio) ProcessHistoricalTrade(orderId OrderId, tradeId string, request OrderRequest, commission decimal.Decimal, executedAt time.Time, program LoyaltyProgram, precision SharePrecision) error {
+	if commission.IsNegative() {
+		return fmt.Errorf("%w: commission must not be negative", ErrInvalidTrade)
+	}
+	if err := p.placeOrder(orderId, request, commission, precision, executedAt); err != nil {
+		return err
+	}
+	return p.processTrade(orderId, tradeId, request.Quantity, request.LimitPrice, program, executedAt)
+}
+
+func (p *Portfolio) processTrade(orderId OrderId, tradeId string, quantity decimal.Decimal, price decimal.Decimal, program LoyaltyProgram, at time.Time) error {
+	order, ok := p.pendingOrders[orderId]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrOrderNotFound, orderId)
+	}
+	quantity = quantity.RoundFloor(order.Precision)
+	if !quantity.IsPositive() || quantity.GreaterThan(order.unfilledQuantity()) {
+		return fmt.Errorf("%w: trade quantity must be between %s and %s", ErrInvalidQuantity, ShareIncrement(order.Precision), order.unfilledQuantity())
+	}
+	if !price.IsPositive() {
+		return fmt.Errorf("%w: trade price must be greater than zero", ErrInvalidTrade)
+	}
+	limited := order.LimitPrice.IsPositive()
+	if limited && (order.Side == Buy && price.GreaterThan(order.LimitPrice) || order.Side == Sell && price.LessThan(order.LimitPrice)) {
+		return fmt.Errorf("%w: %s fill at %s is beyond the limit price of %s", ErrInvalidTrade, order.Side, price, order.LimitPrice)
+	}
+
+	commission := decimal.Zero
+	if order.FilledQuantity.IsZero() {
+		commission = commission.Add(order.Commission)
+	}
+
+	// What the order no longer needs reserved goes back to cash, less the
+	// commission it charges.
+	reserved := order.reservedCash()
+	order.FilledQuantity = order.FilledQuantity.Add(quantity)
+	released := reserved.Sub(order.reservedCash()).Sub(commission)
+
+	value := roundCash(price.Mul(quantity))
+	holding, ok := p.holdings[order.Symbol]
+	if !ok {
+		holding = Holding{Symbol: order.Symbol, Currency: order.Currency, Quantity: decimal.Zero, Cost: decimal.Zero}
+	}
+	switch order.Side {
+	case Buy:
+		// Fills of orders without a limit price may cost more than their
+		// reservation, as long as the available cash covers the difference.
+		if available := p.cash.in(order.Currency); !limited && available.Add(released).LessThan(value) {
+			return fmt.Errorf("%w: %s %s fill costs %s %s, %s available", ErrInsufficientFunds, quantity, order.Symbol, value.StringFixed(2), order.Currency, available.Add(released).StringFixed(2))
+		}
+		holding = holding.bought(quantity, value, order.Precision)
+		p.cash.add(order.Currency, released.Sub(value))
+	case Sell:
+		var err error
+		if holding, err = holding.sold(quantity); err != nil {
+			return err
+		}
+		p.cash.add(order.Currency, released.Add(value))
+	}
+
+	holdingQuantity := holding.Quantity
+	if holdingQuantity.IsZero() {
+		delete(p.holdings, order.Symbol)
+	} else {
+		p.holdings[order.Symbol] = holding
+	}
+
+	if order.unfilledQuantity().IsZero() {
+		delete(p.pendingOrders, orderId)
+	} else {
+		p.pendingOrders[orderId] = order
+	}
+
+	p.domainEvents = append(p.domainEvents, TradeProcessed{
+		baseDomainEvent: common.NewBaseDomainEventAt("trade-processed", at),
+		portfolioId:     string(p.id),
+		orderId:         string(orderId),
+		tradeId:         tradeId,
+		symbol:          order.Symbol,
+		side:            order.Side,
+		quantity:        quantity,
+		price:           price,
+		currency:        order.Currency,
+		commission:      commission,
+		holdingQuantity: holdingQuantity,
+		balance:         p.Balance(),
+	})
+
+	p.updateLoyalty(program, at)
+
+	return nil
+}
+
+// updateLoyalty earns the loyalty level on the value of the portfolio, its cash
+// whether reserved or not and its holdings.
+func (p *Portfolio) updateLoyalty(program LoyaltyProgram, at time.Time) {
+	cash := p.ReservedCashBalances()
+	for currency, amount := range p.cash {
+		cash.add(currency, amount)
+	}
+	level := program.Level(program.worth(cash, p.holdings))
+	if level == p.loyalty {
+		return
+	}
+
+	previous := p.loyalty
+	p.loyalty = level
+
+	p.domainEvents = append(p.domainEvents, LoyaltyLevelChanged{
+		baseDomainEvent: common.NewBaseDomainEventAt("loyalty-level-changed", at),
+		portfolioId:     string(p.id),
+		previousLevel:   previous,
+		level:           level,
+		commission:      program.Commission(level),
+	})
+}
+
+// AcknowledgeOrderFailure releases whatever the unfilled part of a pending
+// order still reserves.
+func (p *Portfolio) AcknowledgeOrderFailure(orderId OrderId, reason string) error {
+	order, ok := p.pendingOrders[orderId]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrOrderNotFound, orderId)
+	}
+
+	p.cash.add(order.Currency, order.reservedCash())
+	delete(p.pendingOrders, orderId)
+
+	p.domainEvents = append(p.domainEvents, OrderFailureAcknowledged{
+		baseDomainEvent:  common.NewBaseDomainEvent("order-failure-acknowledged"),
+		portfolioId:      string(p.id),
+		orderId:          string(orderId),
+		symbol:           order.Symbol,
+		side:             order.Side,
+		unfilledQuantity: order.unfilledQuantity(),
+		reason:           reason,
+		balance:          p.Balance(),
+	})
+
+	return nil
+}
+
+// HeldShares is what the portfolio holds of the symbol right now.
+func (p Portfolio) HeldShares(symbol string) HeldShares {
+	holding, ok := p.holdings[symbol]
+	if !ok {
+		return HeldShares{Quantity: decimal.Zero, Currency: BaseCurrency}
+	}
+	return HeldShares{Quantity: holding.Quantity, Currency: holding.Currency}
+}
+
+// SplitShares turns every denominator shares of the symbol the portfolio held
+// when the split went ex into numerator shares, keeping what was paid for the
+// holding. Shares traded since then were traded at the split quantities already
+// and are left as they are. Fractions of a share finer than the precision of the
+// holding are dropped. Pending orders are cancelled by the corporate action, not
+// rescaled here. Portfolios that held none of the symbol are left as they are.
+func (p *Portfolio) SplitShares(actionId string, symbol string, numerator int64, denominator int64, held HeldShares) error {
+	if numerator <= 0 || denominator <= 0 || numerator == denominator {
+		return errors.New("split ratio must be two different positive numbers")
+	}
+	if !held.Quantity.IsPositive() {
+		return nil
+	}
+
+	holding, ok := p.holdings[symbol]
+	if !ok {
+		holding = Holding{Symbol: symbol, Currency: held.Currency, Quantity: decimal.Zero, Precision: decimalPlaces(held.Quantity), Cost: decimal.Zero}
+	}
+
+	previousQuantity := holding.Quantity
+	split, _ := held.Quantity.Mul(decimal.NewFromInt(numerator)).QuoRem(decimal.NewFromInt(denominator), holding.Precision)
+	quantity := previousQuantity.Sub(held.Quantity).Add(split)
+	if quantity.IsNegative() {
+		return fmt.Errorf("%w: %s %s held, %s split into %s", ErrInsufficientShares, previousQuantity, symbol, held.Quantity, split)
+	}
+	holding.Quantity = quantity
+	if holding.Quantity.IsZero() {
+		delete(p.holdings, symbol)
+	} else {
+		p.holdings[symbol] = holding
+	}
+
+	p.domainEvents = append(p.domainEvents, SharesSplit{
+		baseDomainEvent:  common.NewBaseDomainEvent("shares-split"),
+		portfolioId:      string(p.id),
+		actionId:         actionId,
+		symbol:           symbol,
+		numerator:        numerator,
+		denominator:      denominator,
+		previousQuantity: previousQuantity,
+		quantity:         holding.Quantity,
+		precision:        holding.Precision,
+		balance:          p.Balance(),
+	})
+
+	return nil
+}
+
+// ReceiveDividend credits a cash dividend for every share of the symbol the
+// portfolio held when the dividend went ex, in the currency they were held in,
+// whether or not the portfolio still holds them. Portfolios that held none of
+// the symbol are left as they are.
+func (p *Portfolio) ReceiveDividend(actionId string, symbol string, amountPerShare decimal.Decimal, held HeldShares) error {
+	if !amountPerShare.IsPositive() {
+		return errors.New("dividend per share must be greater than zero")
+	}
+	if !held.Quantity.IsPositive() {
+		return nil
+	}
+
+	amount := roundCash(amountPerShare.Mul(held.Quantity))
+	p.cash.add(held.Currency, amount)
+
+	p.domainEvents = append(p.domainEvents, DividendReceived{
+		baseDomainEvent: common.NewBaseDomainEvent("dividend-received"),
+		portfolioId:     string(p.id),
+		actionId:        actionId,
+		symbol:          symbol,
+		quantity:        held.Quantity,
+		amountPerShare:  amountPerShare,
+		currency:        held.Currency,
+		amount:          amount,
+		balance:         p.Balance(),
+	})
+
+	return nil
+}
+
 func (p Portfolio) DomainEvents() []common.DomainEvent {
 	output := []common.DomainEvent{}
 	for _, value := range p.domainEvents {
@@ -64,3 +605,8 @@ type PortfolioRepository interface {
 	Save(context.Context, *Portfolio) error
 	FindByName(context.Context, string) (*Portfolio, error)
 }
+
+// PortfolioReader loads portfolios that are only read, such as to value them.
+type PortfolioReader interface {
+	FindById(context.Context, PortfolioId) (*Portfolio, error)
+}
@@ -4,16 +4,24 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"stock-trader/portfolio-service/common"
 	"strings"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type portfolioEntity struct {
-	Id   string `gorm:"column:id"`
-	Name string `gorm:"column:name"`
+	Id            string                             `gorm:"column:id"`
+	Name          string                             `gorm:"column:name"`
+	Cash          decimal.Decimal                    `gorm:"column:cash"`
+	ForeignCash   datatypes.JSONType[CashBalances]   `gorm:"column:foreign_cash"`
+	Holdings      datatypes.JSONType[[]Holding]      `gorm:"column:holdings"`
+	PendingOrders datatypes.JSONType[[]pendingOrder] `gorm:"column:pending_orders"`
+	Loyalty       string                             `gorm:"column:loyalty"`
 }
 
 func (portfolioEntity) TableName() string {
@@ -25,24 +33,82 @@ func mapPortfolioEntity(portfolio *Portfolio) (*portfolioEntity, error) {
 		return nil, errors.New("portfolio cannot be nil")
 	}
 
+	pendingOrders := []pendingOrder{}
+	for _, order := range portfolio.pendingOrders {
+		pendingOrders = append(pendingOrders, order)
+	}
+	sort.Slice(pendingOrders, func(i, j int) bool { return pendingOrders[i].Id < pendingOrders[j].Id })
+
 	return &portfolioEntity{
-		Id:   string(portfolio.id),
-		Name: portfolio.name,
+		Id:            string(portfolio.id),
+		Name:          portfolio.name,
+		Cash:          portfolio.Cash(),
+		ForeignCash:   datatypes.NewJSONType(portfolio.cash.foreign()),
+		Holdings:      datatypes.NewJSONType(portfolio.Holdings()),
+		PendingOrders: datatypes.NewJSONType(pendingOrders),
+		Loyalty:       string(portfolio.loyalty),
 	}, nil
 }
 
+func mapPortfolio(entity *portfolioEntity) *Portfolio {
+	portfolio := &Portfolio{
+		id:            PortfolioId(entity.Id),
+		name:          entity.Name,
+		cash:          CashBalances{BaseCurrency: entity.Cash},
+		holdings:      map[string]Holding{},
+		pendingOrders: map[OrderId]pendingOrder{},
+		loyalty:       LoyaltyLevel(entity.Loyalty),
+	}
+	for currency, amount := range entity.ForeignCash.Data() {
+		portfolio.cash[currency] = amount
+	}
+	// Holdings and orders from before cash was kept per currency are in the
+	// base currency.
+	for _, holding := range entity.Holdings.Data() {
+		if holding.Currency == "" {
+			holding.Currency = BaseCurrency
+		}
+		portfolio.holdings[holding.Symbol] = holding
+	}
+	for _, order := range entity.PendingOrders.Data() {
+		if order.Currency == "" {
+			order.Currency = BaseCurrency
+		}
+		portfolio.pendingOrders[order.Id] = order
+	}
+	return portfolio
+}
+
 type mySQLPortfolioRepository struct {
-	db *gorm.DB
+	db         *gorm.DB
+	dispatcher *common.DomainEventDispatcher
+	translator *common.IntegrationEventTranslator
 }
 
-func NewPortfolioRepository(db *gorm.DB) PortfolioRepository {
+func NewPortfolioRepository(db *gorm.DB, dispatcher *common.DomainEventDispatcher) PortfolioRepository {
 	return &mySQLPortfolioRepository{
-		db: db,
+		db:         db,
+		dispatcher: dispatcher,
+		translator: NewIntegrationEventTranslator(),
 	}
 }
 
+// FindById locks the portfolio until the surrounding transaction ends, since it
+// is only loaded to be changed.
 func (r *mySQLPortfolioRepository) FindById(ctx context.Context, portfolioId PortfolioId) (*Portfolio, error) {
-	return nil, nil
+	return findPortfolio(r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), portfolioId)
+}
+
+func findPortfolio(db *gorm.DB, portfolioId PortfolioId) (*Portfolio, error) {
+	entity := &portfolioEntity{}
+	result := db.Where("id = ?", string(portfolioId)).First(entity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrPortfolioNotFound, portfolioId)
+		}
+		return nil, result.Error
+	}
+	return mapPortfolio(entity), nil
 }
 
 func (r *mySQLPortfolioRepository) Save(ctx context.Context, portfolio *Portfolio) error {
@@ -68,15 +134,17 @@ func (r *mySQLPortfolioRepository) Save(ctx context.Context, portfolio *Portfoli
 			}
 		}
 
-		for _, domainEvent := range portfolio.domainEvents {
-			event := &common.DomainEventEntity{
-				Id:        domainEvent.Id(),
-				Timestamp: domainEvent.Timestamp(),
-				Name:      domainEvent.Name(),
-				EventData: datatypes.JSONMap(domainEvent.EventData()),
-			}
+		if err := r.dispatcher.Dispatch(ctx, tx, portfolio.domainEvents); err != nil {
+			return err
+		}
+
+		integrationEvents, err := r.translator.Translate(portfolio.domainEvents)
+		if err != nil {
+			return err
+		}
 
-			if err := tx.Create(event).Error; err != nil {
+		for _, integrationEvent := range integrationEvents {
+			if err := tx.Create(common.NewIntegrationEventEntity(integrationEvent)).Error; err != nil {
 				return err
 			}
 		}
@@ -89,7 +157,31 @@ func (r *mySQLPortfolioRepository) Save(ctx context.Context, portfolio *Portfoli
 }
 
 func (r *mySQLPortfolioRepository) FindByName(ctx context.Context, portfolioName string) (*Portfolio, error) {
-	return nil, nil
+	entity := &portfolioEntity{}
+	result := r.db.WithContext(ctx).Where("name = ?", portfolioName).First(entity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrPortfolioNotFound, portfolioName)
+		}
+		return nil, result.Error
+	}
+	return mapPortfolio(entity), nil
+}
+
+type mySQLPortfolioReader struct {
+	db *gorm.DB
+}
+
+func NewPortfolioReader(db *gorm.DB) PortfolioReader {
+	return &mySQLPortfolioReader{
+		db: db,
+	}
+}
+
+// FindById reads the portfolio as last committed, without locking it, so that
+// reading does not hold up orders being placed.
+func (r *mySQLPortfolioReader) FindById(ctx context.Context, portfolioId PortfolioId) (*Portfolio, error) {
+	return findPortfolio(r.db.WithContext(ctx), portfolioId)
 }
 
 func isDuplicatePortfolioNameError(err error) bool {
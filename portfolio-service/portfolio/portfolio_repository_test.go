@@ -3,6 +3,7 @@ package portfolio_test
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"stock-trader/portfolio-service/common"
 	"stock-trader/portfolio-service/infrastructure"
@@ -13,40 +14,76 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
+func TestPortfolioReader(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+	repo := portfolio.NewPortfolioRepository(db, common.NewDomainEventDispatcher())
+	reader := portfolio.NewPortfolioReader(db)
+
+	t.Run("reads a portfolio locked by another transaction", func(t *testing.T) {
+		saved, _ := portfolio.OpenPortfolio(fmt.Sprintf(`portfolio-%s-%s`, randomString(), randomString()))
+		if !assert.NoError(t, repo.Save(context.Background(), saved)) {
+			return
+		}
+
+		db.Transaction(func(tx *gorm.DB) error {
+			portfolio.NewPortfolioRepository(tx, common.NewDomainEventDispatcher()).FindById(context.Background(), saved.Id())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			read, err := reader.FindById(ctx, saved.Id())
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, saved.Id(), read.Id())
+			}
+			return nil
+		})
+	})
+
+	t.Run("does not find unknown portfolios", func(t *testing.T) {
+		_, err := reader.FindById(context.Background(), portfolio.PortfolioId(uuid.NewString()))
+
+		assert.ErrorIs(t, err, portfolio.ErrPortfolioNotFound)
+	})
+}
+
 func TestSavePortfolio(t *testing.T) {
 	db, _ := infrastructure.ConnectDB()
 
-	repo := portfolio.NewPortfolioRepository(db)
+	dispatcher := common.NewDomainEventDispatcher()
+	repo := portfolio.NewPortfolioRepository(db, dispatcher)
 
-	t.Run("given a portfolio should save with domain events", func(t *testing.T) {
+	t.Run("given a portfolio should save with integration events", func(t *testing.T) {
 		portfolioName := fmt.Sprintf(`portfolio-%s-%s`, randomString(), randomString())
-		portfolio, _ := portfolio.OpenPortfolio(portfolioName)
-		domainEvents := portfolio.DomainEvents()
+		newPortfolio, _ := portfolio.OpenPortfolio(portfolioName)
+		integrationEvents, _ := portfolio.NewIntegrationEventTranslator().Translate(newPortfolio.DomainEvents())
 
-		err := repo.Save(context.Background(), portfolio)
+		err := repo.Save(context.Background(), newPortfolio)
 
 		if assert.NoError(t, err) {
 			var savedPortfolio []map[string]any
-			result := db.Raw("SELECT id, name FROM portfolios WHERE id = ?", portfolio.Id()).Scan(&savedPortfolio)
+			result := db.Raw("SELECT id, name FROM portfolios WHERE id = ?", newPortfolio.Id()).Scan(&savedPortfolio)
 
 			if assert.True(t, result.RowsAffected == 1) {
 				assert.Equal(t, portfolioName, savedPortfolio[0]["name"])
-				assert.Equal(t, string(portfolio.Id()), savedPortfolio[0]["id"])
+				assert.Equal(t, string(newPortfolio.Id()), savedPortfolio[0]["id"])
 			}
-			
-			for _, event := range domainEvents {
-				var savedEvent common.DomainEventEntity
-				db.Raw("SELECT id, timestamp, name, event_data FROM event_journal WHERE id = ?", event.Id()).Scan(&savedEvent)
+
+			assert.Len(t, integrationEvents, 1)
+			for _, event := range integrationEvents {
+				var savedEvent common.IntegrationEventEntity
+				db.Raw("SELECT id, timestamp, name, version, event_data FROM event_journal WHERE id = ?", event.Id()).Scan(&savedEvent)
 				assert.Equal(t, event.Id(), savedEvent.Id)
 				assert.Equal(t, event.Timestamp().Format(time.RFC3339), savedEvent.Timestamp.Format(time.RFC3339))
 				assert.Equal(t, event.Name(), savedEvent.Name)
+				assert.Equal(t, event.Version(), savedEvent.Version)
 
 				b, _ := savedEvent.EventData.MarshalJSON()
 				var eventData map[string]any
 				json.Unmarshal(b, &eventData)
-				assert.Equal(t, event.EventData(), eventData)
+				assert.Equal(t, event.Payload(), eventData)
 			}
 		}
 	})
@@ -87,6 +124,27 @@ func TestSavePortfolio(t *testing.T) {
 		}
 	})
 
+	t.Run("given a failing domain event handler should return error and not save anything", func(t *testing.T) {
+		handlerErr := errors.New("handler failed")
+		failingDispatcher := common.NewDomainEventDispatcher()
+		failingDispatcher.Register("portfolio-opened", func(ctx context.Context, tx *gorm.DB, event common.DomainEvent) error {
+			return handlerErr
+		})
+		failingRepo := portfolio.NewPortfolioRepository(db, failingDispatcher)
+		newPortfolio, _ := portfolio.OpenPortfolio(fmt.Sprintf(`rolled-back-%s`, randomString()))
+		domainEvents := newPortfolio.DomainEvents()
+
+		err := failingRepo.Save(context.Background(), newPortfolio)
+
+		if assert.ErrorIs(t, err, handlerErr) {
+			result := db.Raw("SELECT * FROM portfolios WHERE id = ?", newPortfolio.Id()).Scan(&[]map[string]any{})
+			assert.True(t, result.RowsAffected == 0)
+
+			result = db.Raw("SELECT * FROM event_journal WHERE id = ?", domainEvents[0].Id()).Scan(&[]map[string]any{})
+			assert.True(t, result.RowsAffected == 0)
+		}
+	})
+
 	t.Run("given a portfolio already saved should update it", func(t *testing.T) {
 		//TODO
 	})
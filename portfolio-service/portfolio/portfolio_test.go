@@ -3,7 +3,9 @@ package portfolio_test
 import (
 	"stock-trader/portfolio-service/portfolio"
 	"testing"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,6 +20,7 @@ func TestOpenPortfolio(t *testing.T) {
 		assert.Equal(t, newPortfolio.Name(), "A Really Looong Portfolio Name")
 		assert.IsType(t, portfolio.PortfolioOpened{}, newPortfolio.DomainEvents()[0])
 		assert.Equal(t, string(newPortfolio.Id()), newPortfolio.DomainEvents()[0].(portfolio.PortfolioOpened).PortfolioId())
+		assert.Equal(t, "A Really Looong Portfolio Name", newPortfolio.DomainEvents()[0].(portfolio.PortfolioOpened).PortfolioName())
 	})
 
 	t.Run("Open Portfolio with empty name", func(t *testing.T) {
@@ -43,3 +46,840 @@ func TestOpenPortfolio(t *testing.T) {
 	})
 
 }
+
+func fundedPortfolio(t *testing.T, amount int64) *portfolio.Portfolio {
+	funded, _ := portfolio.OpenPortfolio("A portfolio name")
+	if !assert.NoError(t, funded.ReceiveFunds(decimal.NewFromInt(amount))) {
+		t.FailNow()
+	}
+	funded.ClearDomainEvents()
+	return funded
+}
+
+func buyOrder(symbol string, quantity int64, limitPrice int64) portfolio.OrderRequest {
+	return portfolio.OrderRequest{
+		Symbol:     symbol,
+		Side:       portfolio.Buy,
+		Quantity:   decimal.NewFromInt(quantity),
+		LimitPrice: decimal.NewFromInt(limitPrice),
+	}
+}
+
+func tieredLoyalty(t *testing.T) portfolio.LoyaltyProgram {
+	program, err := portfolio.ParseLoyaltyProgram("basic:0:10,bronze:500:5")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return program
+}
+
+func TestReceiveFunds(t *testing.T) {
+	t.Run("Receive funds successfully", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+
+		err := funded.ReceiveFunds(decimal.RequireFromString("50.25"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "150.25", funded.Cash().String())
+		if assert.IsType(t, portfolio.FundsReceived{}, funded.DomainEvents()[0]) {
+			event := funded.DomainEvents()[0].(portfolio.FundsReceived)
+			assert.Equal(t, "50.25", event.Amount().String())
+			assert.Equal(t, "150.25", event.Balance().Cash.String())
+		}
+	})
+
+	t.Run("Receive a negative amount", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+
+		err := funded.ReceiveFunds(decimal.NewFromInt(-1))
+
+		assert.EqualError(t, err, "amount must be greater than zero")
+		assert.Equal(t, "100", funded.Cash().String())
+		assert.Empty(t, funded.DomainEvents())
+	})
+
+	t.Run("Receive funds in another currency", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+
+		err := funded.ReceiveFundsIn("EUR", decimal.NewFromInt(50))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "100", funded.Cash().String())
+		assert.Equal(t, "50", funded.CashIn("EUR").String())
+		if assert.IsType(t, portfolio.FundsReceived{}, funded.DomainEvents()[0]) {
+			event := funded.DomainEvents()[0].(portfolio.FundsReceived)
+			assert.Equal(t, portfolio.Currency("EUR"), event.Currency())
+			assert.Equal(t, "50", event.Balance().ForeignCash["EUR"].String())
+			assert.Equal(t, "100", event.Balance().BookValue.String())
+		}
+	})
+}
+
+// euroPortfolio has 1000 in USD and 500 in EUR.
+func euroPortfolio(t *testing.T) *portfolio.Portfolio {
+	funded := fundedPortfolio(t, 1000)
+	if !assert.NoError(t, funded.ReceiveFundsIn("EUR", decimal.NewFromInt(500))) {
+		t.FailNow()
+	}
+	funded.ClearDomainEvents()
+	return funded
+}
+
+func euroBuyOrder(symbol string, quantity int64, limitPrice int64) portfolio.OrderRequest {
+	order := buyOrder(symbol, quantity, limitPrice)
+	order.Currency = "EUR"
+	return order
+}
+
+func TestForeignCurrencyOrders(t *testing.T) {
+	t.Run("A buy order in another currency reserves cash in that currency", func(t *testing.T) {
+		funded := euroPortfolio(t)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), euroBuyOrder("SAP", 2, 100), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", funded.Cash().String())
+		assert.Equal(t, "300", funded.CashIn("EUR").String())
+		assert.Equal(t, "200", funded.ReservedCashIn("EUR").String())
+	})
+
+	t.Run("A buy order without enough cash in its currency", func(t *testing.T) {
+		funded := euroPortfolio(t)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), euroBuyOrder("SAP", 6, 100), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientFunds)
+		assert.EqualError(t, err, "insufficient funds: 600.00 EUR needed, 500.00 available")
+		assert.Equal(t, "500", funded.CashIn("EUR").String())
+	})
+
+	t.Run("A trade settles in the currency of the order", func(t *testing.T) {
+		funded := euroPortfolio(t)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, euroBuyOrder("SAP", 2, 100), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(2), decimal.NewFromInt(90), portfolio.LoyaltyProgram{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "320", funded.CashIn("EUR").String())
+		assert.True(t, funded.ReservedCashIn("EUR").IsZero())
+		assert.Equal(t, []portfolio.Holding{{Symbol: "SAP", Currency: "EUR", Quantity: decimal.NewFromInt(2), Cost: decimal.NewFromInt(180)}}, funded.Holdings())
+		assert.Equal(t, "1000", funded.Balance().BookValue.String())
+	})
+
+	t.Run("An order in another currency than the holding", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		err := holder.PlaceOrder(portfolio.NewOrderId(), euroBuyOrder("ACME", 1, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrCurrencyMismatch)
+		assert.Empty(t, holder.DomainEvents())
+	})
+
+	t.Run("A dividend is paid in the currency of the holding", func(t *testing.T) {
+		funded := euroPortfolio(t)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, euroBuyOrder("SAP", 2, 100), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(2), decimal.NewFromInt(100), portfolio.LoyaltyProgram{})
+
+		err := funded.ReceiveDividend("action-1", "SAP", decimal.NewFromInt(3), funded.HeldShares("SAP"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "306", funded.CashIn("EUR").String())
+		assert.Equal(t, "1000", funded.Cash().String())
+	})
+
+	t.Run("Cash and holdings in another currency earn loyalty at the rates of the program", func(t *testing.T) {
+		program := tieredLoyalty(t).WithRates(func(currency portfolio.Currency) (decimal.Decimal, error) {
+			return decimal.NewFromInt(3), nil
+		})
+		for name, c := range map[string]struct {
+			program portfolio.LoyaltyProgram
+			level   portfolio.LoyaltyLevel
+		}{
+			"With rates":    {program, portfolio.Bronze},
+			"Without rates": {tieredLoyalty(t), portfolio.Basic},
+		} {
+			t.Run(name, func(t *testing.T) {
+				funded := fundedPortfolio(t, 100)
+				funded.ReceiveFundsIn("EUR", decimal.NewFromInt(300))
+				orderId := portfolio.NewOrderId()
+				funded.PlaceOrder(orderId, euroBuyOrder("SAP", 2, 100), c.program, portfolio.SharePrecision{})
+
+				err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(2), decimal.NewFromInt(100), c.program)
+
+				assert.NoError(t, err)
+				assert.Equal(t, c.level, funded.Loyalty())
+			})
+		}
+	})
+}
+
+func TestSendFunds(t *testing.T) {
+	t.Run("Send funds successfully", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+
+		err := funded.SendFunds("transfer-1", decimal.NewFromInt(40))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "60", funded.Cash().String())
+		if assert.IsType(t, portfolio.FundsSent{}, funded.DomainEvents()[0]) {
+			event := funded.DomainEvents()[0].(portfolio.FundsSent)
+			assert.Equal(t, "transfer-1", event.TransferId())
+			assert.Equal(t, "40", event.Amount().String())
+		}
+	})
+
+	t.Run("Send more funds than available", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+		funded.PlaceOrder(portfolio.NewOrderId(), buyOrder("ACME", 4, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.SendFunds("transfer-1", decimal.NewFromInt(40))
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientFunds)
+		assert.Equal(t, "20", funded.Cash().String())
+	})
+}
+
+func TestAcceptRefund(t *testing.T) {
+	t.Run("Accept refund of a sent transfer", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+		funded.SendFunds("transfer-1", decimal.NewFromInt(40))
+
+		err := funded.AcceptRefund("transfer-1", decimal.NewFromInt(40))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "100", funded.Cash().String())
+		assert.IsType(t, portfolio.RefundAccepted{}, funded.DomainEvents()[1])
+	})
+}
+
+func TestPlaceOrder(t *testing.T) {
+	t.Run("Place a buy order reserves cash at limit price", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+
+		err := funded.PlaceOrder(orderId, buyOrder(" acme ", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "800", funded.Cash().String())
+		assert.Equal(t, "200", funded.ReservedCash().String())
+		placed, ok := funded.PendingOrder(orderId)
+		assert.True(t, ok)
+		assert.Equal(t, "ACME", placed.Symbol)
+		assert.IsType(t, portfolio.OrderPlaced{}, funded.DomainEvents()[0])
+	})
+
+	t.Run("Place a buy order without enough cash", func(t *testing.T) {
+		funded := fundedPortfolio(t, 100)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientFunds)
+		assert.Equal(t, "100", funded.Cash().String())
+		assert.Empty(t, funded.DomainEvents())
+	})
+
+	t.Run("Place a sell order without enough shares", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		buyId := portfolio.NewOrderId()
+		funded.PlaceOrder(buyId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		funded.ProcessTrade(buyId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+		funded.PlaceOrder(portfolio.NewOrderId(), portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(6), LimitPrice: decimal.NewFromInt(25)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(5), LimitPrice: decimal.NewFromInt(25)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientShares)
+		assert.Equal(t, "4", funded.AvailableShares("ACME").String())
+	})
+
+	t.Run("Place an invalid order", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), buyOrder("ACME", 0, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.EqualError(t, err, "invalid order: order quantity must be greater than zero")
+	})
+
+	t.Run("Place orders missing the prices their type requires", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orders := map[string]portfolio.OrderRequest{
+			"limit orders require a limit price greater than zero":      {Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Limit, Quantity: decimal.NewFromInt(10)},
+			"market orders do not take a limit price":                   {Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Market, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)},
+			"stop orders require a stop price greater than zero":        {Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Stop, Quantity: decimal.NewFromInt(10), ReservePrice: decimal.NewFromInt(20)},
+			"stop-limit orders require a limit price greater than zero": {Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.StopLimit, Quantity: decimal.NewFromInt(10), StopPrice: decimal.NewFromInt(20)},
+			"market orders require a price to reserve cash at":          {Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Market, Quantity: decimal.NewFromInt(10)},
+		}
+
+		for message, order := range orders {
+			err := funded.PlaceOrder(portfolio.NewOrderId(), order, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+			assert.ErrorIs(t, err, portfolio.ErrInvalidOrder)
+			assert.EqualError(t, err, "invalid order: "+message)
+		}
+		assert.Equal(t, "1000", funded.Cash().String())
+	})
+
+	t.Run("Place a market buy order reserving cash at the reserve price", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+
+		err := funded.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Market, Quantity: decimal.NewFromInt(10), ReservePrice: decimal.NewFromInt(21)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "790", funded.Cash().String())
+			assert.Equal(t, "210", funded.ReservedCash().String())
+			order, _ := funded.PendingOrder(orderId)
+			assert.Equal(t, portfolio.Market, order.Type)
+			assert.True(t, order.LimitPrice.IsZero())
+		}
+	})
+}
+
+func TestProcessTrade(t *testing.T) {
+	t.Run("Process a market buy fill above the reserve price from available cash", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Market, Quantity: decimal.NewFromInt(10), ReservePrice: decimal.NewFromInt(21)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(22), portfolio.LoyaltyProgram{})
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "780", funded.Cash().String())
+			assert.True(t, funded.ReservedCash().IsZero())
+		}
+	})
+
+	t.Run("Process a market buy fill costing more than the cash", func(t *testing.T) {
+		funded := fundedPortfolio(t, 210)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Type: portfolio.Market, Quantity: decimal.NewFromInt(10), ReservePrice: decimal.NewFromInt(21)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(22), portfolio.LoyaltyProgram{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientFunds)
+		assert.Equal(t, "210", funded.ReservedCash().String())
+	})
+
+	t.Run("Process a buy fill below limit price", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(4), decimal.NewFromInt(18), portfolio.LoyaltyProgram{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "808", funded.Cash().String())
+		assert.Equal(t, "120", funded.ReservedCash().String())
+		holding, _ := funded.Holding("ACME")
+		assert.Equal(t, "4", holding.Quantity.String())
+		assert.Equal(t, "18", holding.AverageCost().String())
+		_, pending := funded.PendingOrder(orderId)
+		assert.True(t, pending)
+	})
+
+	t.Run("Process the last fill completes the order", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		assert.NoError(t, err)
+		_, pending := funded.PendingOrder(orderId)
+		assert.False(t, pending)
+		assert.True(t, funded.ReservedCash().IsZero())
+	})
+
+	t.Run("Process a sell fill credits cash and reduces the holding", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		buyId, sellId := portfolio.NewOrderId(), portfolio.NewOrderId()
+		funded.PlaceOrder(buyId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		funded.ProcessTrade(buyId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+		funded.PlaceOrder(sellId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(25)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(sellId, "trade-2", decimal.NewFromInt(10), decimal.NewFromInt(26), portfolio.LoyaltyProgram{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1060", funded.Cash().String())
+		_, held := funded.Holding("ACME")
+		assert.False(t, held)
+	})
+
+	t.Run("Process a sell fill of more shares than are held", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+		sellId := portfolio.NewOrderId()
+		holder.PlaceOrder(sellId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(25)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		holder.SplitShares("action-1", "ACME", 1, 2, holder.HeldShares("ACME"))
+
+		err := holder.ProcessTrade(sellId, "trade-2", decimal.NewFromInt(10), decimal.NewFromInt(25), portfolio.LoyaltyProgram{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientShares)
+		assert.EqualError(t, err, "insufficient shares: 10 ACME sold, 5 held")
+		assert.Equal(t, "800", holder.Cash().String())
+		assert.Equal(t, "5", holder.Holdings()[0].Quantity.String())
+	})
+
+	t.Run("Process a fill larger than the unfilled quantity", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(11), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		assert.EqualError(t, err, "invalid quantity: trade quantity must be between 1 and 10")
+	})
+
+	t.Run("Process fills beyond the limit price", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		buyId, sellId := portfolio.NewOrderId(), portfolio.NewOrderId()
+		funded.PlaceOrder(buyId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		funded.ProcessTrade(buyId, "trade-1", decimal.NewFromInt(5), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+		funded.PlaceOrder(sellId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(5), LimitPrice: decimal.NewFromInt(25)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		buyErr := funded.ProcessTrade(buyId, "trade-2", decimal.NewFromInt(5), decimal.RequireFromString("20.01"), portfolio.LoyaltyProgram{})
+		sellErr := funded.ProcessTrade(sellId, "trade-3", decimal.NewFromInt(5), decimal.RequireFromString("24.99"), portfolio.LoyaltyProgram{})
+
+		assert.ErrorIs(t, buyErr, portfolio.ErrInvalidTrade)
+		assert.ErrorIs(t, sellErr, portfolio.ErrInvalidTrade)
+		assert.Equal(t, "800", funded.Cash().String())
+		holding, _ := funded.Holding("ACME")
+		assert.Equal(t, "5", holding.Quantity.String())
+	})
+
+	t.Run("Process a fill of an unknown order", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.ProcessTrade(portfolio.NewOrderId(), "trade-1", decimal.NewFromInt(1), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		assert.ErrorIs(t, err, portfolio.ErrOrderNotFound)
+	})
+}
+
+func TestProcessHistoricalTrade(t *testing.T) {
+	executedAt := time.Date(2024, 3, 4, 15, 30, 0, 0, time.UTC)
+
+	t.Run("Record a trade at the time and commission it was executed with", func(t *testing.T) {
+		funded := fundedPortfolio(t, 300)
+		orderId := portfolio.NewOrderId()
+
+		err := funded.ProcessHistoricalTrade(orderId, "trade-1", buyOrder("ACME", 10, 20), decimal.RequireFromString("4.95"), executedAt, tieredLoyalty(t), portfolio.SharePrecision{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "95.05", funded.Cash().String())
+		assert.True(t, funded.ReservedCash().IsZero())
+		_, pending := funded.PendingOrder(orderId)
+		assert.False(t, pending)
+		events := funded.DomainEvents()
+		if assert.Len(t, events, 2) {
+			assert.Equal(t, executedAt, events[0].Timestamp())
+			traded := events[1].(portfolio.TradeProcessed)
+			assert.Equal(t, executedAt, traded.Timestamp())
+			assert.Equal(t, "4.95", traded.Commission().String())
+		}
+	})
+
+	t.Run("Record a trade with a negative commission", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.ProcessHistoricalTrade(portfolio.NewOrderId(), "trade-1", buyOrder("ACME", 10, 20), decimal.NewFromInt(-1), executedAt, tieredLoyalty(t), portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInvalidTrade)
+		assert.Empty(t, funded.DomainEvents())
+	})
+}
+
+func TestFractionalShares(t *testing.T) {
+	precision, err := portfolio.ParseSharePrecision("ACME:2")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	fractionalOrder := func(quantity string) portfolio.OrderRequest {
+		return portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.RequireFromString(quantity), LimitPrice: decimal.NewFromInt(20)}
+	}
+
+	t.Run("Place an order for a fraction of a share", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+
+		err := funded.PlaceOrder(orderId, fractionalOrder("2.5"), portfolio.LoyaltyProgram{}, precision)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "950", funded.Cash().String())
+		assert.Equal(t, "50", funded.ReservedCash().String())
+	})
+
+	t.Run("Place an order below the minimum increment", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), fractionalOrder("0.005"), portfolio.LoyaltyProgram{}, precision)
+
+		assert.ErrorIs(t, err, portfolio.ErrInvalidQuantity)
+		assert.EqualError(t, err, "invalid quantity: ACME is traded in increments of 0.01, 0.005 is below the minimum")
+		assert.Empty(t, funded.DomainEvents())
+	})
+
+	t.Run("Place an order that is not a multiple of the increment", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), fractionalOrder("1.234"), portfolio.LoyaltyProgram{}, precision)
+
+		assert.EqualError(t, err, "invalid quantity: ACME is traded in increments of 0.01, 1.234 is not a multiple of it")
+	})
+
+	t.Run("Place a fractional order of a symbol traded in whole shares", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), fractionalOrder("1.5"), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInvalidQuantity)
+	})
+
+	t.Run("Fills are rounded down to the precision of the order", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, fractionalOrder("2.5"), portfolio.LoyaltyProgram{}, precision)
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.RequireFromString("1.239"), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "950", funded.Cash().String())
+		assert.Equal(t, "25.4", funded.ReservedCash().String())
+		holding, _ := funded.Holding("ACME")
+		assert.Equal(t, "1.23", holding.Quantity.String())
+		assert.Equal(t, "24.6", holding.Cost.String())
+		assert.Equal(t, "1.23", funded.DomainEvents()[1].(portfolio.TradeProcessed).Quantity().String())
+	})
+
+	t.Run("A fill that rounds down to nothing", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, fractionalOrder("2.5"), portfolio.LoyaltyProgram{}, precision)
+
+		err := funded.ProcessTrade(orderId, "trade-1", decimal.RequireFromString("0.004"), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		assert.EqualError(t, err, "invalid quantity: trade quantity must be between 0.01 and 2.5")
+	})
+
+	t.Run("A split of a fractional holding is rounded down to its precision", func(t *testing.T) {
+		holder := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		holder.PlaceOrder(orderId, fractionalOrder("2.5"), portfolio.LoyaltyProgram{}, precision)
+		holder.ProcessTrade(orderId, "trade-1", decimal.RequireFromString("2.5"), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		holder.SplitShares("action-1", "ACME", 1, 3, holder.HeldShares("ACME"))
+
+		assert.Equal(t, "0.83", holder.Holdings()[0].Quantity.String())
+		assert.Equal(t, "50", holder.Holdings()[0].Cost.String())
+	})
+}
+
+func TestCommissions(t *testing.T) {
+	t.Run("Place an order reserves the commission of the loyalty level", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+
+		err := funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), tieredLoyalty(t), portfolio.SharePrecision{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "790", funded.Cash().String())
+		assert.Equal(t, "210", funded.ReservedCash().String())
+		assert.Equal(t, "10", funded.DomainEvents()[0].(portfolio.OrderPlaced).Commission().String())
+	})
+
+	t.Run("Place a sell order without enough cash for the commission", func(t *testing.T) {
+		funded := fundedPortfolio(t, 200)
+		buyId := portfolio.NewOrderId()
+		funded.PlaceOrder(buyId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		funded.ProcessTrade(buyId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+
+		err := funded.PlaceOrder(portfolio.NewOrderId(), portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(25)}, tieredLoyalty(t), portfolio.SharePrecision{})
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientFunds)
+		assert.Equal(t, "10", funded.AvailableShares("ACME").String())
+	})
+
+	t.Run("The first fill charges the commission", func(t *testing.T) {
+		funded := fundedPortfolio(t, 400)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), tieredLoyalty(t), portfolio.SharePrecision{})
+		funded.ClearDomainEvents()
+
+		funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(4), decimal.NewFromInt(20), tieredLoyalty(t))
+		funded.ProcessTrade(orderId, "trade-2", decimal.NewFromInt(4), decimal.NewFromInt(20), tieredLoyalty(t))
+
+		assert.Equal(t, "190", funded.Cash().String())
+		assert.Equal(t, "40", funded.ReservedCash().String())
+		assert.Equal(t, "10", funded.DomainEvents()[0].(portfolio.TradeProcessed).Commission().String())
+		assert.True(t, funded.DomainEvents()[1].(portfolio.TradeProcessed).Commission().IsZero())
+		assert.Equal(t, "390", funded.Balance().BookValue.String())
+	})
+
+	t.Run("An order failing before any fill releases the commission", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), tieredLoyalty(t), portfolio.SharePrecision{})
+
+		err := funded.AcknowledgeOrderFailure(orderId, "rejected by broker")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", funded.Cash().String())
+		assert.True(t, funded.ReservedCash().IsZero())
+	})
+}
+
+func TestLoyalty(t *testing.T) {
+	t.Run("A portfolio is opened at the basic level", func(t *testing.T) {
+		newPortfolio, _ := portfolio.OpenPortfolio("A portfolio name")
+
+		assert.Equal(t, portfolio.Basic, newPortfolio.Loyalty())
+	})
+
+	t.Run("Trades move the portfolio up and down the loyalty levels", func(t *testing.T) {
+		prices := map[string]decimal.Decimal{"ACME": decimal.NewFromInt(40)}
+		program := tieredLoyalty(t).WithPrices(func(symbol string) (decimal.Decimal, bool) {
+			price, ok := prices[symbol]
+			return price, ok
+		})
+		funded := fundedPortfolio(t, 400)
+		buyId, sellId := portfolio.NewOrderId(), portfolio.NewOrderId()
+		funded.PlaceOrder(buyId, buyOrder("ACME", 10, 20), program, portfolio.SharePrecision{})
+		funded.ClearDomainEvents()
+
+		err := funded.ProcessTrade(buyId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), program)
+
+		assert.NoError(t, err)
+		assert.Equal(t, portfolio.Bronze, funded.Loyalty())
+		if assert.Len(t, funded.DomainEvents(), 2) && assert.IsType(t, portfolio.LoyaltyLevelChanged{}, funded.DomainEvents()[1]) {
+			event := funded.DomainEvents()[1].(portfolio.LoyaltyLevelChanged)
+			assert.Equal(t, portfolio.Basic, event.PreviousLevel())
+			assert.Equal(t, portfolio.Bronze, event.Level())
+			assert.Equal(t, "5", event.Commission().String())
+		}
+
+		prices["ACME"] = decimal.NewFromInt(25)
+		funded.PlaceOrder(sellId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(5), LimitPrice: decimal.NewFromInt(25)}, program, portfolio.SharePrecision{})
+		assert.Equal(t, "5", funded.ReservedCash().String())
+		funded.ProcessTrade(sellId, "trade-2", decimal.NewFromInt(5), decimal.NewFromInt(25), program)
+		assert.Equal(t, portfolio.Basic, funded.Loyalty())
+	})
+
+	t.Run("Holdings without a price count at cost", func(t *testing.T) {
+		program := tieredLoyalty(t).WithPrices(func(symbol string) (decimal.Decimal, bool) {
+			return decimal.Zero, false
+		})
+		funded := fundedPortfolio(t, 400)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 30), program, portfolio.SharePrecision{})
+
+		funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(30), program)
+
+		assert.Equal(t, portfolio.Basic, funded.Loyalty())
+	})
+
+	t.Run("Trades that keep the level raise no event", func(t *testing.T) {
+		funded := fundedPortfolio(t, 300)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), tieredLoyalty(t), portfolio.SharePrecision{})
+		funded.ClearDomainEvents()
+
+		funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), tieredLoyalty(t))
+
+		assert.Len(t, funded.DomainEvents(), 1)
+		assert.Equal(t, portfolio.Basic, funded.Loyalty())
+	})
+}
+
+func TestAcknowledgeOrderFailure(t *testing.T) {
+	t.Run("Acknowledge a partially filled order releases the rest", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		orderId := portfolio.NewOrderId()
+		funded.PlaceOrder(orderId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		funded.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(4), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+		funded.ClearDomainEvents()
+
+		err := funded.AcknowledgeOrderFailure(orderId, "cancelled by broker")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "920", funded.Cash().String())
+		assert.True(t, funded.ReservedCash().IsZero())
+		if assert.IsType(t, portfolio.OrderFailureAcknowledged{}, funded.DomainEvents()[0]) {
+			event := funded.DomainEvents()[0].(portfolio.OrderFailureAcknowledged)
+			assert.Equal(t, "6", event.UnfilledQuantity().String())
+			assert.Equal(t, "cancelled by broker", event.Reason())
+		}
+	})
+
+	t.Run("Acknowledge a failure of an unknown order", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.AcknowledgeOrderFailure(portfolio.NewOrderId(), "cancelled by broker")
+
+		assert.ErrorIs(t, err, portfolio.ErrOrderNotFound)
+	})
+}
+
+func TestRejectOrder(t *testing.T) {
+	t.Run("Reject an order records it without reserving anything", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+		funded.ClearDomainEvents()
+		orderId := portfolio.NewOrderId()
+		reasons := []portfolio.RejectionReason{{Check: "restricted_symbol", Message: "ACME is restricted from trading"}}
+
+		err := funded.RejectOrder(orderId, buyOrder(" acme ", 10, 20), reasons)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", funded.Cash().String())
+		assert.True(t, funded.ReservedCash().IsZero())
+		if assert.IsType(t, portfolio.OrderRejected{}, funded.DomainEvents()[0]) {
+			event := funded.DomainEvents()[0].(portfolio.OrderRejected)
+			assert.Equal(t, string(orderId), event.OrderId())
+			assert.Equal(t, "ACME", event.Symbol())
+			assert.Equal(t, portfolio.BaseCurrency, event.Currency())
+			assert.Equal(t, reasons, event.Reasons())
+		}
+	})
+
+	t.Run("Reject an order without a reason", func(t *testing.T) {
+		funded := fundedPortfolio(t, 1000)
+
+		err := funded.RejectOrder(portfolio.NewOrderId(), buyOrder("ACME", 10, 20), nil)
+
+		assert.EqualError(t, err, "an order can only be rejected for a reason")
+	})
+}
+
+// holdingPortfolio holds 10 ACME bought at 20 and 800 in cash.
+func holdingPortfolio(t *testing.T) *portfolio.Portfolio {
+	holder := fundedPortfolio(t, 1000)
+	orderId := portfolio.NewOrderId()
+	holder.PlaceOrder(orderId, buyOrder("ACME", 10, 20), portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+	holder.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+	holder.ClearDomainEvents()
+	return holder
+}
+
+// sellShares sells ACME at 20.
+func sellShares(t *testing.T, holder *portfolio.Portfolio, quantity int64) {
+	orderId := portfolio.NewOrderId()
+	err := holder.PlaceOrder(orderId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(quantity), LimitPrice: decimal.NewFromInt(20)}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+	if assert.NoError(t, err) {
+		err = holder.ProcessTrade(orderId, "trade-2", decimal.NewFromInt(quantity), decimal.NewFromInt(20), portfolio.LoyaltyProgram{})
+	}
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	holder.ClearDomainEvents()
+}
+
+func TestSplitShares(t *testing.T) {
+	t.Run("A split multiplies the shares and keeps their cost", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		err := holder.SplitShares("action-1", "ACME", 3, 2, holder.HeldShares("ACME"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []portfolio.Holding{{Symbol: "ACME", Currency: portfolio.BaseCurrency, Quantity: decimal.NewFromInt(15), Cost: decimal.NewFromInt(200)}}, holder.Holdings())
+		if assert.Len(t, holder.DomainEvents(), 1) && assert.IsType(t, portfolio.SharesSplit{}, holder.DomainEvents()[0]) {
+			event := holder.DomainEvents()[0].(portfolio.SharesSplit)
+			assert.Equal(t, "action-1", event.ActionId())
+			assert.Equal(t, "10", event.PreviousQuantity().String())
+			assert.Equal(t, "15", event.Quantity().String())
+		}
+	})
+
+	t.Run("A reverse split drops the fractions of a share", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		holder.SplitShares("action-1", "ACME", 1, 3, holder.HeldShares("ACME"))
+
+		assert.Equal(t, "3", holder.Holdings()[0].Quantity.String())
+		assert.Equal(t, "200", holder.Holdings()[0].Cost.String())
+	})
+
+	t.Run("A split of a symbol that is not held changes nothing", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		err := holder.SplitShares("action-1", "OTHER", 2, 1, holder.HeldShares("OTHER"))
+
+		assert.NoError(t, err)
+		assert.Empty(t, holder.DomainEvents())
+	})
+
+	t.Run("A split leaves the shares traded since the ex-date as they are", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+		held := holder.HeldShares("ACME")
+		sellShares(t, holder, 4)
+
+		err := holder.SplitShares("action-1", "ACME", 2, 1, held)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "16", holder.Holdings()[0].Quantity.String())
+	})
+
+	t.Run("A split gives back the shares of a holding sold since the ex-date", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+		held := holder.HeldShares("ACME")
+		sellShares(t, holder, 10)
+
+		err := holder.SplitShares("action-1", "ACME", 2, 1, held)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []portfolio.Holding{{Symbol: "ACME", Currency: portfolio.BaseCurrency, Quantity: decimal.NewFromInt(10), Cost: decimal.Zero}}, holder.Holdings())
+	})
+
+	t.Run("A reverse split of more shares than were sold since the ex-date", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+		held := holder.HeldShares("ACME")
+		sellShares(t, holder, 8)
+
+		err := holder.SplitShares("action-1", "ACME", 1, 2, held)
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientShares)
+		assert.Equal(t, "2", holder.Holdings()[0].Quantity.String())
+	})
+
+	t.Run("A split with an invalid ratio", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		err := holder.SplitShares("action-1", "ACME", 2, 2, holder.HeldShares("ACME"))
+
+		assert.EqualError(t, err, "split ratio must be two different positive numbers")
+	})
+}
+
+func TestReceiveDividend(t *testing.T) {
+	t.Run("A dividend is paid for every share held", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		err := holder.ReceiveDividend("action-1", "ACME", decimal.RequireFromString("0.25"), holder.HeldShares("ACME"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "802.5", holder.Cash().String())
+		if assert.Len(t, holder.DomainEvents(), 1) && assert.IsType(t, portfolio.DividendReceived{}, holder.DomainEvents()[0]) {
+			event := holder.DomainEvents()[0].(portfolio.DividendReceived)
+			assert.Equal(t, "10", event.Quantity().String())
+			assert.Equal(t, "2.5", event.Amount().String())
+		}
+	})
+
+	t.Run("A dividend is paid for the shares held on the ex-date", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+		held := holder.HeldShares("ACME")
+		sellShares(t, holder, 10)
+
+		err := holder.ReceiveDividend("action-1", "ACME", decimal.NewFromInt(1), held)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1010", holder.Cash().String())
+	})
+
+	t.Run("A dividend of a symbol that is not held changes nothing", func(t *testing.T) {
+		holder := holdingPortfolio(t)
+
+		holder.ReceiveDividend("action-1", "OTHER", decimal.NewFromInt(1), holder.HeldShares("OTHER"))
+
+		assert.Equal(t, "800", holder.Cash().String())
+		assert.Empty(t, holder.DomainEvents())
+	})
+}
@@ -0,0 +1,214 @@
+package readmodels
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerEntryType string
+
+const (
+	LedgerDeposit  LedgerEntryType = "deposit"
+	LedgerTransfer LedgerEntryType = "transfer"
+	LedgerRefund   LedgerEntryType = "refund"
+	LedgerBuy      LedgerEntryType = "buy"
+	LedgerSell     LedgerEntryType = "sell"
+	LedgerDividend LedgerEntryType = "dividend"
+)
+
+// LedgerEntry is a cash movement of a portfolio: funds coming in or going out,
+// or an executed trade. Entries are never changed once recorded. Amount is
+// signed and in Currency, and CashBalance is the settled cash of the portfolio
+// in that currency right after the entry, regardless of what pending orders
+// reserve.
+type LedgerEntry struct {
+	Id          string              `gorm:"column:id" json:"id"`
+	PortfolioId string              `gorm:"column:portfolio_id" json:"portfolio_id"`
+	Position    int64               `gorm:"column:position" json:"-"`
+	Type        LedgerEntryType     `gorm:"column:type" json:"type"`
+	OrderId     string              `gorm:"column:order_id" json:"order_id,omitempty"`
+	TradeId     string              `gorm:"column:trade_id" json:"trade_id,omitempty"`
+	TransferId  string              `gorm:"column:transfer_id" json:"transfer_id,omitempty"`
+	Symbol      string              `gorm:"column:symbol" json:"symbol,omitempty"`
+	Quantity    decimal.NullDecimal `gorm:"column:quantity" json:"quantity,omitempty"`
+	Price       decimal.NullDecimal `gorm:"column:price" json:"price,omitempty"`
+	Currency    string              `gorm:"column:currency" json:"currency"`
+	Commission  decimal.Decimal     `gorm:"column:commission" json:"commission"`
+	Amount      decimal.Decimal     `gorm:"column:amount" json:"amount"`
+	CashBalance decimal.Decimal     `gorm:"column:cash_balance" json:"cash_balance"`
+	Timestamp   time.Time           `gorm:"column:timestamp" json:"timestamp"`
+}
+
+func (LedgerEntry) TableName() string {
+	return "ledger_entries"
+}
+
+type LedgerProjector struct{}
+
+func NewLedgerProjector() *LedgerProjector {
+	return &LedgerProjector{}
+}
+
+func (p *LedgerProjector) Name() string {
+	return "portfolio-ledger"
+}
+
+func (p *LedgerProjector) ReadModelTable() string {
+	return LedgerEntry{}.TableName()
+}
+
+func (p *LedgerProjector) ReadModelKey() string {
+	return "id"
+}
+
+// ReadModelPosition lets the ledger be rebuilt from a point in time: entries
+// before it keep the cash balances the replayed ones are chained to.
+func (p *LedgerProjector) ReadModelPosition() string {
+	return "position"
+}
+
+func (p *LedgerProjector) Project(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	var entry *LedgerEntry
+	var err error
+	switch event.Name {
+	case "funds-received":
+		entry, err = fundsEntry(event, LedgerDeposit, 1)
+	case "funds-sent":
+		entry, err = fundsEntry(event, LedgerTransfer, -1)
+	case "refund-accepted":
+		entry, err = fundsEntry(event, LedgerRefund, 1)
+	case "trade-processed":
+		entry, err = tradeEntry(event)
+	case "dividend-received":
+		entry, err = dividendEntry(event)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return p.record(ctx, tx, entry)
+}
+
+// record chains the entry to the last one of the portfolio in the same
+// currency. Events replayed after a crash are recorded once, since entries are
+// keyed by event id.
+func (p *LedgerProjector) record(ctx context.Context, tx *gorm.DB, entry *LedgerEntry) error {
+	if entry.Currency == "" {
+		entry.Currency = string(portfolio.BaseCurrency)
+	}
+
+	previous := &LedgerEntry{}
+	err := tx.WithContext(ctx).Where("portfolio_id = ? AND currency = ? AND position < ?", entry.PortfolioId, entry.Currency, entry.Position).Order("position DESC").First(previous).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	entry.CashBalance = previous.CashBalance.Add(entry.Amount)
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(entry).Error
+}
+
+func fundsEntry(event common.IntegrationEventEntity, entryType LedgerEntryType, sign int64) (*LedgerEntry, error) {
+	var payload struct {
+		PortfolioId string          `json:"portfolioId"`
+		TransferId  string          `json:"transferId"`
+		Currency    string          `json:"currency"`
+		Amount      decimal.Decimal `json:"amount"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	return &LedgerEntry{
+		Id:          event.Id,
+		PortfolioId: payload.PortfolioId,
+		Position:    event.Position,
+		Type:        entryType,
+		TransferId:  payload.TransferId,
+		Currency:    payload.Currency,
+		Commission:  decimal.Zero,
+		Amount:      payload.Amount.Mul(decimal.NewFromInt(sign)),
+		Timestamp:   event.Timestamp,
+	}, nil
+}
+
+// dividendEntry records the dividend per share as the price of the entry.
+func dividendEntry(event common.IntegrationEventEntity) (*LedgerEntry, error) {
+	var payload struct {
+		PortfolioId    string          `json:"portfolioId"`
+		Symbol         string          `json:"symbol"`
+		Quantity       decimal.Decimal `json:"quantity"`
+		AmountPerShare decimal.Decimal `json:"amountPerShare"`
+		Currency       string          `json:"currency"`
+		Amount         decimal.Decimal `json:"amount"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	return &LedgerEntry{
+		Id:          event.Id,
+		PortfolioId: payload.PortfolioId,
+		Position:    event.Position,
+		Type:        LedgerDividend,
+		Symbol:      payload.Symbol,
+		Quantity:    decimal.NewNullDecimal(payload.Quantity),
+		Price:       decimal.NewNullDecimal(payload.AmountPerShare),
+		Currency:    payload.Currency,
+		Commission:  decimal.Zero,
+		Amount:      payload.Amount,
+		Timestamp:   event.Timestamp,
+	}, nil
+}
+
+// tradeEntry nets the commission out of the amount of the trade that charged it.
+func tradeEntry(event common.IntegrationEventEntity) (*LedgerEntry, error) {
+	var payload struct {
+		PortfolioId string          `json:"portfolioId"`
+		OrderId     string          `json:"orderId"`
+		TradeId     string          `json:"tradeId"`
+		Symbol      string          `json:"symbol"`
+		Side        string          `json:"side"`
+		Quantity    decimal.Decimal `json:"quantity"`
+		Price       decimal.Decimal `json:"price"`
+		Currency    string          `json:"currency"`
+		Commission  decimal.Decimal `json:"commission"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return nil, err
+	}
+
+	entry := &LedgerEntry{
+		Id:          event.Id,
+		PortfolioId: payload.PortfolioId,
+		Position:    event.Position,
+		Type:        LedgerEntryType(payload.Side),
+		OrderId:     payload.OrderId,
+		TradeId:     payload.TradeId,
+		Symbol:      payload.Symbol,
+		Quantity:    decimal.NewNullDecimal(payload.Quantity),
+		Price:       decimal.NewNullDecimal(payload.Price),
+		Currency:    payload.Currency,
+		Commission:  payload.Commission,
+		Timestamp:   event.Timestamp,
+	}
+
+	// Fractional shares are settled to four places, as the portfolio does.
+	gross := payload.Price.Mul(payload.Quantity).Round(4)
+	switch entry.Type {
+	case LedgerBuy:
+		entry.Amount = gross.Neg().Sub(payload.Commission)
+	case LedgerSell:
+		entry.Amount = gross.Sub(payload.Commission)
+	default:
+		return nil, errors.New("trade side must be either buy or sell")
+	}
+	return entry, nil
+}
@@ -0,0 +1,75 @@
+package readmodels
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LedgerFilter selects the entries of a portfolio. From is inclusive and To is
+// exclusive; zero values leave the range open.
+type LedgerFilter struct {
+	PortfolioId string
+	From        time.Time
+	To          time.Time
+	Symbol      string
+	Type        LedgerEntryType
+	Currency    string
+	Limit       int
+	Offset      int
+}
+
+type LedgerPage struct {
+	Items  []LedgerEntry `json:"items"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+type LedgerRepository interface {
+	List(context.Context, LedgerFilter) (*LedgerPage, error)
+}
+
+type mySQLLedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) LedgerRepository {
+	return &mySQLLedgerRepository{
+		db: db,
+	}
+}
+
+// List returns entries in the order they were recorded.
+func (r *mySQLLedgerRepository) List(ctx context.Context, filter LedgerFilter) (*LedgerPage, error) {
+	query := r.db.WithContext(ctx).Model(&LedgerEntry{}).Where("portfolio_id = ?", filter.PortfolioId)
+	if !filter.From.IsZero() {
+		query = query.Where("timestamp >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("timestamp < ?", filter.To)
+	}
+	if filter.Symbol != "" {
+		query = query.Where("symbol = ?", filter.Symbol)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+
+	page := &LedgerPage{
+		Items:  []LedgerEntry{},
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Order("position").Limit(filter.Limit).Offset(filter.Offset).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,106 @@
+package readmodels_test
+
+import (
+	"context"
+	"fmt"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLedger(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+
+	repo := portfolio.NewPortfolioRepository(db, common.NewDomainEventDispatcher())
+	projector := readmodels.NewLedgerProjector()
+	ledger := readmodels.NewLedgerRepository(db)
+	program, _ := portfolio.ParseLoyaltyProgram("basic:0:5")
+
+	saveProjected := func(changed *portfolio.Portfolio) error {
+		domainEvents := changed.DomainEvents()
+		if err := repo.Save(context.Background(), changed); err != nil {
+			return err
+		}
+
+		for _, domainEvent := range domainEvents {
+			var journalEvent common.IntegrationEventEntity
+			if err := db.Where("id = ?", domainEvent.Id()).First(&journalEvent).Error; err != nil {
+				return err
+			}
+			if err := projector.Project(context.Background(), db, journalEvent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tradedPortfolio := func() (*portfolio.Portfolio, error) {
+		traded, _ := portfolio.OpenPortfolio(fmt.Sprintf(`ledger-%s`, randomString()))
+		traded.ReceiveFunds(decimal.NewFromInt(1000))
+		buyId, sellId := portfolio.NewOrderId(), portfolio.NewOrderId()
+		traded.PlaceOrder(buyId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)}, program, portfolio.SharePrecision{})
+		traded.ProcessTrade(buyId, "trade-1", decimal.NewFromInt(10), decimal.NewFromInt(20), program)
+		traded.PlaceOrder(sellId, portfolio.OrderRequest{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(4), LimitPrice: decimal.NewFromInt(25)}, program, portfolio.SharePrecision{})
+		traded.ProcessTrade(sellId, "trade-2", decimal.NewFromInt(4), decimal.NewFromInt(25), program)
+		return traded, saveProjected(traded)
+	}
+
+	t.Run("given funds and trade events should record entries with a running cash balance", func(t *testing.T) {
+		traded, err := tradedPortfolio()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		page, err := ledger.List(context.Background(), readmodels.LedgerFilter{PortfolioId: string(traded.Id()), Limit: 10})
+
+		if assert.NoError(t, err) && assert.Len(t, page.Items, 3) {
+			assert.Equal(t, readmodels.LedgerDeposit, page.Items[0].Type)
+			assert.Equal(t, "1000", page.Items[0].CashBalance.String())
+			assert.Equal(t, readmodels.LedgerBuy, page.Items[1].Type)
+			assert.Equal(t, "-205", page.Items[1].Amount.String())
+			assert.Equal(t, "795", page.Items[1].CashBalance.String())
+			assert.Equal(t, readmodels.LedgerSell, page.Items[2].Type)
+			assert.Equal(t, "95", page.Items[2].Amount.String())
+			assert.Equal(t, "890", page.Items[2].CashBalance.String())
+		}
+	})
+
+	t.Run("given a replayed event should record it once", func(t *testing.T) {
+		traded, err := tradedPortfolio()
+		if !assert.NoError(t, err) {
+			return
+		}
+		var journalEvent common.IntegrationEventEntity
+		db.Where("name = ? AND portfolio_id = ?", "trade-processed", string(traded.Id())).Order("position").First(&journalEvent)
+
+		err = projector.Project(context.Background(), db, journalEvent)
+
+		if assert.NoError(t, err) {
+			page, _ := ledger.List(context.Background(), readmodels.LedgerFilter{PortfolioId: string(traded.Id()), Limit: 10})
+			assert.Equal(t, int64(3), page.Total)
+		}
+	})
+
+	t.Run("given filters should list the matching entries", func(t *testing.T) {
+		traded, err := tradedPortfolio()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		sells, err := ledger.List(context.Background(), readmodels.LedgerFilter{PortfolioId: string(traded.Id()), Symbol: "ACME", Type: readmodels.LedgerSell, Limit: 10})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), sells.Total)
+		}
+
+		future, err := ledger.List(context.Background(), readmodels.LedgerFilter{PortfolioId: string(traded.Id()), From: time.Now().Add(time.Hour), Limit: 10})
+		if assert.NoError(t, err) {
+			assert.Empty(t, future.Items)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package readmodels
+
+import (
+	"context"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const PortfolioStatusOpen = "open"
+
+// portfolioLoyaltyBasic is the loyalty level portfolios are opened with.
+const portfolioLoyaltyBasic = "basic"
+
+// PortfolioSummary is the denormalised view of a portfolio served by the query
+// side. It is derived from the event journal only, never from the portfolios table.
+// TotalValue is the book value of the portfolio in the base currency, with cash
+// and holdings in other currencies converted at the rates.
+type PortfolioSummary struct {
+	Id            string          `gorm:"column:id" json:"portfolio_id"`
+	Name          string          `gorm:"column:name" json:"name"`
+	Status        string          `gorm:"column:status" json:"status"`
+	Cash          decimal.Decimal `gorm:"column:cash" json:"cash"`
+	HoldingsCount int             `gorm:"column:holdings_count" json:"holdings_count"`
+	TotalValue    decimal.Decimal `gorm:"column:total_value" json:"total_value"`
+	Loyalty       string          `gorm:"column:loyalty" json:"loyalty"`
+	UpdatedAt     time.Time       `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (PortfolioSummary) TableName() string {
+	return "portfolio_summaries"
+}
+
+type PortfolioSummaryProjector struct {
+	rates fx.RateSource
+}
+
+func NewPortfolioSummaryProjector(rates fx.RateSource) *PortfolioSummaryProjector {
+	return &PortfolioSummaryProjector{
+		rates: rates,
+	}
+}
+
+func (p *PortfolioSummaryProjector) Name() string {
+	return "portfolio-summaries"
+}
+
+func (p *PortfolioSummaryProjector) ReadModelTable() string {
+	return PortfolioSummary{}.TableName()
+}
+
+func (p *PortfolioSummaryProjector) ReadModelKey() string {
+	return "id"
+}
+
+func (p *PortfolioSummaryProjector) Project(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	switch event.Name {
+	case "portfolio-opened":
+		return p.onPortfolioOpened(ctx, tx, event)
+	case "funds-received", "funds-sent", "refund-accepted", "order-placed", "trade-processed", "order-failure-acknowledged", "shares-split", "dividend-received":
+		return p.onBalanceChanged(ctx, tx, event)
+	case "loyalty-level-changed":
+		return p.onLoyaltyLevelChanged(ctx, tx, event)
+	}
+	return nil
+}
+
+func (p *PortfolioSummaryProjector) onPortfolioOpened(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	var payload struct {
+		PortfolioId string `json:"portfolioId"`
+		Name        string `json:"name"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&PortfolioSummary{
+		Id:         payload.PortfolioId,
+		Name:       payload.Name,
+		Status:     PortfolioStatusOpen,
+		Cash:       decimal.Zero,
+		TotalValue: decimal.Zero,
+		Loyalty:    portfolioLoyaltyBasic,
+		UpdatedAt:  event.Timestamp,
+	}).Error
+}
+
+// onBalanceChanged values the portfolio at cost until market prices are
+// available. A currency without a rate fails the event, rather than leave its
+// amounts out of the total.
+func (p *PortfolioSummaryProjector) onBalanceChanged(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	var payload struct {
+		PortfolioId string `json:"portfolioId"`
+		Balance     struct {
+			Cash             decimal.Decimal                        `json:"cash"`
+			HoldingsCount    int                                    `json:"holdingsCount"`
+			BookValue        decimal.Decimal                        `json:"bookValue"`
+			ForeignBookValue map[portfolio.Currency]decimal.Decimal `json:"foreignBookValue"`
+		} `json:"balance"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	totalValue := payload.Balance.BookValue
+	for currency, amount := range payload.Balance.ForeignBookValue {
+		rate, err := p.rates.Rate(ctx, currency, portfolio.BaseCurrency)
+		if err != nil {
+			return err
+		}
+		totalValue = totalValue.Add(amount.Mul(rate).Round(4))
+	}
+
+	return tx.WithContext(ctx).Model(&PortfolioSummary{}).Where("id = ?", payload.PortfolioId).Updates(map[string]any{
+		"cash":           payload.Balance.Cash,
+		"holdings_count": payload.Balance.HoldingsCount,
+		"total_value":    totalValue,
+		"updated_at":     event.Timestamp,
+	}).Error
+}
+
+func (p *PortfolioSummaryProjector) onLoyaltyLevelChanged(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	var payload struct {
+		PortfolioId string `json:"portfolioId"`
+		Level       string `json:"level"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	return tx.WithContext(ctx).Model(&PortfolioSummary{}).Where("id = ?", payload.PortfolioId).Updates(map[string]any{
+		"loyalty":    payload.Level,
+		"updated_at": event.Timestamp,
+	}).Error
+}
@@ -0,0 +1,69 @@
+package readmodels
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var ErrPortfolioSummaryNotFound = errors.New("portfolio not found")
+
+type PortfolioSummaryFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+type PortfolioSummaryPage struct {
+	Items  []PortfolioSummary `json:"items"`
+	Total  int64              `json:"total"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+type PortfolioSummaryRepository interface {
+	FindById(context.Context, string) (*PortfolioSummary, error)
+	List(context.Context, PortfolioSummaryFilter) (*PortfolioSummaryPage, error)
+}
+
+type mySQLPortfolioSummaryRepository struct {
+	db *gorm.DB
+}
+
+func NewPortfolioSummaryRepository(db *gorm.DB) PortfolioSummaryRepository {
+	return &mySQLPortfolioSummaryRepository{
+		db: db,
+	}
+}
+
+func (r *mySQLPortfolioSummaryRepository) FindById(ctx context.Context, portfolioId string) (*PortfolioSummary, error) {
+	summary := &PortfolioSummary{}
+	if err := r.db.WithContext(ctx).Where("id = ?", portfolioId).First(summary).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPortfolioSummaryNotFound
+		}
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (r *mySQLPortfolioSummaryRepository) List(ctx context.Context, filter PortfolioSummaryFilter) (*PortfolioSummaryPage, error) {
+	query := r.db.WithContext(ctx).Model(&PortfolioSummary{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	page := &PortfolioSummaryPage{
+		Items:  []PortfolioSummary{},
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Order("name").Limit(filter.Limit).Offset(filter.Offset).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+	return page, nil
+}
@@ -0,0 +1,145 @@
+package readmodels_test
+
+import (
+	"context"
+	"fmt"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortfolioSummary(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+
+	repo := portfolio.NewPortfolioRepository(db, common.NewDomainEventDispatcher())
+	projector := readmodels.NewPortfolioSummaryProjector(fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{"EUR": decimal.RequireFromString("0.8")}))
+	summaries := readmodels.NewPortfolioSummaryRepository(db)
+
+	saveProjected := func(changed *portfolio.Portfolio) error {
+		domainEvents := changed.DomainEvents()
+		if err := repo.Save(context.Background(), changed); err != nil {
+			return err
+		}
+
+		for _, domainEvent := range domainEvents {
+			var journalEvent common.IntegrationEventEntity
+			if err := db.Where("id = ?", domainEvent.Id()).First(&journalEvent).Error; err != nil {
+				return err
+			}
+			if err := projector.Project(context.Background(), db, journalEvent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	openProjectedPortfolio := func(name string) (*portfolio.Portfolio, error) {
+		newPortfolio, _ := portfolio.OpenPortfolio(name)
+		return newPortfolio, saveProjected(newPortfolio)
+	}
+
+	t.Run("given a portfolio-opened event should create its summary", func(t *testing.T) {
+		newPortfolio, err := openProjectedPortfolio(fmt.Sprintf(`summary-%s`, randomString()))
+
+		if assert.NoError(t, err) {
+			summary, err := summaries.FindById(context.Background(), string(newPortfolio.Id()))
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, string(newPortfolio.Id()), summary.Id)
+				assert.Equal(t, newPortfolio.Name(), summary.Name)
+				assert.Equal(t, readmodels.PortfolioStatusOpen, summary.Status)
+				assert.True(t, summary.Cash.IsZero())
+				assert.Equal(t, 0, summary.HoldingsCount)
+				assert.True(t, summary.TotalValue.IsZero())
+			}
+		}
+	})
+
+	t.Run("given a balance change should update cash and total value", func(t *testing.T) {
+		newPortfolio, err := openProjectedPortfolio(fmt.Sprintf(`funded-%s`, randomString()))
+		if !assert.NoError(t, err) {
+			return
+		}
+		newPortfolio.ClearDomainEvents()
+		newPortfolio.ReceiveFunds(decimal.NewFromInt(1000))
+		newPortfolio.PlaceOrder(portfolio.NewOrderId(), portfolio.OrderRequest{
+			Symbol:     "ACME",
+			Side:       portfolio.Buy,
+			Quantity:   decimal.NewFromInt(10),
+			LimitPrice: decimal.NewFromInt(20),
+		}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+
+		if assert.NoError(t, saveProjected(newPortfolio)) {
+			summary, err := summaries.FindById(context.Background(), string(newPortfolio.Id()))
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, "800", summary.Cash.String())
+				assert.Equal(t, "1000", summary.TotalValue.String())
+			}
+		}
+	})
+
+	t.Run("given a balance in other currencies should convert them into the total value", func(t *testing.T) {
+		newPortfolio, err := openProjectedPortfolio(fmt.Sprintf(`euro-%s`, randomString()))
+		if !assert.NoError(t, err) {
+			return
+		}
+		newPortfolio.ClearDomainEvents()
+		newPortfolio.ReceiveFunds(decimal.NewFromInt(1000))
+		newPortfolio.ReceiveFundsIn("EUR", decimal.NewFromInt(500))
+		orderId := portfolio.NewOrderId()
+		newPortfolio.PlaceOrder(orderId, portfolio.OrderRequest{
+			Symbol:     "SAP",
+			Side:       portfolio.Buy,
+			Quantity:   decimal.NewFromInt(2),
+			LimitPrice: decimal.NewFromInt(100),
+			Currency:   "EUR",
+		}, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+		newPortfolio.ProcessTrade(orderId, "trade-1", decimal.NewFromInt(2), decimal.NewFromInt(100), portfolio.LoyaltyProgram{})
+
+		if assert.NoError(t, saveProjected(newPortfolio)) {
+			summary, err := summaries.FindById(context.Background(), string(newPortfolio.Id()))
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, "1000", summary.Cash.String())
+				assert.Equal(t, "1625", summary.TotalValue.String())
+			}
+		}
+	})
+
+	t.Run("given an unknown portfolio id should return not found", func(t *testing.T) {
+		summary, err := summaries.FindById(context.Background(), uuid.NewString())
+
+		assert.ErrorIs(t, err, readmodels.ErrPortfolioSummaryNotFound)
+		assert.Nil(t, summary)
+	})
+
+	t.Run("given a status filter should list a page of summaries", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			openProjectedPortfolio(fmt.Sprintf(`listed-%s`, randomString()))
+		}
+
+		page, err := summaries.List(context.Background(), readmodels.PortfolioSummaryFilter{
+			Status: readmodels.PortfolioStatusOpen,
+			Limit:  1,
+		})
+
+		if assert.NoError(t, err) {
+			assert.Len(t, page.Items, 1)
+			assert.GreaterOrEqual(t, page.Total, int64(2))
+			assert.Equal(t, 1, page.Limit)
+		}
+	})
+}
+
+func randomString() string {
+	return strings.Split(uuid.NewString(), "-")[0]
+}
@@ -0,0 +1,254 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// amountPlaces is the precision of amounts converted to the base currency.
+const amountPlaces = 4
+
+// The checks an order can be refused by, as given in its rejection reasons.
+const (
+	MaxOrderNotionalCheck = "max_order_notional"
+	MaxConcentrationCheck = "max_concentration"
+	DailyLossLimitCheck   = "daily_loss_limit"
+	RestrictedSymbolCheck = "restricted_symbol"
+)
+
+// Limits are the pre-trade limits every portfolio is held to. Zero limits are
+// not enforced. Amounts are in the base currency, and MaxConcentration is the
+// share of the portfolio, between 0 and 1, a single position may reach.
+type Limits struct {
+	MaxOrderNotional  decimal.Decimal
+	MaxConcentration  decimal.Decimal
+	DailyLossLimit    decimal.Decimal
+	RestrictedSymbols []string
+}
+
+// NewChecks chains the checks of the limits that are set.
+func NewChecks(limits Limits, lots taxlots.TaxLotRepository, quotes valuation.QuoteSource, rates fx.RateSource) *Chain {
+	checks := []portfolio.RiskCheck{}
+	if len(limits.RestrictedSymbols) > 0 {
+		checks = append(checks, NewRestrictedSymbols(limits.RestrictedSymbols))
+	}
+	if limits.MaxOrderNotional.IsPositive() {
+		checks = append(checks, NewMaxOrderNotional(limits.MaxOrderNotional, rates))
+	}
+	if limits.MaxConcentration.IsPositive() {
+		checks = append(checks, NewMaxConcentration(limits.MaxConcentration, quotes, rates))
+	}
+	if limits.DailyLossLimit.IsPositive() {
+		checks = append(checks, NewDailyLossLimit(limits.DailyLossLimit, lots, rates))
+	}
+	return NewChain(checks...)
+}
+
+// Chain runs every one of its checks and gathers all their reasons, so that an
+// order is refused once for everything wrong with it.
+type Chain struct {
+	checks []portfolio.RiskCheck
+}
+
+func NewChain(checks ...portfolio.RiskCheck) *Chain {
+	return &Chain{
+		checks: checks,
+	}
+}
+
+func (c *Chain) Check(ctx context.Context, owner *portfolio.Portfolio, request portfolio.OrderRequest) ([]portfolio.RejectionReason, error) {
+	reasons := []portfolio.RejectionReason{}
+	for _, check := range c.checks {
+		found, err := check.Check(ctx, owner, request)
+		if err != nil {
+			return nil, err
+		}
+		reasons = append(reasons, found...)
+	}
+	return reasons, nil
+}
+
+// RestrictedSymbols refuses any order in the symbols, buy or sell.
+type RestrictedSymbols struct {
+	symbols map[string]bool
+}
+
+func NewRestrictedSymbols(symbols []string) *RestrictedSymbols {
+	restricted := map[string]bool{}
+	for _, symbol := range symbols {
+		restricted[strings.ToUpper(strings.TrimSpace(symbol))] = true
+	}
+	return &RestrictedSymbols{
+		symbols: restricted,
+	}
+}
+
+func (c *RestrictedSymbols) Check(ctx context.Context, owner *portfolio.Portfolio, request portfolio.OrderRequest) ([]portfolio.RejectionReason, error) {
+	if !c.symbols[request.Symbol] {
+		return nil, nil
+	}
+	return []portfolio.RejectionReason{{
+		Check:   RestrictedSymbolCheck,
+		Message: fmt.Sprintf("%s is restricted from trading", request.Symbol),
+	}}, nil
+}
+
+// MaxOrderNotional refuses orders worth more than the limit at their limit
+// price.
+type MaxOrderNotional struct {
+	limit decimal.Decimal
+	rates fx.RateSource
+}
+
+func NewMaxOrderNotional(limit decimal.Decimal, rates fx.RateSource) *MaxOrderNotional {
+	return &MaxOrderNotional{
+		limit: limit,
+		rates: rates,
+	}
+}
+
+func (c *MaxOrderNotional) Check(ctx context.Context, owner *portfolio.Portfolio, request portfolio.OrderRequest) ([]portfolio.RejectionReason, error) {
+	amount, err := notional(ctx, c.rates, request)
+	if err != nil {
+		return nil, err
+	}
+	if amount.LessThanOrEqual(c.limit) {
+		return nil, nil
+	}
+	return []portfolio.RejectionReason{{
+		Check:   MaxOrderNotionalCheck,
+		Message: fmt.Sprintf("order is worth %s %s, above the limit of %s %s", amount.StringFixed(2), portfolio.BaseCurrency, c.limit.StringFixed(2), portfolio.BaseCurrency),
+		Limit:   decimal.NewNullDecimal(c.limit),
+		Value:   decimal.NewNullDecimal(amount),
+	}}, nil
+}
+
+// MaxConcentration refuses buy orders that would grow a position beyond the
+// limit share of the portfolio, both valued as the valuation endpoint does.
+// Sell orders only lower concentration and are let through. The check runs
+// with the portfolio locked, so its quotes should be fetched beforehand, as
+// with a valuation.QuoteSnapshot.
+type MaxConcentration struct {
+	limit  decimal.Decimal
+	quotes valuation.QuoteSource
+	rates  fx.RateSource
+}
+
+func NewMaxConcentration(limit decimal.Decimal, quotes valuation.QuoteSource, rates fx.RateSource) *MaxConcentration {
+	return &MaxConcentration{
+		limit:  limit,
+		quotes: quotes,
+		rates:  rates,
+	}
+}
+
+func (c *MaxConcentration) Check(ctx context.Context, owner *portfolio.Portfolio, request portfolio.OrderRequest) ([]portfolio.RejectionReason, error) {
+	if request.Side != portfolio.Buy {
+		return nil, nil
+	}
+
+	valued, err := valuation.Value(ctx, owner, c.quotes, c.rates, portfolio.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	// A portfolio worth nothing can not pay for the order anyway.
+	if !valued.TotalValue.IsPositive() {
+		return nil, nil
+	}
+	amount, err := notional(ctx, c.rates, request)
+	if err != nil {
+		return nil, err
+	}
+
+	position := amount
+	for _, held := range valued.Positions {
+		if held.Symbol == request.Symbol {
+			position = position.Add(held.MarketValue)
+		}
+	}
+	// Buying turns cash into shares, so the portfolio is worth the same after.
+	concentration := position.DivRound(valued.TotalValue, 4)
+	if concentration.LessThanOrEqual(c.limit) {
+		return nil, nil
+	}
+	return []portfolio.RejectionReason{{
+		Check:   MaxConcentrationCheck,
+		Message: fmt.Sprintf("%s would be %s%% of the portfolio, above the limit of %s%%", request.Symbol, concentration.Shift(2).StringFixed(2), c.limit.Shift(2).StringFixed(2)),
+		Limit:   decimal.NewNullDecimal(c.limit),
+		Value:   decimal.NewNullDecimal(concentration),
+	}}, nil
+}
+
+// DailyLossLimit refuses buy orders once the losses realised since midnight UTC
+// reach the limit. Gains of the day offset its losses, and are added up across
+// symbols in the base currency as the P&L report does. Sell orders are let
+// through, so positions can still be closed.
+type DailyLossLimit struct {
+	limit decimal.Decimal
+	lots  taxlots.TaxLotRepository
+	rates fx.RateSource
+	now   func() time.Time
+}
+
+func NewDailyLossLimit(limit decimal.Decimal, lots taxlots.TaxLotRepository, rates fx.RateSource) *DailyLossLimit {
+	return &DailyLossLimit{
+		limit: limit,
+		lots:  lots,
+		rates: rates,
+		now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (c *DailyLossLimit) Check(ctx context.Context, owner *portfolio.Portfolio, request portfolio.OrderRequest) ([]portfolio.RejectionReason, error) {
+	if request.Side != portfolio.Buy {
+		return nil, nil
+	}
+
+	realised, err := c.lots.Realised(ctx, taxlots.PnLFilter{
+		PortfolioId: string(owner.Id()),
+		From:        c.now().Truncate(24 * time.Hour),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loss := decimal.Zero
+	for _, total := range realised {
+		gain := total.Gain
+		if currency := portfolio.Currency(total.Currency); currency != portfolio.BaseCurrency {
+			rate, err := c.rates.Rate(ctx, currency, portfolio.BaseCurrency)
+			if err != nil {
+				return nil, err
+			}
+			gain = gain.Mul(rate).Round(amountPlaces)
+		}
+		loss = loss.Sub(gain)
+	}
+	if loss.LessThan(c.limit) {
+		return nil, nil
+	}
+	return []portfolio.RejectionReason{{
+		Check:   DailyLossLimitCheck,
+		Message: fmt.Sprintf("losses of %s %s realised today reached the daily limit of %s %s", loss.StringFixed(2), portfolio.BaseCurrency, c.limit.StringFixed(2), portfolio.BaseCurrency),
+		Limit:   decimal.NewNullDecimal(c.limit),
+		Value:   decimal.NewNullDecimal(loss),
+	}}, nil
+}
+
+// notional is what the order is worth at its limit price, or at its reserve
+// price when it has none, in the base currency.
+func notional(ctx context.Context, rates fx.RateSource, request portfolio.OrderRequest) (decimal.Decimal, error) {
+	rate, err := rates.Rate(ctx, request.Currency, portfolio.BaseCurrency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return request.Quantity.Mul(request.Price()).Mul(rate).Round(amountPlaces), nil
+}
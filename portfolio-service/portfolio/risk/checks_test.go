@@ -0,0 +1,263 @@
+package risk_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/risk"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// holdingPortfolio holds 10 ACME bought at 20 and 1500 in cash.
+func holdingPortfolio(t *testing.T) *portfolio.Portfolio {
+	p, _ := portfolio.OpenPortfolio("A portfolio name")
+	p.ReceiveFunds(decimal.NewFromInt(1700))
+	buy := order("ACME", portfolio.Buy, 10, 20)
+	orderId := portfolio.NewOrderId()
+	if !assert.NoError(t, p.PlaceOrder(orderId, buy, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})) || !assert.NoError(t, p.ProcessTrade(orderId, "trade-1", buy.Quantity, buy.LimitPrice, portfolio.LoyaltyProgram{})) {
+		t.FailNow()
+	}
+	return p
+}
+
+func order(symbol string, side portfolio.OrderSide, quantity int64, limitPrice int64) portfolio.OrderRequest {
+	request, _ := portfolio.OrderRequest{
+		Symbol:     symbol,
+		Side:       side,
+		Quantity:   decimal.NewFromInt(quantity),
+		LimitPrice: decimal.NewFromInt(limitPrice),
+	}.Validate()
+	return request
+}
+
+func TestRestrictedSymbols(t *testing.T) {
+	check := risk.NewRestrictedSymbols([]string{" acme ", "INIT"})
+
+	t.Run("Orders in a restricted symbol are refused, buy or sell", func(t *testing.T) {
+		for _, side := range []portfolio.OrderSide{portfolio.Buy, portfolio.Sell} {
+			reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", side, 1, 20))
+
+			assert.NoError(t, err)
+			assert.Equal(t, []portfolio.RejectionReason{{Check: risk.RestrictedSymbolCheck, Message: "ACME is restricted from trading"}}, reasons)
+		}
+	})
+
+	t.Run("Orders in other symbols are let through", func(t *testing.T) {
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ZETA", portfolio.Buy, 1, 20))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+}
+
+func TestMaxOrderNotional(t *testing.T) {
+	rates := fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{
+		"EUR": decimal.RequireFromString("0.8"),
+	})
+	check := risk.NewMaxOrderNotional(decimal.NewFromInt(200), rates)
+
+	t.Run("Orders up to the limit are let through", func(t *testing.T) {
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Sell, 10, 20))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+
+	t.Run("Orders above the limit in the base currency are refused", func(t *testing.T) {
+		request := order("ACME", portfolio.Buy, 10, 20)
+		request.Currency = "EUR"
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), request)
+
+		assert.NoError(t, err)
+		if assert.Len(t, reasons, 1) {
+			assert.Equal(t, risk.MaxOrderNotionalCheck, reasons[0].Check)
+			assert.Equal(t, "order is worth 250.00 USD, above the limit of 200.00 USD", reasons[0].Message)
+			assert.Equal(t, "200", reasons[0].Limit.Decimal.String())
+			assert.Equal(t, "250", reasons[0].Value.Decimal.String())
+		}
+	})
+
+	t.Run("Orders in a currency without a rate fail the check", func(t *testing.T) {
+		request := order("ACME", portfolio.Buy, 10, 20)
+		request.Currency = "GBP"
+
+		_, err := check.Check(context.Background(), holdingPortfolio(t), request)
+
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+	})
+}
+
+func TestMaxConcentration(t *testing.T) {
+	rates := fx.NewRates(portfolio.BaseCurrency, nil)
+	quotes := StubQuoteSource{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25), Timestamp: time.Now()}}
+
+	t.Run("Buy orders growing a position beyond the limit are refused", func(t *testing.T) {
+		check := risk.NewMaxConcentration(decimal.RequireFromString("0.25"), quotes, rates)
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 10, 30))
+
+		assert.NoError(t, err)
+		if assert.Len(t, reasons, 1) {
+			assert.Equal(t, risk.MaxConcentrationCheck, reasons[0].Check)
+			assert.Equal(t, "ACME would be 31.43% of the portfolio, above the limit of 25.00%", reasons[0].Message)
+			assert.Equal(t, "0.3143", reasons[0].Value.Decimal.String())
+		}
+	})
+
+	t.Run("Buy orders within the limit are let through", func(t *testing.T) {
+		check := risk.NewMaxConcentration(decimal.RequireFromString("0.4"), quotes, rates)
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 10, 30))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+
+	t.Run("Sell orders are let through", func(t *testing.T) {
+		check := risk.NewMaxConcentration(decimal.RequireFromString("0.1"), quotes, rates)
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Sell, 5, 30))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+}
+
+func TestDailyLossLimit(t *testing.T) {
+	lots := &StubTaxLotRepository{realised: []taxlots.RealisedTotal{
+		{Symbol: "ACME", Currency: "USD", Gain: decimal.NewFromInt(-300)},
+		{Symbol: "INIT", Currency: "USD", Gain: decimal.NewFromInt(50)},
+	}}
+	rates := fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{
+		"EUR": decimal.RequireFromString("0.8"),
+	})
+
+	t.Run("Buy orders are refused once the losses of the day reach the limit", func(t *testing.T) {
+		owner := holdingPortfolio(t)
+		check := risk.NewDailyLossLimit(decimal.NewFromInt(250), lots, rates)
+
+		reasons, err := check.Check(context.Background(), owner, order("ACME", portfolio.Buy, 1, 20))
+
+		assert.NoError(t, err)
+		assert.Equal(t, []portfolio.RejectionReason{{
+			Check:   risk.DailyLossLimitCheck,
+			Message: "losses of 250.00 USD realised today reached the daily limit of 250.00 USD",
+			Limit:   decimal.NewNullDecimal(decimal.NewFromInt(250)),
+			Value:   decimal.NewNullDecimal(decimal.NewFromInt(250)),
+		}}, reasons)
+		assert.Equal(t, string(owner.Id()), lots.filter.PortfolioId)
+		assert.Equal(t, lots.filter.From.Truncate(24*time.Hour), lots.filter.From)
+		assert.True(t, lots.filter.To.IsZero())
+	})
+
+	t.Run("Buy orders are let through below the limit", func(t *testing.T) {
+		check := risk.NewDailyLossLimit(decimal.NewFromInt(300), lots, rates)
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 1, 20))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+
+	t.Run("Losses in other currencies count at the rates", func(t *testing.T) {
+		lots := &StubTaxLotRepository{realised: []taxlots.RealisedTotal{
+			{Symbol: "ACME", Currency: "USD", Gain: decimal.NewFromInt(-200)},
+			{Symbol: "SAP", Currency: "EUR", Gain: decimal.NewFromInt(-80)},
+		}}
+		check := risk.NewDailyLossLimit(decimal.NewFromInt(300), lots, rates)
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 1, 20))
+
+		assert.NoError(t, err)
+		if assert.Len(t, reasons, 1) {
+			assert.Equal(t, "300", reasons[0].Value.Decimal.String())
+		}
+	})
+
+	t.Run("Fail without the rate of a currency", func(t *testing.T) {
+		lots := &StubTaxLotRepository{realised: []taxlots.RealisedTotal{{Symbol: "SONY", Currency: "JPY", Gain: decimal.NewFromInt(-80)}}}
+		check := risk.NewDailyLossLimit(decimal.NewFromInt(300), lots, rates)
+
+		_, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 1, 20))
+
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+	})
+
+	t.Run("Sell orders are let through", func(t *testing.T) {
+		check := risk.NewDailyLossLimit(decimal.NewFromInt(100), lots, rates)
+
+		reasons, err := check.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Sell, 1, 20))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+}
+
+func TestChain(t *testing.T) {
+	rates := fx.NewRates(portfolio.BaseCurrency, nil)
+
+	t.Run("Every check gives its reasons", func(t *testing.T) {
+		chain := risk.NewChecks(risk.Limits{
+			MaxOrderNotional:  decimal.NewFromInt(100),
+			RestrictedSymbols: []string{"ACME"},
+		}, &StubTaxLotRepository{}, StubQuoteSource{}, rates)
+
+		reasons, err := chain.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 10, 20))
+
+		assert.NoError(t, err)
+		if assert.Len(t, reasons, 2) {
+			assert.Equal(t, risk.RestrictedSymbolCheck, reasons[0].Check)
+			assert.Equal(t, risk.MaxOrderNotionalCheck, reasons[1].Check)
+		}
+	})
+
+	t.Run("Limits that are not set are not enforced", func(t *testing.T) {
+		chain := risk.NewChecks(risk.Limits{}, &StubTaxLotRepository{err: errors.New("unreachable")}, StubQuoteSource{}, rates)
+
+		reasons, err := chain.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 1000, 1000))
+
+		assert.NoError(t, err)
+		assert.Empty(t, reasons)
+	})
+
+	t.Run("A failing check fails the chain", func(t *testing.T) {
+		chain := risk.NewChecks(risk.Limits{DailyLossLimit: decimal.NewFromInt(100)}, &StubTaxLotRepository{err: errors.New("connection refused")}, StubQuoteSource{}, rates)
+
+		_, err := chain.Check(context.Background(), holdingPortfolio(t), order("ACME", portfolio.Buy, 1, 20))
+
+		assert.EqualError(t, err, "connection refused")
+	})
+}
+
+type StubQuoteSource map[string]valuation.Quote
+
+func (s StubQuoteSource) Quote(ctx context.Context, symbol string) (valuation.Quote, error) {
+	quote, ok := s[symbol]
+	if !ok {
+		return valuation.Quote{}, errors.New("quote service unavailable")
+	}
+	return quote, nil
+}
+
+type StubTaxLotRepository struct {
+	realised []taxlots.RealisedTotal
+	err      error
+	filter   taxlots.PnLFilter
+}
+
+func (r *StubTaxLotRepository) Realised(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.RealisedTotal, error) {
+	r.filter = filter
+	return r.realised, r.err
+}
+
+func (r *StubTaxLotRepository) Open(ctx context.Context, filter taxlots.PnLFilter) ([]taxlots.OpenPosition, error) {
+	return nil, r.err
+}
@@ -0,0 +1,23 @@
+package portfolio
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// RejectionReason is why a risk check refused an order. Checks that compare the
+// order against a limit give the limit and the value the order would reach, in
+// the base currency for amounts.
+type RejectionReason struct {
+	Check   string              `json:"check"`
+	Message string              `json:"message"`
+	Limit   decimal.NullDecimal `json:"limit"`
+	Value   decimal.NullDecimal `json:"value"`
+}
+
+// RiskCheck vets an order before anything is reserved for it, and returns the
+// reasons to refuse it, if any. The request is already validated.
+type RiskCheck interface {
+	Check(ctx context.Context, owner *Portfolio, request OrderRequest) ([]RejectionReason, error)
+}
@@ -0,0 +1,131 @@
+package sagas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"stock-trader/portfolio-service/portfolio"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrOrderRejectedByBroker = errors.New("order rejected by broker")
+
+var ErrBrokerOrderNotFound = errors.New("order not found in broker")
+
+var ErrBrokerOrderAlreadyFilled = errors.New("order already filled by broker")
+
+type BrokerOrder struct {
+	OrderId     string                `json:"order_id"`
+	PortfolioId string                `json:"portfolio_id"`
+	Symbol      string                `json:"symbol"`
+	Side        portfolio.OrderSide   `json:"side"`
+	Type        portfolio.OrderType   `json:"type"`
+	TimeInForce portfolio.TimeInForce `json:"time_in_force"`
+	Quantity    decimal.Decimal       `json:"quantity"`
+	LimitPrice  decimal.Decimal       `json:"limit_price"`
+	StopPrice   decimal.Decimal       `json:"stop_price"`
+	// Precision is the decimal places the broker may fill the order in.
+	Precision int32 `json:"precision"`
+}
+
+// Broker is the port to the broker service. Both operations must be safe to
+// retry with the same order id. CancelOrder returns the quantity the broker
+// filled before it cancelled the order, fills it may not have reported yet.
+type Broker interface {
+	SubmitOrder(context.Context, BrokerOrder) error
+	CancelOrder(context.Context, string) (decimal.Decimal, error)
+}
+
+type httpBroker struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewHTTPBroker calls the broker with the token it shares with the portfolio,
+// as "Authorization: Bearer <token>".
+func NewHTTPBroker(baseURL string, token string, client *http.Client) Broker {
+	return &httpBroker{
+		baseURL: baseURL,
+		token:   token,
+		client:  client,
+	}
+}
+
+func (b *httpBroker) SubmitOrder(ctx context.Context, order BrokerOrder) error {
+	body, err := json.Marshal(order)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/orders", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+b.token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Refused credentials are retried rather than taken for a rejected order.
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode < 500 && resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrOrderRejectedByBroker, readError(resp))
+	default:
+		return fmt.Errorf("broker answered %d: %s", resp.StatusCode, readError(resp))
+	}
+}
+
+func (b *httpBroker) CancelOrder(ctx context.Context, orderId string) (decimal.Decimal, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, b.baseURL+"/orders/"+url.PathEscape(orderId), nil)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		var body struct {
+			FilledQuantity decimal.Decimal `json:"filled_quantity"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return decimal.Zero, fmt.Errorf("reading the cancellation of %s: %w", orderId, err)
+		}
+		return body.FilledQuantity, nil
+	case resp.StatusCode == http.StatusNotFound:
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrBrokerOrderNotFound, orderId)
+	case resp.StatusCode == http.StatusConflict:
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrBrokerOrderAlreadyFilled, orderId)
+	default:
+		return decimal.Zero, fmt.Errorf("broker answered %d: %s", resp.StatusCode, readError(resp))
+	}
+}
+
+func readError(resp *http.Response) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err := json.Unmarshal(data, &body); err == nil && body.Message != "" {
+		return body.Message
+	}
+	return string(data)
+}
@@ -0,0 +1,108 @@
+package sagas_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPBroker(t *testing.T) {
+	newBroker := func(handler http.HandlerFunc) sagas.Broker {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		return sagas.NewHTTPBroker(server.URL, "order-token", server.Client())
+	}
+
+	t.Run("Submit an order", func(t *testing.T) {
+		var received sagas.BrokerOrder
+		broker := newBroker(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST /orders", r.Method+" "+r.URL.Path)
+			assert.Equal(t, "Bearer order-token", r.Header.Get("Authorization"))
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusAccepted)
+		})
+
+		err := broker.SubmitOrder(context.Background(), sagas.BrokerOrder{
+			OrderId:    "order-1",
+			Symbol:     "ACME",
+			Side:       portfolio.Buy,
+			Quantity:   decimal.NewFromInt(10),
+			LimitPrice: decimal.NewFromInt(20),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "order-1", received.OrderId)
+		assert.Equal(t, "20", received.LimitPrice.String())
+	})
+
+	t.Run("Submit an order the broker refuses", func(t *testing.T) {
+		broker := newBroker(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message":"unknown symbol"}`))
+		})
+
+		err := broker.SubmitOrder(context.Background(), sagas.BrokerOrder{OrderId: "order-1"})
+
+		assert.ErrorIs(t, err, sagas.ErrOrderRejectedByBroker)
+		assert.EqualError(t, err, "order rejected by broker: unknown symbol")
+	})
+
+	t.Run("Submit an order with credentials the broker refuses", func(t *testing.T) {
+		broker := newBroker(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		err := broker.SubmitOrder(context.Background(), sagas.BrokerOrder{OrderId: "order-1"})
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, sagas.ErrOrderRejectedByBroker)
+	})
+
+	t.Run("Submit an order while the broker is failing", func(t *testing.T) {
+		broker := newBroker(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		err := broker.SubmitOrder(context.Background(), sagas.BrokerOrder{OrderId: "order-1"})
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, sagas.ErrOrderRejectedByBroker)
+	})
+
+	t.Run("Cancel an order", func(t *testing.T) {
+		tests := []struct {
+			status int
+			body   string
+			filled string
+			err    error
+		}{
+			{status: http.StatusOK, body: `{"order_id":"order-1","filled_quantity":"4"}`, filled: "4"},
+			{status: http.StatusNotFound, filled: "0", err: sagas.ErrBrokerOrderNotFound},
+			{status: http.StatusConflict, filled: "0", err: sagas.ErrBrokerOrderAlreadyFilled},
+		}
+
+		for _, tc := range tests {
+			broker := newBroker(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "DELETE /orders/order-1", r.Method+" "+r.URL.Path)
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			})
+
+			filled, err := broker.CancelOrder(context.Background(), "order-1")
+
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+			assert.Equal(t, tc.filled, filled.String())
+		}
+	})
+}
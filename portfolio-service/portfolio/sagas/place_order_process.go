@@ -0,0 +1,225 @@
+package sagas
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/portfolio"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PlaceOrderProcess holds the steps of the place order saga. Every step runs in
+// the transaction its repositories were built with, so the portfolio and the
+// saga always change together.
+type PlaceOrderProcess struct {
+	portfolios portfolio.PortfolioRepository
+	sagas      PlaceOrderSagaRepository
+	timeout    time.Duration
+	loyalty    portfolio.LoyaltyProgram
+	precision  portfolio.SharePrecision
+	risk       portfolio.RiskCheck
+	now        func() time.Time
+}
+
+func NewPlaceOrderProcess(portfolios portfolio.PortfolioRepository, sagas PlaceOrderSagaRepository, timeout time.Duration, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision, risk portfolio.RiskCheck) *PlaceOrderProcess {
+	return &PlaceOrderProcess{
+		portfolios: portfolios,
+		sagas:      sagas,
+		timeout:    timeout,
+		loyalty:    loyalty,
+		precision:  precision,
+		risk:       risk,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Begin reserves cash or shares in the portfolio and starts tracking the order.
+// The order reaches the broker later, through the PlaceOrderSagaRunner. Orders
+// the risk checks refuse are recorded in the portfolio without reserving
+// anything, and the reasons they were refused for are returned instead. A
+// process without risk checks lets every order through.
+func (p *PlaceOrderProcess) Begin(ctx context.Context, portfolioId portfolio.PortfolioId, request portfolio.OrderRequest) (portfolio.OrderId, []portfolio.RejectionReason, error) {
+	owner, err := p.portfolios.FindById(ctx, portfolioId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	request, err = request.Validate()
+	if err != nil {
+		return "", nil, err
+	}
+
+	orderId := portfolio.NewOrderId()
+	if p.risk != nil {
+		reasons, err := p.risk.Check(ctx, owner, request)
+		if err != nil {
+			return "", nil, err
+		}
+		if len(reasons) > 0 {
+			if err := owner.RejectOrder(orderId, request, reasons); err != nil {
+				return "", nil, err
+			}
+			// Rejections are returned rather than failed with, so that the
+			// transaction recording them for audit commits.
+			if err := p.portfolios.Save(ctx, owner); err != nil {
+				return "", nil, err
+			}
+			return orderId, reasons, nil
+		}
+	}
+
+	if err := owner.PlaceOrder(orderId, request, p.loyalty, p.precision); err != nil {
+		return "", nil, err
+	}
+
+	if err := p.portfolios.Save(ctx, owner); err != nil {
+		return "", nil, err
+	}
+
+	placed, _ := owner.PendingOrder(orderId)
+	if err := p.sagas.Save(ctx, NewPlaceOrderSaga(portfolioId, orderId, placed, p.precision.Places(placed.Symbol), p.now(), p.timeout)); err != nil {
+		return "", nil, err
+	}
+
+	return orderId, nil, nil
+}
+
+func (p *PlaceOrderProcess) MarkSubmitted(ctx context.Context, orderId string) error {
+	saga, err := p.sagas.FindByOrderId(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	saga.MarkSubmitted(p.now())
+	return p.sagas.Save(ctx, saga)
+}
+
+func (p *PlaceOrderProcess) RecordSubmitFailure(ctx context.Context, orderId string) error {
+	saga, err := p.sagas.FindByOrderId(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	saga.RecordSubmitFailure(p.now())
+	return p.sagas.Save(ctx, saga)
+}
+
+func (p *PlaceOrderProcess) AwaitFills(ctx context.Context, orderId string) error {
+	saga, err := p.sagas.FindByOrderId(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	// The broker knows the order, even if it never confirmed taking it.
+	saga.MarkSubmitted(p.now())
+	saga.ExtendDeadline(p.now(), p.timeout)
+	return p.sagas.Save(ctx, saga)
+}
+
+// HandleTrade settles a fill reported by the broker. Fills already processed
+// are ignored. The fill is rounded down to the precision of the order once, so
+// that the saga counts the same quantity the portfolio settles.
+func (p *PlaceOrderProcess) HandleTrade(ctx context.Context, orderId string, tradeId string, quantity decimal.Decimal, price decimal.Decimal) error {
+	saga, err := p.sagas.FindByOrderId(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	quantity = quantity.RoundFloor(saga.Precision)
+	recorded, err := saga.RecordTrade(tradeId, quantity, p.now())
+	if err != nil || !recorded {
+		return err
+	}
+
+	owner, err := p.portfolios.FindById(ctx, portfolio.PortfolioId(saga.PortfolioId))
+	if err != nil {
+		return err
+	}
+
+	if err := owner.ProcessTrade(portfolio.OrderId(orderId), tradeId, quantity, price, p.loyalty); err != nil {
+		return err
+	}
+
+	// The last fill of an order the broker already cancelled releases the rest.
+	if saga.State == PlaceOrderTimedOut || saga.State == PlaceOrderCancelled {
+		if err := acknowledgeFailure(owner, orderId, saga.Reason); err != nil {
+			return err
+		}
+	}
+
+	if err := p.portfolios.Save(ctx, owner); err != nil {
+		return err
+	}
+
+	return p.sagas.Save(ctx, saga)
+}
+
+// Reject compensates an order the broker refused to take.
+func (p *PlaceOrderProcess) Reject(ctx context.Context, orderId string, reason string) error {
+	return p.compensate(ctx, orderId, PlaceOrderRejected, reason)
+}
+
+// HandleCancellation compensates the unfilled part of an order the broker
+// cancelled.
+func (p *PlaceOrderProcess) HandleCancellation(ctx context.Context, orderId string, reason string) error {
+	return p.compensate(ctx, orderId, PlaceOrderCancelled, reason)
+}
+
+// Expire compensates an order that was not filled before its deadline, or whose
+// cancellation was requested, once the broker confirmed it will not fill it
+// anymore. When the broker filled more
+// than it reported so far, the order is compensated once the missing fills
+// arrive instead.
+func (p *PlaceOrderProcess) Expire(ctx context.Context, orderId string, brokerFilled decimal.Decimal) error {
+	saga, err := p.sagas.FindByOrderId(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if saga.FilledQuantity.LessThan(brokerFilled) {
+		if err := saga.awaitFills(brokerFilled, p.now()); err != nil {
+			return err
+		}
+		return p.sagas.Save(ctx, saga)
+	}
+
+	state, reason := saga.expiry()
+	return p.compensate(ctx, orderId, state, reason)
+}
+
+func (p *PlaceOrderProcess) compensate(ctx context.Context, orderId string, state PlaceOrderState, reason string) error {
+	saga, err := p.sagas.FindByOrderId(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	if err := saga.finish(state, reason, p.now()); err != nil {
+		return err
+	}
+
+	owner, err := p.portfolios.FindById(ctx, portfolio.PortfolioId(saga.PortfolioId))
+	if err != nil {
+		return err
+	}
+
+	if err := acknowledgeFailure(owner, orderId, reason); err != nil {
+		return err
+	}
+
+	if err := p.portfolios.Save(ctx, owner); err != nil {
+		return err
+	}
+
+	return p.sagas.Save(ctx, saga)
+}
+
+// acknowledgeFailure releases what the portfolio still reserves for the order,
+// if anything.
+func acknowledgeFailure(owner *portfolio.Portfolio, orderId string, reason string) error {
+	err := owner.AcknowledgeOrderFailure(portfolio.OrderId(orderId), reason)
+	if err != nil && !errors.Is(err, portfolio.ErrOrderNotFound) {
+		return err
+	}
+	return nil
+}
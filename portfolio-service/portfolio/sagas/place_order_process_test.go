@@ -0,0 +1,240 @@
+package sagas_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestPlaceOrderProcess(t *testing.T) {
+	t.Run("Begin reserves cash and tracks the order", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil)
+
+		orderId, _, err := process.Begin(context.Background(), owner.Id(), buyOrder("acme", 10, 20))
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, "800", portfolios.portfolio.Cash().String())
+			saga := placeOrderSagas.sagas[string(orderId)]
+			assert.Equal(t, sagas.PlaceOrderReserved, saga.State)
+			assert.Equal(t, "ACME", saga.Symbol)
+			assert.Equal(t, string(owner.Id()), saga.PortfolioId)
+		}
+	})
+
+	t.Run("Begin without enough cash does not track the order", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 100)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil)
+
+		_, _, err := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		assert.ErrorIs(t, err, portfolio.ErrInsufficientFunds)
+		assert.Empty(t, placeOrderSagas.sagas)
+	})
+
+	t.Run("Begin records orders refused by the risk checks without reserving anything", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		reasons := []portfolio.RejectionReason{{Check: "restricted_symbol", Message: "ACME is restricted from trading"}}
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, StubRiskCheck(reasons))
+
+		orderId, rejections, err := process.Begin(context.Background(), owner.Id(), buyOrder("acme", 10, 20))
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, reasons, rejections)
+			assert.Equal(t, "1000", portfolios.portfolio.Cash().String())
+			assert.Empty(t, placeOrderSagas.sagas)
+			if assert.NotEmpty(t, portfolios.saved) {
+				rejected := portfolios.saved[len(portfolios.saved)-1].(portfolio.OrderRejected)
+				assert.Equal(t, string(orderId), rejected.OrderId())
+				assert.Equal(t, "ACME", rejected.Symbol())
+				assert.Equal(t, reasons, rejected.Reasons())
+			}
+		}
+	})
+
+	t.Run("Begin places orders the risk checks let through", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, StubRiskCheck(nil))
+
+		orderId, rejections, err := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		if assert.NoError(t, err) {
+			assert.Empty(t, rejections)
+			assert.Equal(t, "800", portfolios.portfolio.Cash().String())
+			assert.Contains(t, placeOrderSagas.sagas, string(orderId))
+		}
+	})
+
+	t.Run("Trades are processed once and complete the order", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+		process.MarkSubmitted(context.Background(), string(orderId))
+
+		for _, tradeId := range []string{"trade-1", "trade-1", "trade-2"} {
+			err := process.HandleTrade(context.Background(), string(orderId), tradeId, decimal.NewFromInt(5), decimal.NewFromInt(19))
+			assert.NoError(t, err)
+		}
+
+		saga := placeOrderSagas.sagas[string(orderId)]
+		assert.Equal(t, sagas.PlaceOrderCompleted, saga.State)
+		assert.Equal(t, "10", saga.FilledQuantity.String())
+		holding, _ := portfolios.portfolio.Holding("ACME")
+		assert.Equal(t, "10", holding.Quantity.String())
+		assert.Equal(t, "810", portfolios.portfolio.Cash().String())
+	})
+
+	t.Run("Trades are rounded once to the precision of the order", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		precision, _ := portfolio.ParseSharePrecision("ACME:2")
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, precision, nil)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		err := process.HandleTrade(context.Background(), string(orderId), "trade-1", decimal.RequireFromString("4.567"), decimal.NewFromInt(20))
+
+		if assert.NoError(t, err) {
+			saga := placeOrderSagas.sagas[string(orderId)]
+			assert.Equal(t, int32(2), saga.Precision)
+			assert.Equal(t, "4.56", saga.FilledQuantity.String())
+			holding, _ := portfolios.portfolio.Holding("ACME")
+			assert.Equal(t, "4.56", holding.Quantity.String())
+		}
+	})
+
+	t.Run("Cancellation releases the unfilled part", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+		process.HandleTrade(context.Background(), string(orderId), "trade-1", decimal.NewFromInt(4), decimal.NewFromInt(20))
+
+		err := process.HandleCancellation(context.Background(), string(orderId), "cancelled by broker")
+
+		if assert.NoError(t, err) {
+			saga := placeOrderSagas.sagas[string(orderId)]
+			assert.Equal(t, sagas.PlaceOrderCancelled, saga.State)
+			assert.Equal(t, "cancelled by broker", saga.Reason)
+			assert.Equal(t, "920", portfolios.portfolio.Cash().String())
+			assert.True(t, portfolios.portfolio.ReservedCash().IsZero())
+		}
+	})
+
+	t.Run("Trades of a finished order are refused", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+		process.Expire(context.Background(), string(orderId), decimal.Zero)
+
+		err := process.HandleTrade(context.Background(), string(orderId), "trade-1", decimal.NewFromInt(4), decimal.NewFromInt(20))
+
+		assert.ErrorIs(t, err, sagas.ErrPlaceOrderSagaFinished)
+		assert.Equal(t, "1000", portfolios.portfolio.Cash().String())
+	})
+
+	t.Run("Unknown orders are not found", func(t *testing.T) {
+		portfolios, placeOrderSagas, _ := newFundedPortfolio(t, 1000)
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, time.Minute, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{}, nil)
+
+		err := process.HandleCancellation(context.Background(), "unknown", "cancelled by broker")
+
+		assert.ErrorIs(t, err, sagas.ErrPlaceOrderSagaNotFound)
+	})
+}
+
+func newFundedPortfolio(t *testing.T, cash int64) (*StubPortfolioRepository, *InMemoryPlaceOrderSagaRepository, *portfolio.Portfolio) {
+	owner, _ := portfolio.OpenPortfolio("A portfolio name")
+	if !assert.NoError(t, owner.ReceiveFunds(decimal.NewFromInt(cash))) {
+		t.FailNow()
+	}
+	return &StubPortfolioRepository{portfolio: owner}, &InMemoryPlaceOrderSagaRepository{sagas: map[string]sagas.PlaceOrderSaga{}}, owner
+}
+
+func buyOrder(symbol string, quantity int64, limitPrice int64) portfolio.OrderRequest {
+	return portfolio.OrderRequest{
+		Symbol:     symbol,
+		Side:       portfolio.Buy,
+		Quantity:   decimal.NewFromInt(quantity),
+		LimitPrice: decimal.NewFromInt(limitPrice),
+	}
+}
+
+type StubPortfolioRepository struct {
+	portfolio *portfolio.Portfolio
+	saved     []common.DomainEvent
+}
+
+func (r *StubPortfolioRepository) FindById(ctx context.Context, portfolioId portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+	if r.portfolio == nil || r.portfolio.Id() != portfolioId {
+		return nil, fmt.Errorf("%w: %s", portfolio.ErrPortfolioNotFound, portfolioId)
+	}
+	return r.portfolio, nil
+}
+
+func (r *StubPortfolioRepository) FindByName(ctx context.Context, name string) (*portfolio.Portfolio, error) {
+	return nil, nil
+}
+
+func (r *StubPortfolioRepository) Save(ctx context.Context, saved *portfolio.Portfolio) error {
+	r.saved = append(r.saved, saved.DomainEvents()...)
+	saved.ClearDomainEvents()
+	r.portfolio = saved
+	return nil
+}
+
+// StubRiskCheck refuses every order for its reasons.
+type StubRiskCheck []portfolio.RejectionReason
+
+func (c StubRiskCheck) Check(ctx context.Context, owner *portfolio.Portfolio, request portfolio.OrderRequest) ([]portfolio.RejectionReason, error) {
+	return c, nil
+}
+
+type InMemoryPlaceOrderSagaRepository struct {
+	sagas map[string]sagas.PlaceOrderSaga
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) FindByOrderId(ctx context.Context, orderId string) (*sagas.PlaceOrderSaga, error) {
+	saga, ok := r.sagas[orderId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", sagas.ErrPlaceOrderSagaNotFound, orderId)
+	}
+	return &saga, nil
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]sagas.PlaceOrderSaga, error) {
+	due := []sagas.PlaceOrderSaga{}
+	for _, saga := range r.sagas {
+		if saga.State == sagas.PlaceOrderReserved || saga.IsExpired(now) {
+			due = append(due, saga)
+		}
+	}
+	return due, nil
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) FindPending(ctx context.Context, symbol string, before time.Time) ([]sagas.PlaceOrderSaga, error) {
+	pending := []sagas.PlaceOrderSaga{}
+	for _, saga := range r.sagas {
+		if saga.Symbol == symbol && saga.IsPending() && saga.CreatedAt.Before(before) {
+			pending = append(pending, saga)
+		}
+	}
+	return pending, nil
+}
+
+func (r *InMemoryPlaceOrderSagaRepository) Save(ctx context.Context, saga *sagas.PlaceOrderSaga) error {
+	r.sagas[saga.OrderId] = *saga
+	return nil
+}
+
+type StubUnitOfWork struct{}
+
+func (StubUnitOfWork) Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	return fc(nil)
+}
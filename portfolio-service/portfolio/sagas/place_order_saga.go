@@ -0,0 +1,208 @@
+package sagas
+
+import (
+	"errors"
+	"fmt"
+	"stock-trader/portfolio-service/portfolio"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+var ErrPlaceOrderSagaNotFound = errors.New("order not found")
+
+var ErrPlaceOrderSagaFinished = errors.New("order is no longer pending")
+
+const orderTimedOut = "order timed out"
+
+type PlaceOrderState string
+
+const (
+	// PlaceOrderReserved means the portfolio reserved what the order needs and
+	// the order still has to reach the broker.
+	PlaceOrderReserved  PlaceOrderState = "reserved"
+	PlaceOrderSubmitted PlaceOrderState = "submitted"
+	// PlaceOrderCancelling means the broker cancelled the order after filling
+	// more of it than it reported yet. The unfilled part is released once the
+	// missing fills arrive.
+	PlaceOrderCancelling PlaceOrderState = "cancelling"
+	PlaceOrderCompleted  PlaceOrderState = "completed"
+	PlaceOrderRejected   PlaceOrderState = "rejected"
+	PlaceOrderCancelled  PlaceOrderState = "cancelled"
+	PlaceOrderTimedOut   PlaceOrderState = "timed-out"
+)
+
+// PlaceOrderSaga tracks an order from the moment the portfolio reserves cash or
+// shares for it until it is fully filled or its reservation is released.
+type PlaceOrderSaga struct {
+	OrderId     string                `gorm:"column:order_id;primaryKey" json:"order_id"`
+	PortfolioId string                `gorm:"column:portfolio_id" json:"portfolio_id"`
+	Symbol      string                `gorm:"column:symbol" json:"symbol"`
+	Side        portfolio.OrderSide   `gorm:"column:side" json:"side"`
+	Type        portfolio.OrderType   `gorm:"column:type" json:"type"`
+	TimeInForce portfolio.TimeInForce `gorm:"column:time_in_force" json:"time_in_force"`
+	Quantity    decimal.Decimal       `gorm:"column:quantity" json:"quantity"`
+	LimitPrice  decimal.Decimal       `gorm:"column:limit_price" json:"limit_price"`
+	StopPrice   decimal.Decimal       `gorm:"column:stop_price" json:"stop_price"`
+	// Precision is the decimal places of the shares the order is placed and
+	// filled in.
+	Precision      int32           `gorm:"column:precision" json:"precision"`
+	FilledQuantity decimal.Decimal `gorm:"column:filled_quantity" json:"filled_quantity"`
+	// BrokerFilledQuantity is what the broker said it filled when it cancelled
+	// the order.
+	BrokerFilledQuantity decimal.Decimal              `gorm:"column:broker_filled_quantity" json:"-"`
+	State                PlaceOrderState              `gorm:"column:state" json:"state"`
+	Reason               string                       `gorm:"column:reason" json:"reason,omitempty"`
+	ProcessedTrades      datatypes.JSONType[[]string] `gorm:"column:processed_trades" json:"-"`
+	SubmitAttempts       int                          `gorm:"column:submit_attempts" json:"-"`
+	// Deadline is when the order is cancelled unless it finished by then. Orders
+	// resting in the book of the broker have none, as the broker reports when
+	// their time in force runs out.
+	Deadline  *time.Time `gorm:"column:deadline" json:"deadline,omitempty"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (PlaceOrderSaga) TableName() string {
+	return "place_order_sagas"
+}
+
+// NewPlaceOrderSaga gives the order until the timeout to reach the broker.
+func NewPlaceOrderSaga(portfolioId portfolio.PortfolioId, orderId portfolio.OrderId, request portfolio.OrderRequest, precision int32, now time.Time, timeout time.Duration) *PlaceOrderSaga {
+	deadline := now.Add(timeout)
+	return &PlaceOrderSaga{
+		OrderId:              string(orderId),
+		PortfolioId:          string(portfolioId),
+		Symbol:               request.Symbol,
+		Side:                 request.Side,
+		Type:                 request.Type,
+		TimeInForce:          request.TimeInForce,
+		Quantity:             request.Quantity,
+		FilledQuantity:       decimal.Zero,
+		BrokerFilledQuantity: decimal.Zero,
+		LimitPrice:           request.LimitPrice,
+		StopPrice:            request.StopPrice,
+		Precision:            precision,
+		State:                PlaceOrderReserved,
+		ProcessedTrades:      datatypes.NewJSONType([]string{}),
+		Deadline:             &deadline,
+		CreatedAt:            now,
+		UpdatedAt:            now,
+	}
+}
+
+func (s *PlaceOrderSaga) IsPending() bool {
+	return s.State == PlaceOrderReserved || s.State == PlaceOrderSubmitted
+}
+
+func (s *PlaceOrderSaga) IsExpired(now time.Time) bool {
+	return s.IsPending() && s.Deadline != nil && !now.Before(*s.Deadline)
+}
+
+// MarkSubmitted drops the deadline of orders that rest in the book of the
+// broker, which lets them stay there until their time in force runs out.
+// Others keep the deadline they were given to reach the broker.
+func (s *PlaceOrderSaga) MarkSubmitted(now time.Time) {
+	if s.State != PlaceOrderReserved {
+		return
+	}
+	s.State = PlaceOrderSubmitted
+	if s.timeInForce().Rests() {
+		s.Deadline = nil
+	}
+	s.UpdatedAt = now
+}
+
+// timeInForce is day for orders tracked before they had a time in force.
+func (s *PlaceOrderSaga) timeInForce() portfolio.TimeInForce {
+	if s.TimeInForce == "" {
+		return portfolio.Day
+	}
+	return s.TimeInForce
+}
+
+func (s *PlaceOrderSaga) RecordSubmitFailure(now time.Time) {
+	s.SubmitAttempts++
+	s.UpdatedAt = now
+}
+
+// ExtendDeadline gives the broker more time to report fills of an order it
+// could no longer cancel.
+func (s *PlaceOrderSaga) ExtendDeadline(now time.Time, timeout time.Duration) {
+	deadline := now.Add(timeout)
+	s.Deadline = &deadline
+	s.UpdatedAt = now
+}
+
+// RequestCancellation makes a pending order due for cancellation at the broker
+// right away, as when a split changes the shares it was placed for. The reason
+// is kept for when the order is compensated.
+func (s *PlaceOrderSaga) RequestCancellation(reason string, now time.Time) {
+	if !s.IsPending() {
+		return
+	}
+	s.Reason = reason
+	s.Deadline = &now
+	s.UpdatedAt = now
+}
+
+// expiry is how an order ends once the broker will not fill it anymore:
+// cancelled when its cancellation was requested, timed out otherwise.
+func (s *PlaceOrderSaga) expiry() (PlaceOrderState, string) {
+	if s.Reason != "" {
+		return PlaceOrderCancelled, s.Reason
+	}
+	return PlaceOrderTimedOut, orderTimedOut
+}
+
+// RecordTrade returns false when the trade was already processed, which happens
+// when the broker delivers a fill more than once. The last fill the broker made
+// before cancelling the order times the saga out, or cancels it when its
+// cancellation was requested. The quantity must already be
+// rounded to the precision of the order, as the portfolio settles it.
+func (s *PlaceOrderSaga) RecordTrade(tradeId string, quantity decimal.Decimal, now time.Time) (bool, error) {
+	for _, processed := range s.ProcessedTrades.Data() {
+		if processed == tradeId {
+			return false, nil
+		}
+	}
+	if !s.IsPending() && s.State != PlaceOrderCancelling {
+		return false, fmt.Errorf("%w: %s is %s", ErrPlaceOrderSagaFinished, s.OrderId, s.State)
+	}
+
+	s.ProcessedTrades = datatypes.NewJSONType(append(s.ProcessedTrades.Data(), tradeId))
+	s.FilledQuantity = s.FilledQuantity.Add(quantity)
+	switch {
+	case !s.FilledQuantity.LessThan(s.Quantity):
+		s.State = PlaceOrderCompleted
+	case s.State != PlaceOrderCancelling:
+		s.State = PlaceOrderSubmitted
+	case !s.FilledQuantity.LessThan(s.BrokerFilledQuantity):
+		s.State, s.Reason = s.expiry()
+	}
+	s.UpdatedAt = now
+	return true, nil
+}
+
+// awaitFills keeps the saga open for the fills the broker made before it
+// cancelled the order, but did not report yet.
+func (s *PlaceOrderSaga) awaitFills(brokerFilled decimal.Decimal, now time.Time) error {
+	if !s.IsPending() {
+		return fmt.Errorf("%w: %s is %s", ErrPlaceOrderSagaFinished, s.OrderId, s.State)
+	}
+	s.State = PlaceOrderCancelling
+	s.BrokerFilledQuantity = brokerFilled
+	s.UpdatedAt = now
+	return nil
+}
+
+func (s *PlaceOrderSaga) finish(state PlaceOrderState, reason string, now time.Time) error {
+	if !s.IsPending() {
+		return fmt.Errorf("%w: %s is %s", ErrPlaceOrderSagaFinished, s.OrderId, s.State)
+	}
+	s.State = state
+	s.Reason = reason
+	s.UpdatedAt = now
+	return nil
+}
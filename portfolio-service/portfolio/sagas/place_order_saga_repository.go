@@ -0,0 +1,69 @@
+package sagas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlaceOrderSagaRepository interface {
+	FindByOrderId(context.Context, string) (*PlaceOrderSaga, error)
+	FindDue(context.Context, time.Time, int) ([]PlaceOrderSaga, error)
+	FindPending(context.Context, string, time.Time) ([]PlaceOrderSaga, error)
+	Save(context.Context, *PlaceOrderSaga) error
+}
+
+type mySQLPlaceOrderSagaRepository struct {
+	db *gorm.DB
+}
+
+func NewPlaceOrderSagaRepository(db *gorm.DB) PlaceOrderSagaRepository {
+	return &mySQLPlaceOrderSagaRepository{
+		db: db,
+	}
+}
+
+// FindByOrderId locks the saga until the surrounding transaction ends, so
+// broker notifications and timeouts of the same order are handled one at a time.
+func (r *mySQLPlaceOrderSagaRepository) FindByOrderId(ctx context.Context, orderId string) (*PlaceOrderSaga, error) {
+	saga := &PlaceOrderSaga{}
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("order_id = ?", orderId).First(saga).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrPlaceOrderSagaNotFound, orderId)
+		}
+		return nil, err
+	}
+	return saga, nil
+}
+
+// FindDue returns the sagas that still have to reach the broker or whose
+// deadline has passed, oldest first.
+func (r *mySQLPlaceOrderSagaRepository) FindDue(ctx context.Context, now time.Time, limit int) ([]PlaceOrderSaga, error) {
+	var due []PlaceOrderSaga
+	err := r.db.WithContext(ctx).
+		Where("state = ? OR (state = ? AND deadline <= ?)", PlaceOrderReserved, PlaceOrderSubmitted, now).
+		Order("created_at").
+		Limit(limit).
+		Find(&due).Error
+	return due, err
+}
+
+// FindPending locks the pending sagas of orders for the symbol placed before the
+// given time until the surrounding transaction ends.
+func (r *mySQLPlaceOrderSagaRepository) FindPending(ctx context.Context, symbol string, before time.Time) ([]PlaceOrderSaga, error) {
+	var pending []PlaceOrderSaga
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("symbol = ? AND state IN ? AND created_at < ?", symbol, []PlaceOrderState{PlaceOrderReserved, PlaceOrderSubmitted}, before).
+		Order("created_at").
+		Find(&pending).Error
+	return pending, err
+}
+
+func (r *mySQLPlaceOrderSagaRepository) Save(ctx context.Context, saga *PlaceOrderSaga) error {
+	return r.db.WithContext(ctx).Save(saga).Error
+}
@@ -0,0 +1,144 @@
+package sagas
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/infrastructure"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PlaceOrderSagaRunnerOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	OnError      func(orderId string, err error)
+}
+
+// PlaceOrderSagaRunner drives the steps of the place order saga that involve
+// the broker: submitting reserved orders and cancelling expired ones. All its
+// state lives in the saga table, so a restarted service picks up where it left.
+// The broker is called outside of any transaction and each outcome is recorded
+// in a transaction of its own.
+type PlaceOrderSagaRunner struct {
+	uow     infrastructure.GormUnitOfWork
+	process func(tx *gorm.DB) *PlaceOrderProcess
+	sagas   func(tx *gorm.DB) PlaceOrderSagaRepository
+	broker  Broker
+	options PlaceOrderSagaRunnerOptions
+	now     func() time.Time
+}
+
+func NewPlaceOrderSagaRunner(
+	uow infrastructure.GormUnitOfWork,
+	process func(tx *gorm.DB) *PlaceOrderProcess,
+	sagas func(tx *gorm.DB) PlaceOrderSagaRepository,
+	broker Broker,
+	options PlaceOrderSagaRunnerOptions,
+) *PlaceOrderSagaRunner {
+	return &PlaceOrderSagaRunner{
+		uow:     uow,
+		process: process,
+		sagas:   sagas,
+		broker:  broker,
+		options: options,
+		now:     func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (r *PlaceOrderSagaRunner) Run(ctx context.Context) {
+	for {
+		r.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.options.PollInterval):
+		}
+	}
+}
+
+// Tick advances every due saga by one step.
+func (r *PlaceOrderSagaRunner) Tick(ctx context.Context) {
+	var due []PlaceOrderSaga
+	err := r.uow.Transaction(func(tx *gorm.DB) error {
+		var err error
+		due, err = r.sagas(tx).FindDue(ctx, r.now(), r.options.BatchSize)
+		return err
+	})
+	if err != nil {
+		r.options.OnError("", err)
+		return
+	}
+
+	for _, saga := range due {
+		if saga.IsExpired(r.now()) {
+			err = r.expire(ctx, saga)
+		} else {
+			err = r.submit(ctx, saga)
+		}
+		if err != nil {
+			r.options.OnError(saga.OrderId, err)
+		}
+	}
+}
+
+func (r *PlaceOrderSagaRunner) submit(ctx context.Context, saga PlaceOrderSaga) error {
+	err := r.broker.SubmitOrder(ctx, BrokerOrder{
+		OrderId:     saga.OrderId,
+		PortfolioId: saga.PortfolioId,
+		Symbol:      saga.Symbol,
+		Side:        saga.Side,
+		Type:        saga.Type,
+		TimeInForce: saga.timeInForce(),
+		Quantity:    saga.Quantity,
+		LimitPrice:  saga.LimitPrice,
+		StopPrice:   saga.StopPrice,
+		Precision:   saga.Precision,
+	})
+
+	switch {
+	case err == nil:
+		return r.step(func(process *PlaceOrderProcess) error {
+			return process.MarkSubmitted(ctx, saga.OrderId)
+		})
+	case errors.Is(err, ErrOrderRejectedByBroker):
+		return r.step(func(process *PlaceOrderProcess) error {
+			return process.Reject(ctx, saga.OrderId, err.Error())
+		})
+	default:
+		r.step(func(process *PlaceOrderProcess) error {
+			return process.RecordSubmitFailure(ctx, saga.OrderId)
+		})
+		return err
+	}
+}
+
+func (r *PlaceOrderSagaRunner) expire(ctx context.Context, saga PlaceOrderSaga) error {
+	filled, err := r.broker.CancelOrder(ctx, saga.OrderId)
+
+	switch {
+	case err == nil, errors.Is(err, ErrBrokerOrderNotFound):
+		return r.step(func(process *PlaceOrderProcess) error {
+			return process.Expire(ctx, saga.OrderId, filled)
+		})
+	case errors.Is(err, ErrBrokerOrderAlreadyFilled):
+		return r.step(func(process *PlaceOrderProcess) error {
+			return process.AwaitFills(ctx, saga.OrderId)
+		})
+	default:
+		return err
+	}
+}
+
+// step ignores sagas that finished in the meantime, e.g. because the broker
+// reported a fill while it was being called.
+func (r *PlaceOrderSagaRunner) step(apply func(*PlaceOrderProcess) error) error {
+	err := r.uow.Transaction(func(tx *gorm.DB) error {
+		return apply(r.process(tx))
+	})
+	if errors.Is(err, ErrPlaceOrderSagaFinished) {
+		return nil
+	}
+	return err
+}
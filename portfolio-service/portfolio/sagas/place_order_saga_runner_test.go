@@ -0,0 +1,204 @@
+package sagas_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestPlaceOrderSagaRunner(t *testing.T) {
+	newRunner := func(portfolios *StubPortfolioRepository, placeOrderSagas *InMemoryPlaceOrderSagaRepository, timeout time.Duration, broker *StubBroker) (*sagas.PlaceOrderSagaRunner, *sagas.PlaceOrderProcess, *[]error) {
+		errs := &[]error{}
+		precision, _ := portfolio.ParseSharePrecision("ACME:2")
+		process := sagas.NewPlaceOrderProcess(portfolios, placeOrderSagas, timeout, portfolio.LoyaltyProgram{}, precision, nil)
+		runner := sagas.NewPlaceOrderSagaRunner(
+			StubUnitOfWork{},
+			func(tx *gorm.DB) *sagas.PlaceOrderProcess { return process },
+			func(tx *gorm.DB) sagas.PlaceOrderSagaRepository { return placeOrderSagas },
+			broker,
+			sagas.PlaceOrderSagaRunnerOptions{
+				BatchSize: 10,
+				OnError:   func(orderId string, err error) { *errs = append(*errs, err) },
+			},
+		)
+		return runner, process, errs
+	}
+
+	t.Run("Reserved orders are submitted to the broker in the precision of the symbol", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{}
+		runner, process, errs := newRunner(portfolios, placeOrderSagas, time.Minute, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		assert.Empty(t, *errs)
+		assert.Equal(t, []string{string(orderId)}, broker.submitted)
+		assert.Equal(t, int32(2), broker.precision)
+		assert.Equal(t, portfolio.Limit, broker.orderType)
+		assert.Equal(t, sagas.PlaceOrderSubmitted, placeOrderSagas.sagas[string(orderId)].State)
+	})
+
+	t.Run("Day orders stay with the broker until it reports them expired", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, time.Minute, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		saga := placeOrderSagas.sagas[string(orderId)]
+		assert.Equal(t, portfolio.Day, broker.tif)
+		assert.Nil(t, saga.Deadline)
+		assert.False(t, saga.IsExpired(time.Now().UTC().Add(24*time.Hour)))
+
+		err := process.HandleCancellation(context.Background(), string(orderId), "order expired")
+
+		if assert.NoError(t, err) {
+			assert.Equal(t, sagas.PlaceOrderCancelled, placeOrderSagas.sagas[string(orderId)].State)
+			assert.Equal(t, "1000", portfolios.portfolio.Cash().String())
+		}
+	})
+
+	t.Run("Immediate-or-cancel orders keep their deadline at the broker", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, time.Minute, broker)
+		order := buyOrder("ACME", 10, 20)
+		order.TimeInForce = portfolio.ImmediateOrCancel
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), order)
+
+		runner.Tick(context.Background())
+
+		saga := placeOrderSagas.sagas[string(orderId)]
+		assert.Equal(t, portfolio.ImmediateOrCancel, broker.tif)
+		assert.Equal(t, sagas.PlaceOrderSubmitted, saga.State)
+		assert.True(t, saga.IsExpired(time.Now().UTC().Add(time.Hour)))
+	})
+
+	t.Run("Orders rejected by the broker are compensated", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{submitErr: fmt.Errorf("%w: unknown symbol", sagas.ErrOrderRejectedByBroker)}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, time.Minute, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		assert.Equal(t, sagas.PlaceOrderRejected, placeOrderSagas.sagas[string(orderId)].State)
+		assert.Equal(t, "1000", portfolios.portfolio.Cash().String())
+	})
+
+	t.Run("Orders the broker could not take are retried", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{submitErr: errors.New("connection refused")}
+		runner, process, errs := newRunner(portfolios, placeOrderSagas, time.Minute, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		assert.Len(t, *errs, 1)
+		saga := placeOrderSagas.sagas[string(orderId)]
+		assert.Equal(t, sagas.PlaceOrderReserved, saga.State)
+		assert.Equal(t, 1, saga.SubmitAttempts)
+	})
+
+	t.Run("Expired orders are cancelled and compensated", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, -time.Second, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		assert.Equal(t, []string{string(orderId)}, broker.cancelled)
+		assert.Equal(t, sagas.PlaceOrderTimedOut, placeOrderSagas.sagas[string(orderId)].State)
+		assert.Equal(t, "1000", portfolios.portfolio.Cash().String())
+	})
+
+	t.Run("Orders whose cancellation was requested are cancelled and compensated", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, time.Minute, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+		runner.Tick(context.Background())
+		saga := placeOrderSagas.sagas[string(orderId)]
+		saga.RequestCancellation("cancelled by a split of ACME", time.Now().UTC())
+		placeOrderSagas.Save(context.Background(), &saga)
+
+		runner.Tick(context.Background())
+
+		saga = placeOrderSagas.sagas[string(orderId)]
+		assert.Equal(t, []string{string(orderId)}, broker.cancelled)
+		assert.Equal(t, sagas.PlaceOrderCancelled, saga.State)
+		assert.Equal(t, "cancelled by a split of ACME", saga.Reason)
+		assert.Equal(t, "1000", portfolios.portfolio.Cash().String())
+	})
+
+	t.Run("Expired orders partly filled by the broker are compensated once the fills arrive", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{filled: decimal.NewFromInt(4)}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, -time.Second, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		assert.Equal(t, sagas.PlaceOrderCancelling, placeOrderSagas.sagas[string(orderId)].State)
+		assert.Equal(t, "800", portfolios.portfolio.Cash().String())
+
+		err := process.HandleTrade(context.Background(), string(orderId), "trade-1", decimal.NewFromInt(4), decimal.NewFromInt(20))
+
+		if assert.NoError(t, err) {
+			saga := placeOrderSagas.sagas[string(orderId)]
+			assert.Equal(t, sagas.PlaceOrderTimedOut, saga.State)
+			assert.Equal(t, "920", portfolios.portfolio.Cash().String())
+			assert.True(t, portfolios.portfolio.ReservedCash().IsZero())
+			holding, _ := portfolios.portfolio.Holding("ACME")
+			assert.Equal(t, "4", holding.Quantity.String())
+		}
+	})
+
+	t.Run("Expired orders already filled by the broker keep waiting for the fills", func(t *testing.T) {
+		portfolios, placeOrderSagas, owner := newFundedPortfolio(t, 1000)
+		broker := &StubBroker{cancelErr: fmt.Errorf("%w: order", sagas.ErrBrokerOrderAlreadyFilled)}
+		runner, process, _ := newRunner(portfolios, placeOrderSagas, -time.Second, broker)
+		orderId, _, _ := process.Begin(context.Background(), owner.Id(), buyOrder("ACME", 10, 20))
+
+		runner.Tick(context.Background())
+
+		saga := placeOrderSagas.sagas[string(orderId)]
+		assert.Equal(t, sagas.PlaceOrderSubmitted, saga.State)
+		assert.Equal(t, "800", portfolios.portfolio.Cash().String())
+	})
+}
+
+type StubBroker struct {
+	submitted []string
+	precision int32
+	orderType portfolio.OrderType
+	tif       portfolio.TimeInForce
+	cancelled []string
+	filled    decimal.Decimal
+	submitErr error
+	cancelErr error
+}
+
+func (b *StubBroker) SubmitOrder(ctx context.Context, order sagas.BrokerOrder) error {
+	b.submitted = append(b.submitted, order.OrderId)
+	b.precision = order.Precision
+	b.orderType = order.Type
+	b.tif = order.TimeInForce
+	return b.submitErr
+}
+
+func (b *StubBroker) CancelOrder(ctx context.Context, orderId string) (decimal.Decimal, error) {
+	b.cancelled = append(b.cancelled, orderId)
+	return b.filled, b.cancelErr
+}
@@ -0,0 +1,74 @@
+package portfolio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxSharePrecision is the finest fraction of a share that can be traded, in
+// decimal places.
+const MaxSharePrecision = 6
+
+// SharePrecision is how many decimal places of a share each symbol is traded
+// in. The zero value trades every symbol in whole shares.
+type SharePrecision struct {
+	places        map[string]int32
+	defaultPlaces int32
+}
+
+// ParseSharePrecision reads comma separated symbol:places pairs, e.g.
+// "ACME:4,INIT:2". The symbol * sets the places of every symbol not listed,
+// which trade in whole shares otherwise.
+func ParseSharePrecision(spec string) (SharePrecision, error) {
+	precision := SharePrecision{places: map[string]int32{}}
+	for _, field := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(field), ":")
+		if len(parts) != 2 {
+			return SharePrecision{}, fmt.Errorf("share precision %q must be written as symbol:places", field)
+		}
+		places, err := strconv.Atoi(parts[1])
+		if err != nil || places < 0 || places > MaxSharePrecision {
+			return SharePrecision{}, fmt.Errorf("share precision %q must have between 0 and %d places", field, MaxSharePrecision)
+		}
+		symbol := strings.ToUpper(strings.TrimSpace(parts[0]))
+		if symbol == "*" {
+			precision.defaultPlaces = int32(places)
+			continue
+		}
+		if len(symbol) == 0 || len(symbol) > 8 {
+			return SharePrecision{}, fmt.Errorf("share precision %q must name a symbol of up to 8 characters", field)
+		}
+		precision.places[symbol] = int32(places)
+	}
+	return precision, nil
+}
+
+// Places is the number of decimal places the symbol is traded in.
+func (p SharePrecision) Places(symbol string) int32 {
+	if places, ok := p.places[symbol]; ok {
+		return places
+	}
+	return p.defaultPlaces
+}
+
+// ShareIncrement is the smallest quantity traded with the given places, such
+// as 1 for whole shares or 0.01 for two places.
+func ShareIncrement(places int32) decimal.Decimal {
+	return decimal.New(1, -places)
+}
+
+// decimalPlaces is the number of places the quantity needs, without trailing
+// zeros.
+func decimalPlaces(quantity decimal.Decimal) int32 {
+	places := -quantity.Exponent()
+	for places > 0 && quantity.Equal(quantity.Truncate(places-1)) {
+		places--
+	}
+	if places < 0 {
+		return 0
+	}
+	return places
+}
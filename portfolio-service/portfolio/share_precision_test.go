@@ -0,0 +1,47 @@
+package portfolio_test
+
+import (
+	"stock-trader/portfolio-service/portfolio"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharePrecision(t *testing.T) {
+	t.Run("Symbols are traded in the places set for them", func(t *testing.T) {
+		precision, err := portfolio.ParseSharePrecision("acme:4, INIT:2")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4), precision.Places("ACME"))
+		assert.Equal(t, int32(2), precision.Places("INIT"))
+		assert.Equal(t, int32(0), precision.Places("OTHER"))
+	})
+
+	t.Run("The wildcard sets the places of every other symbol", func(t *testing.T) {
+		precision, err := portfolio.ParseSharePrecision("ACME:0,*:3")
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), precision.Places("ACME"))
+		assert.Equal(t, int32(3), precision.Places("OTHER"))
+	})
+
+	t.Run("The zero precision trades whole shares", func(t *testing.T) {
+		assert.Equal(t, int32(0), portfolio.SharePrecision{}.Places("ACME"))
+		assert.Equal(t, "1", portfolio.ShareIncrement(0).String())
+		assert.Equal(t, "0.01", portfolio.ShareIncrement(2).String())
+	})
+
+	t.Run("Parse invalid precisions", func(t *testing.T) {
+		for spec, message := range map[string]string{
+			"ACME":          `share precision "ACME" must be written as symbol:places`,
+			"ACME:7":        `share precision "ACME:7" must have between 0 and 6 places`,
+			"ACME:-1":       `share precision "ACME:-1" must have between 0 and 6 places`,
+			"ACME:two":      `share precision "ACME:two" must have between 0 and 6 places`,
+			"TOOLONGNAME:2": `share precision "TOOLONGNAME:2" must name a symbol of up to 8 characters`,
+		} {
+			_, err := portfolio.ParseSharePrecision(spec)
+
+			assert.EqualError(t, err, message, spec)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package snapshots
+
+import (
+	"fmt"
+	"time"
+)
+
+// MarketClose is the time of day the market closes, in the time zone of its
+// exchange. The market closes on weekdays only; holidays are not known, so
+// they get snapshots like any other weekday.
+//
+// Market dates are given as midnight UTC of the calendar date of the close.
+type MarketClose struct {
+	hour     int
+	minute   int
+	location *time.Location
+}
+
+func NewMarketClose(hour int, minute int, location *time.Location) MarketClose {
+	return MarketClose{
+		hour:     hour,
+		minute:   minute,
+		location: location,
+	}
+}
+
+// ParseMarketClose reads a close time such as "16:00" in a time zone such as
+// "America/New_York".
+func ParseMarketClose(clock string, zone string) (MarketClose, error) {
+	at, err := time.Parse("15:04", clock)
+	if err != nil {
+		return MarketClose{}, fmt.Errorf("market close must be given as HH:MM, got %q", clock)
+	}
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return MarketClose{}, fmt.Errorf("unknown market time zone %q: %w", zone, err)
+	}
+	return NewMarketClose(at.Hour(), at.Minute(), location), nil
+}
+
+// On is the instant the market closes on a market date.
+func (c MarketClose) On(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), c.hour, c.minute, 0, 0, c.location)
+}
+
+// Latest is the market date of the latest close at or before now.
+func (c MarketClose) Latest(now time.Time) time.Time {
+	date := c.dateOf(now)
+	for !isWeekday(date) || c.On(date).After(now) {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
+
+// Next is the first close after now.
+func (c MarketClose) Next(now time.Time) time.Time {
+	date := c.dateOf(now)
+	for !isWeekday(date) || !c.On(date).After(now) {
+		date = date.AddDate(0, 0, 1)
+	}
+	return c.On(date)
+}
+
+// Previous is the market date before date.
+func (c MarketClose) Previous(date time.Time) time.Time {
+	date = date.AddDate(0, 0, -1)
+	for !isWeekday(date) {
+		date = date.AddDate(0, 0, -1)
+	}
+	return date
+}
+
+func (c MarketClose) dateOf(instant time.Time) time.Time {
+	local := instant.In(c.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func isWeekday(date time.Time) bool {
+	return date.Weekday() != time.Saturday && date.Weekday() != time.Sunday
+}
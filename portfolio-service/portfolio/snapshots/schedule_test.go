@@ -0,0 +1,47 @@
+package snapshots_test
+
+import (
+	"stock-trader/portfolio-service/portfolio/snapshots"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketClose(t *testing.T) {
+	market, err := snapshots.ParseMarketClose("16:00", "America/New_York")
+	if !assert.NoError(t, err) {
+		return
+	}
+	date := func(day int) time.Time {
+		return time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("Closes in the time zone of the market", func(t *testing.T) {
+		assert.Equal(t, time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC), market.On(date(19)).UTC())
+		assert.Equal(t, time.Date(2026, 11, 2, 21, 0, 0, 0, time.UTC), market.On(time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)).UTC())
+	})
+
+	t.Run("The latest close is the previous weekday until the market closes", func(t *testing.T) {
+		assert.Equal(t, date(16), market.Latest(time.Date(2026, 10, 19, 19, 59, 0, 0, time.UTC)))
+		assert.Equal(t, date(19), market.Latest(time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)))
+		assert.Equal(t, date(16), market.Latest(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("The next close skips the weekend", func(t *testing.T) {
+		assert.Equal(t, market.On(date(19)), market.Next(time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)))
+		assert.Equal(t, market.On(date(16)), market.Next(time.Date(2026, 10, 16, 19, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("The previous market date skips the weekend", func(t *testing.T) {
+		assert.Equal(t, date(16), market.Previous(date(19)))
+		assert.Equal(t, date(19), market.Previous(date(20)))
+	})
+
+	t.Run("Reject invalid settings", func(t *testing.T) {
+		_, err := snapshots.ParseMarketClose("4pm", "America/New_York")
+		assert.Error(t, err)
+		_, err = snapshots.ParseMarketClose("16:00", "Nowhere/Special")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,201 @@
+package snapshots
+
+import (
+	"context"
+	"sort"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+// PriceSource tells where the prices of a snapshot come from.
+type PriceSource string
+
+const (
+	// PricedAtQuotes snapshots were taken at the close, at the quotes of the
+	// market.
+	PricedAtQuotes PriceSource = "quotes"
+	// PricedAtTrades snapshots were backfilled after the close, at the price
+	// of the latest trade of each symbol.
+	PricedAtTrades PriceSource = "trades"
+)
+
+// SnapshotPosition is a holding of a snapshot. Its price is in the currency the
+// symbol trades in, and its market value in the base currency.
+type SnapshotPosition struct {
+	Symbol      string             `json:"symbol"`
+	Currency    portfolio.Currency `json:"currency"`
+	Quantity    decimal.Decimal    `json:"quantity"`
+	Price       decimal.Decimal    `json:"price"`
+	MarketValue decimal.Decimal    `json:"market_value"`
+	Stale       bool               `json:"stale"`
+}
+
+// SnapshotCash is the cash of a snapshot in one currency, unconverted.
+type SnapshotCash struct {
+	Currency     portfolio.Currency `json:"currency"`
+	Cash         decimal.Decimal    `json:"cash"`
+	ReservedCash decimal.Decimal    `json:"reserved_cash"`
+}
+
+// Snapshot is what a portfolio held and was worth at the close of a market
+// date, in the base currency. CashBalances keep the cash of every currency as
+// it was. NetFlow is the cash that came in or went out since the close before,
+// empty on snapshots taken before it was recorded. JournalPosition is the
+// latest journal event the snapshot accounts for.
+type Snapshot struct {
+	PortfolioId     string                                 `gorm:"column:portfolio_id;primaryKey" json:"portfolio_id"`
+	Date            time.Time                              `gorm:"column:date;primaryKey" json:"date"`
+	Cash            decimal.Decimal                        `gorm:"column:cash" json:"cash"`
+	ReservedCash    decimal.Decimal                        `gorm:"column:reserved_cash" json:"reserved_cash"`
+	CashBalances    datatypes.JSONType[[]SnapshotCash]     `gorm:"column:cash_balances" json:"cash_balances"`
+	HoldingsValue   decimal.Decimal                        `gorm:"column:holdings_value" json:"holdings_value"`
+	TotalValue      decimal.Decimal                        `gorm:"column:total_value" json:"total_value"`
+	NetFlow         decimal.NullDecimal                    `gorm:"column:net_flow" json:"net_flow"`
+	Positions       datatypes.JSONType[[]SnapshotPosition] `gorm:"column:positions" json:"positions"`
+	PriceSource     PriceSource                            `gorm:"column:price_source" json:"price_source"`
+	Stale           bool                                   `gorm:"column:stale" json:"stale"`
+	JournalPosition int64                                  `gorm:"column:journal_position" json:"-"`
+	TakenAt         time.Time                              `gorm:"column:taken_at" json:"taken_at"`
+}
+
+func (Snapshot) TableName() string {
+	return "portfolio_snapshots"
+}
+
+// NewSnapshot values the state of a portfolio at the close of a date, in the
+// base currency at the rates, along with what flowed in and out since its
+// previous state. Without quotes, holdings are priced at their
+// latest trade; with quotes, a symbol whose quote can not be had keeps that
+// price and is marked stale. Rates that can not be had fail the snapshot.
+func NewSnapshot(ctx context.Context, portfolioId string, date time.Time, state performance.JournalState, previous performance.JournalState, quotes valuation.QuoteSource, rates fx.RateSource, takenAt time.Time) (Snapshot, error) {
+	snapshot := Snapshot{
+		PortfolioId:     portfolioId,
+		Date:            date,
+		Cash:            decimal.Zero,
+		ReservedCash:    decimal.Zero,
+		HoldingsValue:   decimal.Zero,
+		PriceSource:     PricedAtTrades,
+		JournalPosition: state.Position,
+		TakenAt:         takenAt,
+	}
+	if quotes != nil {
+		snapshot.PriceSource = PricedAtQuotes
+	}
+
+	netFlow, err := state.FlowSince(ctx, rates, previous)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshot.NetFlow = decimal.NewNullDecimal(netFlow)
+
+	balances := []SnapshotCash{}
+	for _, currency := range currencies(state.Cash, state.ReservedCash) {
+		balance := SnapshotCash{Currency: currency, Cash: state.Cash[currency], ReservedCash: state.ReservedCash[currency]}
+		cash, err := performance.Convert(ctx, rates, balance.Cash, currency)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		reserved, err := performance.Convert(ctx, rates, balance.ReservedCash, currency)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Cash = snapshot.Cash.Add(cash)
+		snapshot.ReservedCash = snapshot.ReservedCash.Add(reserved)
+		balances = append(balances, balance)
+	}
+
+	symbols := make([]string, 0, len(state.Holdings))
+	for symbol := range state.Holdings {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	positions := []SnapshotPosition{}
+	for _, symbol := range symbols {
+		position := SnapshotPosition{
+			Symbol:   symbol,
+			Currency: state.Currencies[symbol],
+			Quantity: state.Holdings[symbol],
+			Price:    state.Prices[symbol],
+		}
+		if position.Currency == "" {
+			position.Currency = portfolio.BaseCurrency
+		}
+		if quotes != nil {
+			if quote, err := quotes.Quote(ctx, symbol); err == nil {
+				position.Price = quote.Last
+				position.Stale = quote.Stale
+			} else {
+				position.Stale = true
+			}
+		}
+		marketValue, err := performance.Convert(ctx, rates, position.Price.Mul(position.Quantity), position.Currency)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		position.MarketValue = marketValue
+
+		snapshot.HoldingsValue = snapshot.HoldingsValue.Add(position.MarketValue)
+		snapshot.Stale = snapshot.Stale || position.Stale
+		positions = append(positions, position)
+	}
+
+	snapshot.CashBalances = datatypes.NewJSONType(balances)
+	snapshot.Positions = datatypes.NewJSONType(positions)
+	snapshot.TotalValue = snapshot.Cash.Add(snapshot.ReservedCash).Add(snapshot.HoldingsValue)
+	return snapshot, nil
+}
+
+// State is the portfolio as the snapshot recorded it, with its holdings at the
+// prices they were valued at. Flows are left out. Snapshots taken before cash
+// was kept per currency hold their cash in the base currency.
+func (s Snapshot) State() performance.JournalState {
+	state := performance.JournalState{
+		Position:     s.JournalPosition,
+		Cash:         portfolio.CashBalances{},
+		ReservedCash: portfolio.CashBalances{},
+		Holdings:     map[string]decimal.Decimal{},
+		Prices:       map[string]decimal.Decimal{},
+		Currencies:   map[string]portfolio.Currency{},
+		Flows:        portfolio.CashBalances{},
+	}
+	balances := s.CashBalances.Data()
+	if len(balances) == 0 {
+		balances = []SnapshotCash{{Currency: portfolio.BaseCurrency, Cash: s.Cash, ReservedCash: s.ReservedCash}}
+	}
+	for _, balance := range balances {
+		state.Cash[balance.Currency] = balance.Cash
+		state.ReservedCash[balance.Currency] = balance.ReservedCash
+	}
+	for _, position := range s.Positions.Data() {
+		state.Holdings[position.Symbol] = position.Quantity
+		state.Prices[position.Symbol] = position.Price
+		state.Currencies[position.Symbol] = position.Currency
+		if position.Currency == "" {
+			state.Currencies[position.Symbol] = portfolio.BaseCurrency
+		}
+	}
+	return state
+}
+
+// currencies returns the currencies of the balances in alphabetical order.
+func currencies(balances ...portfolio.CashBalances) []portfolio.Currency {
+	seen := map[portfolio.Currency]bool{}
+	for _, balance := range balances {
+		for currency := range balance {
+			seen[currency] = true
+		}
+	}
+	sorted := make([]portfolio.Currency, 0, len(seen))
+	for currency := range seen {
+		sorted = append(sorted, currency)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
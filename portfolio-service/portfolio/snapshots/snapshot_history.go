@@ -0,0 +1,62 @@
+package snapshots
+
+import (
+	"context"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DaySource values the days of a portfolio after a state it was in at the end
+// of a date, up to until.
+type DaySource func(ctx context.Context, portfolioId string, state performance.JournalState, date time.Time, until time.Time) ([]performance.DailyValuation, error)
+
+type snapshotHistorySource struct {
+	snapshots SnapshotRepository
+	days      DaySource
+	journal   performance.HistorySource
+}
+
+// NewSnapshotHistorySource reads daily valuations from the snapshots of the
+// portfolio, priced at the quotes of each close, and replays the journal only
+// for the days after the latest one. Periods the snapshots do not cover from
+// the day before, or that hold snapshots taken before they recorded flows, are
+// replayed from the journal as a whole.
+func NewSnapshotHistorySource(db *gorm.DB, rates fx.RateSource) performance.HistorySource {
+	return &snapshotHistorySource{
+		snapshots: NewSnapshotRepository(db),
+		days: func(ctx context.Context, portfolioId string, state performance.JournalState, date time.Time, until time.Time) ([]performance.DailyValuation, error) {
+			return performance.ReplayDays(ctx, db, rates, portfolioId, state, date, until)
+		},
+		journal: performance.NewJournalHistorySource(db, rates),
+	}
+}
+
+func (s *snapshotHistorySource) History(ctx context.Context, portfolioId string, from time.Time, to time.Time) (performance.History, error) {
+	from, to = from.UTC().Truncate(24*time.Hour), to.UTC().Truncate(24*time.Hour)
+	span, err := s.snapshots.Span(ctx, portfolioId, from, to)
+	if err != nil {
+		return performance.History{}, err
+	}
+	if len(span) == 0 || !span[0].Date.Before(from) {
+		return s.journal.History(ctx, portfolioId, from, to)
+	}
+
+	valuations := make([]performance.DailyValuation, 0, len(span))
+	for i, snapshot := range span {
+		// The flows of the snapshot before the period are not needed.
+		if i > 0 && !snapshot.NetFlow.Valid {
+			return s.journal.History(ctx, portfolioId, from, to)
+		}
+		valuations = append(valuations, performance.DailyValuation{Date: snapshot.Date, Value: snapshot.TotalValue, NetFlow: snapshot.NetFlow.Decimal})
+	}
+
+	last := span[len(span)-1]
+	days, err := s.days(ctx, portfolioId, last.State(), last.Date, to.AddDate(0, 0, 1))
+	if err != nil {
+		return performance.History{}, err
+	}
+	return performance.Spread(append(valuations, days...), from, to), nil
+}
@@ -0,0 +1,79 @@
+package snapshots
+
+import (
+	"context"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubHistorySource struct {
+	calls int
+}
+
+func (s *stubHistorySource) History(ctx context.Context, portfolioId string, from time.Time, to time.Time) (performance.History, error) {
+	s.calls++
+	return performance.History{StartValue: decimal.Zero}, nil
+}
+
+func TestSnapshotHistorySource(t *testing.T) {
+	date := func(day int) time.Time {
+		return time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+	}
+	snapshot := func(day int, value int64, flow int64) Snapshot {
+		return Snapshot{PortfolioId: "a-portfolio", Date: date(day), TotalValue: decimal.NewFromInt(value), NetFlow: decimal.NewNullDecimal(decimal.NewFromInt(flow)), JournalPosition: int64(day)}
+	}
+	newSource := func(saved ...Snapshot) (*snapshotHistorySource, *stubHistorySource) {
+		journal := &stubHistorySource{}
+		return &snapshotHistorySource{
+			snapshots: &memorySnapshotRepository{saved: map[string][]Snapshot{"a-portfolio": saved}},
+			days: func(ctx context.Context, portfolioId string, state performance.JournalState, after time.Time, until time.Time) ([]performance.DailyValuation, error) {
+				assert.Equal(t, date(15), after)
+				assert.Equal(t, int64(15), state.Position)
+				assert.Equal(t, date(19), until)
+				return []performance.DailyValuation{{Date: date(18), Value: decimal.NewFromInt(1200), NetFlow: decimal.NewFromInt(100)}}, nil
+			},
+			journal: journal,
+		}, journal
+	}
+
+	t.Run("Read the days of the snapshots and replay the days after the latest one", func(t *testing.T) {
+		source, journal := newSource(snapshot(13, 1000, 1000), snapshot(14, 1010, 0), snapshot(15, 1080, 50))
+
+		history, err := source.History(context.Background(), "a-portfolio", date(14), date(18))
+
+		assert.NoError(t, err)
+		assert.Zero(t, journal.calls)
+		assert.Equal(t, "1000", history.StartValue.String())
+		values, flows := []string{}, []string{}
+		for _, day := range history.Days {
+			values = append(values, day.Value.String())
+			flows = append(flows, day.NetFlow.String())
+		}
+		assert.Equal(t, []string{"1010", "1080", "1080", "1080", "1200"}, values)
+		assert.Equal(t, []string{"0", "50", "0", "0", "100"}, flows)
+	})
+
+	t.Run("Replay the journal without a snapshot before the period", func(t *testing.T) {
+		source, journal := newSource(snapshot(14, 1010, 0))
+
+		_, err := source.History(context.Background(), "a-portfolio", date(14), date(18))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, journal.calls)
+	})
+
+	t.Run("Replay the journal over snapshots taken before they recorded flows", func(t *testing.T) {
+		legacy := snapshot(14, 1010, 0)
+		legacy.NetFlow = decimal.NullDecimal{}
+		source, journal := newSource(snapshot(13, 1000, 1000), legacy)
+
+		_, err := source.History(context.Background(), "a-portfolio", date(14), date(18))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, journal.calls)
+	})
+}
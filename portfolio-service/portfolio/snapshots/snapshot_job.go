@@ -0,0 +1,140 @@
+package snapshots
+
+import (
+	"context"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"time"
+)
+
+// StateSource replays the state of a portfolio as of each of the instants,
+// given in order.
+type StateSource func(ctx context.Context, portfolioId string, instants []time.Time) ([]performance.JournalState, error)
+
+type SnapshotJobOptions struct {
+	// Backfill is how many market dates, up to the latest one, a portfolio
+	// catches up on when it has no snapshot for them.
+	Backfill int
+	// LiveWindow is how long after a close the quotes of the market still
+	// price its snapshot. Later snapshots are priced at the latest trades.
+	LiveWindow time.Duration
+	OnError    func(portfolioId string, err error)
+}
+
+// SnapshotJob records a snapshot of every portfolio at every market close.
+// Cash and holdings are replayed from the journal up to the close, so that a
+// late run or a backfill records the portfolio as it was, not as it is. It
+// resumes from the latest snapshot of each portfolio, so a restarted service
+// backfills the closes it missed, and running it twice only replaces
+// snapshots with equal ones.
+type SnapshotJob struct {
+	snapshots SnapshotRepository
+	states    StateSource
+	quotes    valuation.QuoteSource
+	rates     fx.RateSource
+	market    MarketClose
+	options   SnapshotJobOptions
+	now       func() time.Time
+}
+
+func NewSnapshotJob(snapshots SnapshotRepository, states StateSource, quotes valuation.QuoteSource, rates fx.RateSource, market MarketClose, options SnapshotJobOptions) *SnapshotJob {
+	return &SnapshotJob{
+		snapshots: snapshots,
+		states:    states,
+		quotes:    quotes,
+		rates:     rates,
+		market:    market,
+		options:   options,
+		now:       time.Now,
+	}
+}
+
+// Run catches up right away, then ticks at every close until the context is
+// done.
+func (j *SnapshotJob) Run(ctx context.Context) {
+	for {
+		j.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(j.market.Next(j.now()).Sub(j.now())):
+		}
+	}
+}
+
+// Tick records the snapshots every portfolio is missing, up to the latest
+// close.
+func (j *SnapshotJob) Tick(ctx context.Context) {
+	now := j.now()
+	latest := j.market.Latest(now)
+
+	progress, err := j.snapshots.Progress(ctx)
+	if err != nil {
+		j.options.OnError("", err)
+		return
+	}
+
+	for _, portfolio := range progress {
+		dates := j.missing(portfolio.LastDate, latest)
+		if len(dates) == 0 {
+			continue
+		}
+		if err := j.take(ctx, portfolio.PortfolioId, portfolio.LastDate, dates, now); err != nil {
+			j.options.OnError(portfolio.PortfolioId, err)
+		}
+	}
+}
+
+// missing is the market dates after the last snapshot up to the latest one,
+// oldest first and no more than the backfill.
+func (j *SnapshotJob) missing(last *time.Time, latest time.Time) []time.Time {
+	dates := []time.Time{}
+	for date := latest; len(dates) < j.options.Backfill; date = j.market.Previous(date) {
+		if last != nil && !date.After(*last) {
+			break
+		}
+		dates = append([]time.Time{date}, dates...)
+	}
+	return dates
+}
+
+// take records the snapshots of the dates. The state at the close before the
+// first date, that of the last snapshot if there is one, tells what flowed in
+// and out by the first close.
+func (j *SnapshotJob) take(ctx context.Context, portfolioId string, last *time.Time, dates []time.Time, now time.Time) error {
+	previous := j.market.Previous(dates[0])
+	if last != nil {
+		previous = *last
+	}
+	closes := make([]time.Time, len(dates))
+	for i, date := range dates {
+		closes[i] = j.market.On(date)
+	}
+
+	states, err := j.states(ctx, portfolioId, append([]time.Time{j.market.On(previous)}, closes...))
+	if err != nil {
+		return err
+	}
+
+	for i, state := range states[1:] {
+		// The portfolio was not opened yet.
+		if state.Position == 0 {
+			continue
+		}
+
+		var quotes valuation.QuoteSource
+		if now.Sub(closes[i]) <= j.options.LiveWindow {
+			quotes = j.quotes
+		}
+		snapshot, err := NewSnapshot(ctx, portfolioId, dates[i], state, states[i], quotes, j.rates, now)
+		if err != nil {
+			return err
+		}
+		if err := j.snapshots.Save(ctx, &snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
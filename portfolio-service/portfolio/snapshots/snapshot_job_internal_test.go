@@ -0,0 +1,180 @@
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySnapshotRepository struct {
+	portfolios []string
+	saved      map[string][]Snapshot
+}
+
+func (r *memorySnapshotRepository) Save(ctx context.Context, snapshot *Snapshot) error {
+	snapshots := r.saved[snapshot.PortfolioId]
+	for i := range snapshots {
+		if snapshots[i].Date.Equal(snapshot.Date) {
+			snapshots[i] = *snapshot
+			return nil
+		}
+	}
+	r.saved[snapshot.PortfolioId] = append(snapshots, *snapshot)
+	return nil
+}
+
+func (r *memorySnapshotRepository) Progress(ctx context.Context) ([]SnapshotProgress, error) {
+	progress := []SnapshotProgress{}
+	for _, portfolioId := range r.portfolios {
+		var last *time.Time
+		for _, snapshot := range r.saved[portfolioId] {
+			if date := snapshot.Date; last == nil || date.After(*last) {
+				last = &date
+			}
+		}
+		progress = append(progress, SnapshotProgress{PortfolioId: portfolioId, LastDate: last})
+	}
+	return progress, nil
+}
+
+func (r *memorySnapshotRepository) List(ctx context.Context, filter SnapshotFilter) (*SnapshotPage, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *memorySnapshotRepository) Span(ctx context.Context, portfolioId string, from time.Time, to time.Time) ([]Snapshot, error) {
+	span := []Snapshot{}
+	for _, snapshot := range r.saved[portfolioId] {
+		switch {
+		case snapshot.Date.Before(from):
+			span = []Snapshot{snapshot}
+		case !snapshot.Date.After(to):
+			span = append(span, snapshot)
+		}
+	}
+	return span, nil
+}
+
+type stubQuotes struct{}
+
+func (stubQuotes) Quote(ctx context.Context, symbol string) (valuation.Quote, error) {
+	return valuation.Quote{Symbol: symbol, Last: decimal.NewFromInt(30)}, nil
+}
+
+func TestSnapshotJob(t *testing.T) {
+	market := NewMarketClose(16, 0, time.UTC)
+	date := func(day int) time.Time {
+		return time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC)
+	}
+	// The portfolio is opened and funded on the 14th and buys ACME at 20 on the
+	// 15th.
+	states := func(ctx context.Context, portfolioId string, instants []time.Time) ([]performance.JournalState, error) {
+		result := []performance.JournalState{}
+		for _, instant := range instants {
+			state := performance.JournalState{Cash: portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(1000)}, ReservedCash: portfolio.CashBalances{}, Holdings: map[string]decimal.Decimal{}, Prices: map[string]decimal.Decimal{}}
+			if !instant.Before(market.On(date(14))) {
+				state.Position = 1
+				state.Flows = portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(1000)}
+			}
+			if !instant.Before(market.On(date(15))) {
+				state.Position = 2
+				state.Cash = portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(800)}
+				state.Holdings["ACME"] = decimal.NewFromInt(10)
+				state.Prices["ACME"] = decimal.NewFromInt(20)
+			}
+			result = append(result, state)
+		}
+		return result, nil
+	}
+	newJob := func(repository SnapshotRepository, now time.Time, fail func(string, error)) *SnapshotJob {
+		job := NewSnapshotJob(repository, states, stubQuotes{}, fx.NewRates(portfolio.BaseCurrency, nil), market, SnapshotJobOptions{
+			Backfill:   10,
+			LiveWindow: time.Hour,
+			OnError:    fail,
+		})
+		job.now = func() time.Time { return now }
+		return job
+	}
+	noErrors := func(portfolioId string, err error) {
+		t.Errorf("snapshot of %s: %v", portfolioId, err)
+	}
+
+	t.Run("Backfill every market date since the portfolio was opened", func(t *testing.T) {
+		repository := &memorySnapshotRepository{portfolios: []string{"a-portfolio"}, saved: map[string][]Snapshot{}}
+
+		newJob(repository, time.Date(2026, 10, 19, 16, 30, 0, 0, time.UTC), noErrors).Tick(context.Background())
+
+		saved := repository.saved["a-portfolio"]
+		if assert.Len(t, saved, 4) {
+			assert.Equal(t, []time.Time{date(14), date(15), date(16), date(19)}, []time.Time{saved[0].Date, saved[1].Date, saved[2].Date, saved[3].Date})
+			assert.Equal(t, "1000", saved[0].TotalValue.String())
+			assert.Equal(t, "1000", saved[0].NetFlow.Decimal.String())
+			assert.Equal(t, "0", saved[1].NetFlow.Decimal.String())
+			assert.Equal(t, PricedAtTrades, saved[2].PriceSource)
+			assert.Equal(t, "1000", saved[2].TotalValue.String())
+			assert.Equal(t, PricedAtQuotes, saved[3].PriceSource)
+			assert.Equal(t, "1100", saved[3].TotalValue.String())
+		}
+	})
+
+	t.Run("Price the latest close at trades once the live window has passed", func(t *testing.T) {
+		repository := &memorySnapshotRepository{portfolios: []string{"a-portfolio"}, saved: map[string][]Snapshot{}}
+
+		newJob(repository, time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC), noErrors).Tick(context.Background())
+
+		saved := repository.saved["a-portfolio"]
+		assert.Equal(t, PricedAtTrades, saved[len(saved)-1].PriceSource)
+	})
+
+	t.Run("Resume from the latest snapshot", func(t *testing.T) {
+		repository := &memorySnapshotRepository{portfolios: []string{"a-portfolio"}, saved: map[string][]Snapshot{}}
+		newJob(repository, time.Date(2026, 10, 16, 16, 5, 0, 0, time.UTC), noErrors).Tick(context.Background())
+		assert.Len(t, repository.saved["a-portfolio"], 3)
+
+		newJob(repository, time.Date(2026, 10, 20, 16, 5, 0, 0, time.UTC), noErrors).Tick(context.Background())
+		newJob(repository, time.Date(2026, 10, 20, 16, 10, 0, 0, time.UTC), noErrors).Tick(context.Background())
+
+		saved := repository.saved["a-portfolio"]
+		if assert.Len(t, saved, 5) {
+			assert.Equal(t, date(19), saved[3].Date)
+			assert.Equal(t, date(20), saved[4].Date)
+			assert.Equal(t, time.Date(2026, 10, 20, 16, 5, 0, 0, time.UTC), saved[4].TakenAt)
+		}
+	})
+
+	t.Run("Backfill no more than the configured market dates", func(t *testing.T) {
+		repository := &memorySnapshotRepository{portfolios: []string{"a-portfolio"}, saved: map[string][]Snapshot{}}
+		job := newJob(repository, time.Date(2026, 10, 19, 16, 30, 0, 0, time.UTC), noErrors)
+		job.options.Backfill = 2
+
+		job.Tick(context.Background())
+
+		saved := repository.saved["a-portfolio"]
+		if assert.Len(t, saved, 2) {
+			assert.Equal(t, date(16), saved[0].Date)
+		}
+	})
+
+	t.Run("Report portfolios whose state can not be replayed", func(t *testing.T) {
+		repository := &memorySnapshotRepository{portfolios: []string{"a-portfolio"}, saved: map[string][]Snapshot{}}
+		var failed []string
+		job := newJob(repository, time.Date(2026, 10, 19, 16, 30, 0, 0, time.UTC), func(portfolioId string, err error) {
+			failed = append(failed, portfolioId)
+		})
+		job.states = func(ctx context.Context, portfolioId string, instants []time.Time) ([]performance.JournalState, error) {
+			return nil, errors.New("database is down")
+		}
+
+		job.Tick(context.Background())
+
+		assert.Equal(t, []string{"a-portfolio"}, failed)
+		assert.Empty(t, repository.saved)
+	})
+}
@@ -0,0 +1,117 @@
+package snapshots
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SnapshotFilter selects the snapshots of a portfolio. From is inclusive and To
+// is exclusive; zero values leave the range open.
+type SnapshotFilter struct {
+	PortfolioId string
+	From        time.Time
+	To          time.Time
+	Limit       int
+	Offset      int
+}
+
+type SnapshotPage struct {
+	Items  []Snapshot `json:"items"`
+	Total  int64      `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// SnapshotProgress is the latest snapshot date of a portfolio, nil when it has
+// none yet.
+type SnapshotProgress struct {
+	PortfolioId string
+	LastDate    *time.Time
+}
+
+type SnapshotRepository interface {
+	// Save records a snapshot, replacing any earlier one of the same
+	// portfolio and date.
+	Save(context.Context, *Snapshot) error
+	Progress(context.Context) ([]SnapshotProgress, error)
+	List(context.Context, SnapshotFilter) (*SnapshotPage, error)
+	// Span returns the snapshots of a portfolio dated from up to to, both
+	// included, oldest first, after the latest one dated before from if any.
+	Span(ctx context.Context, portfolioId string, from time.Time, to time.Time) ([]Snapshot, error)
+}
+
+type mySQLSnapshotRepository struct {
+	db *gorm.DB
+}
+
+func NewSnapshotRepository(db *gorm.DB) SnapshotRepository {
+	return &mySQLSnapshotRepository{
+		db: db,
+	}
+}
+
+func (r *mySQLSnapshotRepository) Save(ctx context.Context, snapshot *Snapshot) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(snapshot).Error
+}
+
+func (r *mySQLSnapshotRepository) Progress(ctx context.Context) ([]SnapshotProgress, error) {
+	progress := []SnapshotProgress{}
+	err := r.db.WithContext(ctx).Table("portfolios").
+		Select("portfolios.id AS portfolio_id, MAX(portfolio_snapshots.date) AS last_date").
+		Joins("LEFT JOIN portfolio_snapshots ON portfolio_snapshots.portfolio_id = portfolios.id").
+		Group("portfolios.id").
+		Order("portfolios.id").
+		Scan(&progress).Error
+	return progress, err
+}
+
+// List returns snapshots from the oldest date to the newest.
+func (r *mySQLSnapshotRepository) List(ctx context.Context, filter SnapshotFilter) (*SnapshotPage, error) {
+	query := r.db.WithContext(ctx).Model(&Snapshot{}).Where("portfolio_id = ?", filter.PortfolioId)
+	if !filter.From.IsZero() {
+		query = query.Where("date >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("date < ?", filter.To)
+	}
+
+	page := &SnapshotPage{
+		Items:  []Snapshot{},
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if err := query.Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Order("date").Limit(filter.Limit).Offset(filter.Offset).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (r *mySQLSnapshotRepository) Span(ctx context.Context, portfolioId string, from time.Time, to time.Time) ([]Snapshot, error) {
+	span := []Snapshot{}
+	err := r.db.WithContext(ctx).
+		Where("portfolio_id = ?", portfolioId).
+		Where("date < ?", from).
+		Order("date DESC").
+		Limit(1).
+		Find(&span).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var period []Snapshot
+	err = r.db.WithContext(ctx).
+		Where("portfolio_id = ?", portfolioId).
+		Where("date >= ? AND date <= ?", from, to).
+		Order("date").
+		Find(&period).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(span, period...), nil
+}
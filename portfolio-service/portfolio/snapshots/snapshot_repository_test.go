@@ -0,0 +1,73 @@
+package snapshots_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"stock-trader/portfolio-service/portfolio/snapshots"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotRepository(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+
+	portfolios := portfolio.NewPortfolioRepository(db, common.NewDomainEventDispatcher())
+	repository := snapshots.NewSnapshotRepository(db)
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	t.Run("given a snapshot taken twice should keep the latest one", func(t *testing.T) {
+		opened, _ := portfolio.OpenPortfolio(fmt.Sprintf("snapshots-%d", rand.Int63()))
+		if !assert.NoError(t, portfolios.Save(context.Background(), opened)) {
+			return
+		}
+		portfolioId := string(opened.Id())
+
+		first, _ := snapshots.NewSnapshot(context.Background(), portfolioId, date, journalState(), performance.JournalState{}, nil, rates, date.Add(20*time.Hour))
+		second, _ := snapshots.NewSnapshot(context.Background(), portfolioId, date, journalState(), performance.JournalState{}, StubQuoteSource{}, rates, date.Add(21*time.Hour))
+		assert.NoError(t, repository.Save(context.Background(), &first))
+		assert.NoError(t, repository.Save(context.Background(), &second))
+
+		page, err := repository.List(context.Background(), snapshots.SnapshotFilter{PortfolioId: portfolioId, From: date, Limit: 10})
+		if assert.NoError(t, err) && assert.Len(t, page.Items, 1) {
+			assert.Equal(t, snapshots.PricedAtQuotes, page.Items[0].PriceSource)
+			assert.Equal(t, date, page.Items[0].Date)
+			assert.Len(t, page.Items[0].Positions.Data(), 2)
+		}
+
+		progress, err := repository.Progress(context.Background())
+		if assert.NoError(t, err) {
+			for _, p := range progress {
+				if p.PortfolioId == portfolioId && assert.NotNil(t, p.LastDate) {
+					assert.Equal(t, date, p.LastDate.UTC())
+				}
+			}
+		}
+	})
+
+	t.Run("given snapshots around a period should span it from the one before", func(t *testing.T) {
+		opened, _ := portfolio.OpenPortfolio(fmt.Sprintf("snapshots-%d", rand.Int63()))
+		if !assert.NoError(t, portfolios.Save(context.Background(), opened)) {
+			return
+		}
+		portfolioId := string(opened.Id())
+		for _, day := range []int{14, 15, 16, 19, 20} {
+			snapshot, _ := snapshots.NewSnapshot(context.Background(), portfolioId, date.AddDate(0, 0, day-19), journalState(), performance.JournalState{}, nil, rates, date)
+			assert.NoError(t, repository.Save(context.Background(), &snapshot))
+		}
+
+		span, err := repository.Span(context.Background(), portfolioId, date.AddDate(0, 0, -2), date)
+
+		if assert.NoError(t, err) && assert.Len(t, span, 3) {
+			assert.Equal(t, date.AddDate(0, 0, -4), span[0].Date.UTC())
+			assert.Equal(t, date, span[2].Date.UTC())
+			assert.Equal(t, "1000", span[2].NetFlow.Decimal.String())
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package snapshots_test
+
+import (
+	"context"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"stock-trader/portfolio-service/portfolio/snapshots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type StubQuoteSource map[string]valuation.Quote
+
+func (s StubQuoteSource) Quote(ctx context.Context, symbol string) (valuation.Quote, error) {
+	quote, ok := s[symbol]
+	if !ok {
+		return valuation.Quote{}, valuation.ErrQuoteNotFound
+	}
+	return quote, nil
+}
+
+func journalState() performance.JournalState {
+	return performance.JournalState{
+		Position:     42,
+		Cash:         portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(500)},
+		ReservedCash: portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(100)},
+		Holdings:     map[string]decimal.Decimal{"ACME": decimal.NewFromInt(10), "INIT": decimal.NewFromInt(5)},
+		Prices:       map[string]decimal.Decimal{"ACME": decimal.NewFromInt(20), "INIT": decimal.NewFromInt(8)},
+		Currencies:   map[string]portfolio.Currency{"ACME": portfolio.BaseCurrency, "INIT": portfolio.BaseCurrency},
+		Flows:        portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(1000)},
+	}
+}
+
+var rates = fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{
+	"EUR": decimal.RequireFromString("0.8"),
+})
+
+func TestNewSnapshot(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	takenAt := time.Date(2026, 10, 19, 20, 0, 5, 0, time.UTC)
+
+	t.Run("Price holdings at the quotes of the market", func(t *testing.T) {
+		quotes := StubQuoteSource{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25)}}
+
+		snapshot, err := snapshots.NewSnapshot(context.Background(), "a-portfolio", date, journalState(), performance.JournalState{}, quotes, rates, takenAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, snapshots.PricedAtQuotes, snapshot.PriceSource)
+		assert.Equal(t, "290", snapshot.HoldingsValue.String())
+		assert.Equal(t, "890", snapshot.TotalValue.String())
+		assert.Equal(t, int64(42), snapshot.JournalPosition)
+		assert.True(t, snapshot.Stale)
+		positions := snapshot.Positions.Data()
+		if assert.Len(t, positions, 2) {
+			assert.Equal(t, "ACME", positions[0].Symbol)
+			assert.Equal(t, "25", positions[0].Price.String())
+			assert.False(t, positions[0].Stale)
+			assert.Equal(t, "8", positions[1].Price.String())
+			assert.True(t, positions[1].Stale)
+		}
+	})
+
+	t.Run("Price holdings at their latest trade without quotes", func(t *testing.T) {
+		snapshot, err := snapshots.NewSnapshot(context.Background(), "a-portfolio", date, journalState(), performance.JournalState{}, nil, rates, takenAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, snapshots.PricedAtTrades, snapshot.PriceSource)
+		assert.Equal(t, "240", snapshot.HoldingsValue.String())
+		assert.Equal(t, "840", snapshot.TotalValue.String())
+		assert.False(t, snapshot.Stale)
+	})
+
+	t.Run("Convert cash and holdings in other currencies", func(t *testing.T) {
+		state := journalState()
+		state.Cash["EUR"] = decimal.NewFromInt(400)
+		state.Holdings["SAP"] = decimal.NewFromInt(2)
+		state.Prices["SAP"] = decimal.NewFromInt(40)
+		state.Currencies["SAP"] = "EUR"
+
+		snapshot, err := snapshots.NewSnapshot(context.Background(), "a-portfolio", date, state, performance.JournalState{}, nil, rates, takenAt)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", snapshot.Cash.String())
+		assert.Equal(t, "340", snapshot.HoldingsValue.String())
+		assert.Equal(t, "1440", snapshot.TotalValue.String())
+		balances := snapshot.CashBalances.Data()
+		if assert.Len(t, balances, 2) {
+			assert.Equal(t, portfolio.Currency("EUR"), balances[0].Currency)
+			assert.Equal(t, "400", balances[0].Cash.String())
+			assert.Equal(t, "0", balances[0].ReservedCash.String())
+			assert.Equal(t, "100", balances[1].ReservedCash.String())
+		}
+		sap := snapshot.Positions.Data()[2]
+		assert.Equal(t, portfolio.Currency("EUR"), sap.Currency)
+		assert.Equal(t, "40", sap.Price.String())
+		assert.Equal(t, "100", sap.MarketValue.String())
+	})
+
+	t.Run("Record what flowed in and out since the previous state", func(t *testing.T) {
+		state := journalState()
+		state.Flows["EUR"] = decimal.NewFromInt(80)
+		previous := performance.JournalState{Flows: portfolio.CashBalances{portfolio.BaseCurrency: decimal.NewFromInt(900)}}
+
+		snapshot, err := snapshots.NewSnapshot(context.Background(), "a-portfolio", date, state, previous, nil, rates, takenAt)
+
+		assert.NoError(t, err)
+		assert.True(t, snapshot.NetFlow.Valid)
+		assert.Equal(t, "200", snapshot.NetFlow.Decimal.String())
+	})
+
+	t.Run("Fail without the rate of a currency", func(t *testing.T) {
+		state := journalState()
+		state.Cash["JPY"] = decimal.NewFromInt(1000)
+
+		_, err := snapshots.NewSnapshot(context.Background(), "a-portfolio", date, state, performance.JournalState{}, nil, rates, takenAt)
+
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+	})
+}
+
+func TestSnapshotState(t *testing.T) {
+	date := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Restore the state with the prices it was valued at", func(t *testing.T) {
+		state := journalState()
+		state.Cash["EUR"] = decimal.NewFromInt(400)
+		quotes := StubQuoteSource{"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25)}}
+		snapshot, _ := snapshots.NewSnapshot(context.Background(), "a-portfolio", date, state, performance.JournalState{}, quotes, rates, date)
+
+		restored := snapshot.State()
+
+		assert.Equal(t, int64(42), restored.Position)
+		assert.Equal(t, "400", restored.Cash["EUR"].String())
+		assert.Equal(t, "100", restored.ReservedCash[portfolio.BaseCurrency].String())
+		assert.Equal(t, "25", restored.Prices["ACME"].String())
+		value, err := restored.Value(context.Background(), rates)
+		assert.NoError(t, err)
+		assert.Equal(t, snapshot.TotalValue.String(), value.String())
+	})
+
+	t.Run("Restore the cash of snapshots taken before cash was kept per currency", func(t *testing.T) {
+		snapshot := snapshots.Snapshot{Cash: decimal.NewFromInt(500), ReservedCash: decimal.NewFromInt(100)}
+
+		restored := snapshot.State()
+
+		assert.Equal(t, "500", restored.Cash[portfolio.BaseCurrency].String())
+		assert.Equal(t, "100", restored.ReservedCash[portfolio.BaseCurrency].String())
+	})
+}
@@ -0,0 +1,268 @@
+package statements
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const statementDateLayout = "2006-01-02"
+
+// Line is a ledger entry of a statement, along with the gain realised by the
+// sell trades.
+type Line struct {
+	readmodels.LedgerEntry `gorm:"embedded"`
+	RealisedGain           decimal.NullDecimal `gorm:"column:realised_gain" json:"realised_gain"`
+}
+
+// Totals add up the lines of a statement. Bought and sold are the gross value
+// of the trades, before the fees they were charged.
+type Totals struct {
+	Deposits      decimal.Decimal `json:"deposits"`
+	Withdrawals   decimal.Decimal `json:"withdrawals"`
+	Refunds       decimal.Decimal `json:"refunds"`
+	Dividends     decimal.Decimal `json:"dividends"`
+	Bought        decimal.Decimal `json:"bought"`
+	Sold          decimal.Decimal `json:"sold"`
+	Fees          decimal.Decimal `json:"fees"`
+	RealisedGains decimal.Decimal `json:"realised_gains"`
+}
+
+func newTotals() Totals {
+	return Totals{
+		Deposits:      decimal.Zero,
+		Withdrawals:   decimal.Zero,
+		Refunds:       decimal.Zero,
+		Dividends:     decimal.Zero,
+		Bought:        decimal.Zero,
+		Sold:          decimal.Zero,
+		Fees:          decimal.Zero,
+		RealisedGains: decimal.Zero,
+	}
+}
+
+func (t *Totals) add(line Line) {
+	switch line.Type {
+	case readmodels.LedgerDeposit:
+		t.Deposits = t.Deposits.Add(line.Amount)
+	case readmodels.LedgerTransfer:
+		t.Withdrawals = t.Withdrawals.Sub(line.Amount)
+	case readmodels.LedgerRefund:
+		t.Refunds = t.Refunds.Add(line.Amount)
+	case readmodels.LedgerDividend:
+		t.Dividends = t.Dividends.Add(line.Amount)
+	case readmodels.LedgerBuy:
+		t.Bought = t.Bought.Add(line.Price.Decimal.Mul(line.Quantity.Decimal).Round(4))
+	case readmodels.LedgerSell:
+		t.Sold = t.Sold.Add(line.Price.Decimal.Mul(line.Quantity.Decimal).Round(4))
+	}
+	t.Fees = t.Fees.Add(line.Commission)
+	if line.RealisedGain.Valid {
+		t.RealisedGains = t.RealisedGains.Add(line.RealisedGain.Decimal)
+	}
+}
+
+// Statement is the cash activity of a portfolio over a period of UTC dates.
+// Balances are settled cash, regardless of what pending orders reserve, and are
+// kept per currency, as are the totals. Lines are read from the source while
+// the statement is written, so a statement of any length takes little memory.
+type Statement struct {
+	PortfolioId     string
+	From            time.Time
+	To              time.Time
+	OpeningBalances map[string]decimal.Decimal
+	source          StatementSource
+}
+
+// NewStatement covers the dates from from up to, but not including, to. The
+// base currency opens at zero unless it has an opening balance.
+func NewStatement(portfolioId string, from time.Time, to time.Time, openingBalances map[string]decimal.Decimal, source StatementSource) *Statement {
+	balances := map[string]decimal.Decimal{string(portfolio.BaseCurrency): decimal.Zero}
+	for currency, balance := range openingBalances {
+		balances[currency] = balance
+	}
+	return &Statement{
+		PortfolioId:     portfolioId,
+		From:            from,
+		To:              to,
+		OpeningBalances: balances,
+		source:          source,
+	}
+}
+
+// lastDate is the last date the statement covers.
+func (s *Statement) lastDate() string {
+	return s.To.AddDate(0, 0, -1).Format(statementDateLayout)
+}
+
+// closing follows the balances and totals of every currency through the lines.
+type closing struct {
+	balances map[string]decimal.Decimal
+	totals   map[string]Totals
+}
+
+func (s *Statement) newClosing() *closing {
+	c := &closing{balances: map[string]decimal.Decimal{}, totals: map[string]Totals{}}
+	for currency, balance := range s.OpeningBalances {
+		c.balances[currency] = balance
+		c.totals[currency] = newTotals()
+	}
+	return c
+}
+
+func (c *closing) add(line Line) {
+	totals, ok := c.totals[line.Currency]
+	if !ok {
+		totals = newTotals()
+	}
+	totals.add(line)
+	c.totals[line.Currency] = totals
+	c.balances[line.Currency] = line.CashBalance
+}
+
+func (c *closing) currencies() []string {
+	currencies := make([]string, 0, len(c.balances))
+	for currency := range c.balances {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// WriteJSON writes the statement as a single JSON document, with its lines
+// in the order they were recorded and its closing balances and totals last.
+func (s *Statement) WriteJSON(ctx context.Context, out io.Writer) error {
+	buffered := bufio.NewWriter(out)
+	encoder := json.NewEncoder(buffered)
+
+	header, err := json.Marshal(map[string]any{
+		"portfolio_id":     s.PortfolioId,
+		"from":             s.From.Format(statementDateLayout),
+		"to":               s.lastDate(),
+		"opening_balances": s.OpeningBalances,
+	})
+	if err != nil {
+		return err
+	}
+	// Leave the header object open to append the lines to it.
+	buffered.Write(header[:len(header)-1])
+	buffered.WriteString(`,"lines":[`)
+
+	closing, first := s.newClosing(), true
+	err = s.source.Lines(ctx, s.PortfolioId, s.From, s.To, func(line Line) error {
+		if !first {
+			buffered.WriteString(",")
+		}
+		first = false
+		closing.add(line)
+		return encoder.Encode(line)
+	})
+	if err != nil {
+		return err
+	}
+
+	footer, err := json.Marshal(map[string]any{
+		"closing_balances": closing.balances,
+		"totals":           closing.totals,
+	})
+	if err != nil {
+		return err
+	}
+	buffered.WriteString("],")
+	buffered.Write(footer[1:])
+	buffered.WriteString("\n")
+	return buffered.Flush()
+}
+
+var csvColumns = []string{"timestamp", "type", "order_id", "trade_id", "transfer_id", "symbol", "quantity", "price", "currency", "commission", "amount", "realised_gain", "cash_balance"}
+
+// WriteCSV writes a row for every line, between opening balance rows and
+// closing balance rows, one of each per currency. The closing balance rows also
+// carry the total fees, net cash movement and realised gains of the period.
+func (s *Statement) WriteCSV(ctx context.Context, out io.Writer) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write(csvColumns); err != nil {
+		return err
+	}
+	opening := s.newClosing()
+	for _, currency := range opening.currencies() {
+		if err := writer.Write(balanceRow(s.From, "opening_balance", currency, s.OpeningBalances[currency])); err != nil {
+			return err
+		}
+	}
+
+	closing := s.newClosing()
+	err := s.source.Lines(ctx, s.PortfolioId, s.From, s.To, func(line Line) error {
+		closing.add(line)
+		return writer.Write(lineRow(line))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, currency := range closing.currencies() {
+		balance, totals := closing.balances[currency], closing.totals[currency]
+		row := balanceRow(s.To, "closing_balance", currency, balance)
+		row[9] = totals.Fees.String()
+		row[10] = balance.Sub(s.openingBalance(currency)).String()
+		row[11] = totals.RealisedGains.String()
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// openingBalance is zero for currencies first seen during the period.
+func (s *Statement) openingBalance(currency string) decimal.Decimal {
+	if balance, ok := s.OpeningBalances[currency]; ok {
+		return balance
+	}
+	return decimal.Zero
+}
+
+func balanceRow(at time.Time, rowType string, currency string, balance decimal.Decimal) []string {
+	row := make([]string, len(csvColumns))
+	row[0] = at.UTC().Format(time.RFC3339)
+	row[1] = rowType
+	row[8] = currency
+	row[12] = balance.String()
+	return row
+}
+
+func lineRow(line Line) []string {
+	row := []string{
+		line.Timestamp.UTC().Format(time.RFC3339Nano),
+		string(line.Type),
+		line.OrderId,
+		line.TradeId,
+		line.TransferId,
+		line.Symbol,
+		"",
+		"",
+		line.Currency,
+		line.Commission.String(),
+		line.Amount.String(),
+		"",
+		line.CashBalance.String(),
+	}
+	if line.Quantity.Valid {
+		row[6] = line.Quantity.Decimal.String()
+	}
+	if line.Price.Valid {
+		row[7] = line.Price.Decimal.String()
+	}
+	if line.RealisedGain.Valid {
+		row[11] = line.RealisedGain.Decimal.String()
+	}
+	return row
+}
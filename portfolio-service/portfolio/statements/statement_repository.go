@@ -0,0 +1,71 @@
+package statements
+
+import (
+	"context"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// StatementSource reads the ledger of a portfolio in every currency for
+// statements. From is inclusive and To is exclusive.
+type StatementSource interface {
+	// OpeningBalances are keyed by currency.
+	OpeningBalances(ctx context.Context, portfolioId string, from time.Time) (map[string]decimal.Decimal, error)
+	// Lines visits the lines of the period in the order they were recorded,
+	// without holding them all in memory.
+	Lines(ctx context.Context, portfolioId string, from time.Time, to time.Time, visit func(Line) error) error
+}
+
+type mySQLStatementSource struct {
+	db *gorm.DB
+}
+
+func NewStatementSource(db *gorm.DB) StatementSource {
+	return &mySQLStatementSource{
+		db: db,
+	}
+}
+
+// OpeningBalances are the cash balances after the last entry of each currency
+// before the period.
+func (s *mySQLStatementSource) OpeningBalances(ctx context.Context, portfolioId string, from time.Time) (map[string]decimal.Decimal, error) {
+	var previous []readmodels.LedgerEntry
+	last := s.db.Model(&readmodels.LedgerEntry{}).Select("MAX(position)").Where("portfolio_id = ? AND timestamp < ?", portfolioId, from).Group("currency")
+	err := s.db.WithContext(ctx).Where("portfolio_id = ? AND position IN (?)", portfolioId, last).Find(&previous).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[string]decimal.Decimal, len(previous))
+	for _, entry := range previous {
+		balances[entry.Currency] = entry.CashBalance
+	}
+	return balances, nil
+}
+
+func (s *mySQLStatementSource) Lines(ctx context.Context, portfolioId string, from time.Time, to time.Time, visit func(Line) error) error {
+	rows, err := s.db.WithContext(ctx).Model(&readmodels.LedgerEntry{}).
+		Select("ledger_entries.*, realised_gains.gain AS realised_gain").
+		Joins("LEFT JOIN realised_gains ON realised_gains.id = ledger_entries.id").
+		Where("ledger_entries.portfolio_id = ? AND ledger_entries.timestamp >= ? AND ledger_entries.timestamp < ?", portfolioId, from, to).
+		Order("ledger_entries.position").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line Line
+		if err := s.db.ScanRows(rows, &line); err != nil {
+			return err
+		}
+		if err := visit(line); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,140 @@
+package statements_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"stock-trader/portfolio-service/portfolio/statements"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type StubStatementSource struct {
+	lines []statements.Line
+	err   error
+}
+
+func (s StubStatementSource) OpeningBalances(ctx context.Context, portfolioId string, from time.Time) (map[string]decimal.Decimal, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s StubStatementSource) Lines(ctx context.Context, portfolioId string, from time.Time, to time.Time, visit func(statements.Line) error) error {
+	for _, line := range s.lines {
+		if err := visit(line); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func statementLines() []statements.Line {
+	at := func(hour int) time.Time {
+		return time.Date(2026, 10, 5, hour, 0, 0, 0, time.UTC)
+	}
+	return []statements.Line{
+		{LedgerEntry: readmodels.LedgerEntry{Id: "e1", PortfolioId: "a-portfolio", Currency: "USD", Type: readmodels.LedgerDeposit, Commission: decimal.Zero, Amount: decimal.NewFromInt(1000), CashBalance: decimal.NewFromInt(1100), Timestamp: at(9)}},
+		{LedgerEntry: readmodels.LedgerEntry{Id: "e2", PortfolioId: "a-portfolio", Currency: "USD", Type: readmodels.LedgerBuy, OrderId: "o1", TradeId: "t1", Symbol: "ACME", Quantity: decimal.NewNullDecimal(decimal.NewFromInt(10)), Price: decimal.NewNullDecimal(decimal.NewFromInt(20)), Commission: decimal.NewFromInt(5), Amount: decimal.NewFromInt(-205), CashBalance: decimal.NewFromInt(895), Timestamp: at(10)}},
+		{
+			LedgerEntry:  readmodels.LedgerEntry{Id: "e3", PortfolioId: "a-portfolio", Currency: "USD", Type: readmodels.LedgerSell, OrderId: "o2", TradeId: "t2", Symbol: "ACME", Quantity: decimal.NewNullDecimal(decimal.NewFromInt(4)), Price: decimal.NewNullDecimal(decimal.NewFromInt(25)), Commission: decimal.NewFromInt(5), Amount: decimal.NewFromInt(95), CashBalance: decimal.NewFromInt(990), Timestamp: at(11)},
+			RealisedGain: decimal.NewNullDecimal(decimal.NewFromInt(13)),
+		},
+		{LedgerEntry: readmodels.LedgerEntry{Id: "e4", PortfolioId: "a-portfolio", Currency: "USD", Type: readmodels.LedgerTransfer, TransferId: "w1", Commission: decimal.Zero, Amount: decimal.NewFromInt(-90), CashBalance: decimal.NewFromInt(900), Timestamp: at(12)}},
+	}
+}
+
+func newStatement(source statements.StatementSource) *statements.Statement {
+	return statements.NewStatement("a-portfolio", time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), map[string]decimal.Decimal{"USD": decimal.NewFromInt(100)}, source)
+}
+
+func TestStatement(t *testing.T) {
+	t.Run("Write the statement as CSV", func(t *testing.T) {
+		var out strings.Builder
+
+		err := newStatement(StubStatementSource{lines: statementLines()}).WriteCSV(context.Background(), &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Join([]string{
+			"timestamp,type,order_id,trade_id,transfer_id,symbol,quantity,price,currency,commission,amount,realised_gain,cash_balance",
+			"2026-10-01T00:00:00Z,opening_balance,,,,,,,USD,,,,100",
+			"2026-10-05T09:00:00Z,deposit,,,,,,,USD,0,1000,,1100",
+			"2026-10-05T10:00:00Z,buy,o1,t1,,ACME,10,20,USD,5,-205,,895",
+			"2026-10-05T11:00:00Z,sell,o2,t2,,ACME,4,25,USD,5,95,13,990",
+			"2026-10-05T12:00:00Z,transfer,,,w1,,,,USD,0,-90,,900",
+			"2026-11-01T00:00:00Z,closing_balance,,,,,,,USD,10,800,13,900",
+			"",
+		}, "\n"), out.String())
+	})
+
+	t.Run("Write the statement as JSON", func(t *testing.T) {
+		var out strings.Builder
+
+		err := newStatement(StubStatementSource{lines: statementLines()[:3]}).WriteJSON(context.Background(), &out)
+
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"portfolio_id": "a-portfolio",
+			"from": "2026-10-01",
+			"to": "2026-10-31",
+			"opening_balances": {"USD": "100"},
+			"lines": [
+				{"id": "e1", "portfolio_id": "a-portfolio", "currency": "USD", "type": "deposit", "quantity": null, "price": null, "commission": "0", "amount": "1000", "cash_balance": "1100", "timestamp": "2026-10-05T09:00:00Z", "realised_gain": null},
+				{"id": "e2", "portfolio_id": "a-portfolio", "currency": "USD", "type": "buy", "order_id": "o1", "trade_id": "t1", "symbol": "ACME", "quantity": "10", "price": "20", "commission": "5", "amount": "-205", "cash_balance": "895", "timestamp": "2026-10-05T10:00:00Z", "realised_gain": null},
+				{"id": "e3", "portfolio_id": "a-portfolio", "currency": "USD", "type": "sell", "order_id": "o2", "trade_id": "t2", "symbol": "ACME", "quantity": "4", "price": "25", "commission": "5", "amount": "95", "cash_balance": "990", "timestamp": "2026-10-05T11:00:00Z", "realised_gain": "13"}
+			],
+			"closing_balances": {"USD": "990"},
+			"totals": {
+				"USD": {
+					"deposits": "1000",
+					"withdrawals": "0",
+					"refunds": "0",
+					"dividends": "0",
+					"bought": "200",
+					"sold": "100",
+					"fees": "10",
+					"realised_gains": "13"
+				}
+			}
+		}`, out.String())
+	})
+
+	t.Run("Close on the opening balance without lines", func(t *testing.T) {
+		var out strings.Builder
+
+		err := newStatement(StubStatementSource{}).WriteJSON(context.Background(), &out)
+
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), `"lines":[]`)
+		assert.Contains(t, out.String(), `"closing_balances":{"USD":"100"}`)
+	})
+
+	t.Run("Keep the balances and totals of every currency apart", func(t *testing.T) {
+		var out strings.Builder
+		lines := append(statementLines()[:2], statements.Line{LedgerEntry: readmodels.LedgerEntry{Id: "e5", PortfolioId: "a-portfolio", Currency: "EUR", Type: readmodels.LedgerDeposit, Commission: decimal.Zero, Amount: decimal.NewFromInt(300), CashBalance: decimal.NewFromInt(300), Timestamp: time.Date(2026, 10, 6, 9, 0, 0, 0, time.UTC)}})
+
+		err := newStatement(StubStatementSource{lines: lines}).WriteCSV(context.Background(), &out)
+
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Join([]string{
+			"timestamp,type,order_id,trade_id,transfer_id,symbol,quantity,price,currency,commission,amount,realised_gain,cash_balance",
+			"2026-10-01T00:00:00Z,opening_balance,,,,,,,USD,,,,100",
+			"2026-10-05T09:00:00Z,deposit,,,,,,,USD,0,1000,,1100",
+			"2026-10-05T10:00:00Z,buy,o1,t1,,ACME,10,20,USD,5,-205,,895",
+			"2026-10-06T09:00:00Z,deposit,,,,,,,EUR,0,300,,300",
+			"2026-11-01T00:00:00Z,closing_balance,,,,,,,EUR,0,300,0,300",
+			"2026-11-01T00:00:00Z,closing_balance,,,,,,,USD,5,795,0,895",
+			"",
+		}, "\n"), out.String())
+	})
+
+	t.Run("Fail when the lines can not be read", func(t *testing.T) {
+		var out strings.Builder
+
+		err := newStatement(StubStatementSource{lines: statementLines(), err: errors.New("database is down")}).WriteCSV(context.Background(), &out)
+
+		assert.EqualError(t, err, "database is down")
+	})
+}
@@ -0,0 +1,172 @@
+package taxlots
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// costPlaces is the precision amounts are stored with.
+const costPlaces = 4
+
+// Method decides which lots a sell relieves, and so its cost basis.
+type Method string
+
+const (
+	FIFO        Method = "fifo"
+	LIFO        Method = "lifo"
+	AverageCost Method = "average"
+)
+
+func ParseMethod(value string) (Method, error) {
+	method := Method(strings.ToLower(strings.TrimSpace(value)))
+	switch method {
+	case FIFO, LIFO, AverageCost:
+		return method, nil
+	}
+	return "", fmt.Errorf("unknown lot relief method %q, expected one of fifo, lifo or average", value)
+}
+
+// Lot is what remains of the shares bought by one trade, along with what they
+// cost.
+type Lot struct {
+	Id       string
+	Quantity decimal.Decimal
+	Cost     decimal.Decimal
+}
+
+// Relieve takes quantity out of lots, given from the oldest to the newest, and
+// returns the cost basis of what was taken along with the lots as they remain,
+// in the same order. Quantity the lots cannot cover is relieved at zero cost.
+//
+// FIFO and LIFO relieve whole lots first, taking each lot's own cost. Average
+// cost relieves lots in FIFO order but at the average cost of all of them, and
+// reprices what remains at that average.
+func Relieve(lots []Lot, quantity decimal.Decimal, method Method) (decimal.Decimal, []Lot) {
+	remaining := append([]Lot{}, lots...)
+	if method == AverageCost {
+		return relieveAverage(remaining, quantity)
+	}
+
+	order := make([]int, len(remaining))
+	for i := range order {
+		order[i] = i
+		if method == LIFO {
+			order[i] = len(remaining) - 1 - i
+		}
+	}
+
+	basis := decimal.Zero
+	for _, i := range order {
+		if quantity.IsZero() {
+			break
+		}
+		taken := decimal.Min(quantity, remaining[i].Quantity)
+		if taken.IsZero() {
+			continue
+		}
+		cost := remaining[i].Cost
+		if taken.LessThan(remaining[i].Quantity) {
+			cost = cost.Mul(taken).DivRound(remaining[i].Quantity, costPlaces)
+		}
+		remaining[i].Quantity = remaining[i].Quantity.Sub(taken)
+		remaining[i].Cost = remaining[i].Cost.Sub(cost)
+		basis = basis.Add(cost)
+		quantity = quantity.Sub(taken)
+	}
+	return basis, remaining
+}
+
+// Split rescales lots, given from the oldest to the newest, by
+// numerator/denominator and keeps what they cost. Each lot is rounded down to
+// the places the holding is traded in and the last lot takes the shares lost to
+// rounding, so that the lots add up to the rescaled holding. A lot that rounds
+// down to nothing hands its cost on to the last lot left.
+func Split(lots []Lot, numerator int64, denominator int64, places int32) []Lot {
+	rescale := func(quantity decimal.Decimal) decimal.Decimal {
+		rescaled, _ := quantity.Mul(decimal.NewFromInt(numerator)).QuoRem(decimal.NewFromInt(denominator), places)
+		return rescaled
+	}
+
+	split := append([]Lot{}, lots...)
+	held, kept := decimal.Zero, decimal.Zero
+	for i := range split {
+		held = held.Add(split[i].Quantity)
+		split[i].Quantity = rescale(split[i].Quantity)
+		kept = kept.Add(split[i].Quantity)
+	}
+
+	last := -1
+	for i := range split {
+		if lots[i].Quantity.IsPositive() {
+			last = i
+		}
+	}
+	if last < 0 {
+		return split
+	}
+	split[last].Quantity = split[last].Quantity.Add(rescale(held).Sub(kept))
+
+	left := -1
+	for i := range split {
+		if split[i].Quantity.IsPositive() {
+			left = i
+		}
+	}
+	if left < 0 {
+		return split
+	}
+	for i := range split {
+		if i != left && split[i].Quantity.IsZero() && !split[i].Cost.IsZero() {
+			split[left].Cost = split[left].Cost.Add(split[i].Cost)
+			split[i].Cost = decimal.Zero
+		}
+	}
+	return split
+}
+
+func relieveAverage(remaining []Lot, quantity decimal.Decimal) (decimal.Decimal, []Lot) {
+	held, cost := decimal.Zero, decimal.Zero
+	for _, lot := range remaining {
+		held = held.Add(lot.Quantity)
+		cost = cost.Add(lot.Cost)
+	}
+	if held.IsZero() {
+		return decimal.Zero, remaining
+	}
+
+	taken := decimal.Min(quantity, held)
+	basis := cost
+	if taken.LessThan(held) {
+		basis = cost.Mul(taken).DivRound(held, costPlaces)
+	}
+
+	for i := range remaining {
+		relieved := decimal.Min(taken, remaining[i].Quantity)
+		remaining[i].Quantity = remaining[i].Quantity.Sub(relieved)
+		taken = taken.Sub(relieved)
+	}
+
+	// The last lot takes the rounding, so that the lots still add up to what
+	// was not relieved.
+	left, leftCost, last := held.Sub(decimal.Min(quantity, held)), cost.Sub(basis), -1
+	for i := range remaining {
+		if remaining[i].Quantity.IsZero() {
+			remaining[i].Cost = decimal.Zero
+			continue
+		}
+		remaining[i].Cost = leftCost.Mul(remaining[i].Quantity).DivRound(left, costPlaces)
+		last = i
+	}
+	if last >= 0 {
+		spread := decimal.Zero
+		for i := range remaining {
+			if i != last {
+				spread = spread.Add(remaining[i].Cost)
+			}
+		}
+		remaining[last].Cost = leftCost.Sub(spread)
+	}
+	return basis, remaining
+}
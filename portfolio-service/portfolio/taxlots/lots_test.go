@@ -0,0 +1,166 @@
+package taxlots_test
+
+import (
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// openLots bought 10 at 10, then 10 at 20, then 5 at 30.
+func openLots() []taxlots.Lot {
+	return []taxlots.Lot{
+		{Id: "first", Quantity: decimal.NewFromInt(10), Cost: decimal.NewFromInt(100)},
+		{Id: "second", Quantity: decimal.NewFromInt(10), Cost: decimal.NewFromInt(200)},
+		{Id: "third", Quantity: decimal.NewFromInt(5), Cost: decimal.NewFromInt(150)},
+	}
+}
+
+func remaining(lots []taxlots.Lot) map[string]string {
+	result := map[string]string{}
+	for _, lot := range lots {
+		result[lot.Id] = lot.Quantity.String() + "@" + lot.Cost.String()
+	}
+	return result
+}
+
+func TestRelieve(t *testing.T) {
+	tests := []struct {
+		testName          string
+		method            taxlots.Method
+		quantity          decimal.Decimal
+		expectedBasis     string
+		expectedRemaining map[string]string
+	}{
+		{
+			testName:          "FIFO relieves the oldest lots first",
+			method:            taxlots.FIFO,
+			quantity:          decimal.NewFromInt(15),
+			expectedBasis:     "200",
+			expectedRemaining: map[string]string{"first": "0@0", "second": "5@100", "third": "5@150"},
+		},
+		{
+			testName:          "LIFO relieves the newest lots first",
+			method:            taxlots.LIFO,
+			quantity:          decimal.NewFromInt(15),
+			expectedBasis:     "350",
+			expectedRemaining: map[string]string{"first": "10@100", "second": "0@0", "third": "0@0"},
+		},
+		{
+			testName:          "Average cost relieves at the average and reprices what remains",
+			method:            taxlots.AverageCost,
+			quantity:          decimal.NewFromInt(15),
+			expectedBasis:     "270",
+			expectedRemaining: map[string]string{"first": "0@0", "second": "5@90", "third": "5@90"},
+		},
+		{
+			testName:          "Part of a lot is relieved at its share of the cost",
+			method:            taxlots.FIFO,
+			quantity:          decimal.NewFromInt(3),
+			expectedBasis:     "30",
+			expectedRemaining: map[string]string{"first": "7@70", "second": "10@200", "third": "5@150"},
+		},
+		{
+			testName:          "Fractional shares are relieved at their share of the cost",
+			method:            taxlots.FIFO,
+			quantity:          decimal.RequireFromString("2.5"),
+			expectedBasis:     "25",
+			expectedRemaining: map[string]string{"first": "7.5@75", "second": "10@200", "third": "5@150"},
+		},
+		{
+			testName:          "Quantity beyond the lots is relieved at zero cost",
+			method:            taxlots.LIFO,
+			quantity:          decimal.NewFromInt(30),
+			expectedBasis:     "450",
+			expectedRemaining: map[string]string{"first": "0@0", "second": "0@0", "third": "0@0"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			lots := openLots()
+
+			basis, relieved := taxlots.Relieve(lots, tc.quantity, tc.method)
+
+			assert.Equal(t, tc.expectedBasis, basis.String())
+			assert.Equal(t, tc.expectedRemaining, remaining(relieved))
+			assert.Equal(t, openLots(), lots)
+		})
+	}
+
+	t.Run("Rounded average costs still add up", func(t *testing.T) {
+		lots := []taxlots.Lot{
+			{Id: "first", Quantity: decimal.NewFromInt(3), Cost: decimal.NewFromInt(10)},
+			{Id: "second", Quantity: decimal.NewFromInt(3), Cost: decimal.NewFromInt(10)},
+			{Id: "third", Quantity: decimal.NewFromInt(1), Cost: decimal.Zero},
+		}
+
+		basis, relieved := taxlots.Relieve(lots, decimal.NewFromInt(1), taxlots.AverageCost)
+
+		assert.Equal(t, "2.8571", basis.String())
+		left := decimal.Zero
+		for _, lot := range relieved {
+			left = left.Add(lot.Cost)
+		}
+		assert.Equal(t, "17.1429", left.String())
+	})
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		testName          string
+		numerator         int64
+		denominator       int64
+		places            int32
+		expectedRemaining map[string]string
+	}{
+		{
+			testName:          "A split multiplies every lot and keeps its cost",
+			numerator:         2,
+			denominator:       1,
+			expectedRemaining: map[string]string{"first": "20@100", "second": "20@200", "third": "10@150"},
+		},
+		{
+			testName:          "The last lot takes the shares lost to rounding",
+			numerator:         1,
+			denominator:       3,
+			expectedRemaining: map[string]string{"first": "3@100", "second": "3@200", "third": "2@150"},
+		},
+		{
+			testName:          "Lots rounded down to nothing hand their cost to the last lot left",
+			numerator:         1,
+			denominator:       12,
+			expectedRemaining: map[string]string{"first": "0@0", "second": "0@0", "third": "2@450"},
+		},
+		{
+			testName:          "Fractional lots are rounded down to the places of the holding",
+			numerator:         1,
+			denominator:       3,
+			places:            2,
+			expectedRemaining: map[string]string{"first": "3.33@100", "second": "3.33@200", "third": "1.67@150"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testName, func(t *testing.T) {
+			lots := openLots()
+
+			split := taxlots.Split(lots, tc.numerator, tc.denominator, tc.places)
+
+			assert.Equal(t, tc.expectedRemaining, remaining(split))
+			assert.Equal(t, openLots(), lots)
+		})
+	}
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := taxlots.ParseMethod(" LIFO ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, taxlots.LIFO, method)
+
+	_, err = taxlots.ParseMethod("hifo")
+
+	assert.EqualError(t, err, `unknown lot relief method "hifo", expected one of fifo, lifo or average`)
+}
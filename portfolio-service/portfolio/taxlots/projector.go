@@ -0,0 +1,235 @@
+package taxlots
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TaxLot is a buy trade, along with what remains of it after the sells that
+// relieved it and the splits that rescaled it. SplitPosition is the journal
+// position of the last split applied to the lot. Costs are in Currency, the
+// currency the symbol was bought in.
+type TaxLot struct {
+	Id                string          `gorm:"column:id"`
+	PortfolioId       string          `gorm:"column:portfolio_id"`
+	Symbol            string          `gorm:"column:symbol"`
+	Currency          string          `gorm:"column:currency"`
+	Position          int64           `gorm:"column:position"`
+	Quantity          decimal.Decimal `gorm:"column:quantity"`
+	Cost              decimal.Decimal `gorm:"column:cost"`
+	RemainingQuantity decimal.Decimal `gorm:"column:remaining_quantity"`
+	RemainingCost     decimal.Decimal `gorm:"column:remaining_cost"`
+	SplitPosition     int64           `gorm:"column:split_position"`
+	OpenedAt          time.Time       `gorm:"column:opened_at"`
+}
+
+func (TaxLot) TableName() string {
+	return "tax_lots"
+}
+
+// RealisedGain is the outcome of a sell trade, in the currency the trade was
+// made in. Proceeds are net of the commission the trade charged.
+type RealisedGain struct {
+	Id          string          `gorm:"column:id"`
+	PortfolioId string          `gorm:"column:portfolio_id"`
+	Symbol      string          `gorm:"column:symbol"`
+	Currency    string          `gorm:"column:currency"`
+	OrderId     string          `gorm:"column:order_id"`
+	TradeId     string          `gorm:"column:trade_id"`
+	Quantity    decimal.Decimal `gorm:"column:quantity"`
+	Proceeds    decimal.Decimal `gorm:"column:proceeds"`
+	CostBasis   decimal.Decimal `gorm:"column:cost_basis"`
+	Gain        decimal.Decimal `gorm:"column:gain"`
+	RealisedAt  time.Time       `gorm:"column:realised_at"`
+}
+
+func (RealisedGain) TableName() string {
+	return "realised_gains"
+}
+
+// TaxLotProjector opens a lot for every buy trade, relieves lots for every
+// sell trade with its method and rescales lots for every split. Changing the
+// method only applies to past trades once the projection is rebuilt.
+type TaxLotProjector struct {
+	method Method
+}
+
+func NewTaxLotProjector(method Method) *TaxLotProjector {
+	return &TaxLotProjector{
+		method: method,
+	}
+}
+
+func (p *TaxLotProjector) Name() string {
+	return "tax-lots"
+}
+
+func (p *TaxLotProjector) ReadModelTable() string {
+	return RealisedGain{}.TableName()
+}
+
+func (p *TaxLotProjector) ReadModelKey() string {
+	return "id"
+}
+
+func (p *TaxLotProjector) WorkingStateTables() []string {
+	return []string{TaxLot{}.TableName()}
+}
+
+func (p *TaxLotProjector) Project(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	switch event.Name {
+	case "trade-processed":
+		return p.trade(ctx, tx, event)
+	case "shares-split":
+		return p.split(ctx, tx, event)
+	}
+	return nil
+}
+
+func (p *TaxLotProjector) trade(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+
+	var payload struct {
+		PortfolioId string          `json:"portfolioId"`
+		OrderId     string          `json:"orderId"`
+		TradeId     string          `json:"tradeId"`
+		Symbol      string          `json:"symbol"`
+		Side        string          `json:"side"`
+		Quantity    decimal.Decimal `json:"quantity"`
+		Price       decimal.Decimal `json:"price"`
+		Currency    string          `json:"currency"`
+		Commission  decimal.Decimal `json:"commission"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+	// Trades recorded before portfolios held other currencies carry none.
+	if payload.Currency == "" {
+		payload.Currency = string(portfolio.BaseCurrency)
+	}
+
+	gross := payload.Price.Mul(payload.Quantity).Round(costPlaces)
+	switch payload.Side {
+	case "buy":
+		cost := gross.Add(payload.Commission)
+		return tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&TaxLot{
+			Id:                event.Id,
+			PortfolioId:       payload.PortfolioId,
+			Symbol:            payload.Symbol,
+			Currency:          payload.Currency,
+			Position:          event.Position,
+			Quantity:          payload.Quantity,
+			Cost:              cost,
+			RemainingQuantity: payload.Quantity,
+			RemainingCost:     cost,
+			OpenedAt:          event.Timestamp,
+		}).Error
+	case "sell":
+		gain := &RealisedGain{
+			Id:          event.Id,
+			PortfolioId: payload.PortfolioId,
+			Symbol:      payload.Symbol,
+			Currency:    payload.Currency,
+			OrderId:     payload.OrderId,
+			TradeId:     payload.TradeId,
+			Quantity:    payload.Quantity,
+			Proceeds:    gross.Sub(payload.Commission),
+			RealisedAt:  event.Timestamp,
+		}
+		return p.realise(ctx, tx, gain)
+	}
+	return errors.New("trade side must be either buy or sell")
+}
+
+// realise relieves the open lots of the symbol, unless the sell was already
+// realised by an earlier delivery of the event.
+func (p *TaxLotProjector) realise(ctx context.Context, tx *gorm.DB, gain *RealisedGain) error {
+	var realised int64
+	if err := tx.WithContext(ctx).Model(&RealisedGain{}).Where("id = ?", gain.Id).Count(&realised).Error; err != nil {
+		return err
+	}
+	if realised > 0 {
+		return nil
+	}
+
+	var open []TaxLot
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("portfolio_id = ? AND symbol = ? AND remaining_quantity > 0", gain.PortfolioId, gain.Symbol).
+		Order("position").
+		Find(&open).Error
+	if err != nil {
+		return err
+	}
+
+	lots := make([]Lot, len(open))
+	for i, lot := range open {
+		lots[i] = Lot{Id: lot.Id, Quantity: lot.RemainingQuantity, Cost: lot.RemainingCost}
+	}
+
+	basis, relieved := Relieve(lots, gain.Quantity, p.method)
+	for i, lot := range relieved {
+		if lot.Quantity.Equal(open[i].RemainingQuantity) && lot.Cost.Equal(open[i].RemainingCost) {
+			continue
+		}
+		err := tx.WithContext(ctx).Model(&TaxLot{}).Where("id = ?", lot.Id).Updates(map[string]any{
+			"remaining_quantity": lot.Quantity,
+			"remaining_cost":     lot.Cost,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	gain.CostBasis = basis
+	gain.Gain = gain.Proceeds.Sub(basis)
+	return tx.WithContext(ctx).Create(gain).Error
+}
+
+// split rescales the open lots of the symbol, leaving out the lots an earlier
+// delivery of the event already rescaled.
+func (p *TaxLotProjector) split(ctx context.Context, tx *gorm.DB, event common.IntegrationEventEntity) error {
+	var payload struct {
+		PortfolioId string `json:"portfolioId"`
+		Symbol      string `json:"symbol"`
+		Numerator   int64  `json:"numerator"`
+		Denominator int64  `json:"denominator"`
+		Precision   int32  `json:"precision"`
+	}
+	if err := event.DecodePayload(&payload); err != nil {
+		return err
+	}
+
+	var open []TaxLot
+	err := tx.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("portfolio_id = ? AND symbol = ? AND remaining_quantity > 0 AND position < ? AND split_position < ?", payload.PortfolioId, payload.Symbol, event.Position, event.Position).
+		Order("position").
+		Find(&open).Error
+	if err != nil {
+		return err
+	}
+
+	lots := make([]Lot, len(open))
+	for i, lot := range open {
+		lots[i] = Lot{Id: lot.Id, Quantity: lot.RemainingQuantity, Cost: lot.RemainingCost}
+	}
+
+	for _, lot := range Split(lots, payload.Numerator, payload.Denominator, payload.Precision) {
+		err := tx.WithContext(ctx).Model(&TaxLot{}).Where("id = ?", lot.Id).Updates(map[string]any{
+			"remaining_quantity": lot.Quantity,
+			"remaining_cost":     lot.Cost,
+			"split_position":     event.Position,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
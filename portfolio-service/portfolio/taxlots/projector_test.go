@@ -0,0 +1,98 @@
+package taxlots_test
+
+import (
+	"context"
+	"fmt"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxLotProjector(t *testing.T) {
+	db, _ := infrastructure.ConnectDB()
+
+	repo := portfolio.NewPortfolioRepository(db, common.NewDomainEventDispatcher())
+	lots := taxlots.NewTaxLotRepository(db)
+
+	tradeAndProject := func(projector *taxlots.TaxLotProjector, actions ...func(*portfolio.Portfolio)) (*portfolio.Portfolio, error) {
+		traded, _ := portfolio.OpenPortfolio(fmt.Sprintf(`lots-%s`, strings.Split(uuid.NewString(), "-")[0]))
+		traded.ReceiveFunds(decimal.NewFromInt(1000))
+		for i, trade := range []portfolio.OrderRequest{
+			{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(10)},
+			{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)},
+			{Symbol: "ACME", Side: portfolio.Sell, Quantity: decimal.NewFromInt(15), LimitPrice: decimal.NewFromInt(30)},
+		} {
+			orderId := portfolio.NewOrderId()
+			traded.PlaceOrder(orderId, trade, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})
+			traded.ProcessTrade(orderId, fmt.Sprintf("trade-%d", i), trade.Quantity, trade.LimitPrice, portfolio.LoyaltyProgram{})
+		}
+		for _, action := range actions {
+			action(traded)
+		}
+
+		domainEvents := traded.DomainEvents()
+		if err := repo.Save(context.Background(), traded); err != nil {
+			return nil, err
+		}
+		for _, domainEvent := range domainEvents {
+			var journalEvent common.IntegrationEventEntity
+			if err := db.Where("id = ?", domainEvent.Id()).First(&journalEvent).Error; err != nil {
+				return nil, err
+			}
+			// Projecting twice shows replays are harmless.
+			for i := 0; i < 2; i++ {
+				if err := projector.Project(context.Background(), db, journalEvent); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return traded, nil
+	}
+
+	t.Run("given buys and a sell should realise the gain with the lot relief method", func(t *testing.T) {
+		for method, expected := range map[taxlots.Method][]string{
+			taxlots.FIFO: {"250", "100"},
+			taxlots.LIFO: {"200", "50"},
+		} {
+			traded, err := tradeAndProject(taxlots.NewTaxLotProjector(method))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			filter := taxlots.PnLFilter{PortfolioId: string(traded.Id())}
+			realised, err := lots.Realised(context.Background(), filter)
+			if assert.NoError(t, err) && assert.Len(t, realised, 1) {
+				assert.Equal(t, "USD", realised[0].Currency)
+				assert.Equal(t, "15", realised[0].Quantity.String())
+				assert.Equal(t, "450", realised[0].Proceeds.String())
+				assert.Equal(t, expected[0], realised[0].Gain.String(), method)
+			}
+			open, err := lots.Open(context.Background(), filter)
+			if assert.NoError(t, err) && assert.Len(t, open, 1) {
+				assert.Equal(t, "5", open[0].Quantity.String())
+				assert.Equal(t, expected[1], open[0].Cost.String(), method)
+			}
+		}
+	})
+	t.Run("given a split should rescale the open lots and keep their cost", func(t *testing.T) {
+		traded, err := tradeAndProject(taxlots.NewTaxLotProjector(taxlots.FIFO), func(traded *portfolio.Portfolio) {
+			traded.SplitShares(uuid.NewString(), "ACME", 3, 1, traded.HeldShares("ACME"))
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		open, err := lots.Open(context.Background(), taxlots.PnLFilter{PortfolioId: string(traded.Id())})
+		if assert.NoError(t, err) && assert.Len(t, open, 1) {
+			assert.Equal(t, "15", open[0].Quantity.String())
+			assert.Equal(t, "100", open[0].Cost.String())
+		}
+	})
+}
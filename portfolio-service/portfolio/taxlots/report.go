@@ -0,0 +1,194 @@
+package taxlots
+
+import (
+	"context"
+	"sort"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PnLFilter selects the gains realised by a portfolio. From is inclusive and To
+// is exclusive; zero values leave the range open. Open lots are not filtered by
+// period, since they are valued as of now.
+type PnLFilter struct {
+	PortfolioId string
+	From        time.Time
+	To          time.Time
+	Symbol      string
+}
+
+type RealisedTotal struct {
+	Symbol    string
+	Currency  string
+	Quantity  decimal.Decimal
+	Proceeds  decimal.Decimal
+	CostBasis decimal.Decimal
+	Gain      decimal.Decimal
+}
+
+type OpenPosition struct {
+	Symbol   string
+	Currency string
+	Quantity decimal.Decimal
+	Cost     decimal.Decimal
+}
+
+type TaxLotRepository interface {
+	Realised(context.Context, PnLFilter) ([]RealisedTotal, error)
+	Open(context.Context, PnLFilter) ([]OpenPosition, error)
+}
+
+type mySQLTaxLotRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxLotRepository(db *gorm.DB) TaxLotRepository {
+	return &mySQLTaxLotRepository{
+		db: db,
+	}
+}
+
+func (r *mySQLTaxLotRepository) Realised(ctx context.Context, filter PnLFilter) ([]RealisedTotal, error) {
+	query := r.db.WithContext(ctx).Model(&RealisedGain{}).
+		Select("symbol, currency, SUM(quantity) AS quantity, SUM(proceeds) AS proceeds, SUM(cost_basis) AS cost_basis, SUM(gain) AS gain").
+		Where("portfolio_id = ?", filter.PortfolioId)
+	if !filter.From.IsZero() {
+		query = query.Where("realised_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("realised_at < ?", filter.To)
+	}
+	if filter.Symbol != "" {
+		query = query.Where("symbol = ?", filter.Symbol)
+	}
+
+	totals := []RealisedTotal{}
+	err := query.Group("symbol, currency").Order("symbol, currency").Scan(&totals).Error
+	return totals, err
+}
+
+func (r *mySQLTaxLotRepository) Open(ctx context.Context, filter PnLFilter) ([]OpenPosition, error) {
+	query := r.db.WithContext(ctx).Model(&TaxLot{}).
+		Select("symbol, currency, SUM(remaining_quantity) AS quantity, SUM(remaining_cost) AS cost").
+		Where("portfolio_id = ? AND remaining_quantity > 0", filter.PortfolioId)
+	if filter.Symbol != "" {
+		query = query.Where("symbol = ?", filter.Symbol)
+	}
+
+	positions := []OpenPosition{}
+	err := query.Group("symbol, currency").Order("symbol, currency").Scan(&positions).Error
+	return positions, err
+}
+
+// SymbolPnL is what was gained on a symbol in the currency it was traded in.
+// The price is in that currency too, and Rate converts the rest to the
+// currency of the report.
+type SymbolPnL struct {
+	Symbol       string              `json:"symbol"`
+	Currency     string              `json:"currency"`
+	QuantitySold decimal.Decimal     `json:"quantity_sold"`
+	Proceeds     decimal.Decimal     `json:"proceeds"`
+	CostBasis    decimal.Decimal     `json:"cost_basis"`
+	Realised     decimal.Decimal     `json:"realised_pnl"`
+	OpenQuantity decimal.Decimal     `json:"open_quantity"`
+	OpenCost     decimal.Decimal     `json:"open_cost"`
+	Price        decimal.NullDecimal `json:"price"`
+	MarketValue  decimal.Decimal     `json:"market_value"`
+	Unrealised   decimal.Decimal     `json:"unrealised_pnl"`
+	Rate         decimal.Decimal     `json:"rate"`
+	Stale        bool                `json:"stale"`
+}
+
+// PnLReport totals the gains of every symbol in Currency, the base currency.
+type PnLReport struct {
+	PortfolioId string             `json:"portfolio_id"`
+	Method      Method             `json:"method"`
+	Currency    portfolio.Currency `json:"currency"`
+	Realised    decimal.Decimal    `json:"realised_pnl"`
+	Unrealised  decimal.Decimal    `json:"unrealised_pnl"`
+	Symbols     []SymbolPnL        `json:"symbols"`
+	Stale       bool               `json:"stale"`
+}
+
+// NewPnLReport puts realised gains and open lots together by symbol, valuing
+// the open lots at the latest quotes, and totals them in the base currency at
+// the latest rates. Like valuations, positions without a quote are valued at
+// cost and marked stale, and rates that can not be had fail the report.
+func NewPnLReport(ctx context.Context, portfolioId string, method Method, realised []RealisedTotal, open []OpenPosition, quotes valuation.QuoteSource, rates fx.RateSource) (PnLReport, error) {
+	report := PnLReport{
+		PortfolioId: portfolioId,
+		Method:      method,
+		Currency:    portfolio.BaseCurrency,
+		Realised:    decimal.Zero,
+		Unrealised:  decimal.Zero,
+		Symbols:     []SymbolPnL{},
+	}
+
+	// A symbol sold out in one currency can be bought again in another.
+	type key struct{ symbol, currency string }
+	bySymbol := map[key]*SymbolPnL{}
+	symbol := func(name string, currency string) *SymbolPnL {
+		if _, ok := bySymbol[key{name, currency}]; !ok {
+			bySymbol[key{name, currency}] = &SymbolPnL{
+				Symbol:       name,
+				Currency:     currency,
+				QuantitySold: decimal.Zero,
+				Proceeds:     decimal.Zero,
+				CostBasis:    decimal.Zero,
+				Realised:     decimal.Zero,
+				OpenQuantity: decimal.Zero,
+				OpenCost:     decimal.Zero,
+				MarketValue:  decimal.Zero,
+				Unrealised:   decimal.Zero,
+			}
+		}
+		return bySymbol[key{name, currency}]
+	}
+
+	for _, total := range realised {
+		pnl := symbol(total.Symbol, total.Currency)
+		pnl.QuantitySold = total.Quantity
+		pnl.Proceeds = total.Proceeds
+		pnl.CostBasis = total.CostBasis
+		pnl.Realised = total.Gain
+	}
+
+	for _, position := range open {
+		pnl := symbol(position.Symbol, position.Currency)
+		pnl.OpenQuantity = position.Quantity
+		pnl.OpenCost = position.Cost
+		pnl.MarketValue = position.Cost
+		if quote, err := quotes.Quote(ctx, position.Symbol); err == nil {
+			pnl.Price = decimal.NewNullDecimal(quote.Last)
+			pnl.MarketValue = quote.Last.Mul(position.Quantity)
+			pnl.Stale = quote.Stale
+		} else {
+			pnl.Stale = true
+		}
+		pnl.Unrealised = pnl.MarketValue.Sub(pnl.OpenCost)
+		report.Stale = report.Stale || pnl.Stale
+	}
+
+	for _, pnl := range bySymbol {
+		rate, err := rates.Rate(ctx, portfolio.Currency(pnl.Currency), report.Currency)
+		if err != nil {
+			return PnLReport{}, err
+		}
+		pnl.Rate = rate
+		report.Realised = report.Realised.Add(pnl.Realised.Mul(rate).Round(costPlaces))
+		report.Unrealised = report.Unrealised.Add(pnl.Unrealised.Mul(rate).Round(costPlaces))
+		report.Symbols = append(report.Symbols, *pnl)
+	}
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		if report.Symbols[i].Symbol != report.Symbols[j].Symbol {
+			return report.Symbols[i].Symbol < report.Symbols[j].Symbol
+		}
+		return report.Symbols[i].Currency < report.Symbols[j].Currency
+	})
+	return report, nil
+}
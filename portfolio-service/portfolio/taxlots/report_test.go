@@ -0,0 +1,116 @@
+package taxlots_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPnLReport(t *testing.T) {
+	realised := []taxlots.RealisedTotal{
+		{Symbol: "ACME", Currency: "USD", Quantity: decimal.NewFromInt(5), Proceeds: decimal.NewFromInt(150), CostBasis: decimal.NewFromInt(100), Gain: decimal.NewFromInt(50)},
+		{Symbol: "GONE", Currency: "USD", Quantity: decimal.NewFromInt(2), Proceeds: decimal.NewFromInt(10), CostBasis: decimal.NewFromInt(30), Gain: decimal.NewFromInt(-20)},
+	}
+	rates := fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{
+		"EUR": decimal.RequireFromString("0.8"),
+	})
+	open := []taxlots.OpenPosition{
+		{Symbol: "ACME", Currency: "USD", Quantity: decimal.NewFromInt(5), Cost: decimal.NewFromInt(100)},
+		{Symbol: "INIT", Currency: "USD", Quantity: decimal.NewFromInt(2), Cost: decimal.NewFromInt(80)},
+	}
+
+	t.Run("Realised and unrealised gains are reported by symbol", func(t *testing.T) {
+		quotes := StubQuoteSource{
+			"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25)},
+			"INIT": {Symbol: "INIT", Last: decimal.NewFromInt(35)},
+		}
+
+		report, err := taxlots.NewPnLReport(context.Background(), "a-portfolio", taxlots.FIFO, realised, open, quotes, rates)
+
+		assert.NoError(t, err)
+		assert.Equal(t, taxlots.FIFO, report.Method)
+		assert.Equal(t, "30", report.Realised.String())
+		assert.Equal(t, "15", report.Unrealised.String())
+		assert.False(t, report.Stale)
+		if assert.Len(t, report.Symbols, 3) {
+			acme := report.Symbols[0]
+			assert.Equal(t, "ACME", acme.Symbol)
+			assert.Equal(t, "50", acme.Realised.String())
+			assert.Equal(t, "125", acme.MarketValue.String())
+			assert.Equal(t, "25", acme.Unrealised.String())
+			gone := report.Symbols[1]
+			assert.Equal(t, "GONE", gone.Symbol)
+			assert.Equal(t, "0", gone.OpenQuantity.String())
+			assert.True(t, gone.Unrealised.IsZero())
+			assert.Equal(t, "-10", report.Symbols[2].Unrealised.String())
+		}
+	})
+
+	t.Run("Open lots without a quote are valued at cost and stale", func(t *testing.T) {
+		quotes := StubQuoteSource{
+			"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25)},
+		}
+
+		report, err := taxlots.NewPnLReport(context.Background(), "a-portfolio", taxlots.FIFO, nil, open, quotes, rates)
+
+		assert.NoError(t, err)
+		assert.True(t, report.Stale)
+		assert.Equal(t, "25", report.Unrealised.String())
+		assert.Equal(t, "80", report.Symbols[1].MarketValue.String())
+		assert.False(t, report.Symbols[1].Price.Valid)
+	})
+
+	t.Run("Gains in other currencies are totalled at the latest rates", func(t *testing.T) {
+		quotes := StubQuoteSource{
+			"SAP": {Symbol: "SAP", Last: decimal.NewFromInt(12)},
+		}
+		realised := []taxlots.RealisedTotal{
+			{Symbol: "ACME", Currency: "USD", Quantity: decimal.NewFromInt(5), Proceeds: decimal.NewFromInt(150), CostBasis: decimal.NewFromInt(100), Gain: decimal.NewFromInt(50)},
+			{Symbol: "SAP", Currency: "EUR", Quantity: decimal.NewFromInt(2), Proceeds: decimal.NewFromInt(30), CostBasis: decimal.NewFromInt(20), Gain: decimal.NewFromInt(10)},
+		}
+		open := []taxlots.OpenPosition{
+			{Symbol: "SAP", Currency: "EUR", Quantity: decimal.NewFromInt(4), Cost: decimal.NewFromInt(40)},
+		}
+
+		report, err := taxlots.NewPnLReport(context.Background(), "a-portfolio", taxlots.FIFO, realised, open, quotes, rates)
+
+		assert.NoError(t, err)
+		assert.Equal(t, portfolio.BaseCurrency, report.Currency)
+		assert.Equal(t, "62.5", report.Realised.String())
+		assert.Equal(t, "10", report.Unrealised.String())
+		if assert.Len(t, report.Symbols, 2) {
+			sap := report.Symbols[1]
+			assert.Equal(t, "EUR", sap.Currency)
+			assert.Equal(t, "10", sap.Realised.String())
+			assert.Equal(t, "8", sap.Unrealised.String())
+			assert.Equal(t, "1.25", sap.Rate.String())
+		}
+	})
+
+	t.Run("Fail without the rate of a currency", func(t *testing.T) {
+		realised := []taxlots.RealisedTotal{
+			{Symbol: "SONY", Currency: "JPY", Quantity: decimal.NewFromInt(1), Proceeds: decimal.NewFromInt(100), CostBasis: decimal.NewFromInt(90), Gain: decimal.NewFromInt(10)},
+		}
+
+		_, err := taxlots.NewPnLReport(context.Background(), "a-portfolio", taxlots.FIFO, realised, nil, StubQuoteSource{}, rates)
+
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+	})
+}
+
+type StubQuoteSource map[string]valuation.Quote
+
+func (s StubQuoteSource) Quote(ctx context.Context, symbol string) (valuation.Quote, error) {
+	quote, ok := s[symbol]
+	if !ok {
+		return valuation.Quote{}, errors.New("no quote")
+	}
+	return quote, nil
+}
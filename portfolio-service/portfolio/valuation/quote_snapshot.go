@@ -0,0 +1,47 @@
+package valuation
+
+import (
+	"context"
+	"fmt"
+)
+
+// QuoteSnapshot is a set of quotes fetched ahead of time, served without
+// asking their source again. Symbols it has no quote for are not found.
+type QuoteSnapshot map[string]Quote
+
+func (s QuoteSnapshot) Quote(ctx context.Context, symbol string) (Quote, error) {
+	quote, ok := s[symbol]
+	if !ok {
+		return Quote{}, fmt.Errorf("%w: %s", ErrQuoteNotFound, symbol)
+	}
+	return quote, nil
+}
+
+// FetchQuotes takes a snapshot of the quotes of the symbols. Quotes that can
+// not be had are left out, which leaves their positions stale when valued.
+func FetchQuotes(ctx context.Context, source QuoteSource, symbols []string) QuoteSnapshot {
+	snapshot := QuoteSnapshot{}
+	for _, symbol := range symbols {
+		if quote, err := source.Quote(ctx, symbol); err == nil {
+			snapshot[symbol] = quote
+		}
+	}
+	return snapshot
+}
+
+type quotesContextKey struct{}
+
+// ContextWithQuotes carries a snapshot of quotes to the handler of a command,
+// so that it does not fetch them while its transaction holds rows locked.
+func ContextWithQuotes(ctx context.Context, snapshot QuoteSnapshot) context.Context {
+	return context.WithValue(ctx, quotesContextKey{}, snapshot)
+}
+
+// QuotesFromContext returns the snapshot carried by ctx, or source when there
+// is none.
+func QuotesFromContext(ctx context.Context, source QuoteSource) QuoteSource {
+	if snapshot, ok := ctx.Value(quotesContextKey{}).(QuoteSnapshot); ok {
+		return snapshot
+	}
+	return source
+}
@@ -0,0 +1,154 @@
+package valuation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"stock-trader/portfolio-service/portfolio"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrQuoteNotFound = errors.New("quote not found")
+
+type Quote struct {
+	Symbol    string          `json:"symbol"`
+	Last      decimal.Decimal `json:"last"`
+	Bid       decimal.Decimal `json:"bid"`
+	Ask       decimal.Decimal `json:"ask"`
+	Timestamp time.Time       `json:"timestamp"`
+	// Stale is set on quotes served after the quote source failed to give a
+	// fresher one.
+	Stale bool `json:"-"`
+}
+
+// QuoteSource is the port to the latest quotes of the market.
+type QuoteSource interface {
+	Quote(ctx context.Context, symbol string) (Quote, error)
+}
+
+type httpQuoteSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPQuoteSource reads quotes from the quote service of the broker.
+func NewHTTPQuoteSource(baseURL string, client *http.Client) QuoteSource {
+	return &httpQuoteSource{
+		baseURL: baseURL,
+		client:  client,
+	}
+}
+
+func (s *httpQuoteSource) Quote(ctx context.Context, symbol string) (Quote, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/quotes/"+url.PathEscape(symbol), nil)
+	if err != nil {
+		return Quote{}, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Quote{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return Quote{}, fmt.Errorf("%w: %s", ErrQuoteNotFound, symbol)
+	case resp.StatusCode >= 300:
+		return Quote{}, fmt.Errorf("quote service answered %d", resp.StatusCode)
+	}
+
+	var quote Quote
+	if err := json.NewDecoder(resp.Body).Decode(&quote); err != nil {
+		return Quote{}, err
+	}
+	return quote, nil
+}
+
+type cachedQuote struct {
+	quote     Quote
+	fetchedAt time.Time
+	failedAt  time.Time
+	err       error
+}
+
+// quoteCache keeps quotes for a TTL. When the source fails, it serves the last
+// quote it got, marked stale, and waits a TTL before asking the source again.
+type quoteCache struct {
+	source QuoteSource
+	ttl    time.Duration
+	mu     sync.Mutex
+	quotes map[string]cachedQuote
+	now    func() time.Time
+}
+
+func NewQuoteCache(source QuoteSource, ttl time.Duration) QuoteSource {
+	return &quoteCache{
+		source: source,
+		ttl:    ttl,
+		quotes: map[string]cachedQuote{},
+		now:    time.Now,
+	}
+}
+
+func (c *quoteCache) Quote(ctx context.Context, symbol string) (Quote, error) {
+	c.mu.Lock()
+	cached, ok := c.quotes[symbol]
+	c.mu.Unlock()
+
+	now := c.now()
+	if ok && now.Sub(cached.fetchedAt) < c.ttl {
+		return cached.quote, nil
+	}
+	if ok && now.Sub(cached.failedAt) < c.ttl {
+		return cached.fallback()
+	}
+
+	quote, err := c.source.Quote(ctx, symbol)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		cached.failedAt, cached.err = now, err
+		c.quotes[symbol] = cached
+		return cached.fallback()
+	}
+
+	c.quotes[symbol] = cachedQuote{quote: quote, fetchedAt: now}
+	return quote, nil
+}
+
+// LastPrices reads the last prices of the quotes the source cached, without
+// fetching any. Sources other than a quote cache know no prices.
+func LastPrices(source QuoteSource) portfolio.SharePrices {
+	return func(symbol string) (decimal.Decimal, bool) {
+		cache, ok := source.(*quoteCache)
+		if !ok {
+			return decimal.Zero, false
+		}
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		cached, ok := cache.quotes[symbol]
+		if !ok || cached.fetchedAt.IsZero() || !cached.quote.Last.IsPositive() {
+			return decimal.Zero, false
+		}
+		return cached.quote.Last, true
+	}
+}
+
+// fallback is the last quote fetched, marked stale, or the last error when no
+// quote was ever fetched.
+func (c cachedQuote) fallback() (Quote, error) {
+	if c.fetchedAt.IsZero() {
+		return Quote{}, c.err
+	}
+	quote := c.quote
+	quote.Stale = true
+	return quote, nil
+}
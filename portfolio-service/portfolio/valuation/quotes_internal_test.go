@@ -0,0 +1,101 @@
+package valuation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingQuoteSource struct {
+	calls int
+	last  int64
+	err   error
+}
+
+func (s *countingQuoteSource) Quote(ctx context.Context, symbol string) (Quote, error) {
+	s.calls++
+	if s.err != nil {
+		return Quote{}, s.err
+	}
+	return Quote{Symbol: symbol, Last: decimal.NewFromInt(s.last)}, nil
+}
+
+func TestQuoteCache(t *testing.T) {
+	newCache := func(source QuoteSource) (*quoteCache, *time.Time) {
+		now := time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC)
+		cache := NewQuoteCache(source, 5*time.Second).(*quoteCache)
+		cache.now = func() time.Time { return now }
+		return cache, &now
+	}
+
+	t.Run("Quotes are served from the cache until they expire", func(t *testing.T) {
+		source := &countingQuoteSource{last: 20}
+		cache, now := newCache(source)
+
+		cache.Quote(context.Background(), "ACME")
+		source.last = 21
+		cached, _ := cache.Quote(context.Background(), "ACME")
+		*now = now.Add(5 * time.Second)
+		fresh, _ := cache.Quote(context.Background(), "ACME")
+
+		assert.Equal(t, "20", cached.Last.String())
+		assert.Equal(t, "21", fresh.Last.String())
+		assert.Equal(t, 2, source.calls)
+	})
+
+	t.Run("The last quote is served stale while the source fails", func(t *testing.T) {
+		source := &countingQuoteSource{last: 20}
+		cache, now := newCache(source)
+		cache.Quote(context.Background(), "ACME")
+
+		source.err = errors.New("connection refused")
+		*now = now.Add(5 * time.Second)
+		first, err := cache.Quote(context.Background(), "ACME")
+		second, _ := cache.Quote(context.Background(), "ACME")
+
+		assert.NoError(t, err)
+		assert.True(t, first.Stale)
+		assert.Equal(t, "20", first.Last.String())
+		assert.True(t, second.Stale)
+		assert.Equal(t, 2, source.calls)
+	})
+
+	t.Run("Failures are remembered for symbols never quoted", func(t *testing.T) {
+		source := &countingQuoteSource{err: ErrQuoteNotFound}
+		cache, _ := newCache(source)
+
+		_, first := cache.Quote(context.Background(), "ACME")
+		_, second := cache.Quote(context.Background(), "ACME")
+
+		assert.ErrorIs(t, first, ErrQuoteNotFound)
+		assert.ErrorIs(t, second, ErrQuoteNotFound)
+		assert.Equal(t, 1, source.calls)
+	})
+}
+
+func TestLastPrices(t *testing.T) {
+	t.Run("Prices are read from the cache without asking the source", func(t *testing.T) {
+		source := &countingQuoteSource{last: 20}
+		cache := NewQuoteCache(source, 5*time.Second)
+		cache.Quote(context.Background(), "ACME")
+		prices := LastPrices(cache)
+
+		price, ok := prices("ACME")
+		_, missing := prices("INIT")
+
+		assert.True(t, ok)
+		assert.Equal(t, "20", price.String())
+		assert.False(t, missing)
+		assert.Equal(t, 1, source.calls)
+	})
+
+	t.Run("Other sources know no prices", func(t *testing.T) {
+		_, ok := LastPrices(&countingQuoteSource{last: 20})("ACME")
+
+		assert.False(t, ok)
+	})
+}
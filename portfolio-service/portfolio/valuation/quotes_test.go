@@ -0,0 +1,42 @@
+package valuation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPQuoteSource(t *testing.T) {
+	newSource := func(handler http.HandlerFunc) valuation.QuoteSource {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		return valuation.NewHTTPQuoteSource(server.URL, server.Client())
+	}
+
+	t.Run("Get a quote", func(t *testing.T) {
+		source := newSource(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/quotes/ACME", r.URL.Path)
+			w.Write([]byte(`{"symbol":"ACME","last":"20.02","bid":"20.01","ask":"20.03","timestamp":"2026-10-19T13:30:00Z"}`))
+		})
+
+		quote, err := source.Quote(context.Background(), "ACME")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "20.02", quote.Last.String())
+		assert.False(t, quote.Stale)
+	})
+
+	t.Run("Get a quote of an unknown symbol", func(t *testing.T) {
+		source := newSource(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := source.Quote(context.Background(), "ACME")
+
+		assert.ErrorIs(t, err, valuation.ErrQuoteNotFound)
+	})
+}
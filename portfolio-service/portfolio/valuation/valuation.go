@@ -0,0 +1,161 @@
+package valuation
+
+import (
+	"context"
+	"sort"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// valuePlaces is the precision of amounts converted to the valuation currency.
+const valuePlaces = 4
+
+// CashBalance is the cash of a portfolio in one currency, and what it is worth
+// in the valuation currency.
+type CashBalance struct {
+	Currency     portfolio.Currency `json:"currency"`
+	Cash         decimal.Decimal    `json:"cash"`
+	ReservedCash decimal.Decimal    `json:"reserved_cash"`
+	Rate         decimal.Decimal    `json:"rate"`
+	Value        decimal.Decimal    `json:"value"`
+}
+
+// Position is a holding valued at the latest quote of its symbol. A position
+// without any quote is valued at cost and marked stale, with no price. The
+// price and average cost are in the listing currency of the symbol, and the
+// amounts in the valuation currency.
+type Position struct {
+	Symbol        string              `json:"symbol"`
+	Currency      portfolio.Currency  `json:"currency"`
+	Quantity      decimal.Decimal     `json:"quantity"`
+	AverageCost   decimal.Decimal     `json:"average_cost"`
+	Cost          decimal.Decimal     `json:"cost"`
+	Price         decimal.NullDecimal `json:"price"`
+	PricedAt      *time.Time          `json:"priced_at"`
+	Rate          decimal.Decimal     `json:"rate"`
+	MarketValue   decimal.Decimal     `json:"market_value"`
+	UnrealisedPnL decimal.Decimal     `json:"unrealised_pnl"`
+	// Weight is the share of the total value of the portfolio in the position.
+	Weight decimal.Decimal `json:"weight"`
+	Stale  bool            `json:"stale"`
+}
+
+// Valuation is what a portfolio is worth at the latest quotes, in Currency. It
+// is stale when any of its positions is.
+type Valuation struct {
+	PortfolioId   portfolio.PortfolioId `json:"portfolio_id"`
+	Currency      portfolio.Currency    `json:"currency"`
+	Cash          decimal.Decimal       `json:"cash"`
+	ReservedCash  decimal.Decimal       `json:"reserved_cash"`
+	HoldingsValue decimal.Decimal       `json:"holdings_value"`
+	TotalValue    decimal.Decimal       `json:"total_value"`
+	UnrealisedPnL decimal.Decimal       `json:"unrealised_pnl"`
+	CashBalances  []CashBalance         `json:"cash_balances"`
+	Positions     []Position            `json:"positions"`
+	Stale         bool                  `json:"stale"`
+}
+
+// Value values every holding of the portfolio at the latest quote of its
+// symbol, and converts cash and holdings to the currency at the latest rates.
+// Quotes that can not be had leave their positions stale rather than failing
+// the valuation, but rates that can not be had fail it.
+func Value(ctx context.Context, p *portfolio.Portfolio, quotes QuoteSource, rates fx.RateSource, currency portfolio.Currency) (Valuation, error) {
+	valuation := Valuation{
+		PortfolioId:   p.Id(),
+		Currency:      currency,
+		Cash:          decimal.Zero,
+		ReservedCash:  decimal.Zero,
+		HoldingsValue: decimal.Zero,
+		UnrealisedPnL: decimal.Zero,
+		CashBalances:  []CashBalance{},
+		Positions:     []Position{},
+	}
+
+	available, reserved := p.CashBalances(), p.ReservedCashBalances()
+	for _, cashCurrency := range currencies(available, reserved) {
+		rate, err := rates.Rate(ctx, cashCurrency, currency)
+		if err != nil {
+			return Valuation{}, err
+		}
+		balance := CashBalance{
+			Currency:     cashCurrency,
+			Cash:         available[cashCurrency],
+			ReservedCash: reserved[cashCurrency],
+			Rate:         rate,
+		}
+		balance.Value = convert(balance.Cash.Add(balance.ReservedCash), rate)
+
+		valuation.Cash = valuation.Cash.Add(convert(balance.Cash, rate))
+		valuation.ReservedCash = valuation.ReservedCash.Add(convert(balance.ReservedCash, rate))
+		valuation.CashBalances = append(valuation.CashBalances, balance)
+	}
+
+	for _, holding := range p.Holdings() {
+		rate, err := rates.Rate(ctx, holding.Currency, currency)
+		if err != nil {
+			return Valuation{}, err
+		}
+		position := Position{
+			Symbol:      holding.Symbol,
+			Currency:    holding.Currency,
+			Quantity:    holding.Quantity,
+			AverageCost: holding.AverageCost().Round(4),
+			Cost:        convert(holding.Cost, rate),
+			Rate:        rate,
+			MarketValue: convert(holding.Cost, rate),
+		}
+
+		if quote, err := quotes.Quote(ctx, holding.Symbol); err == nil {
+			pricedAt := quote.Timestamp
+			position.Price = decimal.NewNullDecimal(quote.Last)
+			position.PricedAt = &pricedAt
+			position.MarketValue = convert(quote.Last.Mul(holding.Quantity), rate)
+			position.Stale = quote.Stale
+		} else {
+			position.Stale = true
+		}
+		position.UnrealisedPnL = position.MarketValue.Sub(position.Cost)
+
+		valuation.HoldingsValue = valuation.HoldingsValue.Add(position.MarketValue)
+		valuation.UnrealisedPnL = valuation.UnrealisedPnL.Add(position.UnrealisedPnL)
+		valuation.Stale = valuation.Stale || position.Stale
+		valuation.Positions = append(valuation.Positions, position)
+	}
+
+	valuation.TotalValue = valuation.Cash.Add(valuation.ReservedCash).Add(valuation.HoldingsValue)
+	for i := range valuation.Positions {
+		valuation.Positions[i].Weight = weight(valuation.Positions[i].MarketValue, valuation.TotalValue)
+	}
+	return valuation, nil
+}
+
+// currencies returns the currencies of the balances in alphabetical order.
+func currencies(balances ...portfolio.CashBalances) []portfolio.Currency {
+	seen := map[portfolio.Currency]bool{}
+	for _, balance := range balances {
+		for currency := range balance {
+			seen[currency] = true
+		}
+	}
+
+	result := []portfolio.Currency{}
+	for currency := range seen {
+		result = append(result, currency)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+func convert(amount decimal.Decimal, rate decimal.Decimal) decimal.Decimal {
+	return amount.Mul(rate).Round(valuePlaces)
+}
+
+func weight(value decimal.Decimal, total decimal.Decimal) decimal.Decimal {
+	if total.IsZero() {
+		return decimal.Zero
+	}
+	return value.DivRound(total, 4)
+}
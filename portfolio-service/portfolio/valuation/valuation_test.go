@@ -0,0 +1,151 @@
+package valuation_test
+
+import (
+	"context"
+	"errors"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+// portfolioHolding opens a portfolio with 1000 in cash that bought 10 ACME at
+// 20 and 5 INIT at 100.
+func portfolioHolding(t *testing.T) *portfolio.Portfolio {
+	p, _ := portfolio.OpenPortfolio("A portfolio name")
+	p.ReceiveFunds(decimal.NewFromInt(1700))
+	for _, buy := range []portfolio.OrderRequest{
+		{Symbol: "ACME", Side: portfolio.Buy, Quantity: decimal.NewFromInt(10), LimitPrice: decimal.NewFromInt(20)},
+		{Symbol: "INIT", Side: portfolio.Buy, Quantity: decimal.NewFromInt(5), LimitPrice: decimal.NewFromInt(100)},
+	} {
+		orderId := portfolio.NewOrderId()
+		if !assert.NoError(t, p.PlaceOrder(orderId, buy, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})) || !assert.NoError(t, p.ProcessTrade(orderId, "trade-"+buy.Symbol, buy.Quantity, buy.LimitPrice, portfolio.LoyaltyProgram{})) {
+			t.FailNow()
+		}
+	}
+	return p
+}
+
+func TestValue(t *testing.T) {
+	pricedAt := time.Date(2026, 10, 19, 13, 30, 0, 0, time.UTC)
+	rates := fx.NewRates(portfolio.BaseCurrency, map[portfolio.Currency]decimal.Decimal{
+		"EUR": decimal.RequireFromString("0.8"),
+	})
+
+	t.Run("Value holdings at the latest quotes", func(t *testing.T) {
+		quotes := StubQuoteSource{
+			"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25), Timestamp: pricedAt},
+			"INIT": {Symbol: "INIT", Last: decimal.NewFromInt(90), Timestamp: pricedAt},
+		}
+
+		result, err := valuation.Value(context.Background(), portfolioHolding(t), quotes, rates, portfolio.BaseCurrency)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "1000", result.Cash.String())
+		assert.Equal(t, "700", result.HoldingsValue.String())
+		assert.Equal(t, "1700", result.TotalValue.String())
+		assert.Equal(t, "0", result.UnrealisedPnL.String())
+		assert.False(t, result.Stale)
+		if assert.Len(t, result.Positions, 2) {
+			acme := result.Positions[0]
+			assert.Equal(t, "250", acme.MarketValue.String())
+			assert.Equal(t, "50", acme.UnrealisedPnL.String())
+			assert.Equal(t, "0.1471", acme.Weight.String())
+			assert.Equal(t, pricedAt, *acme.PricedAt)
+			init := result.Positions[1]
+			assert.Equal(t, "450", init.MarketValue.String())
+			assert.Equal(t, "-50", init.UnrealisedPnL.String())
+			assert.Equal(t, "0.2647", init.Weight.String())
+		}
+	})
+
+	t.Run("Holdings without a quote are valued at cost and marked stale", func(t *testing.T) {
+		quotes := StubQuoteSource{
+			"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25), Timestamp: pricedAt, Stale: true},
+		}
+
+		result, err := valuation.Value(context.Background(), portfolioHolding(t), quotes, rates, portfolio.BaseCurrency)
+
+		assert.NoError(t, err)
+		assert.True(t, result.Stale)
+		assert.True(t, result.Positions[0].Stale)
+		assert.Equal(t, "250", result.Positions[0].MarketValue.String())
+		init := result.Positions[1]
+		assert.True(t, init.Stale)
+		assert.False(t, init.Price.Valid)
+		assert.Nil(t, init.PricedAt)
+		assert.Equal(t, "500", init.MarketValue.String())
+		assert.Equal(t, "0", init.UnrealisedPnL.String())
+	})
+
+	t.Run("Value a portfolio without holdings", func(t *testing.T) {
+		p, _ := portfolio.OpenPortfolio("A portfolio name")
+
+		result, err := valuation.Value(context.Background(), p, StubQuoteSource{}, rates, portfolio.BaseCurrency)
+
+		assert.NoError(t, err)
+		assert.Empty(t, result.Positions)
+		assert.Equal(t, "0", result.TotalValue.String())
+	})
+
+	t.Run("Value cash and holdings in other currencies", func(t *testing.T) {
+		p := portfolioHolding(t)
+		p.ReceiveFundsIn("EUR", decimal.NewFromInt(500))
+		orderId := portfolio.NewOrderId()
+		buy := portfolio.OrderRequest{Symbol: "SAP", Side: portfolio.Buy, Quantity: decimal.NewFromInt(2), LimitPrice: decimal.NewFromInt(100), Currency: "EUR"}
+		if !assert.NoError(t, p.PlaceOrder(orderId, buy, portfolio.LoyaltyProgram{}, portfolio.SharePrecision{})) || !assert.NoError(t, p.ProcessTrade(orderId, "trade-SAP", decimal.NewFromInt(2), buy.LimitPrice, portfolio.LoyaltyProgram{})) {
+			t.FailNow()
+		}
+		quotes := StubQuoteSource{
+			"ACME": {Symbol: "ACME", Last: decimal.NewFromInt(25), Timestamp: pricedAt},
+			"INIT": {Symbol: "INIT", Last: decimal.NewFromInt(90), Timestamp: pricedAt},
+			"SAP":  {Symbol: "SAP", Last: decimal.NewFromInt(120), Timestamp: pricedAt},
+		}
+
+		result, err := valuation.Value(context.Background(), p, quotes, rates, "EUR")
+
+		assert.NoError(t, err)
+		assert.Equal(t, portfolio.Currency("EUR"), result.Currency)
+		// 1000 USD is 800 EUR, and 300 EUR is left after buying SAP.
+		assert.Equal(t, "1100", result.Cash.String())
+		if assert.Len(t, result.CashBalances, 2) {
+			assert.Equal(t, portfolio.Currency("EUR"), result.CashBalances[0].Currency)
+			assert.Equal(t, "300", result.CashBalances[0].Value.String())
+			assert.Equal(t, "1", result.CashBalances[0].Rate.String())
+			assert.Equal(t, portfolio.Currency("USD"), result.CashBalances[1].Currency)
+			assert.Equal(t, "800", result.CashBalances[1].Value.String())
+		}
+		if assert.Len(t, result.Positions, 3) {
+			acme := result.Positions[0]
+			assert.Equal(t, portfolio.Currency("USD"), acme.Currency)
+			assert.Equal(t, "0.8", acme.Rate.String())
+			assert.Equal(t, "200", acme.MarketValue.String())
+			assert.Equal(t, "160", acme.Cost.String())
+			sap := result.Positions[2]
+			assert.Equal(t, portfolio.Currency("EUR"), sap.Currency)
+			assert.Equal(t, "240", sap.MarketValue.String())
+			assert.Equal(t, "40", sap.UnrealisedPnL.String())
+		}
+		assert.Equal(t, "1900", result.TotalValue.String())
+	})
+
+	t.Run("Fail without a rate to the currency", func(t *testing.T) {
+		_, err := valuation.Value(context.Background(), portfolioHolding(t), StubQuoteSource{}, rates, "JPY")
+
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+	})
+}
+
+type StubQuoteSource map[string]valuation.Quote
+
+func (s StubQuoteSource) Quote(ctx context.Context, symbol string) (valuation.Quote, error) {
+	quote, ok := s[symbol]
+	if !ok {
+		return valuation.Quote{}, errors.New("quote service unavailable")
+	}
+	return quote, nil
+}
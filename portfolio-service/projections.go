@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/readmodels"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+
+	"gorm.io/gorm"
+)
+
+// TaxLotMethod is the lot relief method set by TAX_LOT_METHOD, FIFO unless
+// set. Past sells only follow a new method once the tax-lots projection is
+// rebuilt.
+func TaxLotMethod() (taxlots.Method, error) {
+	method := os.Getenv("TAX_LOT_METHOD")
+	if method == "" {
+		return taxlots.FIFO, nil
+	}
+	return taxlots.ParseMethod(method)
+}
+
+func ReadModelProjectors(taxLotMethod taxlots.Method, rates fx.RateSource) []common.RebuildableProjector {
+	return []common.RebuildableProjector{
+		readmodels.NewPortfolioSummaryProjector(rates),
+		readmodels.NewLedgerProjector(),
+		taxlots.NewTaxLotProjector(taxLotMethod),
+	}
+}
+
+func BuildProjectionEngine(db *gorm.DB, taxLotMethod taxlots.Method, rates fx.RateSource, options common.ProjectionOptions) *common.ProjectionEngine {
+	projectors := []common.Projector{}
+	for _, projector := range ReadModelProjectors(taxLotMethod, rates) {
+		projectors = append(projectors, projector)
+	}
+	return common.NewProjectionEngine(db, options, projectors...)
+}
+
+func BuildProjectionRebuilder(db *gorm.DB, taxLotMethod taxlots.Method, rates fx.RateSource) *common.ProjectionRebuilder {
+	return common.NewProjectionRebuilder(db, ReadModelProjectors(taxLotMethod, rates)...)
+}
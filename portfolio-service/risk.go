@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/risk"
+	"stock-trader/portfolio-service/portfolio/taxlots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// RiskLimits are the pre-trade limits set by RISK_MAX_ORDER_NOTIONAL,
+// RISK_MAX_CONCENTRATION, RISK_DAILY_LOSS_LIMIT and RISK_RESTRICTED_SYMBOLS, a
+// comma separated list. Limits that are not set are not enforced.
+func RiskLimits() (risk.Limits, error) {
+	limits := risk.Limits{}
+	for _, setting := range []struct {
+		name  string
+		limit *decimal.Decimal
+	}{
+		{"RISK_MAX_ORDER_NOTIONAL", &limits.MaxOrderNotional},
+		{"RISK_MAX_CONCENTRATION", &limits.MaxConcentration},
+		{"RISK_DAILY_LOSS_LIMIT", &limits.DailyLossLimit},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		limit, err := decimal.NewFromString(value)
+		if err != nil || limit.IsNegative() {
+			return risk.Limits{}, fmt.Errorf("%s must be a number no lower than zero, got %q", setting.name, value)
+		}
+		*setting.limit = limit
+	}
+	if limits.MaxConcentration.GreaterThan(decimal.NewFromInt(1)) {
+		return risk.Limits{}, fmt.Errorf("RISK_MAX_CONCENTRATION must be a share of the portfolio between 0 and 1, got %s", limits.MaxConcentration)
+	}
+
+	for _, symbol := range strings.Split(os.Getenv("RISK_RESTRICTED_SYMBOLS"), ",") {
+		if symbol = strings.TrimSpace(symbol); symbol != "" {
+			limits.RestrictedSymbols = append(limits.RestrictedSymbols, symbol)
+		}
+	}
+	return limits, nil
+}
+
+func BuildRiskChecks(tx *gorm.DB, limits risk.Limits, quotes valuation.QuoteSource, rates fx.RateSource) portfolio.RiskCheck {
+	return risk.NewChecks(limits, taxlots.NewTaxLotRepository(tx), quotes, rates)
+}
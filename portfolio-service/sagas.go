@@ -0,0 +1,79 @@
+package main
+
+import (
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/corporateactions"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/portfolio/sagas"
+	"stock-trader/portfolio-service/wiretransfers"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PlaceOrderTimeout is how long an order may take to reach the broker, and an
+// immediate-or-cancel or fill-or-kill order then to be settled by it, before it
+// is cancelled and its reservation released. Day and good-till-cancelled orders
+// stay with the broker until it reports them expired.
+const PlaceOrderTimeout = 15 * time.Minute
+
+// BuildPlaceOrderProcess builds the process with the risk checks orders are
+// placed with. Steps other than placing orders can do without.
+func BuildPlaceOrderProcess(tx *gorm.DB, dispatcher *common.DomainEventDispatcher, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision, risk portfolio.RiskCheck) *sagas.PlaceOrderProcess {
+	return sagas.NewPlaceOrderProcess(
+		portfolio.NewPortfolioRepository(tx, dispatcher),
+		sagas.NewPlaceOrderSagaRepository(tx),
+		PlaceOrderTimeout,
+		loyalty,
+		precision,
+		risk,
+	)
+}
+
+func BuildPlaceOrderSagaRunner(db *gorm.DB, dispatcher *common.DomainEventDispatcher, loyalty portfolio.LoyaltyProgram, precision portfolio.SharePrecision, broker sagas.Broker, options sagas.PlaceOrderSagaRunnerOptions) *sagas.PlaceOrderSagaRunner {
+	return sagas.NewPlaceOrderSagaRunner(
+		db,
+		func(tx *gorm.DB) *sagas.PlaceOrderProcess {
+			return BuildPlaceOrderProcess(tx, dispatcher, loyalty, precision, nil)
+		},
+		sagas.NewPlaceOrderSagaRepository,
+		broker,
+		options,
+	)
+}
+
+func BuildWireTransferProcess(tx *gorm.DB, dispatcher *common.DomainEventDispatcher) *wiretransfers.WireTransferProcess {
+	return wiretransfers.NewWireTransferProcess(
+		portfolio.NewPortfolioRepository(tx, dispatcher),
+		wiretransfers.NewWireTransferRepository(tx),
+	)
+}
+
+func BuildWireTransferRunner(db *gorm.DB, dispatcher *common.DomainEventDispatcher, options wiretransfers.WireTransferRunnerOptions) *wiretransfers.WireTransferRunner {
+	return wiretransfers.NewWireTransferRunner(
+		db,
+		func(tx *gorm.DB) *wiretransfers.WireTransferProcess {
+			return BuildWireTransferProcess(tx, dispatcher)
+		},
+		wiretransfers.NewWireTransferRepository,
+		options,
+	)
+}
+
+func BuildCorporateActionProcess(tx *gorm.DB, dispatcher *common.DomainEventDispatcher) *corporateactions.CorporateActionProcess {
+	return corporateactions.NewCorporateActionProcess(
+		portfolio.NewPortfolioRepository(tx, dispatcher),
+		corporateactions.NewCorporateActionRepository(tx),
+		sagas.NewPlaceOrderSagaRepository(tx),
+	)
+}
+
+func BuildCorporateActionRunner(db *gorm.DB, dispatcher *common.DomainEventDispatcher, options corporateactions.CorporateActionRunnerOptions) *corporateactions.CorporateActionRunner {
+	return corporateactions.NewCorporateActionRunner(
+		db,
+		func(tx *gorm.DB) *corporateactions.CorporateActionProcess {
+			return BuildCorporateActionProcess(tx, dispatcher)
+		},
+		options,
+	)
+}
@@ -8,6 +8,31 @@ table "portfolios" {
     null = false
     type = varchar(30)
   }
+  column "cash" {
+    null = false
+    type = decimal(19,4)
+    default = 0
+  }
+  column "foreign_cash" {
+    null = false
+    type = json
+    default = sql("(json_object())")
+  }
+  column "holdings" {
+    null = false
+    type = json
+    default = sql("(json_array())")
+  }
+  column "pending_orders" {
+    null = false
+    type = json
+    default = sql("(json_array())")
+  }
+  column "loyalty" {
+    null = false
+    type = varchar(16)
+    default = "basic"
+  }
 
   primary_key {
     columns = [column.id]
@@ -42,17 +67,671 @@ table "event_journal" {
     type = boolean
     default = false
   }
+  column "version" {
+    null = false
+    type = int
+    default = 1
+  }
+  column "position" {
+    null = false
+    type = bigint
+    auto_increment = true
+  }
+  column "portfolio_id" {
+    null = true
+    type = varchar(36)
+    as {
+      expr = "json_unquote(json_extract(`event_data`,_utf8mb4'$.portfolioId'))"
+      type = VIRTUAL
+    }
+  }
 
   primary_key {
     columns = [column.id]
   }
 
+  index "idx_position" {
+    columns = [column.position]
+    unique = true
+  }
+
   index "idx_sent_x_timestamp" {
     columns = [
       column.sent,
       column.timestamp
     ]
   }
+
+  index "idx_portfolio_id_x_position" {
+    columns = [
+      column.portfolio_id,
+      column.position
+    ]
+  }
+}
+
+table "portfolio_summaries" {
+  schema = schema.portfolio
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "name" {
+    null = false
+    type = varchar(30)
+  }
+  column "status" {
+    null = false
+    type = varchar(16)
+  }
+  column "cash" {
+    null = false
+    type = decimal(19,4)
+    default = 0
+  }
+  column "holdings_count" {
+    null = false
+    type = int
+    default = 0
+  }
+  column "total_value" {
+    null = false
+    type = decimal(19,4)
+    default = 0
+  }
+  column "loyalty" {
+    null = false
+    type = varchar(16)
+    default = "basic"
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_status_x_name" {
+    columns = [
+      column.status,
+      column.name
+    ]
+  }
+}
+
+table "projection_checkpoints" {
+  schema = schema.portfolio
+  column "name" {
+    null = false
+    type = varchar(64)
+  }
+  column "position" {
+    null = false
+    type = bigint
+    default = 0
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.name]
+  }
+}
+
+table "projection_skipped_positions" {
+  schema = schema.portfolio
+  column "name" {
+    null = false
+    type = varchar(64)
+  }
+  column "position" {
+    null = false
+    type = bigint
+  }
+  column "skipped_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [
+      column.name,
+      column.position
+    ]
+  }
+}
+
+table "place_order_sagas" {
+  schema = schema.portfolio
+  column "order_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "symbol" {
+    null = false
+    type = varchar(8)
+  }
+  column "side" {
+    null = false
+    type = varchar(4)
+  }
+  column "type" {
+    null = false
+    type = varchar(16)
+    default = "limit"
+  }
+  column "time_in_force" {
+    null = false
+    type = varchar(8)
+    default = "day"
+  }
+  column "quantity" {
+    null = false
+    type = decimal(19,6)
+  }
+  column "limit_price" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "stop_price" {
+    null = false
+    type = decimal(19,4)
+    default = 0
+  }
+  column "precision" {
+    null = false
+    type = int
+    default = 0
+  }
+  column "filled_quantity" {
+    null = false
+    type = decimal(19,6)
+    default = 0
+  }
+  column "broker_filled_quantity" {
+    null = false
+    type = decimal(19,6)
+    default = 0
+  }
+  column "state" {
+    null = false
+    type = varchar(16)
+  }
+  column "reason" {
+    null = false
+    type = varchar(256)
+    default = ""
+  }
+  column "processed_trades" {
+    null = false
+    type = json
+    default = sql("(json_array())")
+  }
+  column "submit_attempts" {
+    null = false
+    type = int
+    default = 0
+  }
+  column "deadline" {
+    null = true
+    type = datetime(6)
+  }
+  column "created_at" {
+    null = false
+    type = datetime(6)
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.order_id]
+  }
+
+  index "idx_state_x_deadline" {
+    columns = [
+      column.state,
+      column.deadline
+    ]
+  }
+}
+
+table "wire_transfers" {
+  schema = schema.portfolio
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "sender_portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "receiver_portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "amount" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "state" {
+    null = false
+    type = varchar(16)
+  }
+  column "reason" {
+    null = false
+    type = varchar(256)
+    default = ""
+  }
+  column "steps" {
+    null = false
+    type = json
+    default = sql("(json_array())")
+  }
+  column "failed_attempts" {
+    null = false
+    type = int
+    default = 0
+  }
+  column "created_at" {
+    null = false
+    type = datetime(6)
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_state_x_created_at" {
+    columns = [
+      column.state,
+      column.created_at
+    ]
+  }
+}
+
+table "ledger_entries" {
+  schema = schema.portfolio
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "position" {
+    null = false
+    type = bigint
+  }
+  column "type" {
+    null = false
+    type = varchar(16)
+  }
+  column "order_id" {
+    null = false
+    type = varchar(36)
+    default = ""
+  }
+  column "trade_id" {
+    null = false
+    type = varchar(64)
+    default = ""
+  }
+  column "transfer_id" {
+    null = false
+    type = varchar(36)
+    default = ""
+  }
+  column "symbol" {
+    null = false
+    type = varchar(8)
+    default = ""
+  }
+  column "quantity" {
+    null = true
+    type = decimal(19,6)
+  }
+  column "price" {
+    null = true
+    type = decimal(19,4)
+  }
+  column "currency" {
+    null = false
+    type = varchar(3)
+    default = "USD"
+  }
+  column "commission" {
+    null = false
+    type = decimal(19,4)
+    default = 0
+  }
+  column "amount" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "cash_balance" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "timestamp" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_portfolio_id_x_position" {
+    columns = [
+      column.portfolio_id,
+      column.position
+    ]
+    unique = true
+  }
+
+  index "idx_portfolio_id_x_timestamp" {
+    columns = [
+      column.portfolio_id,
+      column.timestamp
+    ]
+  }
+}
+
+table "tax_lots" {
+  schema = schema.portfolio
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "symbol" {
+    null = false
+    type = varchar(8)
+  }
+  column "currency" {
+    null = false
+    type = varchar(3)
+    default = "USD"
+  }
+  column "position" {
+    null = false
+    type = bigint
+  }
+  column "quantity" {
+    null = false
+    type = decimal(19,6)
+  }
+  column "cost" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "remaining_quantity" {
+    null = false
+    type = decimal(19,6)
+  }
+  column "remaining_cost" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "split_position" {
+    null = false
+    type = bigint
+    default = 0
+  }
+  column "opened_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_portfolio_id_x_symbol_x_position" {
+    columns = [
+      column.portfolio_id,
+      column.symbol,
+      column.position
+    ]
+  }
+}
+
+table "realised_gains" {
+  schema = schema.portfolio
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "symbol" {
+    null = false
+    type = varchar(8)
+  }
+  column "currency" {
+    null = false
+    type = varchar(3)
+    default = "USD"
+  }
+  column "order_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "trade_id" {
+    null = false
+    type = varchar(64)
+  }
+  column "quantity" {
+    null = false
+    type = decimal(19,6)
+  }
+  column "proceeds" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "cost_basis" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "gain" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "realised_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_portfolio_id_x_realised_at" {
+    columns = [
+      column.portfolio_id,
+      column.realised_at
+    ]
+  }
+}
+
+table "portfolio_snapshots" {
+  schema = schema.portfolio
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "date" {
+    null = false
+    type = date
+  }
+  column "cash" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "reserved_cash" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "cash_balances" {
+    null = false
+    type = json
+    default = sql("(json_object())")
+  }
+  column "holdings_value" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "total_value" {
+    null = false
+    type = decimal(19,4)
+  }
+  column "net_flow" {
+    null = true
+    type = decimal(19,4)
+  }
+  column "positions" {
+    null = false
+    type = json
+    default = sql("(json_array())")
+  }
+  column "price_source" {
+    null = false
+    type = varchar(16)
+  }
+  column "stale" {
+    null = false
+    type = boolean
+    default = false
+  }
+  column "journal_position" {
+    null = false
+    type = bigint
+  }
+  column "taken_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [
+      column.portfolio_id,
+      column.date
+    ]
+  }
+}
+
+table "corporate_actions" {
+  schema = schema.portfolio
+  column "id" {
+    null = false
+    type = varchar(36)
+  }
+  column "type" {
+    null = false
+    type = varchar(16)
+  }
+  column "symbol" {
+    null = false
+    type = varchar(8)
+  }
+  column "ex_date" {
+    null = false
+    type = date
+  }
+  column "numerator" {
+    null = false
+    type = bigint
+    default = 0
+  }
+  column "denominator" {
+    null = false
+    type = bigint
+    default = 0
+  }
+  column "amount_per_share" {
+    null = true
+    type = decimal(19,4)
+  }
+  column "state" {
+    null = false
+    type = varchar(16)
+  }
+  column "holders" {
+    null = false
+    type = int
+    default = 0
+  }
+  column "created_at" {
+    null = false
+    type = datetime(6)
+  }
+  column "updated_at" {
+    null = false
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [column.id]
+  }
+
+  index "idx_state_x_ex_date" {
+    columns = [
+      column.state,
+      column.ex_date
+    ]
+  }
+}
+
+table "corporate_action_applications" {
+  schema = schema.portfolio
+  column "action_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "portfolio_id" {
+    null = false
+    type = varchar(36)
+  }
+  column "quantity" {
+    null = true
+    type = decimal(19,6)
+  }
+  column "currency" {
+    null = false
+    type = varchar(3)
+    default = ""
+  }
+  column "applied_at" {
+    null = true
+    type = datetime(6)
+  }
+
+  primary_key {
+    columns = [
+      column.action_id,
+      column.portfolio_id
+    ]
+  }
 }
 
 schema "portfolio" {
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"stock-trader/portfolio-service/portfolio/fx"
+	"stock-trader/portfolio-service/portfolio/performance"
+	"stock-trader/portfolio-service/portfolio/snapshots"
+	"stock-trader/portfolio-service/portfolio/valuation"
+	"strconv"
+	"time"
+	// The runtime image ships without a time zone database.
+	_ "time/tzdata"
+
+	"gorm.io/gorm"
+)
+
+// SnapshotSettings reads the market close from SNAPSHOT_MARKET_CLOSE and
+// SNAPSHOT_TIME_ZONE, 16:00 in New York unless set, and how many market dates
+// to backfill from SNAPSHOT_BACKFILL_DAYS, 10 unless set.
+func SnapshotSettings() (snapshots.MarketClose, int, error) {
+	clock, zone := os.Getenv("SNAPSHOT_MARKET_CLOSE"), os.Getenv("SNAPSHOT_TIME_ZONE")
+	if clock == "" {
+		clock = "16:00"
+	}
+	if zone == "" {
+		zone = "America/New_York"
+	}
+	market, err := snapshots.ParseMarketClose(clock, zone)
+	if err != nil {
+		return snapshots.MarketClose{}, 0, err
+	}
+
+	backfill := 10
+	if days := os.Getenv("SNAPSHOT_BACKFILL_DAYS"); days != "" {
+		if backfill, err = strconv.Atoi(days); err != nil || backfill < 1 {
+			return snapshots.MarketClose{}, 0, fmt.Errorf("SNAPSHOT_BACKFILL_DAYS must be a positive number of days, got %q", days)
+		}
+	}
+	return market, backfill, nil
+}
+
+func BuildSnapshotJob(db *gorm.DB, quotes valuation.QuoteSource, rates fx.RateSource, market snapshots.MarketClose, backfill int, onError func(portfolioId string, err error)) *snapshots.SnapshotJob {
+	return snapshots.NewSnapshotJob(
+		snapshots.NewSnapshotRepository(db),
+		func(ctx context.Context, portfolioId string, instants []time.Time) ([]performance.JournalState, error) {
+			return performance.JournalStates(ctx, db, portfolioId, instants)
+		},
+		quotes,
+		rates,
+		market,
+		snapshots.SnapshotJobOptions{
+			Backfill:   backfill,
+			LiveWindow: time.Hour,
+			OnError:    onError,
+		},
+	)
+}
@@ -0,0 +1,61 @@
+package wiretransfers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/wiretransfers"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetWireTransferEndpoint struct {
+	handler common.Handler[GetWireTransferQuery, *wiretransfers.WireTransfer]
+}
+
+func NewGetWireTransferEndpoint(handler common.Handler[GetWireTransferQuery, *wiretransfers.WireTransfer]) *GetWireTransferEndpoint {
+	return &GetWireTransferEndpoint{
+		handler: handler,
+	}
+}
+
+func (e *GetWireTransferEndpoint) Get(c echo.Context) error {
+	query := new(GetWireTransferQuery)
+	if err := c.Bind(query); err != nil {
+		return err
+	}
+
+	if err := c.Validate(query); err != nil {
+		return err
+	}
+
+	transfer, err := e.handler.Handle(c.Request().Context(), *query)
+
+	if err != nil {
+		if errors.Is(err, wiretransfers.ErrWireTransferNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, transfer)
+}
+
+type GetWireTransferQuery struct {
+	TransferId string `param:"id" validate:"required,uuid"`
+}
+
+type GetWireTransferHandler struct {
+	transferRepository wiretransfers.WireTransferRepository
+}
+
+func NewGetWireTransferHandler(repository wiretransfers.WireTransferRepository) *GetWireTransferHandler {
+	return &GetWireTransferHandler{
+		transferRepository: repository,
+	}
+}
+
+func (h *GetWireTransferHandler) Handle(ctx context.Context, query GetWireTransferQuery) (*wiretransfers.WireTransfer, error) {
+	return h.transferRepository.FindById(ctx, query.TransferId)
+}
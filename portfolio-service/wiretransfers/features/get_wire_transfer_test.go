@@ -0,0 +1,91 @@
+package wiretransfers_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	"stock-trader/portfolio-service/wiretransfers"
+	features "stock-trader/portfolio-service/wiretransfers/features"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+)
+
+func Test_GetWireTransferEndpoint(t *testing.T) {
+	newContext := func(transferId string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodGet, "/transfers/"+transferId, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(transferId)
+		return c, rec
+	}
+
+	t.Run("Get Wire Transfer Successfully", func(t *testing.T) {
+		transferId, sender, receiver := uuid.NewString(), uuid.NewString(), uuid.NewString()
+		at := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+		endpoint := features.NewGetWireTransferEndpoint(&StubHandler[features.GetWireTransferQuery, *wiretransfers.WireTransfer]{
+			call: func(ctx context.Context, query features.GetWireTransferQuery) (*wiretransfers.WireTransfer, error) {
+				return &wiretransfers.WireTransfer{
+					Id:                  query.TransferId,
+					SenderPortfolioId:   sender,
+					ReceiverPortfolioId: receiver,
+					Amount:              decimal.NewFromInt(40),
+					State:               wiretransfers.TransferRefunded,
+					Reason:              "portfolio not found",
+					Steps: datatypes.NewJSONType([]wiretransfers.WireTransferStep{
+						{Name: wiretransfers.SendFundsStep, Succeeded: true, At: at},
+						{Name: wiretransfers.ReceiveFundsStep, Error: "portfolio not found", At: at},
+						{Name: wiretransfers.RefundSenderStep, Succeeded: true, At: at},
+					}),
+					CreatedAt: at,
+					UpdatedAt: at,
+				}, nil
+			},
+		})
+		c, rec := newContext(transferId)
+
+		if assert.NoError(t, endpoint.Get(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{
+				"transfer_id": "`+transferId+`",
+				"sender_portfolio_id": "`+sender+`",
+				"receiver_portfolio_id": "`+receiver+`",
+				"amount": "40",
+				"state": "refunded",
+				"reason": "portfolio not found",
+				"steps": [
+					{"name": "send-funds", "succeeded": true, "at": "2026-10-19T12:00:00Z"},
+					{"name": "receive-funds", "succeeded": false, "error": "portfolio not found", "at": "2026-10-19T12:00:00Z"},
+					{"name": "refund-sender", "succeeded": true, "at": "2026-10-19T12:00:00Z"}
+				],
+				"created_at": "2026-10-19T12:00:00Z",
+				"updated_at": "2026-10-19T12:00:00Z"
+			}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Get Unknown Wire Transfer", func(t *testing.T) {
+		endpoint := features.NewGetWireTransferEndpoint(&StubHandler[features.GetWireTransferQuery, *wiretransfers.WireTransfer]{
+			call: func(ctx context.Context, query features.GetWireTransferQuery) (*wiretransfers.WireTransfer, error) {
+				return nil, fmt.Errorf("%w: %s", wiretransfers.ErrWireTransferNotFound, query.TransferId)
+			},
+		})
+		c, _ := newContext(uuid.NewString())
+
+		err := endpoint.Get(c)
+
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+		}
+	})
+}
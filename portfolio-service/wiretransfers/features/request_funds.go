@@ -0,0 +1,66 @@
+package wiretransfers
+
+import (
+	"context"
+	"net/http"
+	"stock-trader/portfolio-service/common"
+	"stock-trader/portfolio-service/wiretransfers"
+
+	"github.com/labstack/echo/v4"
+	"github.com/shopspring/decimal"
+)
+
+type RequestFundsEndpoint struct {
+	handler common.Handler[RequestFundsCommand, string]
+}
+
+func NewRequestFundsEndpoint(handler common.Handler[RequestFundsCommand, string]) *RequestFundsEndpoint {
+	return &RequestFundsEndpoint{
+		handler: handler,
+	}
+}
+
+// Request answers as soon as the transfer is recorded. Its progress is served
+// by GET /transfers/:id.
+func (e *RequestFundsEndpoint) Request(c echo.Context) error {
+	command := new(RequestFundsCommand)
+	if err := c.Bind(command); err != nil {
+		return err
+	}
+
+	if err := c.Validate(command); err != nil {
+		return err
+	}
+
+	transferId, err := e.handler.Handle(c.Request().Context(), *command)
+
+	if err != nil {
+		return echo.NewHTTPError(500, err.Error())
+	}
+
+	return c.JSON(http.StatusAccepted, struct {
+		TransferId string `json:"transfer_id"`
+	}{
+		TransferId: transferId,
+	})
+}
+
+type RequestFundsCommand struct {
+	SenderPortfolioId   string          `json:"sender_portfolio_id" validate:"required,uuid"`
+	ReceiverPortfolioId string          `json:"receiver_portfolio_id" validate:"required,uuid,nefield=SenderPortfolioId"`
+	Amount              decimal.Decimal `json:"amount" validate:"gt=0"`
+}
+
+type RequestFundsHandler struct {
+	process *wiretransfers.WireTransferProcess
+}
+
+func NewRequestFundsHandler(process *wiretransfers.WireTransferProcess) *RequestFundsHandler {
+	return &RequestFundsHandler{
+		process: process,
+	}
+}
+
+func (h *RequestFundsHandler) Handle(ctx context.Context, command RequestFundsCommand) (string, error) {
+	return h.process.Request(ctx, command.SenderPortfolioId, command.ReceiverPortfolioId, command.Amount)
+}
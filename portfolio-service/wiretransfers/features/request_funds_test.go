@@ -0,0 +1,72 @@
+package wiretransfers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"stock-trader/portfolio-service/infrastructure"
+	features "stock-trader/portfolio-service/wiretransfers/features"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RequestFundsEndpoint(t *testing.T) {
+	newContext := func(body string) (echo.Context, *httptest.ResponseRecorder) {
+		e := echo.New()
+		e.Validator = infrastructure.NewRequestValidator()
+		req := httptest.NewRequest(http.MethodPost, "/transfers", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		return e.NewContext(req, rec), rec
+	}
+
+	t.Run("Request Funds Successfully", func(t *testing.T) {
+		transferId := uuid.NewString()
+		sender, receiver := uuid.NewString(), uuid.NewString()
+		endpoint := features.NewRequestFundsEndpoint(&StubHandler[features.RequestFundsCommand, string]{
+			call: func(ctx context.Context, command features.RequestFundsCommand) (string, error) {
+				assert.Equal(t, sender, command.SenderPortfolioId)
+				assert.Equal(t, receiver, command.ReceiverPortfolioId)
+				assert.Equal(t, "75.5", command.Amount.String())
+				return transferId, nil
+			},
+		})
+		c, rec := newContext(`{"sender_portfolio_id":"` + sender + `","receiver_portfolio_id":"` + receiver + `","amount":"75.5"}`)
+
+		if assert.NoError(t, endpoint.Request(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.JSONEq(t, `{"transfer_id":"`+transferId+`"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("Request Funds To The Same Portfolio", func(t *testing.T) {
+		portfolioId := uuid.NewString()
+		endpoint := features.NewRequestFundsEndpoint(nil)
+		c, _ := newContext(`{"sender_portfolio_id":"` + portfolioId + `","receiver_portfolio_id":"` + portfolioId + `","amount":10}`)
+
+		err := endpoint.Request(c)
+
+		if assert.Error(t, err) {
+			err := err.(*echo.HTTPError)
+			assert.Equal(t, http.StatusBadRequest, err.Code)
+			assert.Equal(t, &infrastructure.ValidationErrorsResponse{
+				Message: "there were validation errors",
+				Errors: []infrastructure.FieldError{
+					{Field: "ReceiverPortfolioId", Error: "ReceiverPortfolioId cannot be equal to SenderPortfolioId"},
+				},
+			}, err.Message)
+		}
+	})
+}
+
+type StubHandler[K any, V any] struct {
+	call func(context.Context, K) (V, error)
+}
+
+func (s *StubHandler[K, V]) Handle(ctx context.Context, command K) (V, error) {
+	return s.call(ctx, command)
+}
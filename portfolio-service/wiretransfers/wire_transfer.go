@@ -0,0 +1,136 @@
+package wiretransfers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/datatypes"
+)
+
+var ErrWireTransferNotFound = errors.New("wire transfer not found")
+
+var ErrWireTransferFinished = errors.New("wire transfer is no longer pending")
+
+type WireTransferState string
+
+const (
+	// TransferRequested means the sender still has to be debited.
+	TransferRequested WireTransferState = "requested"
+	// TransferFundsSent means the sender was debited and the receiver still has
+	// to be credited.
+	TransferFundsSent WireTransferState = "funds-sent"
+	// TransferRefunding means the receiver could not be credited and the sender
+	// still has to get its funds back.
+	TransferRefunding WireTransferState = "refunding"
+	TransferCompleted WireTransferState = "completed"
+	TransferRefunded  WireTransferState = "refunded"
+	// TransferFailed means the sender could not be debited, so nothing moved.
+	TransferFailed WireTransferState = "failed"
+)
+
+const (
+	SendFundsStep    = "send-funds"
+	ReceiveFundsStep = "receive-funds"
+	RefundSenderStep = "refund-sender"
+)
+
+// WireTransferStep is the outcome of one attempt of a saga step.
+type WireTransferStep struct {
+	Name      string    `json:"name"`
+	Succeeded bool      `json:"succeeded"`
+	Error     string    `json:"error,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// WireTransfer moves funds from one portfolio to another. Each step changes a
+// single portfolio and is committed together with the transfer, so a transfer
+// can always be resumed from its state.
+type WireTransfer struct {
+	Id                  string                                 `gorm:"column:id;primaryKey" json:"transfer_id"`
+	SenderPortfolioId   string                                 `gorm:"column:sender_portfolio_id" json:"sender_portfolio_id"`
+	ReceiverPortfolioId string                                 `gorm:"column:receiver_portfolio_id" json:"receiver_portfolio_id"`
+	Amount              decimal.Decimal                        `gorm:"column:amount" json:"amount"`
+	State               WireTransferState                      `gorm:"column:state" json:"state"`
+	Reason              string                                 `gorm:"column:reason" json:"reason,omitempty"`
+	Steps               datatypes.JSONType[[]WireTransferStep] `gorm:"column:steps" json:"steps"`
+	FailedAttempts      int                                    `gorm:"column:failed_attempts" json:"-"`
+	CreatedAt           time.Time                              `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt           time.Time                              `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (WireTransfer) TableName() string {
+	return "wire_transfers"
+}
+
+func NewWireTransfer(senderPortfolioId string, receiverPortfolioId string, amount decimal.Decimal, now time.Time) (*WireTransfer, error) {
+	if senderPortfolioId == receiverPortfolioId {
+		return nil, errors.New("sender and receiver must be different portfolios")
+	}
+	if !amount.IsPositive() {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	return &WireTransfer{
+		Id:                  uuid.NewString(),
+		SenderPortfolioId:   senderPortfolioId,
+		ReceiverPortfolioId: receiverPortfolioId,
+		Amount:              amount,
+		State:               TransferRequested,
+		Steps:               datatypes.NewJSONType([]WireTransferStep{}),
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}, nil
+}
+
+func (t *WireTransfer) IsPending() bool {
+	return t.State == TransferRequested || t.State == TransferFundsSent || t.State == TransferRefunding
+}
+
+// RecordFailedAttempt keeps track of a step that failed for a reason that may
+// go away, such as the database being unavailable. The step is retried later.
+func (t *WireTransfer) RecordFailedAttempt(step string, err error, now time.Time) {
+	t.FailedAttempts++
+	t.appendStep(WireTransferStep{Name: step, Error: err.Error(), At: now})
+}
+
+func (t *WireTransfer) stepSucceeded(step string, next WireTransferState, now time.Time) error {
+	if !t.IsPending() {
+		return fmt.Errorf("%w: %s is %s", ErrWireTransferFinished, t.Id, t.State)
+	}
+	t.State = next
+	t.FailedAttempts = 0
+	t.appendStep(WireTransferStep{Name: step, Succeeded: true, At: now})
+	return nil
+}
+
+func (t *WireTransfer) stepFailed(step string, err error, next WireTransferState, now time.Time) error {
+	if !t.IsPending() {
+		return fmt.Errorf("%w: %s is %s", ErrWireTransferFinished, t.Id, t.State)
+	}
+	t.State = next
+	t.Reason = err.Error()
+	t.FailedAttempts = 0
+	t.appendStep(WireTransferStep{Name: step, Error: err.Error(), At: now})
+	return nil
+}
+
+func (t *WireTransfer) appendStep(step WireTransferStep) {
+	t.Steps = datatypes.NewJSONType(append(t.Steps.Data(), step))
+	t.UpdatedAt = step.At
+}
+
+// NextStep is the step that moves the transfer out of its current state.
+func (t *WireTransfer) NextStep() string {
+	switch t.State {
+	case TransferRequested:
+		return SendFundsStep
+	case TransferFundsSent:
+		return ReceiveFundsStep
+	case TransferRefunding:
+		return RefundSenderStep
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package wiretransfers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stock-trader/portfolio-service/portfolio"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxReceiveAttempts is how many times crediting the receiver may fail before
+// the sender is refunded.
+const MaxReceiveAttempts = 5
+
+// WireTransferProcess holds the steps of the wire transfer saga. Every step runs
+// in the transaction its repositories were built with.
+type WireTransferProcess struct {
+	portfolios portfolio.PortfolioRepository
+	transfers  WireTransferRepository
+	now        func() time.Time
+}
+
+func NewWireTransferProcess(portfolios portfolio.PortfolioRepository, transfers WireTransferRepository) *WireTransferProcess {
+	return &WireTransferProcess{
+		portfolios: portfolios,
+		transfers:  transfers,
+		now:        func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Request records a transfer. Its steps are run afterwards by the
+// WireTransferRunner.
+func (p *WireTransferProcess) Request(ctx context.Context, senderPortfolioId string, receiverPortfolioId string, amount decimal.Decimal) (string, error) {
+	transfer, err := NewWireTransfer(senderPortfolioId, receiverPortfolioId, amount, p.now())
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.transfers.Save(ctx, transfer); err != nil {
+		return "", err
+	}
+
+	return transfer.Id, nil
+}
+
+// Advance runs the next step of a transfer and reports whether it still has
+// steps to run. A portfolio refusing a step moves the transfer to its failure
+// path; any other error is returned and the step is retried later.
+func (p *WireTransferProcess) Advance(ctx context.Context, transferId string) (bool, error) {
+	transfer, err := p.transfers.FindById(ctx, transferId)
+	if err != nil {
+		return false, err
+	}
+
+	switch transfer.State {
+	case TransferRequested:
+		err = p.sendFunds(ctx, transfer)
+	case TransferFundsSent:
+		err = p.receiveFunds(ctx, transfer)
+	case TransferRefunding:
+		err = p.refundSender(ctx, transfer)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	return transfer.IsPending(), p.transfers.Save(ctx, transfer)
+}
+
+func (p *WireTransferProcess) RecordFailedAttempt(ctx context.Context, transferId string, cause error) error {
+	transfer, err := p.transfers.FindById(ctx, transferId)
+	if err != nil {
+		return err
+	}
+
+	transfer.RecordFailedAttempt(transfer.NextStep(), cause, p.now())
+	return p.transfers.Save(ctx, transfer)
+}
+
+func (p *WireTransferProcess) sendFunds(ctx context.Context, transfer *WireTransfer) error {
+	sender, err := p.portfolios.FindById(ctx, portfolio.PortfolioId(transfer.SenderPortfolioId))
+	if err == nil {
+		err = sender.SendFunds(transfer.Id, transfer.Amount)
+	}
+	if isRefusal(err) {
+		return transfer.stepFailed(SendFundsStep, err, TransferFailed, p.now())
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.portfolios.Save(ctx, sender); err != nil {
+		return err
+	}
+
+	return transfer.stepSucceeded(SendFundsStep, TransferFundsSent, p.now())
+}
+
+func (p *WireTransferProcess) receiveFunds(ctx context.Context, transfer *WireTransfer) error {
+	if transfer.FailedAttempts >= MaxReceiveAttempts {
+		err := fmt.Errorf("receiver could not be credited after %d attempts", transfer.FailedAttempts)
+		return transfer.stepFailed(ReceiveFundsStep, err, TransferRefunding, p.now())
+	}
+
+	receiver, err := p.portfolios.FindById(ctx, portfolio.PortfolioId(transfer.ReceiverPortfolioId))
+	if err == nil {
+		err = receiver.ReceiveFunds(transfer.Amount)
+	}
+	if isRefusal(err) {
+		return transfer.stepFailed(ReceiveFundsStep, err, TransferRefunding, p.now())
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := p.portfolios.Save(ctx, receiver); err != nil {
+		return err
+	}
+
+	return transfer.stepSucceeded(ReceiveFundsStep, TransferCompleted, p.now())
+}
+
+// refundSender is the compensation of sendFunds. It is retried until it
+// succeeds.
+func (p *WireTransferProcess) refundSender(ctx context.Context, transfer *WireTransfer) error {
+	sender, err := p.portfolios.FindById(ctx, portfolio.PortfolioId(transfer.SenderPortfolioId))
+	if err != nil {
+		return err
+	}
+
+	if err := sender.AcceptRefund(transfer.Id, transfer.Amount); err != nil {
+		return err
+	}
+
+	if err := p.portfolios.Save(ctx, sender); err != nil {
+		return err
+	}
+
+	return transfer.stepSucceeded(RefundSenderStep, TransferRefunded, p.now())
+}
+
+func isRefusal(err error) bool {
+	return errors.Is(err, portfolio.ErrPortfolioNotFound) || errors.Is(err, portfolio.ErrInsufficientFunds)
+}
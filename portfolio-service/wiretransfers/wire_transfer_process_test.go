@@ -0,0 +1,191 @@
+package wiretransfers_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"stock-trader/portfolio-service/portfolio"
+	"stock-trader/portfolio-service/wiretransfers"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestWireTransferRunner(t *testing.T) {
+	setup := func(senderCash int64) (*StubPortfolioRepository, *InMemoryWireTransferRepository, *portfolio.Portfolio, *portfolio.Portfolio) {
+		sender, _ := portfolio.OpenPortfolio("The sender")
+		sender.ReceiveFunds(decimal.NewFromInt(senderCash))
+		receiver, _ := portfolio.OpenPortfolio("The receiver")
+		portfolios := &StubPortfolioRepository{portfolios: map[portfolio.PortfolioId]*portfolio.Portfolio{
+			sender.Id():   sender,
+			receiver.Id(): receiver,
+		}}
+		return portfolios, &InMemoryWireTransferRepository{transfers: map[string]wiretransfers.WireTransfer{}}, sender, receiver
+	}
+
+	newRunner := func(portfolios *StubPortfolioRepository, transfers *InMemoryWireTransferRepository) (*wiretransfers.WireTransferRunner, *wiretransfers.WireTransferProcess, *[]error) {
+		errs := &[]error{}
+		process := wiretransfers.NewWireTransferProcess(portfolios, transfers)
+		runner := wiretransfers.NewWireTransferRunner(
+			StubUnitOfWork{},
+			func(tx *gorm.DB) *wiretransfers.WireTransferProcess { return process },
+			func(tx *gorm.DB) wiretransfers.WireTransferRepository { return transfers },
+			wiretransfers.WireTransferRunnerOptions{
+				BatchSize: 10,
+				OnError:   func(transferId string, err error) { *errs = append(*errs, err) },
+			},
+		)
+		return runner, process, errs
+	}
+
+	steps := func(transfer wiretransfers.WireTransfer) []string {
+		names := []string{}
+		for _, step := range transfer.Steps.Data() {
+			names = append(names, fmt.Sprintf("%s:%t", step.Name, step.Succeeded))
+		}
+		return names
+	}
+
+	t.Run("Funds move from the sender to the receiver", func(t *testing.T) {
+		portfolios, transfers, sender, receiver := setup(100)
+		runner, process, errs := newRunner(portfolios, transfers)
+		transferId, _ := process.Request(context.Background(), string(sender.Id()), string(receiver.Id()), decimal.NewFromInt(40))
+
+		runner.Tick(context.Background())
+
+		assert.Empty(t, *errs)
+		transfer := transfers.transfers[transferId]
+		assert.Equal(t, wiretransfers.TransferCompleted, transfer.State)
+		assert.Equal(t, []string{"send-funds:true", "receive-funds:true"}, steps(transfer))
+		assert.Equal(t, "60", sender.Cash().String())
+		assert.Equal(t, "40", receiver.Cash().String())
+	})
+
+	t.Run("Nothing moves when the sender has not enough funds", func(t *testing.T) {
+		portfolios, transfers, sender, receiver := setup(10)
+		runner, process, _ := newRunner(portfolios, transfers)
+		transferId, _ := process.Request(context.Background(), string(sender.Id()), string(receiver.Id()), decimal.NewFromInt(40))
+
+		runner.Tick(context.Background())
+
+		transfer := transfers.transfers[transferId]
+		assert.Equal(t, wiretransfers.TransferFailed, transfer.State)
+		assert.Contains(t, transfer.Reason, "insufficient funds")
+		assert.Equal(t, "10", sender.Cash().String())
+	})
+
+	t.Run("The sender is refunded when the receiver does not exist", func(t *testing.T) {
+		portfolios, transfers, sender, _ := setup(100)
+		runner, process, _ := newRunner(portfolios, transfers)
+		transferId, _ := process.Request(context.Background(), string(sender.Id()), "f47ac10b-58cc-4372-a567-0e02b2c3d479", decimal.NewFromInt(40))
+
+		runner.Tick(context.Background())
+
+		transfer := transfers.transfers[transferId]
+		assert.Equal(t, wiretransfers.TransferRefunded, transfer.State)
+		assert.Equal(t, []string{"send-funds:true", "receive-funds:false", "refund-sender:true"}, steps(transfer))
+		assert.Contains(t, transfer.Reason, "portfolio not found")
+		assert.Equal(t, "100", sender.Cash().String())
+	})
+
+	t.Run("The sender is refunded when the receiver keeps failing", func(t *testing.T) {
+		portfolios, transfers, sender, receiver := setup(100)
+		runner, process, errs := newRunner(portfolios, transfers)
+		transferId, _ := process.Request(context.Background(), string(sender.Id()), string(receiver.Id()), decimal.NewFromInt(40))
+		portfolios.failLoading = receiver.Id()
+
+		for i := 0; i <= wiretransfers.MaxReceiveAttempts; i++ {
+			runner.Tick(context.Background())
+		}
+
+		assert.Len(t, *errs, wiretransfers.MaxReceiveAttempts)
+		transfer := transfers.transfers[transferId]
+		assert.Equal(t, wiretransfers.TransferRefunded, transfer.State)
+		assert.Equal(t, "100", sender.Cash().String())
+	})
+
+	t.Run("Finished transfers are not run again", func(t *testing.T) {
+		portfolios, transfers, sender, receiver := setup(100)
+		runner, process, _ := newRunner(portfolios, transfers)
+		transferId, _ := process.Request(context.Background(), string(sender.Id()), string(receiver.Id()), decimal.NewFromInt(40))
+		runner.Tick(context.Background())
+
+		pending, err := process.Advance(context.Background(), transferId)
+
+		assert.NoError(t, err)
+		assert.False(t, pending)
+		assert.Equal(t, "40", receiver.Cash().String())
+	})
+}
+
+func TestNewWireTransfer(t *testing.T) {
+	t.Run("Transfer to the same portfolio", func(t *testing.T) {
+		_, err := wiretransfers.NewWireTransferProcess(nil, nil).Request(context.Background(), "same", "same", decimal.NewFromInt(1))
+
+		assert.EqualError(t, err, "sender and receiver must be different portfolios")
+	})
+}
+
+// StubPortfolioRepository hands out the portfolios it was given, so the tests
+// can look at them after each step.
+type StubPortfolioRepository struct {
+	portfolios  map[portfolio.PortfolioId]*portfolio.Portfolio
+	failLoading portfolio.PortfolioId
+}
+
+func (r *StubPortfolioRepository) FindById(ctx context.Context, portfolioId portfolio.PortfolioId) (*portfolio.Portfolio, error) {
+	if portfolioId == r.failLoading {
+		return nil, errors.New("connection lost")
+	}
+	found, ok := r.portfolios[portfolioId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", portfolio.ErrPortfolioNotFound, portfolioId)
+	}
+	return found, nil
+}
+
+func (r *StubPortfolioRepository) FindByName(ctx context.Context, name string) (*portfolio.Portfolio, error) {
+	return nil, nil
+}
+
+func (r *StubPortfolioRepository) Save(ctx context.Context, saved *portfolio.Portfolio) error {
+	saved.ClearDomainEvents()
+	r.portfolios[saved.Id()] = saved
+	return nil
+}
+
+type InMemoryWireTransferRepository struct {
+	transfers map[string]wiretransfers.WireTransfer
+}
+
+func (r *InMemoryWireTransferRepository) FindById(ctx context.Context, transferId string) (*wiretransfers.WireTransfer, error) {
+	transfer, ok := r.transfers[transferId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", wiretransfers.ErrWireTransferNotFound, transferId)
+	}
+	return &transfer, nil
+}
+
+func (r *InMemoryWireTransferRepository) FindPending(ctx context.Context, limit int) ([]string, error) {
+	pending := []string{}
+	for id, transfer := range r.transfers {
+		if transfer.IsPending() {
+			pending = append(pending, id)
+		}
+	}
+	return pending, nil
+}
+
+func (r *InMemoryWireTransferRepository) Save(ctx context.Context, transfer *wiretransfers.WireTransfer) error {
+	r.transfers[transfer.Id] = *transfer
+	return nil
+}
+
+type StubUnitOfWork struct{}
+
+func (StubUnitOfWork) Transaction(fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	return fc(nil)
+}
@@ -0,0 +1,56 @@
+package wiretransfers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WireTransferRepository interface {
+	FindById(context.Context, string) (*WireTransfer, error)
+	FindPending(context.Context, int) ([]string, error)
+	Save(context.Context, *WireTransfer) error
+}
+
+type mySQLWireTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewWireTransferRepository(db *gorm.DB) WireTransferRepository {
+	return &mySQLWireTransferRepository{
+		db: db,
+	}
+}
+
+// FindById locks the transfer until the surrounding transaction ends, so each
+// step runs at most once.
+func (r *mySQLWireTransferRepository) FindById(ctx context.Context, transferId string) (*WireTransfer, error) {
+	transfer := &WireTransfer{}
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", transferId).First(transfer).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrWireTransferNotFound, transferId)
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// FindPending returns the ids of the transfers that still have steps to run,
+// oldest first.
+func (r *mySQLWireTransferRepository) FindPending(ctx context.Context, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&WireTransfer{}).
+		Where("state IN ?", []WireTransferState{TransferRequested, TransferFundsSent, TransferRefunding}).
+		Order("created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *mySQLWireTransferRepository) Save(ctx context.Context, transfer *WireTransfer) error {
+	return r.db.WithContext(ctx).Save(transfer).Error
+}
@@ -0,0 +1,88 @@
+package wiretransfers
+
+import (
+	"context"
+	"stock-trader/portfolio-service/infrastructure"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WireTransferRunnerOptions struct {
+	PollInterval time.Duration
+	BatchSize    int
+	OnError      func(transferId string, err error)
+}
+
+// WireTransferRunner runs the steps of pending wire transfers, each step in a
+// transaction of its own. All its state lives in the wire transfers table, so a
+// restarted service resumes the transfers where they were left.
+type WireTransferRunner struct {
+	uow       infrastructure.GormUnitOfWork
+	process   func(tx *gorm.DB) *WireTransferProcess
+	transfers func(tx *gorm.DB) WireTransferRepository
+	options   WireTransferRunnerOptions
+}
+
+func NewWireTransferRunner(
+	uow infrastructure.GormUnitOfWork,
+	process func(tx *gorm.DB) *WireTransferProcess,
+	transfers func(tx *gorm.DB) WireTransferRepository,
+	options WireTransferRunnerOptions,
+) *WireTransferRunner {
+	return &WireTransferRunner{
+		uow:       uow,
+		process:   process,
+		transfers: transfers,
+		options:   options,
+	}
+}
+
+func (r *WireTransferRunner) Run(ctx context.Context) {
+	for {
+		r.Tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.options.PollInterval):
+		}
+	}
+}
+
+// Tick runs every pending transfer until it finishes or one of its steps fails.
+func (r *WireTransferRunner) Tick(ctx context.Context) {
+	var pending []string
+	err := r.uow.Transaction(func(tx *gorm.DB) error {
+		var err error
+		pending, err = r.transfers(tx).FindPending(ctx, r.options.BatchSize)
+		return err
+	})
+	if err != nil {
+		r.options.OnError("", err)
+		return
+	}
+
+	for _, transferId := range pending {
+		if err := r.advance(ctx, transferId); err != nil {
+			r.options.OnError(transferId, err)
+		}
+	}
+}
+
+func (r *WireTransferRunner) advance(ctx context.Context, transferId string) error {
+	for pending := true; pending; {
+		err := r.uow.Transaction(func(tx *gorm.DB) error {
+			var err error
+			pending, err = r.process(tx).Advance(ctx, transferId)
+			return err
+		})
+		if err != nil {
+			r.uow.Transaction(func(tx *gorm.DB) error {
+				return r.process(tx).RecordFailedAttempt(ctx, transferId, err)
+			})
+			return err
+		}
+	}
+	return nil
+}